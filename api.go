@@ -4,8 +4,123 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 	"github.com/schmurfy/chipi/builder"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
 )
 
+// Problem is an RFC 7807 error which can be returned from Handle,
+// see response.Problem
+type Problem = response.Problem
+
+type InvalidParam = response.InvalidParam
+
+// Error is a structured error with a status and an error code which can
+// be returned from Handle, see response.Error
+type Error = response.Error
+
+// FileResponse can be used as Response to download a file, see
+// response.FileResponse
+type FileResponse = response.FileResponse
+
+// BaseRequest can be embedded in the request objects to handle their
+// errors, see wrapper.BaseRequest
+type BaseRequest = wrapper.BaseRequest
+
+// OperationInfo is returned by the Describe method of request objects,
+// see builder.Describer
+type OperationInfo = builder.OperationInfo
+
+// Schemer can be implemented by types publishing their own schema,
+// see schema.Schemer
+type Schemer = schema.Schemer
+
+// PageQuery can be embedded in the Query of the request objects listing items,
+// see wrapper.PageQuery
+type PageQuery = wrapper.PageQuery
+
+// Cursor is an opaque pagination token, see wrapper.Cursor
+type Cursor = wrapper.Cursor
+
+// CursorQuery can be embedded in the Query of the request objects listing
+// items after a cursor, see wrapper.CursorQuery
+type CursorQuery = wrapper.CursorQuery
+
+// Sort is bound from ?sort=-created_at,name, see wrapper.Sort
+type Sort = wrapper.Sort
+type SortField = wrapper.SortField
+
+// Filter is bound from ?filter[status]=active, see wrapper.Filter
+type Filter = wrapper.Filter
+
+// Fieldset selects the Response fields sent with ?fields=id,name, see
+// wrapper.Fieldset
+type Fieldset = wrapper.Fieldset
+
+// BatchRequest sends several requests at once, see wrapper.BatchRequest
+type BatchRequest = wrapper.BatchRequest
+type BatchItem = wrapper.BatchItem
+type BatchResult = wrapper.BatchResult
+
+// AsyncResult can be returned by Handle to continue in the background, see
+// wrapper.AsyncResult
+type AsyncResult = wrapper.AsyncResult
+type OperationStatus = wrapper.OperationStatus
+
+// EncodeCursor returns the cursor of position (ex: the sort key and id of
+// the last item sent)
+func EncodeCursor(position interface{}) (Cursor, error) {
+	return wrapper.EncodeCursor(position)
+}
+
+// Async returns the AsyncResult running fn in the background, the client
+// gets a 202 with the Location of its status
+func Async(fn wrapper.AsyncFunc) *AsyncResult {
+	return wrapper.Async(fn)
+}
+
+func NewProblem(status int, detail string) *Problem {
+	return response.NewProblem(status, detail)
+}
+
+func NewError(status int, code string, message string) *Error {
+	return response.NewError(status, code, message)
+}
+
 func New(r *chi.Mux, infos *openapi3.Info) (*builder.Builder, error) {
 	return builder.New(r, infos)
 }
+
+// Verify checks that obj is a valid request object for the given route,
+// see wrapper.Verify
+func Verify(obj interface{}, method string, pattern string) error {
+	return wrapper.Verify(obj, method, pattern)
+}
+
+// PagedResponse can be used as Response to list a page of items selected by
+// an embedded PageQuery, the Link headers of the other pages are sent with it
+type PagedResponse[T any] struct {
+	Items []T `json:"items"`
+
+	// total number of items, 0 if it is unknown
+	Total int `json:"total,omitempty"`
+}
+
+// PageSize implements wrapper.Pager
+func (p PagedResponse[T]) PageSize() (int, int) {
+	return len(p.Items), p.Total
+}
+
+// CursorResponse can be used as Response to list the items after the cursor
+// of an embedded CursorQuery, the Link header of the next page is sent with it
+type CursorResponse[T any] struct {
+	Items []T `json:"items"`
+
+	// cursor of the next page, empty for the last one
+	Next Cursor `json:"next_cursor,omitempty"`
+}
+
+// NextCursor implements wrapper.CursorPager
+func (p CursorResponse[T]) NextCursor() Cursor {
+	return p.Next
+}
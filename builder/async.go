@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var _operationStatusRequestType = reflect.TypeOf(wrapper.OperationStatusRequest{})
+
+// AsyncStatus mounts a wrapper.OperationStatusRequest on GET pattern (ex:
+// /operations/{ID}) serving the operations saved in the store of the Async
+// wrapper option
+func (b *Builder) AsyncStatus(r chi.Router, pattern string) error {
+	if (b.wrapperOptions == nil) || (b.wrapperOptions.Async == nil) || (b.wrapperOptions.Async.Store == nil) {
+		return errors.New("the status of the asynchronous operations needs the Async wrapper option")
+	}
+
+	return b.Get(r, pattern, &wrapper.OperationStatusRequest{Store: b.wrapperOptions.Async.Store})
+}
+
+// generateAsyncDoc documents the 202 response of the operations with an
+// `async:"true"` tag on their Path, linked to the status operation if it is
+// mounted, and the 404 response of the status operation
+func (b *Builder) generateAsyncDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type, filterObject shared.FilterInterface) error {
+	if requestObjectType == _operationStatusRequestType {
+		key := strconv.Itoa(http.StatusNotFound)
+		if op.Responses[key] != nil {
+			return nil
+		}
+
+		resp, err := b.errorResponse(ctx, swagger, "unknown operation", &response.Problem{}, filterObject)
+		if err != nil {
+			return err
+		}
+
+		op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+		return nil
+	}
+
+	if !wrapper.IsAsyncOperation(requestObjectType) {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, "accepted, the operation continues in the background and its status is served at the Location url", &wrapper.OperationStatus{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	resp.Headers = openapi3.Headers{
+		"Location": &openapi3.HeaderRef{
+			Value: &openapi3.Header{
+				Parameter: openapi3.Parameter{
+					Description: "url of the status of the operation",
+					Schema:      openapi3.NewStringSchema().NewRef(),
+				},
+			},
+		},
+	}
+
+	for _, m := range b.methods {
+		if reflect.TypeOf(m.reqObject).Elem() == _operationStatusRequestType {
+			resp.Links = openapi3.Links{
+				"status": &openapi3.LinkRef{
+					Value: &openapi3.Link{
+						OperationID: _operationStatusRequestType.Name(),
+						Description: "status of the operation",
+						Parameters:  map[string]interface{}{"ID": "$response.body#/id"},
+					},
+				},
+			}
+			break
+		}
+	}
+
+	op.Responses[strconv.Itoa(http.StatusAccepted)] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportCatsRequest struct {
+	Path struct{} `example:"/exports" async:"true"`
+}
+
+func (r *exportCatsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestAsyncDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("asynchronous operations documentation", func() {
+		var router *chi.Mux
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+		})
+
+		generate := func() *openapi3.T {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should document the 202 response linked to the status operation", func() {
+			b.SetWrapperOptions(&wrapper.Options{Async: &wrapper.AsyncOptions{Store: wrapper.NewMemoryOperationStore()}})
+			require.NoError(g, b.Post(router, "/exports", &exportCatsRequest{}))
+			require.NoError(g, b.AsyncStatus(router, "/operations/{ID}"))
+
+			swagger := generate()
+
+			accepted := swagger.Paths["/exports"].Post.Responses["202"]
+			require.NotNil(g, accepted)
+			assert.Contains(g, accepted.Value.Headers, "Location")
+			assert.Equal(g, "#/components/schemas/wrapper.OperationStatus", accepted.Value.Content["application/json"].Schema.Ref)
+
+			link := accepted.Value.Links["status"]
+			require.NotNil(g, link)
+			assert.Equal(g, "OperationStatusRequest", link.Value.OperationID)
+			assert.Equal(g, "$response.body#/id", link.Value.Parameters["ID"])
+
+			status := swagger.Paths["/operations/{ID}"].Get
+			require.NotNil(g, status)
+			assert.Equal(g, "OperationStatusRequest", status.OperationID)
+			assert.NotNil(g, status.Responses["404"])
+
+			state := swagger.Components.Schemas["wrapper.OperationStatus"].Value.Properties["status"]
+			assert.Len(g, state.Value.Enum, 4)
+		})
+
+		g.It("should not link the operations without status endpoint", func() {
+			require.NoError(g, b.Post(router, "/exports", &exportCatsRequest{}))
+
+			accepted := generate().Paths["/exports"].Post.Responses["202"]
+			require.NotNil(g, accepted)
+			assert.Empty(g, accepted.Value.Links)
+		})
+
+		g.It("should need the Async option to mount the status endpoint", func() {
+			assert.Error(g, b.AsyncStatus(router, "/operations/{ID}"))
+		})
+	})
+}
@@ -0,0 +1,12 @@
+package builder
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// Batch mounts a wrapper.BatchRequest on POST pattern (ex: /batch), the
+// requests of a batch are routed by the router serving it
+func (b *Builder) Batch(r chi.Router, pattern string) error {
+	return b.Post(r, pattern, &wrapper.BatchRequest{})
+}
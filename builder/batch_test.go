@@ -0,0 +1,57 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("batch endpoint", func() {
+		var swagger *openapi3.T
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Batch(router, "/batch"))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			swagger = convertToSwagger(g, data)
+		})
+
+		g.It("should document the batch operation", func() {
+			op := swagger.Paths["/batch"].Post
+			require.NotNil(g, op)
+			assert.Equal(g, "BatchRequest", op.OperationID)
+
+			item := swagger.Components.Schemas["wrapper.BatchItem"]
+			require.NotNil(g, item)
+			assert.ElementsMatch(g, []string{"method", "path"}, item.Value.Required)
+
+			// the bodies are any json value
+			assert.Equal(g, "", item.Value.Properties["body"].Value.Type)
+			assert.Contains(g, swagger.Components.Schemas, "wrapper.BatchResult")
+		})
+
+		g.It("should type the bodies as unknown in the TypeScript client", func() {
+			var buf bytes.Buffer
+			require.NoError(g, b.GenerateTypeScriptClient(&buf))
+
+			assert.Contains(g, buf.String(), "body?: unknown;")
+		})
+	})
+}
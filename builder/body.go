@@ -3,9 +3,12 @@ package builder
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"reflect"
+	"strconv"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/response"
 	"github.com/schmurfy/chipi/schema"
 	"github.com/schmurfy/chipi/shared"
 	"github.com/schmurfy/chipi/wrapper"
@@ -14,34 +17,44 @@ import (
 func (b *Builder) generateBodyDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, requestObjectType reflect.Type, filterObject shared.FilterInterface) error {
 	bodyField, found := requestObjectType.FieldByName("Body")
 	if found {
-		bodySchema, err := b.schema.GenerateFilteredSchemaFor(ctx, swagger, bodyField.Type, filterObject)
+		bodySchema, err := b.bodySchema(ctx, swagger, bodyField, filterObject)
 		if err != nil {
 			return err
 		}
 
-		// check that a body decoder is available
-		if _, ok := requestObject.(wrapper.BodyDecoder); !ok {
-			return fmt.Errorf("%s must implement BodyDecoder", requestObjectType.Name())
+		contentTypes := []string{"application/json"}
+		if wrapper.IsStreamedBody(bodyField) {
+			contentTypes = []string{wrapper.StreamedResponseContentType(bodyField)}
+		} else if contentType, found := bodyField.Tag.Lookup("content-type"); found {
+			contentTypes = []string{contentType}
+		} else if typer, ok := requestObject.(wrapper.BodyMediaTyper); ok && (len(typer.BodyMediaTypes()) > 0) {
+			contentTypes = typer.BodyMediaTypes()
 		}
 
-		contentType, found := bodyField.Tag.Lookup("content-type")
-		if !found {
-			contentType = "application/json"
+		// check that a body decoder is available for custom content types
+		if _, ok := requestObject.(wrapper.BodyDecoder); !ok && !wrapper.HasDefaultBodyDecoding(bodyField) {
+			return fmt.Errorf("%s must implement BodyDecoder for %s bodies", requestObjectType.Name(), contentTypes[0])
 		}
 
 		body := openapi3.NewRequestBody()
 		bodyRef := &openapi3.RequestBodyRef{Value: body}
 
-		body.Content = openapi3.Content{
-			contentType: &openapi3.MediaType{
+		body.Content = openapi3.Content{}
+		for _, contentType := range contentTypes {
+			body.Content[contentType] = &openapi3.MediaType{
 				Schema: bodySchema,
-			},
+			}
 		}
 
 		tag := schema.ParseJsonTag(bodyField)
 
+		// pointer bodies are optional
+		body.Required = (bodyField.Type.Kind() != reflect.Ptr)
+
 		if tag.Description != nil {
 			body.Description = *tag.Description
+		} else {
+			body.Description = b.comments.fieldDoc(requestObjectType, "Body")
 		}
 
 		if tag.Required != nil {
@@ -53,3 +66,42 @@ func (b *Builder) generateBodyDoc(ctx context.Context, swagger *openapi3.T, op *
 
 	return nil
 }
+
+// bodySchema returns the schema of the body, streamed bodies are binary data
+func (b *Builder) bodySchema(ctx context.Context, swagger *openapi3.T, bodyField reflect.StructField, filterObject shared.FilterInterface) (*openapi3.SchemaRef, error) {
+	if wrapper.IsStreamedBody(bodyField) {
+		return openapi3.NewSchemaRef("", &openapi3.Schema{
+			Type:   "string",
+			Format: "binary",
+		}), nil
+	}
+
+	return b.schema.GenerateFilteredSchemaFor(ctx, swagger, bodyField.Type, filterObject)
+}
+
+// generateBodyLimitDoc documents the 413 response of the bodies limited by
+// their `max-bytes` tag or the MaxBodyBytes wrapper option
+func (b *Builder) generateBodyLimitDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type, filterObject shared.FilterInterface) error {
+	key := strconv.Itoa(http.StatusRequestEntityTooLarge)
+	if (op.RequestBody == nil) || (op.Responses[key] != nil) {
+		return nil
+	}
+
+	limit := wrapper.MaxBodyBytes(requestObjectType)
+	if (limit == 0) && (b.wrapperOptions != nil) {
+		limit = b.wrapperOptions.MaxBodyBytes
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, fmt.Sprintf("body larger than %d bytes", limit), &response.Problem{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package builder
 
 import (
 	"context"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"testing"
@@ -26,6 +28,17 @@ type bodyTestWithoutDecoderRequest struct {
 	Path struct {
 	} `example:"/pet"`
 
+	Body struct {
+		Name string
+	} `content-type:"text/csv"`
+}
+
+type bodyTestDefaultDecoderRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
 	Body struct {
 		Name string
 	}
@@ -45,6 +58,76 @@ type bodyTestWithDecoderRequest struct {
 	}
 }
 
+type bodyTestFormRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body struct {
+		Name string
+	} `content-type:"application/x-www-form-urlencoded"`
+}
+
+type bodyTestStreamRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body io.ReadCloser
+}
+
+type bodyTestUploadRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body struct {
+		Name  string
+		Photo *multipart.FileHeader
+	} `content-type:"multipart/form-data"`
+}
+
+type bodyTestOptionalRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body *struct {
+		Name string
+	}
+}
+
+type bodyTestMediaTypesRequest struct {
+	noopHandler
+	request.JsonBodyDecoder
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body struct {
+		Name string
+	}
+}
+
+func (r *bodyTestMediaTypesRequest) BodyMediaTypes() []string {
+	return []string{"application/json", "application/merge-patch+json"}
+}
+
+type bodyTestMapRequest struct {
+	noopHandler
+
+	Path struct {
+	} `example:"/pet"`
+
+	Body map[string]struct {
+		Name string
+	}
+}
+
 func TestBodyGenerator(t *testing.T) {
 	g := goblin.Goblin(t)
 
@@ -64,7 +147,13 @@ func TestBodyGenerator(t *testing.T) {
 			require.NoError(g, err)
 		})
 
-		g.It("should return an error if structure does not implements BodyDecoder", func() {
+		g.It("should not require a BodyDecoder for json bodies", func() {
+			req := bodyTestDefaultDecoderRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+		})
+
+		g.It("should return an error if structure does not implements BodyDecoder for custom content types", func() {
 			req := bodyTestWithoutDecoderRequest{}
 			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
 			require.Error(g, err)
@@ -72,6 +161,78 @@ func TestBodyGenerator(t *testing.T) {
 			assert.Contains(g, err.Error(), "must implement BodyDecoder")
 		})
 
+		g.It("should document form bodies without BodyDecoder", func() {
+			req := bodyTestFormRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			require.NotNil(g, op.RequestBody)
+			assert.NotNil(g, op.RequestBody.Value.Content.Get("application/x-www-form-urlencoded"))
+		})
+
+		g.It("should document multipart bodies with binary files", func() {
+			req := bodyTestUploadRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			content := op.RequestBody.Value.Content.Get("multipart/form-data")
+			require.NotNil(g, content)
+			photo := content.Schema.Value.Properties["Photo"]
+			require.NotNil(g, photo)
+			assert.Equal(g, "binary", photo.Value.Format)
+		})
+
+		g.It("should document streamed bodies as binary", func() {
+			req := bodyTestStreamRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			content := op.RequestBody.Value.Content.Get("application/octet-stream")
+			require.NotNil(g, content)
+			assert.Equal(g, "string", content.Schema.Value.Type)
+			assert.Equal(g, "binary", content.Schema.Value.Format)
+			assert.True(g, op.RequestBody.Value.Required)
+		})
+
+		g.It("should require value bodies", func() {
+			req := bodyTestDefaultDecoderRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			assert.True(g, op.RequestBody.Value.Required)
+			assert.NotNil(g, op.RequestBody.Value.Content.Get("application/json"))
+		})
+
+		g.It("should not require pointer bodies", func() {
+			req := bodyTestOptionalRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			assert.False(g, op.RequestBody.Value.Required)
+		})
+
+		g.It("should document the media types of the decoder", func() {
+			req := bodyTestMediaTypesRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			content := op.RequestBody.Value.Content
+			assert.Len(g, content, 2)
+			assert.NotNil(g, content.Get("application/json"))
+			assert.NotNil(g, content.Get("application/merge-patch+json"))
+		})
+
+		g.It("should document map bodies with additionalProperties", func() {
+			req := bodyTestMapRequest{}
+			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			schema := op.RequestBody.Value.Content.Get("application/json").Schema.Value
+			assert.Equal(g, "object", schema.Type)
+			require.NotNil(g, schema.AdditionalProperties)
+			assert.Contains(g, schema.AdditionalProperties.Value.Properties, "Name")
+		})
+
 		g.It("should return nil if structure implements BodyDecoder", func() {
 			req := bodyTestWithDecoderRequest{}
 			err := b.generateBodyDoc(ctx, b.swagger, &op, &req, reflect.TypeOf(req), nil)
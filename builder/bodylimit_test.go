@@ -0,0 +1,77 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type limitedUploadRequest struct {
+	Path struct{} `example:"/uploads"`
+	Body struct {
+		Name string
+	} `max-bytes:"1024"`
+}
+
+func (r *limitedUploadRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type unlimitedUploadRequest struct {
+	Path struct{} `example:"/pets"`
+	Body struct {
+		Name string
+	}
+}
+
+func (r *unlimitedUploadRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestBodyLimitDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("body size limit documentation", func() {
+		generate := func(opts *wrapper.Options) *openapi3.T {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.SetWrapperOptions(opts)
+			require.NoError(g, b.Post(router, "/uploads", &limitedUploadRequest{}))
+			require.NoError(g, b.Post(router, "/pets", &unlimitedUploadRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should document the 413 of the limited routes", func() {
+			swagger := generate(nil)
+
+			resp := swagger.Paths["/uploads"].Post.Responses["413"]
+			require.NotNil(g, resp)
+			assert.Equal(g, "body larger than 1024 bytes", *resp.Value.Description)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+
+			assert.Nil(g, swagger.Paths["/pets"].Post.Responses["413"])
+		})
+
+		g.It("should document the global limit", func() {
+			swagger := generate(&wrapper.Options{MaxBodyBytes: 4096})
+
+			resp := swagger.Paths["/pets"].Post.Responses["413"]
+			require.NotNil(g, resp)
+			assert.Equal(g, "body larger than 4096 bytes", *resp.Value.Description)
+
+			assert.Equal(g, "body larger than 1024 bytes", *swagger.Paths["/uploads"].Post.Responses["413"].Value.Description)
+		})
+	})
+}
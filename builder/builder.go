@@ -4,8 +4,10 @@ import (
 	"context"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
 	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
 	"github.com/schmurfy/chipi/schema"
@@ -27,11 +29,38 @@ type Builder struct {
 	schema  *schema.Schema
 	router  *chi.Mux
 	methods []*Method
+
+	wrapperOptions *wrapper.Options
+
+	validateRequests  bool
+	validateResponses bool
+	validation        validationSpec
+
+	comments docComments
+
+	version         OpenAPIVersion
+	webhooks        map[string]*openapi3.PathItem
+	webhookRequests []*webhookRequest
+	merged          []*openapi3.T
+
+	logger shared.Logger
+
+	noMountTags bool
+
+	defaultError interface{}
+
+	servedJson   servedDocument
+	servedYAML   servedDocument
+	servedPublic servedDocument
 }
 
 func New(r *chi.Mux, infos *openapi3.Info) (*Builder, error) {
+	if infos == nil {
+		infos = &openapi3.Info{}
+	}
+
 	swagger := &openapi3.T{
-		OpenAPI: "3.1.0",
+		OpenAPI: string(OpenAPI30),
 		Info:    infos,
 	}
 
@@ -44,11 +73,32 @@ func New(r *chi.Mux, infos *openapi3.Info) (*Builder, error) {
 		swagger: swagger,
 		schema:  s,
 		router:  r,
+		version: OpenAPI30,
 	}
 
 	return ret, nil
 }
 
+// SetWrapperOptions defines the options used to wrap the handlers of
+// routes registered after this call
+func (b *Builder) SetWrapperOptions(opts *wrapper.Options) {
+	b.wrapperOptions = opts
+}
+
+// SetLogger defines the logger of the builder, it is also used by the
+// routes registered after this call unless their wrapper options have one
+func (b *Builder) SetLogger(logger shared.Logger) {
+	b.logger = logger
+}
+
+func (b *Builder) log() shared.Logger {
+	if b.logger == nil {
+		return shared.NopLogger{}
+	}
+
+	return b.logger
+}
+
 func (b *Builder) AddTag(tag *openapi3.Tag) {
 	b.swagger.Tags = append(b.swagger.Tags, tag)
 }
@@ -57,6 +107,8 @@ func (b *Builder) AddServer(server *openapi3.Server) {
 	b.swagger.AddServer(server)
 }
 
+// AddSecurityScheme declares a security scheme (bearer, apiKey, oauth2, ...)
+// which can be required globally with AddSecurityRequirement or by operation (see Secured)
 func (b *Builder) AddSecurityScheme(name string, s *openapi3.SecurityScheme) {
 	if b.swagger.Components.SecuritySchemes == nil {
 		b.swagger.Components.SecuritySchemes = make(openapi3.SecuritySchemes)
@@ -71,18 +123,29 @@ func (b *Builder) AddSecurityRequirement(req openapi3.SecurityRequirement) {
 	b.swagger.Security.With(req)
 }
 
+// ServeSchema is an http.HandlerFunc serving the json document with ETag and
+// Last-Modified headers so clients can cache it
 func (b *Builder) ServeSchema(w http.ResponseWriter, r *http.Request) {
 	data, err := b.GenerateJson(r.Context(), nil)
 	if err != nil {
+		b.log().Error(r.Context(), "failed to generate the document", shared.Field{Key: "error", Value: err})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Add("Content-Type", "application/json")
-	_, err = w.Write(data)
+
+	serveDocument(w, r, &b.servedJson, "application/json", data)
+}
+
+// ServeSchemaYAML is the same as ServeSchema with a YAML document
+func (b *Builder) ServeSchemaYAML(w http.ResponseWriter, r *http.Request) {
+	data, err := b.GenerateYAML(r.Context(), nil)
 	if err != nil {
+		b.log().Error(r.Context(), "failed to generate the document", shared.Field{Key: "error", Value: err})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	serveDocument(w, r, &b.servedYAML, "application/yaml", data)
 }
 
 type CallbackFunc func(http.ResponseWriter, interface{})
@@ -107,25 +170,53 @@ func (b *Builder) Delete(r chi.Router, pattern string, reqObject interface{}) er
 	return b.Method(r, pattern, "DELETE", reqObject)
 }
 
-func (b *Builder) findRoute(typ reflect.Type, method string) (*chi.Context, error) {
+// findRoute matches the example of the Path field of typ (if any) against the
+// router, the matched route must have been registered with pattern otherwise
+// the nested routers are walked to find it
+func (b *Builder) findRoute(typ reflect.Type, method string, pattern string) (*chi.Context, error) {
 	pathField, found := typ.FieldByName("Path")
 	if !found {
 		return nil, errors.New("Path field not found on : " + typ.Name())
 	}
 
-	routeExample, found := pathField.Tag.Lookup("example")
-	if !found {
-		return nil, errors.New("example tag not found on : " + pathField.Name)
+	routeExample, hasExample := pathField.Tag.Lookup("example")
+	if hasExample {
+		tctx := chi.NewRouteContext()
+		if b.router.Match(tctx, method, routeExample) && isRouteOf(tctx.RoutePattern(), pattern) {
+			return tctx, nil
+		}
 	}
 
-	tctx := chi.NewRouteContext()
-	if b.router.Match(tctx, method, routeExample) {
-		return tctx, nil
+	// the example may match another route or a handler mounted with a
+	// middleware hiding its routes
+	var routes []string
+	err := chi.Walk(b.router, func(walkedMethod string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if (walkedMethod == method) && isRouteOf(route, pattern) {
+			routes = append(routes, route)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	return nil, errors.New("route not found : " + method + " - " + routeExample)
+	switch {
+	case len(routes) == 1:
+		return routeContext(routes[0]), nil
+	case (len(routes) == 0) && hasExample:
+		return nil, errors.New("route not found : " + method + " - " + routeExample)
+	case len(routes) == 0:
+		return nil, errors.New("route not found : " + method + " - " + pattern)
+	case hasExample:
+		return nil, errors.Errorf("example %s of %s does not match its route %s, candidates: %s", routeExample, typ.Name(), pattern, strings.Join(routes, ", "))
+	}
+
+	return nil, errors.Errorf("%s %s of %s is ambiguous, candidates: %s (add an example tag to the Path field)", method, pattern, typ.Name(), strings.Join(routes, ", "))
 }
 
+// Method registers the handler of reqObject on r and documents its operation,
+// the example tag of the Path field is only needed when pattern is not
+// enough to find the route in the nested routers (ex: "/" on several sub routers)
 func (b *Builder) Method(r chi.Router, pattern string, method string, reqObject interface{}) error {
 
 	typ := reflect.TypeOf(reqObject)
@@ -133,8 +224,18 @@ func (b *Builder) Method(r chi.Router, pattern string, method string, reqObject
 		return errors.New("wrong type, pointer to struct expected")
 	}
 
-	if _, ok := reqObject.(wrapper.HandlerInterface); ok {
-		r.Method(method, pattern, wrapper.WrapRequest(reqObject))
+	_, isHandler := reqObject.(wrapper.HandlerInterface)
+	_, isHandlerWithRequest := reqObject.(wrapper.HandlerWithRequestInterface)
+	_, isStreamer := reqObject.(wrapper.Streamer)
+
+	if isHandler || isHandlerWithRequest || isStreamer {
+		// use the same rules as the wrapper
+		err := wrapper.Verify(reqObject, method, pattern)
+		if err != nil {
+			return err
+		}
+
+		r.Method(method, pattern, wrapper.WrapRequestWithOptions(reqObject, b.routeOptions()))
 	} else if rr, ok := reqObject.(rawHandler); ok {
 		r.Method(method, pattern, http.HandlerFunc(rr.Handle))
 	} else {
@@ -147,78 +248,219 @@ func (b *Builder) Method(r chi.Router, pattern string, method string, reqObject
 		reqObject: reqObject,
 	})
 
+	b.log().Debug(context.Background(), "route registered",
+		shared.Field{Key: "method", Value: method},
+		shared.Field{Key: "pattern", Value: pattern},
+		shared.Field{Key: "operation", Value: typ.Elem().Name()},
+	)
+
 	return nil
 }
 
+// GenerateJson returns the document in the version selected with SetOpenAPIVersion
 func (b *Builder) GenerateJson(ctx context.Context, filterObject shared.FilterInterface) ([]byte, error) {
+	return b.generateJson(ctx, documentOptions{filterObject: filterObject})
+}
 
-	swagger := *b.swagger
-	for _, m := range b.methods {
-
-		typ := reflect.TypeOf(m.reqObject).Elem()
+func (b *Builder) generateJson(ctx context.Context, opts documentOptions) ([]byte, error) {
+	if b.version == OpenAPI31 {
+		opts.withWebhooks = true
 
-		routeContext, err := b.findRoute(typ, m.method)
-		if routeContext == nil {
+		data, webhooks, err := b.generateDocument(ctx, opts)
+		if err != nil {
 			return nil, err
 		}
 
-		if filterObject != nil && !reflect.ValueOf(filterObject).IsNil() {
-			removeRoute, err := filterObject.FilterRoute(ctx, m.method, routeContext.RoutePattern())
-			if err != nil {
-				return nil, err
-			}
+		return convertTo31(data, webhooks)
+	}
 
-			if removeRoute {
-				continue
-			}
-		}
+	data, _, err := b.generateDocument(ctx, opts)
+	return data, err
+}
+
+// GenerateYAML returns the same document as GenerateJson in YAML
+func (b *Builder) GenerateYAML(ctx context.Context, filterObject shared.FilterInterface) ([]byte, error) {
+	data, err := b.GenerateJson(ctx, filterObject)
+	if err != nil {
+		return nil, err
+	}
 
-		op := openapi3.NewOperation()
-		op.OperationID = typ.Name()
+	ret, err := yaml.JSONToYAML(data)
+	return ret, errors.WithStack(err)
+}
 
-		err = generateOperationDoc(op, typ)
-		if err != nil {
-			return nil, err
+// generateSpec returns the OpenAPI 3.0 document, the version used internally
+func (b *Builder) generateSpec(ctx context.Context, filterObject shared.FilterInterface) ([]byte, error) {
+	data, _, err := b.generateDocument(ctx, documentOptions{filterObject: filterObject})
+	return data, err
+}
+
+type documentOptions struct {
+	filterObject shared.FilterInterface
+	// withWebhooks also generates the webhooks
+	withWebhooks bool
+	// apiVersion, if set, only keeps the operations of this version
+	apiVersion string
+	// keep, if set, is called with the generated operations
+	keep OperationFilter
+}
+
+// generateDocument returns the OpenAPI 3.0 document and its webhooks if
+// requested, their schemas are part of the document components
+func (b *Builder) generateDocument(ctx context.Context, opts documentOptions) ([]byte, map[string]*openapi3.PathItem, error) {
+	var webhooks map[string]*openapi3.PathItem
+
+	swagger := *b.swagger
+	if opts.apiVersion != "" {
+		info := *swagger.Info
+		info.Version = opts.apiVersion
+		swagger.Info = &info
+	}
+
+	for _, m := range b.methods {
+		if (opts.apiVersion != "") && !m.inVersion(opts.apiVersion) {
+			continue
 		}
 
-		// URL Parameters
-		err = b.generateParametersDoc(ctx, &swagger, op, typ, m.method, routeContext)
+		err := b.generateOperation(ctx, &swagger, m, opts)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+	}
 
-		// Query parameters
-		err = b.generateQueryParametersDoc(ctx, &swagger, op, typ)
+	err := b.mergeMerged(&swagger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.withWebhooks {
+		webhooks, err = b.generateWebhooks(ctx, &swagger, opts.filterObject)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+	}
 
-		// Headers
-		err = b.generateHeadersDoc(ctx, &swagger, op, typ)
+	if opts.keep != nil {
+		err = pruneSchemas(&swagger, webhooks)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+	}
+
+	json, err := swagger.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return json, webhooks, nil
+}
+
+// generateOperation documents the route of m in swagger
+func (b *Builder) generateOperation(ctx context.Context, swagger *openapi3.T, m *Method, opts documentOptions) error {
+	filterObject := opts.filterObject
+
+	typ := reflect.TypeOf(m.reqObject).Elem()
 
-		// body
-		err = b.generateBodyDoc(ctx, &swagger, op, m.reqObject, typ, filterObject)
+	routeContext, err := b.findRoute(typ, m.method, m.pattern)
+	if routeContext == nil {
+		return err
+	}
+
+	if filterObject != nil && !reflect.ValueOf(filterObject).IsNil() {
+		removeRoute, err := filterObject.FilterRoute(ctx, m.method, routeContext.RoutePattern())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		// response
-		err = b.generateResponseDoc(ctx, &swagger, op, m.reqObject, typ, filterObject)
-		if err != nil {
-			return nil, err
+		if removeRoute {
+			return nil
 		}
+	}
 
-		swagger.AddOperation(routeContext.RoutePattern(), m.method, op)
+	op := openapi3.NewOperation()
+	op.OperationID = typ.Name()
 
+	err = b.generateOperationDoc(op, m.reqObject, typ)
+	if err != nil {
+		return err
 	}
 
-	json, err := swagger.MarshalJSON()
+	if (len(op.Tags) == 0) && !b.noMountTags {
+		if tag := mountTag(routeContext.RoutePattern(), m.pattern); tag != "" {
+			op.Tags = []string{tag}
+		}
+	}
+
+	// URL Parameters
+	err = b.generateParametersDoc(ctx, swagger, op, typ, m.method, routeContext)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	err = b.generateMessageDoc(ctx, swagger, op, m.reqObject, typ, filterObject)
+	if err != nil {
+		return err
 	}
 
-	return json, nil
+	// conditional requests
+	err = b.generateETagDoc(ctx, swagger, op, m.reqObject, m.method, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// body size limit
+	err = b.generateBodyLimitDoc(ctx, swagger, op, typ, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// handler timeout
+	err = b.generateTimeoutDoc(ctx, swagger, op, m.reqObject, typ, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// rate limit
+	err = b.generateRateLimitDoc(ctx, swagger, op, typ, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// pagination links
+	b.generatePaginationDoc(op, typ)
+
+	// response caching
+	b.generateCacheDoc(op, m.reqObject, m.method)
+
+	// idempotency keys
+	err = b.generateIdempotencyDoc(ctx, swagger, op, m.reqObject, m.method, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// asynchronous operations
+	err = b.generateAsyncDoc(ctx, swagger, op, typ, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// callbacks
+	err = b.generateCallbacksDoc(ctx, swagger, op, m.reqObject, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	// security
+	err = b.generateSecurityDoc(ctx, swagger, op, m.reqObject, filterObject)
+	if err != nil {
+		return errors.Wrap(err, typ.Name())
+	}
+
+	if (opts.keep != nil) && !opts.keep(m.method, routeContext.RoutePattern(), op) {
+		return nil
+	}
+
+	swagger.AddOperation(routeContext.RoutePattern(), m.method, op)
+
+	return nil
 }
@@ -87,6 +87,12 @@ func TestBuilder(t *testing.T) {
 				require.NoError(g, err)
 			})
 
+			g.It("should verify request objects", func() {
+				err := b.Post(router, "/pets/{Id}/{Name}", &builderTestPathRequest{})
+				require.Error(g, err)
+				require.Contains(g, err.Error(), "Path.Name field missing")
+			})
+
 			g.It("should detect nested path", func() {
 				petsRoute := chi.NewRouter()
 				router.Mount("/pets", petsRoute)
@@ -0,0 +1,38 @@
+package builder
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// generateCacheDoc documents the Cache-Control header of the successful
+// responses of the GET operations with a `cache` tag
+func (b *Builder) generateCacheDoc(op *openapi3.Operation, requestObject interface{}, method string) {
+	cacheControl := wrapper.CacheControl(requestObject)
+	if (cacheControl == "") || (method != http.MethodGet) {
+		return
+	}
+
+	resp := op.Responses.Get(http.StatusOK)
+	if (resp == nil) || (resp.Value == nil) {
+		return
+	}
+
+	if resp.Value.Headers == nil {
+		resp.Value.Headers = openapi3.Headers{}
+	}
+
+	schema := openapi3.NewStringSchema()
+	schema.Example = cacheControl
+
+	resp.Value.Headers["Cache-Control"] = &openapi3.HeaderRef{
+		Value: &openapi3.Header{
+			Parameter: openapi3.Parameter{
+				Description: "how long the response can be cached",
+				Schema:      schema.NewRef(),
+			},
+		},
+	}
+}
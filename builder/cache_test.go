@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cachedCatalogRequest struct {
+	Path     struct{} `example:"/catalog" cache:"10m"`
+	Response struct {
+		Items []string
+	}
+}
+
+func (r *cachedCatalogRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestCacheDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("cache documentation", func() {
+		g.It("should document the Cache-Control header of the cached operations", func() {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/catalog", &cachedCatalogRequest{}))
+			require.NoError(g, b.Post(router, "/catalog", &cachedCatalogRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			swagger := convertToSwagger(g, data)
+
+			header := swagger.Paths["/catalog"].Get.Responses["200"].Value.Headers["Cache-Control"]
+			require.NotNil(g, header)
+			assert.Equal(g, "public, max-age=600", header.Value.Schema.Value.Example)
+
+			assert.NotContains(g, swagger.Paths["/catalog"].Post.Responses["200"].Value.Headers, "Cache-Control")
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/shared"
+)
+
+// Callback is a request sent by the api while handling an operation, Object
+// follows the convention of the request objects (Query, Header, Cookie, Body
+// and Response) without being handled
+type Callback struct {
+	Name string
+	// Expression is the runtime expression of the url (ex: "{$request.body#/callbackUrl}")
+	Expression string
+	// Method defaults to POST
+	Method string
+	Object interface{}
+}
+
+// Callbacker can be implemented by request objects to document the
+// callbacks of their operation
+type Callbacker interface {
+	Callbacks() []Callback
+}
+
+type webhookRequest struct {
+	name   string
+	method string
+	obj    interface{}
+}
+
+// AddWebhookRequest documents a webhook with an object following the request
+// objects convention like AddWebhook, it is only part of the OpenAPI 3.1 documents
+func (b *Builder) AddWebhookRequest(name string, method string, obj interface{}) error {
+	if err := checkMessageObject(obj); err != nil {
+		return errors.Wrapf(err, "webhook %s", name)
+	}
+
+	b.webhookRequests = append(b.webhookRequests, &webhookRequest{
+		name:   name,
+		method: method,
+		obj:    obj,
+	})
+
+	return nil
+}
+
+func checkMessageObject(obj interface{}) error {
+	typ := reflect.TypeOf(obj)
+	if (typ == nil) || (typ.Kind() != reflect.Ptr) || (typ.Elem().Kind() != reflect.Struct) {
+		return errors.New("wrong type, pointer to struct expected")
+	}
+
+	return nil
+}
+
+// generateMessageDoc documents the parameters, the body and the responses
+// of obj in op
+func (b *Builder) generateMessageDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, obj interface{}, typ reflect.Type, filterObject shared.FilterInterface) error {
+	// Query parameters
+	err := b.generateQueryParametersDoc(ctx, swagger, op, typ)
+	if err != nil {
+		return err
+	}
+
+	// Headers
+	err = b.generateHeadersDoc(ctx, swagger, op, typ)
+	if err != nil {
+		return err
+	}
+
+	// Cookies
+	err = b.generateCookiesDoc(ctx, swagger, op, typ)
+	if err != nil {
+		return err
+	}
+
+	// body
+	err = b.generateBodyDoc(ctx, swagger, op, obj, typ, filterObject)
+	if err != nil {
+		return err
+	}
+
+	// response
+	return b.generateResponseDoc(ctx, swagger, op, obj, typ, filterObject)
+}
+
+// generateMessageOperation returns the operation of a callback or a webhook
+func (b *Builder) generateMessageOperation(ctx context.Context, swagger *openapi3.T, obj interface{}, filterObject shared.FilterInterface) (*openapi3.Operation, error) {
+	if err := checkMessageObject(obj); err != nil {
+		return nil, err
+	}
+
+	typ := reflect.TypeOf(obj).Elem()
+
+	op := openapi3.NewOperation()
+	op.OperationID = typ.Name()
+
+	err := b.generateOperationDoc(op, obj, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.generateMessageDoc(ctx, swagger, op, obj, typ, filterObject)
+	if err != nil {
+		return nil, errors.Wrap(err, typ.Name())
+	}
+
+	return op, nil
+}
+
+func (b *Builder) generateCallbacksDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, reqObject interface{}, filterObject shared.FilterInterface) error {
+	callbacker, ok := reqObject.(Callbacker)
+	if !ok {
+		return nil
+	}
+
+	for _, callback := range callbacker.Callbacks() {
+		callbackOp, err := b.generateMessageOperation(ctx, swagger, callback.Object, filterObject)
+		if err != nil {
+			return errors.Wrapf(err, "callback %s", callback.Name)
+		}
+
+		method := callback.Method
+		if method == "" {
+			method = http.MethodPost
+		}
+
+		if op.Callbacks == nil {
+			op.Callbacks = openapi3.Callbacks{}
+		}
+
+		ref, found := op.Callbacks[callback.Name]
+		if !found {
+			ref = &openapi3.CallbackRef{Value: &openapi3.Callback{}}
+			op.Callbacks[callback.Name] = ref
+		}
+
+		item, found := (*ref.Value)[callback.Expression]
+		if !found {
+			item = &openapi3.PathItem{}
+			(*ref.Value)[callback.Expression] = item
+		}
+		item.SetOperation(method, callbackOp)
+	}
+
+	return nil
+}
+
+// generateWebhooks returns the webhooks declared with AddWebhook and AddWebhookRequest
+func (b *Builder) generateWebhooks(ctx context.Context, swagger *openapi3.T, filterObject shared.FilterInterface) (map[string]*openapi3.PathItem, error) {
+	webhooks := map[string]*openapi3.PathItem{}
+	for name, item := range b.webhooks {
+		webhooks[name] = item
+	}
+
+	for _, webhook := range b.webhookRequests {
+		op, err := b.generateMessageOperation(ctx, swagger, webhook.obj, filterObject)
+		if err != nil {
+			return nil, errors.Wrapf(err, "webhook %s", webhook.name)
+		}
+
+		// the items given to AddWebhook are not modified
+		item := &openapi3.PathItem{}
+		if existing, found := webhooks[webhook.name]; found {
+			copied := *existing
+			item = &copied
+		}
+		item.SetOperation(webhook.method, op)
+		webhooks[webhook.name] = item
+	}
+
+	return webhooks, nil
+}
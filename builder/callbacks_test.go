@@ -0,0 +1,126 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type AdoptionEvent struct {
+	PetId    int     `json:"pet_id"`
+	Nickname *string `json:"nickname"`
+}
+
+type adoptionCallback struct {
+	Header struct {
+		Signature string `name:"X-Signature"`
+	}
+	Body     AdoptionEvent
+	Response struct {
+		Received bool `json:"received"`
+	}
+}
+
+type adoptPetRequest struct {
+	Path struct{} `example:"/adoptions"`
+	Body struct {
+		CallbackUrl string `json:"callback_url"`
+	}
+}
+
+func (r *adoptPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *adoptPetRequest) Callbacks() []Callback {
+	return []Callback{
+		{Name: "adopted", Expression: "{$request.body#/callback_url}", Object: &adoptionCallback{}},
+	}
+}
+
+func (r *adoptionCallback) Describe() OperationInfo {
+	return OperationInfo{Summary: "sent once the pet is adopted"}
+}
+
+func TestCallbacks(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("callbacks and webhooks", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			err = b.Post(router, "/adoptions", &adoptPetRequest{})
+			require.NoError(g, err)
+		})
+
+		g.It("should document the callbacks of the operations", func() {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			op := doc.Paths["/adoptions"].Post
+			require.NotNil(g, op)
+			require.Contains(g, op.Callbacks, "adopted")
+
+			item := (*op.Callbacks["adopted"].Value)["{$request.body#/callback_url}"]
+			require.NotNil(g, item)
+			require.NotNil(g, item.Post)
+			assert.Equal(g, "sent once the pet is adopted", item.Post.Summary)
+			assert.NotNil(g, item.Post.Parameters.GetByInAndName("header", "X-Signature"))
+			assert.Equal(g, "#/components/schemas/builder.AdoptionEvent", item.Post.RequestBody.Value.Content["application/json"].Schema.Ref)
+			assert.Contains(g, doc.Components.Schemas, "builder.AdoptionEvent")
+		})
+
+		g.It("should document the webhooks declared with request objects", func() {
+			require.NoError(g, b.SetOpenAPIVersion(OpenAPI31))
+			require.NoError(g, b.AddWebhookRequest("petAdopted", http.MethodPost, &adoptionCallback{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			var doc struct {
+				Webhooks   map[string]map[string]interface{}
+				Components struct {
+					Schemas map[string]json.RawMessage
+				}
+			}
+			require.NoError(g, json.Unmarshal(data, &doc))
+
+			require.Contains(g, doc.Webhooks, "petAdopted")
+			assert.Contains(g, doc.Webhooks["petAdopted"], "post")
+			assert.JSONEq(g, `{
+				"type": "object",
+				"properties": {
+					"pet_id": {"type": "integer", "format": "int64"},
+					"nickname": {"type": ["string", "null"]}
+				}
+			}`, string(doc.Components.Schemas["builder.AdoptionEvent"]))
+		})
+
+		g.It("should not generate the webhooks in 3.0 documents", func() {
+			require.NoError(g, b.AddWebhookRequest("petAdopted", http.MethodPost, &adoptionCallback{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			assert.NotContains(g, string(data), "webhooks")
+		})
+
+		g.It("should reject invalid objects", func() {
+			assert.Error(g, b.AddWebhookRequest("petAdopted", http.MethodPost, adoptionCallback{}))
+		})
+	})
+}
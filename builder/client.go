@@ -0,0 +1,945 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+type clientParam struct {
+	Name string
+	// condition for the parameter to be sent, always sent if empty
+	Check string
+	Expr  string
+
+	// map sent as name[key]=value
+	DeepObject bool
+	// separator of the slice items, a comma if empty
+	Separator string
+}
+
+type clientMethod struct {
+	Name        string
+	HTTPMethod  string
+	RequestType string
+
+	// empty if the request has no Response field
+	ResponseType string
+	RawResponse  bool
+
+	PathCheck string
+	PathExpr  string
+
+	QueryCheck string
+	Query      []clientParam
+	QueryRest  string
+
+	HeaderCheck string
+	Header      []clientParam
+
+	CookieCheck string
+	Cookie      []clientParam
+
+	BodyCheck string
+	BodyExpr  string
+
+	// documented error bodies by status code
+	Errors []clientErrorBody
+}
+
+type clientErrorBody struct {
+	Status int
+	Type   string
+}
+
+var clientTemplate = template.Must(template.New("client_template").Parse(`
+{{- define "error_body" }}
+	{{- if .Errors -}}
+	func(status int) interface{} {
+		switch status {
+		{{- range .Errors }}
+		case {{ .Status }}:
+			return new({{ .Type }})
+		{{- end }}
+		}
+		return nil
+	}
+	{{- else -}}
+	nil
+	{{- end -}}
+{{- end }}
+{{- define "query_param" }}
+	{{- if .DeepObject }}
+		for k, v := range {{ .Expr }} {
+			query.Set({{ printf "%q" .Name }}+"["+chipiFormat(k)+"]", chipiFormat(v))
+		}
+	{{- else if .Separator }}
+		query.Set({{ printf "%q" .Name }}, chipiJoin({{ .Expr }}, {{ printf "%q" .Separator }}))
+	{{- else }}
+		query.Set({{ printf "%q" .Name }}, chipiFormat({{ .Expr }}))
+	{{- end }}
+{{- end }}
+// Code generated by chipi. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+// Client calls the api endpoints
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// ClientError is returned when the server answers with an error status code,
+// Value is the body decoded with its documented type (a *ClientProblem for
+// the problems)
+type ClientError struct {
+	StatusCode int
+	Body       []byte
+	Value      interface{}
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// Unwrap returns Value if it is an error (ex: a *ClientProblem)
+func (e *ClientError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// Problem returns the problem+json body of the response, if any
+func (e *ClientError) Problem() *ClientProblem {
+	problem, _ := e.Value.(*ClientProblem)
+	return problem
+}
+
+// ClientProblem is an RFC 7807 error sent by the server
+type ClientProblem struct {
+	Type          string               ` + "`json:\"type,omitempty\"`" + `
+	Title         string               ` + "`json:\"title\"`" + `
+	Status        int                  ` + "`json:\"status\"`" + `
+	Detail        string               ` + "`json:\"detail,omitempty\"`" + `
+	Instance      string               ` + "`json:\"instance,omitempty\"`" + `
+	InvalidParams []ClientInvalidParam ` + "`json:\"invalid-params,omitempty\"`" + `
+	RequestID     string               ` + "`json:\"request_id,omitempty\"`" + `
+}
+
+// ClientInvalidParam describes one invalid parameter of a ClientProblem
+type ClientInvalidParam struct {
+	Name   string ` + "`json:\"name\"`" + `
+	Reason string ` + "`json:\"reason\"`" + `
+}
+
+func (p *ClientProblem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+	}
+
+	return p.Title
+}
+
+{{ range .Methods }}
+func (c *Client) {{ .Name }}(ctx context.Context, req *{{ .RequestType }}) {{ if .ResponseType }}(*{{ .ResponseType }}, error){{ else }}error{{ end }} {
+	{{- if .PathCheck }}
+	if {{ .PathCheck }} {
+		return {{ if .ResponseType }}nil, {{ end }}fmt.Errorf("Path is required")
+	}
+	{{- end }}
+
+	path := {{ .PathExpr }}
+	query := url.Values{}
+	header := http.Header{}
+	var body interface{}
+
+	{{- if or .Query .QueryRest }}
+	if {{ .QueryCheck }} {
+		{{- range .Query }}
+		{{- if .Check }}
+		if {{ .Check }} {
+			{{- template "query_param" . }}
+		}
+		{{- else }}
+		{{- template "query_param" . }}
+		{{- end }}
+		{{- end }}
+		{{- if .QueryRest }}
+		for k, values := range {{ .QueryRest }} {
+			for _, v := range values {
+				query.Add(k, v)
+			}
+		}
+		{{- end }}
+	}
+	{{- end }}
+
+	{{- if .Header }}
+	if {{ .HeaderCheck }} {
+		{{- range .Header }}
+		{{- if .Check }}
+		if {{ .Check }} {
+			header.Set({{ printf "%q" .Name }}, chipiFormat({{ .Expr }}))
+		}
+		{{- else }}
+		header.Set({{ printf "%q" .Name }}, chipiFormat({{ .Expr }}))
+		{{- end }}
+		{{- end }}
+	}
+	{{- end }}
+
+	{{- if .Cookie }}
+	if {{ .CookieCheck }} {
+		{{- range .Cookie }}
+		{{- if .Check }}
+		if {{ .Check }} {
+			header.Add("Cookie", (&http.Cookie{Name: {{ printf "%q" .Name }}, Value: chipiFormat({{ .Expr }})}).String())
+		}
+		{{- else }}
+		header.Add("Cookie", (&http.Cookie{Name: {{ printf "%q" .Name }}, Value: chipiFormat({{ .Expr }})}).String())
+		{{- end }}
+		{{- end }}
+	}
+	{{- end }}
+
+	{{- if .BodyCheck }}
+	if {{ .BodyCheck }} {
+		body = {{ .BodyExpr }}
+	}
+	{{- else if .BodyExpr }}
+	body = {{ .BodyExpr }}
+	{{- end }}
+
+	resp, err := c.do(ctx, {{ printf "%q" .HTTPMethod }}, path, query, header, body, {{ template "error_body" . }})
+	if err != nil {
+		return {{ if .ResponseType }}nil, {{ end }}err
+	}
+	defer resp.Body.Close()
+
+	{{- if .ResponseType }}
+	ret := new({{ .ResponseType }})
+	err = chipiDecode(resp.Body, ret, {{ .RawResponse }})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+	{{- else }}
+
+	return nil
+	{{- end }}
+}
+{{ end }}
+
+// do sends the request, errorBody returns the value to decode the error
+// bodies into for a status code (nil if not documented)
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, header http.Header, body interface{}, errorBody func(status int) interface{}) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		reader = strings.NewReader(b.Encode())
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+	default:
+		data, err := chipiMarshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, values := range header {
+		req.Header[k] = values
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, chipiError(resp, data, errorBody)
+	}
+
+	return resp, nil
+}
+
+// chipiDefaultError returns the value the other error bodies are decoded into
+func chipiDefaultError() interface{} {
+	return {{ if .DefaultError }}new({{ .DefaultError }}){{ else }}nil{{ end }}
+}
+
+// chipiError decodes the error body of resp into the type documented for its
+// status code, a ClientProblem for the problems or the default error
+func chipiError(resp *http.Response, data []byte, errorBody func(status int) interface{}) *ClientError {
+	ret := &ClientError{StatusCode: resp.StatusCode, Body: data}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return ret
+	}
+
+	var target interface{}
+	if errorBody != nil {
+		target = errorBody(resp.StatusCode)
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	if (target == nil) && strings.EqualFold(mediaType, "application/problem+json") {
+		target = new(ClientProblem)
+	}
+
+	if target == nil {
+		target = chipiDefaultError()
+	}
+
+	if (target != nil) && (chipiDecode(bytes.NewReader(data), target, false) == nil) {
+		ret.Value = target
+	}
+
+	return ret
+}
+
+func chipiDecode(r io.Reader, target interface{}, raw bool) error {
+	if raw {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		reflect.ValueOf(target).Elem().SetBytes(data)
+		return nil
+	}
+
+	// the numbers are kept as is, float64 would round the large integers
+	var decoded interface{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	err := decoder.Decode(&decoded)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(chipiRename(decoded, reflect.TypeOf(target), false))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+// chipiMarshal encodes a body with the names expected by the server
+func chipiMarshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(chipiRename(decoded, reflect.TypeOf(v), true))
+}
+
+// chipiRename rewrites the keys of a json value of type t, the server uses the
+// names of the chipi tags (` + "`chipi:\"name=...\"`" + `), ignores the excluded fields
+// and rejects the read only ones
+func chipiRename(value interface{}, t reflect.Type, encoding bool) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if (t.Kind() == reflect.Slice) || (t.Kind() == reflect.Array) {
+			for i, item := range v {
+				v[i] = chipiRename(item, t.Elem(), encoding)
+			}
+		}
+
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, item := range v {
+				v[key] = chipiRename(item, t.Elem(), encoding)
+			}
+
+		case reflect.Struct:
+			ret := map[string]interface{}{}
+			chipiRenameFields(v, ret, t, encoding)
+			return ret
+		}
+	}
+
+	return value
+}
+
+func chipiRenameFields(src map[string]interface{}, dst map[string]interface{}, t reflect.Type, encoding bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonTag, named := f.Tag.Lookup("json")
+		if jsonTag == "-" {
+			continue
+		}
+
+		fieldType := f.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if f.Anonymous && !named && (fieldType.Kind() == reflect.Struct) {
+			chipiRenameFields(src, dst, fieldType, encoding)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		jsonName := strings.Split(jsonTag, ",")[0]
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+
+		name := jsonName
+		skipped := false
+		for _, option := range strings.Split(f.Tag.Get("chipi"), ",") {
+			switch {
+			case option == "-":
+				skipped = true
+			case (option == "readonly") && encoding:
+				skipped = true
+			case strings.HasPrefix(option, "name="):
+				name = strings.TrimPrefix(option, "name=")
+			}
+		}
+
+		if skipped {
+			continue
+		}
+
+		from, to := jsonName, name
+		if !encoding {
+			from, to = to, from
+		}
+
+		if item, found := src[from]; found {
+			dst[to] = chipiRename(item, f.Type, encoding)
+		}
+	}
+}
+
+// chipiForm encodes a structure as form values
+func chipiForm(v interface{}) url.Values {
+	values := url.Values{}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if (f.PkgPath != "") || (name == "-") || rv.Field(i).IsZero() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		field := reflect.Indirect(rv.Field(i))
+		if field.Kind() == reflect.Map {
+			iter := field.MapRange()
+			for iter.Next() {
+				values.Set(name+"["+chipiFormat(iter.Key().Interface())+"]", chipiFormat(iter.Value().Interface()))
+			}
+			continue
+		}
+
+		values.Set(name, chipiFormat(field.Interface()))
+	}
+
+	return values
+}
+
+func chipiIsZero(v interface{}) bool {
+	return reflect.ValueOf(v).IsZero()
+}
+
+// chipiFormat serializes a parameter the way chipi parses it
+func chipiFormat(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		data, _ := m.MarshalText()
+		return string(data)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+
+	case reflect.Slice:
+		return chipiJoin(v, ",")
+
+	case reflect.Struct, reflect.Map:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func chipiJoin(v interface{}, separator string) string {
+	rv := reflect.ValueOf(v)
+	parts := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		parts[i] = chipiFormat(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, separator)
+}
+`))
+
+// packages always imported by the generated code
+var _clientBaseImports = map[string]bool{
+	"bytes":         true,
+	"context":       true,
+	"encoding":      true,
+	"encoding/json": true,
+	"fmt":           true,
+	"io":            true,
+	"net/http":      true,
+	"net/url":       true,
+	"reflect":       true,
+	"strings":       true,
+	"time":          true,
+}
+
+// clientImports keeps track of the packages referenced by the generated code
+type clientImports struct {
+	pkgName string
+	imports map[string]string
+}
+
+func (ci *clientImports) qualifier(t reflect.Type) string {
+	if (t.PkgPath() == "") || (pkgName(t) == ci.pkgName) {
+		return ""
+	}
+
+	ci.imports[t.PkgPath()] = pkgName(t)
+	return pkgName(t) + "."
+}
+
+func (ci *clientImports) list() []string {
+	ret := []string{}
+	for path := range ci.imports {
+		if _clientBaseImports[path] {
+			continue
+		}
+		ret = append(ret, strconv.Quote(path))
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func pkgName(t reflect.Type) string {
+	parts := strings.Split(t.PkgPath(), "/")
+	return parts[len(parts)-1]
+}
+
+// typeExpr returns the go expression for t
+func (ci *clientImports) typeExpr(t reflect.Type) string {
+	if t.Name() != "" {
+		return ci.qualifier(t) + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + ci.typeExpr(t.Elem())
+	case reflect.Slice:
+		return "[]" + ci.typeExpr(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), ci.typeExpr(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", ci.typeExpr(t.Key()), ci.typeExpr(t.Elem()))
+	case reflect.Struct:
+		fields := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			field := f.Name + " " + ci.typeExpr(f.Type)
+			if f.Anonymous {
+				field = ci.typeExpr(f.Type)
+			}
+			if f.Tag != "" {
+				field += " " + strconv.Quote(string(f.Tag))
+			}
+			fields = append(fields, field)
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	}
+
+	return t.String()
+}
+
+func clientMethodName(typ reflect.Type) string {
+	name := strings.TrimSuffix(typ.Name(), "Request")
+	if name == "" {
+		return typ.Name()
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// patternSegment is either a literal part of a route pattern or the name
+// of a parameter
+type patternSegment struct {
+	Literal string
+	Param   string
+}
+
+// patternSegments splits a route pattern, the regular expressions of the
+// parameters are dropped
+func patternSegments(pattern string) []patternSegment {
+	segments := []patternSegment{}
+	literal := ""
+	depth := 0
+	start := 0
+
+	for i, c := range pattern {
+		switch {
+		case c == '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+
+		case c == '}':
+			depth--
+			if depth == 0 {
+				name := pattern[start:i]
+				if idx := strings.Index(name, ":"); idx >= 0 {
+					name = name[:idx]
+				}
+				if literal != "" {
+					segments = append(segments, patternSegment{Literal: literal})
+					literal = ""
+				}
+				segments = append(segments, patternSegment{Param: name})
+			}
+
+		case depth == 0:
+			literal += string(c)
+		}
+	}
+
+	if (literal != "") || (len(segments) == 0) {
+		segments = append(segments, patternSegment{Literal: literal})
+	}
+
+	return segments
+}
+
+// clientPathExpr converts a route pattern to a go expression building the path
+func clientPathExpr(pattern string) string {
+	parts := []string{}
+	for _, segment := range patternSegments(pattern) {
+		if segment.Param != "" {
+			parts = append(parts, fmt.Sprintf("url.PathEscape(chipiFormat(req.Path.%s))", segment.Param))
+		} else {
+			parts = append(parts, strconv.Quote(segment.Literal))
+		}
+	}
+
+	return strings.Join(parts, " + ")
+}
+
+// clientParams returns the parameters of a section (Query, Header, Cookie)
+func clientParams(section string, st reflect.Type, paramName func(reflect.StructField) string) ([]clientParam, string) {
+	params := []clientParam{}
+	rest := ""
+
+	for _, f := range wrapper.SectionFields(st) {
+		if f.PkgPath != "" {
+			continue
+		}
+
+		expr := fmt.Sprintf("req.%s.%s", section, f.Name)
+
+		tag := schema.ParseJsonTag(f)
+		if (tag.Rest != nil) && *tag.Rest {
+			rest = expr
+			continue
+		}
+
+		param := clientParam{
+			Name:  paramName(f),
+			Check: fmt.Sprintf("!chipiIsZero(%s)", expr),
+			Expr:  expr,
+		}
+
+		param.DeepObject = (section == "Query") && (sectionType(f.Type).Kind() == reflect.Map)
+		if section == "Query" {
+			param.Separator = wrapper.DescribeParam(section, f).Separator
+		}
+
+		switch {
+		case f.Type.Kind() == reflect.Ptr:
+			param.Check = expr + " != nil"
+			param.Expr = "*" + expr
+
+		case (tag.Required != nil) && *tag.Required:
+			// always sent, the zero value may be valid
+			param.Check = ""
+		}
+
+		params = append(params, param)
+	}
+
+	return params, rest
+}
+
+func sectionCheck(section string, t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return fmt.Sprintf("req.%s != nil", section)
+	}
+
+	return "true"
+}
+
+// clientSkipped returns true for the routes the generated clients cannot call
+func clientSkipped(m *Method) bool {
+	typ := reflect.TypeOf(m.reqObject).Elem()
+
+	// event streams and websockets need a dedicated client
+	if _, ok := m.reqObject.(wrapper.Streamer); ok || wrapper.IsWebsocketHandler(m.reqObject) {
+		return true
+	}
+
+	// file uploads cannot be expressed with the request types
+	if bodyField, found := typ.FieldByName("Body"); found && ((bodyField.Tag.Get("content-type") == wrapper.MultipartContentType) || wrapper.IsStreamedBody(bodyField)) {
+		return true
+	}
+
+	return false
+}
+
+func (b *Builder) clientMethod(ci *clientImports, m *Method) (*clientMethod, error) {
+	typ := reflect.TypeOf(m.reqObject).Elem()
+
+	if clientSkipped(m) {
+		return nil, nil
+	}
+
+	routeContext, err := b.findRoute(typ, m.method, m.pattern)
+	if routeContext == nil {
+		return nil, err
+	}
+
+	cm := &clientMethod{
+		Name:        clientMethodName(typ),
+		HTTPMethod:  m.method,
+		RequestType: ci.typeExpr(typ),
+		PathExpr:    clientPathExpr(routeContext.RoutePattern()),
+	}
+
+	if pathField, found := typ.FieldByName("Path"); found && (pathField.Type.Kind() == reflect.Ptr) && (len(routeContext.URLParams.Keys) > 0) {
+		cm.PathCheck = "req.Path == nil"
+	}
+
+	if queryField, found := typ.FieldByName("Query"); found {
+		cm.QueryCheck = sectionCheck("Query", queryField.Type)
+		cm.Query, cm.QueryRest = clientParams("Query", sectionType(queryField.Type), wrapper.QueryParamName)
+	}
+
+	if headerField, found := typ.FieldByName("Header"); found {
+		cm.HeaderCheck = sectionCheck("Header", headerField.Type)
+		cm.Header, _ = clientParams("Header", sectionType(headerField.Type), wrapper.HeaderParamName)
+	}
+
+	if cookieField, found := typ.FieldByName("Cookie"); found {
+		cm.CookieCheck = sectionCheck("Cookie", cookieField.Type)
+		cm.Cookie, _ = clientParams("Cookie", sectionType(cookieField.Type), wrapper.CookieParamName)
+	}
+
+	if bodyField, found := typ.FieldByName("Body"); found {
+		cm.BodyExpr = "req.Body"
+		if bodyField.Type.Kind() == reflect.Ptr {
+			cm.BodyCheck = "req.Body != nil"
+		} else {
+			cm.BodyExpr = "&req.Body"
+		}
+
+		if contentType := bodyField.Tag.Get("content-type"); contentType == wrapper.FormContentType {
+			cm.BodyExpr = fmt.Sprintf("chipiForm(%s)", cm.BodyExpr)
+		}
+	}
+
+	cm.Errors = clientErrors(ci, m.reqObject)
+
+	if responseField, found := typ.FieldByName("Response"); found {
+		responseType := responseField.Type
+		if responseType.Kind() == reflect.Ptr {
+			responseType = responseType.Elem()
+		}
+
+		cm.ResponseType = ci.typeExpr(responseType)
+		cm.RawResponse = (responseType.Kind() == reflect.Slice) && (responseType.Elem().Kind() == reflect.Uint8)
+
+		// streams are read whole
+		if wrapper.IsStreamedResponse(responseField) {
+			cm.ResponseType = "[]byte"
+			cm.RawResponse = true
+		}
+	}
+
+	return cm, nil
+}
+
+// clientErrorType returns the type the error body is decoded into, the
+// problems use the ClientProblem of the generated code
+func clientErrorType(ci *clientImports, body interface{}) string {
+	if body == nil {
+		return ""
+	}
+
+	typ := reflect.TypeOf(body)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == _problemType {
+		return "ClientProblem"
+	}
+
+	return ci.typeExpr(typ)
+}
+
+// clientErrors returns the error bodies documented for reqObject, see ErrorResponder
+func clientErrors(ci *clientImports, reqObject interface{}) []clientErrorBody {
+	ret := []clientErrorBody{}
+
+	if responder, ok := reqObject.(ErrorResponder); ok {
+		for status, body := range responder.ErrorResponses() {
+			if typ := clientErrorType(ci, body); typ != "" {
+				ret = append(ret, clientErrorBody{Status: status, Type: typ})
+			}
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Status < ret[j].Status
+	})
+
+	return ret
+}
+
+// GenerateClient writes the source of a go client for every registered
+// route, the code only depends on the standard library and the request types
+// (multipart uploads, event streams and websockets are skipped)
+func (b *Builder) GenerateClient(pkgName string, w io.Writer) error {
+	ci := &clientImports{
+		pkgName: pkgName,
+		imports: map[string]string{},
+	}
+
+	methods := []*clientMethod{}
+	names := map[string]bool{}
+
+	for _, m := range b.methods {
+		cm, err := b.clientMethod(ci, m)
+		if err != nil {
+			return err
+		}
+
+		if cm == nil {
+			continue
+		}
+
+		if names[cm.Name] {
+			return errors.Errorf("duplicate client method %s", cm.Name)
+		}
+		names[cm.Name] = true
+
+		methods = append(methods, cm)
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	defaultError := clientErrorType(ci, b.defaultError)
+
+	buffer := bytes.NewBufferString("")
+	err := clientTemplate.Execute(buffer, map[string]interface{}{
+		"Package":      pkgName,
+		"Imports":      ci.list(),
+		"Methods":      methods,
+		"DefaultError": defaultError,
+	})
+	if err != nil {
+		return err
+	}
+
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "invalid generated client")
+	}
+
+	_, err = w.Write(source)
+	return err
+}
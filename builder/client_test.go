@@ -0,0 +1,266 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/internal/testdata/petclient"
+	"github.com/schmurfy/chipi/request"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ClientTestPet struct {
+	Name string `json:"name"`
+}
+
+type GetClientPetRequest struct {
+	response.ErrorEncoder
+	response.JsonEncoder
+
+	Path struct {
+		Id int
+	} `example:"/pets/43"`
+
+	Query struct {
+		Count  *int
+		Tags   []string `json:"tag"`
+		Colors []string `style:"pipeDelimited"`
+		Range  map[string]int
+		Extra  map[string][]string `chipi:"rest"`
+	}
+
+	Header struct {
+		ApiKey string `name:"X-Api-Key" chipi:"required"`
+	}
+
+	Cookie struct {
+		Session string `name:"session_id"`
+	}
+
+	Response ClientTestPet
+}
+
+func (r *GetClientPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type DeleteClientPetRequest struct {
+	response.ErrorEncoder
+	request.JsonBodyDecoder
+
+	Path *struct {
+		Id int
+	} `example:"/pets/43"`
+
+	Body *ClientTestPet
+}
+
+func (r *DeleteClientPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+// clientGeneratedTest runs the generated client against a fake server
+const clientGeneratedTest = `package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/schmurfy/chipi/internal/testdata/petclient"
+	"github.com/schmurfy/chipi/response"
+)
+
+func TestGeneratedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST":
+			// the body is sent back as is
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.Copy(w, r.Body)
+		case (r.Method == "GET") && (r.Header.Get("X-Api-Key") != "secret"):
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(` + "`" + `{"title": "Unauthorized", "status": 401}` + "`" + `))
+		case r.URL.Path == "/pets/1":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(` + "`" + `{"missing": "pet"}` + "`" + `))
+		case r.URL.Path == "/pets/2":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(` + "`" + `{"title": "Conflict", "status": 409, "detail": "busy"}` + "`" + `))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(` + "`" + `{"status": 500, "code": "internal", "message": "boom"}` + "`" + `))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	ctx := context.Background()
+
+	// larger than 2^53
+	created := petclient.Pet{ID: 9007199254740993, Name: "rex", Nickname: "rexy"}
+	pet, err := c.CreatePet(ctx, &petclient.CreatePetRequest{Body: &created})
+	if (err != nil) || (*pet != created) {
+		t.Fatalf("unexpected create result: %v, %v", pet, err)
+	}
+
+	var problem *ClientProblem
+	_, err = c.GetPet(ctx, &petclient.GetPetRequest{})
+	if !errors.As(err, &problem) || (problem.Status != http.StatusUnauthorized) {
+		t.Fatalf("expected an unauthorized problem, got %v", err)
+	}
+
+	var clientErr *ClientError
+	req := &petclient.GetPetRequest{}
+	req.Path.Id = 1
+	req.Header.ApiKey = "secret"
+	_, err = c.GetPet(ctx, req)
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a client error, got %v", err)
+	}
+	if notFound, ok := clientErr.Value.(*petclient.NotFound); !ok || (notFound.Missing != "pet") {
+		t.Fatalf("expected a not found body, got %v", clientErr.Value)
+	}
+
+	req.Path.Id = 2
+	_, err = c.GetPet(ctx, req)
+	if !errors.As(err, &clientErr) || (clientErr.Problem() == nil) || (clientErr.Problem().Detail != "busy") {
+		t.Fatalf("expected a conflict problem, got %v", err)
+	}
+
+	err = c.DeletePet(ctx, &petclient.DeletePetRequest{Path: &struct{ Id int }{Id: 3}})
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a client error, got %v", err)
+	}
+	if apiErr, ok := clientErr.Value.(*response.Error); !ok || (apiErr.Message != "boom") {
+		t.Fatalf("expected the default error, got %v", clientErr.Value)
+	}
+
+	err = c.DeletePet(ctx, &petclient.DeletePetRequest{})
+	if (err == nil) || (err.Error() != "Path is required") {
+		t.Fatalf("expected a missing path error, got %v", err)
+	}
+}
+`
+
+func TestClient(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("GenerateClient", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			router := chi.NewRouter()
+
+			var err error
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			petsRoute := chi.NewRouter()
+			router.Mount("/pets", petsRoute)
+
+			err = b.Get(petsRoute, "/{Id}", &GetClientPetRequest{})
+			require.NoError(g, err)
+
+			err = b.Delete(petsRoute, "/{Id}", &DeleteClientPetRequest{})
+			require.NoError(g, err)
+		})
+
+		g.It("should generate a method per route", func() {
+			buffer := bytes.NewBufferString("")
+			err := b.GenerateClient("builder", buffer)
+			require.NoError(g, err)
+
+			source := buffer.String()
+			assert.Contains(g, source, "package builder\n")
+			assert.Contains(g, source, "func (c *Client) GetClientPet(ctx context.Context, req *GetClientPetRequest) (*ClientTestPet, error) {")
+			assert.Contains(g, source, "func (c *Client) DeleteClientPet(ctx context.Context, req *DeleteClientPetRequest) error {")
+			assert.Contains(g, source, `path := "/pets/" + url.PathEscape(chipiFormat(req.Path.Id))`)
+			assert.Contains(g, source, `query.Set("count", chipiFormat(*req.Query.Count))`)
+			assert.Contains(g, source, `query.Set("tag", chipiFormat(req.Query.Tags))`)
+			assert.Contains(g, source, `query.Set("colors", chipiJoin(req.Query.Colors, "|"))`)
+			assert.Contains(g, source, "for k, values := range req.Query.Extra {")
+			assert.Contains(g, source, `query.Set("range"+"["+chipiFormat(k)+"]", chipiFormat(v))`)
+			assert.Contains(g, source, "{\n\t\theader.Set(\"X-Api-Key\", chipiFormat(req.Header.ApiKey))\n\t}")
+			assert.Contains(g, source, `header.Add("Cookie", (&http.Cookie{Name: "session_id", Value: chipiFormat(req.Cookie.Session)}).String())`)
+			assert.Contains(g, source, "if req.Path == nil {")
+			assert.Contains(g, source, "body = req.Body")
+			assert.Contains(g, source, "data, err := chipiMarshal(body)")
+		})
+
+		g.It("should qualify types from other packages", func() {
+			buffer := bytes.NewBufferString("")
+			err := b.GenerateClient("client", buffer)
+			require.NoError(g, err)
+
+			source := buffer.String()
+			assert.Contains(g, source, `"github.com/schmurfy/chipi/builder"`)
+			assert.Contains(g, source, "req *builder.GetClientPetRequest) (*builder.ClientTestPet, error)")
+		})
+
+		g.It("should generate a client decoding the error bodies", func() {
+			g.Timeout(2 * time.Minute)
+
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+			b.SetDefaultErrorResponse(&response.Error{})
+
+			require.NoError(g, b.Get(router, "/pets/{Id}", &petclient.GetPetRequest{}))
+			require.NoError(g, b.Post(router, "/pets", &petclient.CreatePetRequest{}))
+			require.NoError(g, b.Delete(router, "/pets/{Id}", &petclient.DeletePetRequest{}))
+
+			buffer := bytes.NewBufferString("")
+			err = b.GenerateClient("client", buffer)
+			require.NoError(g, err)
+
+			source := buffer.String()
+			assert.Contains(g, source, "case 404:\n\t\t\treturn new(petclient.NotFound)")
+			assert.Contains(g, source, "case 409:\n\t\t\treturn new(ClientProblem)")
+			assert.NotContains(g, source, "case 410:")
+			assert.Contains(g, source, "return new(response.Error)")
+
+			// the package must be in the module to import the internal request types
+			dir, err := os.MkdirTemp("../internal/testdata/petclient", "client")
+			require.NoError(g, err)
+			defer os.RemoveAll(dir)
+
+			require.NoError(g, os.WriteFile(filepath.Join(dir, "client.go"), buffer.Bytes(), 0600))
+			require.NoError(g, os.WriteFile(filepath.Join(dir, "client_test.go"), []byte(clientGeneratedTest), 0600))
+
+			cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "test", ".")
+			cmd.Dir = dir
+			output, err := cmd.CombinedOutput()
+			require.NoError(g, err, string(output))
+		})
+
+		g.It("should be deterministic", func() {
+			first := bytes.NewBufferString("")
+			err := b.GenerateClient("builder", first)
+			require.NoError(g, err)
+
+			second := bytes.NewBufferString("")
+			err = b.GenerateClient("builder", second)
+			require.NoError(g, err)
+
+			assert.Equal(g, first.String(), second.String())
+		})
+	})
+}
@@ -0,0 +1,187 @@
+package builder
+
+import (
+	"go/ast"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// docComments holds the doc comments of the structures of the loaded
+// packages, indexed by package path and type name
+type docComments map[string]*typeComments
+
+type typeComments struct {
+	doc string
+
+	// indexed by field name, section fields are prefixed by
+	// the section name (ex: "Query.Count")
+	fields map[string]string
+}
+
+// LoadDocComments parses the packages matching patterns (ex: "./api/...") and
+// uses the doc comments of the structures and of their fields as descriptions
+// when none is given by tags or annotations, comments with annotations
+// (ex: "@description") are left to chipi-gen
+func (b *Builder) LoadDocComments(patterns ...string) error {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+	}, patterns...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if b.comments == nil {
+		b.comments = docComments{}
+	}
+
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return errors.Errorf("failed to load %s: %s", pkg.PkgPath, pkg.Errors[0])
+		}
+
+		// reflect reports "main" as the path of main packages
+		paths := []string{pkg.PkgPath}
+		if pkg.Name == "main" {
+			paths = append(paths, "main")
+		}
+
+		for _, file := range pkg.Syntax {
+			for name, comments := range fileComments(file) {
+				for _, path := range paths {
+					b.comments[path+"."+name] = comments
+				}
+			}
+		}
+	}
+
+	b.schema.SetDescriptions(b.comments.describe)
+
+	return nil
+}
+
+func fileComments(file *ast.File) map[string]*typeComments {
+	ret := map[string]*typeComments{}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			st, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			doc := typeSpec.Doc
+			if (doc == nil) && (len(genDecl.Specs) == 1) {
+				doc = genDecl.Doc
+			}
+
+			comments := &typeComments{
+				doc:    commentText(doc),
+				fields: map[string]string{},
+			}
+			addFieldComments(comments.fields, "", st)
+
+			ret[typeSpec.Name.Name] = comments
+		}
+	}
+
+	return ret
+}
+
+func addFieldComments(fields map[string]string, prefix string, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		text := commentText(field.Doc)
+		if text == "" {
+			text = commentText(field.Comment)
+		}
+
+		for _, name := range field.Names {
+			if text != "" {
+				fields[prefix+name.Name] = text
+			}
+
+			// inline sections (ex: Query struct { ... })
+			if (prefix == "") && isSectionName(name.Name) {
+				if sectionStruct, ok := field.Type.(*ast.StructType); ok {
+					addFieldComments(fields, name.Name+".", sectionStruct)
+				}
+			}
+		}
+	}
+}
+
+// commentText returns the text of a comment, empty if it contains annotations
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+
+	text := strings.TrimSpace(group.Text())
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "@") {
+			return ""
+		}
+	}
+
+	return text
+}
+
+func (c docComments) lookup(t reflect.Type) *typeComments {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if c == nil {
+		return nil
+	}
+
+	return c[t.PkgPath()+"."+t.Name()]
+}
+
+// describe implements schema.DescriptionFunc
+func (c docComments) describe(t reflect.Type, f *reflect.StructField) string {
+	if f == nil {
+		return c.typeDoc(t)
+	}
+
+	return c.fieldDoc(t, f.Name)
+}
+
+func (c docComments) typeDoc(t reflect.Type) string {
+	if comments := c.lookup(t); comments != nil {
+		return comments.doc
+	}
+
+	return ""
+}
+
+// fieldDoc returns the comment of a field of t, path is the field name
+// optionally prefixed by its section (ex: "Query.Count")
+func (c docComments) fieldDoc(t reflect.Type, path string) string {
+	if comments := c.lookup(t); comments != nil {
+		return comments.fields[path]
+	}
+
+	return ""
+}
+
+func isSectionName(name string) bool {
+	switch name {
+	case "Path", "Query", "Header", "Cookie", "Body", "Response":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/internal/testdata/documented"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocComments(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("doc comments", func() {
+		var swagger *openapi3.T
+
+		g.Before(func() {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			err = b.LoadDocComments("../internal/testdata/documented")
+			require.NoError(g, err)
+
+			err = b.Get(router, "/pets/{Id}/owner", &documented.GetOwnerRequest{})
+			require.NoError(g, err)
+
+			err = b.Get(router, "/annotated", &documented.AnnotatedRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			swagger = convertToSwagger(g, data)
+		})
+
+		g.It("should describe the operation", func() {
+			op := swagger.Paths["/pets/{Id}/owner"].Get
+			require.NotNil(g, op)
+
+			assert.Equal(g, "GetOwnerRequest fetches the owner of a pet.", op.Description)
+			assert.Equal(g, "the owner", *op.Responses["200"].Value.Description)
+		})
+
+		g.It("should describe the parameters", func() {
+			op := swagger.Paths["/pets/{Id}/owner"].Get
+			require.NotNil(g, op)
+
+			assert.Equal(g, "Id of the pet.", op.Parameters.GetByInAndName("path", "Id").Description)
+			assert.Equal(g, "also return the contact details", op.Parameters.GetByInAndName("query", "full").Description)
+		})
+
+		g.It("should describe the schemas", func() {
+			owner := swagger.Components.Schemas["documented.Owner"]
+			require.NotNil(g, owner)
+
+			assert.Equal(g, "Owner is the owner of a pet.", owner.Value.Description)
+			assert.Equal(g, "Name is the full name of the owner.", owner.Value.Properties["Name"].Value.Description)
+			assert.Equal(g, "from the tag", owner.Value.Properties["Email"].Value.Description)
+		})
+
+		g.It("should ignore annotated comments", func() {
+			op := swagger.Paths["/annotated"].Get
+			require.NotNil(g, op)
+			assert.Empty(g, op.Description)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+func (b *Builder) generateCookiesDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type) error {
+	cookieField, found := requestObjectType.FieldByName("Cookie")
+	if !found {
+		return nil
+	}
+
+	cookieStructType := sectionType(cookieField.Type)
+	if cookieStructType.Kind() != reflect.Struct {
+		return errors.New("expected struct for Cookie")
+	}
+
+	for _, field := range wrapper.SectionFields(cookieStructType) {
+		if !isDocumentedParam(field) {
+			continue
+		}
+
+		schema, err := b.schema.GenerateSchemaFor(ctx, swagger, field.Type)
+		if err != nil {
+			return err
+		}
+
+		param := openapi3.NewCookieParameter(field.Name).
+			WithSchema(schema.Value)
+
+		err = b.fillParamFromTags(requestObjectType, param, field, "Cookie")
+		if err != nil {
+			return err
+		}
+
+		if name := field.Tag.Get("name"); name != "" {
+			param.Name = name
+		}
+
+		op.AddParameter(param)
+	}
+
+	return nil
+}
@@ -0,0 +1,487 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+)
+
+const (
+	componentSchemaPrefix = "#/components/schemas/"
+)
+
+// direction tells who sends a schema, the compatibility rules of the
+// responses are the reverse of the request ones
+type direction int
+
+const (
+	// requestDirection schemas are sent by the clients
+	requestDirection direction = iota
+	// responseDirection schemas are sent by the server
+	responseDirection
+)
+
+// Change describes a single difference between two documents
+type Change struct {
+	Breaking bool   `json:"breaking"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "breaking"
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", kind, c.Location, c.Message)
+}
+
+// Report lists the changes found by Diff
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// HasBreakingChanges returns true if at least one change is breaking
+func (r Report) HasBreakingChanges() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BreakingChanges returns only the breaking changes
+func (r Report) BreakingChanges() []Change {
+	ret := []Change{}
+	for _, c := range r.Changes {
+		if c.Breaking {
+			ret = append(ret, c)
+		}
+	}
+
+	return ret
+}
+
+func (r Report) String() string {
+	lines := make([]string, 0, len(r.Changes))
+	for _, c := range r.Changes {
+		lines = append(lines, c.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns the machine readable version of the report
+func (r Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+type differ struct {
+	oldDoc  *openapi3.T
+	newDoc  *openapi3.T
+	changes []Change
+}
+
+func (d *differ) breaking(location string, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Breaking: true,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) nonBreaking(location string, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Diff compares two documents and reports what changed between them,
+// schema references are resolved before comparison and the response
+// schemas break the clients when they widen rather than narrow
+func Diff(oldDoc *openapi3.T, newDoc *openapi3.T) (Report, error) {
+	if (oldDoc == nil) || (newDoc == nil) {
+		return Report{}, errors.New("both documents are required")
+	}
+
+	d := &differ{
+		oldDoc: oldDoc,
+		newDoc: newDoc,
+	}
+
+	d.diffPaths()
+
+	return Report{Changes: d.changes}, nil
+}
+
+// DiffAgainstFile compares a previously saved document with the current one
+func (b *Builder) DiffAgainstFile(ctx context.Context, path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, errors.WithStack(err)
+	}
+
+	oldDoc := &openapi3.T{}
+	err = oldDoc.UnmarshalJSON(data)
+	if err != nil {
+		return Report{}, errors.WithStack(err)
+	}
+
+	data, err = b.generateSpec(ctx, nil)
+	if err != nil {
+		return Report{}, err
+	}
+
+	newDoc := &openapi3.T{}
+	err = newDoc.UnmarshalJSON(data)
+	if err != nil {
+		return Report{}, errors.WithStack(err)
+	}
+
+	return Diff(oldDoc, newDoc)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *differ) diffPaths() {
+	for _, path := range sortedKeys(d.oldDoc.Paths) {
+		newItem, found := d.newDoc.Paths[path]
+		if !found {
+			d.breaking(path, "path removed")
+			continue
+		}
+
+		oldOps := d.oldDoc.Paths[path].Operations()
+		newOps := newItem.Operations()
+
+		for _, method := range sortedKeys(oldOps) {
+			location := method + " " + path
+			newOp, found := newOps[method]
+			if !found {
+				d.breaking(location, "operation removed")
+				continue
+			}
+
+			d.diffOperation(location, oldOps[method], newOp)
+		}
+
+		for _, method := range sortedKeys(newOps) {
+			if _, found := oldOps[method]; !found {
+				d.nonBreaking(method+" "+path, "operation added")
+			}
+		}
+	}
+
+	for _, path := range sortedKeys(d.newDoc.Paths) {
+		if _, found := d.oldDoc.Paths[path]; !found {
+			d.nonBreaking(path, "path added")
+		}
+	}
+}
+
+func parameterKey(p *openapi3.Parameter) string {
+	return p.In + "." + p.Name
+}
+
+func parametersMap(params openapi3.Parameters) map[string]*openapi3.Parameter {
+	ret := map[string]*openapi3.Parameter{}
+	for _, p := range params {
+		if (p != nil) && (p.Value != nil) {
+			ret[parameterKey(p.Value)] = p.Value
+		}
+	}
+
+	return ret
+}
+
+func parameterSchema(p *openapi3.Parameter) *openapi3.SchemaRef {
+	if p.Schema != nil {
+		return p.Schema
+	}
+
+	for _, mt := range p.Content {
+		return mt.Schema
+	}
+
+	return nil
+}
+
+func (d *differ) diffOperation(location string, oldOp *openapi3.Operation, newOp *openapi3.Operation) {
+	if oldOp.Description != newOp.Description || oldOp.Summary != newOp.Summary {
+		d.nonBreaking(location, "description changed")
+	}
+
+	// parameters
+	oldParams := parametersMap(oldOp.Parameters)
+	newParams := parametersMap(newOp.Parameters)
+
+	for _, key := range sortedKeys(oldParams) {
+		paramLocation := location + " parameter " + key
+		oldParam := oldParams[key]
+		newParam, found := newParams[key]
+		if !found {
+			d.breaking(paramLocation, "parameter removed")
+			continue
+		}
+
+		if !oldParam.Required && newParam.Required {
+			d.breaking(paramLocation, "parameter is now required")
+		}
+
+		if oldParam.Description != newParam.Description {
+			d.nonBreaking(paramLocation, "description changed")
+		}
+
+		d.diffSchema(paramLocation, requestDirection, parameterSchema(oldParam), parameterSchema(newParam), map[string]bool{})
+	}
+
+	for _, key := range sortedKeys(newParams) {
+		if _, found := oldParams[key]; !found {
+			if newParams[key].Required {
+				d.breaking(location+" parameter "+key, "required parameter added")
+			} else {
+				d.nonBreaking(location+" parameter "+key, "optional parameter added")
+			}
+		}
+	}
+
+	// request body
+	bodyLocation := location + " request body"
+	switch {
+	case (oldOp.RequestBody == nil) && (newOp.RequestBody != nil):
+		if newOp.RequestBody.Value.Required {
+			d.breaking(bodyLocation, "required request body added")
+		} else {
+			d.nonBreaking(bodyLocation, "request body added")
+		}
+
+	case (oldOp.RequestBody != nil) && (newOp.RequestBody == nil):
+		d.breaking(bodyLocation, "request body removed")
+
+	case (oldOp.RequestBody != nil) && (newOp.RequestBody != nil):
+		oldBody := oldOp.RequestBody.Value
+		newBody := newOp.RequestBody.Value
+
+		if !oldBody.Required && newBody.Required {
+			d.breaking(bodyLocation, "request body is now required")
+		}
+
+		d.diffContent(bodyLocation, requestDirection, oldBody.Content, newBody.Content)
+	}
+
+	// responses
+	for _, status := range sortedKeys(oldOp.Responses) {
+		responseLocation := location + " response " + status
+		newResponse, found := newOp.Responses[status]
+		if !found {
+			d.breaking(responseLocation, "response removed")
+			continue
+		}
+
+		if (oldOp.Responses[status].Value != nil) && (newResponse.Value != nil) {
+			d.diffContent(responseLocation, responseDirection, oldOp.Responses[status].Value.Content, newResponse.Value.Content)
+		}
+	}
+
+	for _, status := range sortedKeys(newOp.Responses) {
+		if _, found := oldOp.Responses[status]; !found {
+			d.nonBreaking(location+" response "+status, "response added")
+		}
+	}
+}
+
+func (d *differ) diffContent(location string, dir direction, oldContent openapi3.Content, newContent openapi3.Content) {
+	for _, mediaType := range sortedKeys(oldContent) {
+		newMediaType, found := newContent[mediaType]
+		if !found {
+			d.breaking(location+" "+mediaType, "media type removed")
+			continue
+		}
+
+		d.diffSchema(location+" "+mediaType, dir, oldContent[mediaType].Schema, newMediaType.Schema, map[string]bool{})
+	}
+
+	for _, mediaType := range sortedKeys(newContent) {
+		if _, found := oldContent[mediaType]; !found {
+			d.nonBreaking(location+" "+mediaType, "media type added")
+		}
+	}
+}
+
+// resolveSchema follows component references
+func resolveSchema(doc *openapi3.T, ref *openapi3.SchemaRef) *openapi3.Schema {
+	// a reference pointing to itself would loop forever
+	for i := 0; (ref != nil) && (i <= len(doc.Components.Schemas)); i++ {
+		if ref.Value != nil {
+			return ref.Value
+		}
+
+		if !strings.HasPrefix(ref.Ref, componentSchemaPrefix) {
+			return nil
+		}
+
+		ref = doc.Components.Schemas[strings.TrimPrefix(ref.Ref, componentSchemaPrefix)]
+	}
+
+	return nil
+}
+
+func (d *differ) diffSchema(location string, dir direction, oldRef *openapi3.SchemaRef, newRef *openapi3.SchemaRef, visited map[string]bool) {
+	// stop on recursive structures
+	if (oldRef != nil) && (newRef != nil) && (oldRef.Ref != "") {
+		key := oldRef.Ref + "|" + newRef.Ref
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+	}
+
+	oldSchema := resolveSchema(d.oldDoc, oldRef)
+	newSchema := resolveSchema(d.newDoc, newRef)
+
+	switch {
+	case (oldSchema == nil) && (newSchema == nil):
+		return
+	case newSchema == nil:
+		d.breaking(location, "schema removed")
+		return
+	case oldSchema == nil:
+		d.nonBreaking(location, "schema added")
+		return
+	}
+
+	if (oldSchema.Type != newSchema.Type) || (oldSchema.Format != newSchema.Format) {
+		d.breaking(location, "type changed from %s to %s", schemaType(oldSchema), schemaType(newSchema))
+		return
+	}
+
+	if oldSchema.Description != newSchema.Description {
+		d.nonBreaking(location, "description changed")
+	}
+
+	d.diffEnum(location, dir, oldSchema.Enum, newSchema.Enum)
+
+	if (oldSchema.Items != nil) || (newSchema.Items != nil) {
+		d.diffSchema(location+"[]", dir, oldSchema.Items, newSchema.Items, visited)
+	}
+
+	if (oldSchema.AdditionalProperties != nil) || (newSchema.AdditionalProperties != nil) {
+		d.diffSchema(location+".*", dir, oldSchema.AdditionalProperties, newSchema.AdditionalProperties, visited)
+	}
+
+	oldRequired := stringSet(oldSchema.Required)
+	newRequired := stringSet(newSchema.Required)
+
+	for _, name := range sortedKeys(oldSchema.Properties) {
+		propertyLocation := location + "." + name
+		newProperty, found := newSchema.Properties[name]
+		if !found {
+			d.breaking(propertyLocation, "field removed")
+			continue
+		}
+
+		// the clients must send the required request fields and can
+		// rely on the required response ones
+		switch {
+		case !oldRequired[name] && newRequired[name] && (dir == requestDirection):
+			d.breaking(propertyLocation, "field is now required")
+		case !oldRequired[name] && newRequired[name]:
+			d.nonBreaking(propertyLocation, "field is now required")
+		case oldRequired[name] && !newRequired[name] && (dir == responseDirection):
+			d.breaking(propertyLocation, "field is no longer required")
+		}
+
+		d.diffSchema(propertyLocation, dir, oldSchema.Properties[name], newProperty, visited)
+	}
+
+	for _, name := range sortedKeys(newSchema.Properties) {
+		if _, found := oldSchema.Properties[name]; !found {
+			if newRequired[name] && (dir == requestDirection) {
+				d.breaking(location+"."+name, "required field added")
+			} else if newRequired[name] {
+				d.nonBreaking(location+"."+name, "required field added")
+			} else {
+				d.nonBreaking(location+"."+name, "optional field added")
+			}
+		}
+	}
+}
+
+// diffEnum reports the values the clients can no longer send in requests
+// and the ones they may now receive in responses as breaking
+func (d *differ) diffEnum(location string, dir direction, oldValues []interface{}, newValues []interface{}) {
+	if (len(oldValues) == 0) && (len(newValues) == 0) {
+		return
+	}
+
+	narrowed, widened := d.breaking, d.nonBreaking
+	if dir == responseDirection {
+		narrowed, widened = widened, narrowed
+	}
+
+	if len(oldValues) == 0 {
+		narrowed(location, "enum restriction added")
+		return
+	}
+
+	if len(newValues) == 0 {
+		widened(location, "enum restriction removed")
+		return
+	}
+
+	oldSet := map[string]bool{}
+	for _, v := range oldValues {
+		oldSet[fmt.Sprint(v)] = true
+	}
+
+	newSet := map[string]bool{}
+	for _, v := range newValues {
+		newSet[fmt.Sprint(v)] = true
+	}
+
+	for _, v := range oldValues {
+		if !newSet[fmt.Sprint(v)] {
+			narrowed(location, "enum value %v removed", v)
+		}
+	}
+
+	for _, v := range newValues {
+		if !oldSet[fmt.Sprint(v)] {
+			widened(location, "enum value %v added", v)
+		}
+	}
+}
+
+func schemaType(s *openapi3.Schema) string {
+	if s.Format != "" {
+		return s.Type + "(" + s.Format + ")"
+	}
+
+	return s.Type
+}
+
+func stringSet(values []string) map[string]bool {
+	ret := map[string]bool{}
+	for _, v := range values {
+		ret[v] = true
+	}
+
+	return ret
+}
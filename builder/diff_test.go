@@ -0,0 +1,295 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type diffTestRequest struct {
+	response.ErrorEncoder
+	response.JsonEncoder
+
+	Path struct {
+		Id int
+	} `example:"/pets/43"`
+
+	Response struct {
+		Name string
+	}
+}
+
+func (r *diffTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func diffTestDoc(g *goblin.G, data string) *openapi3.T {
+	doc := &openapi3.T{}
+	err := doc.UnmarshalJSON([]byte(data))
+	require.NoError(g, err)
+	return doc
+}
+
+const diffTestBaseDoc = `{
+	"openapi": "3.0.0",
+	"info": {"title": "", "version": ""},
+	"paths": {
+		"/pets/{Id}": {
+			"get": {
+				"parameters": [
+					{"in": "path", "name": "Id", "required": true, "schema": {"type": "integer"}},
+					{"in": "query", "name": "count", "schema": {"type": "integer"}}
+				],
+				"responses": {
+					"200": {
+						"description": "",
+						"content": {
+							"application/json": {
+								"schema": {"$ref": "#/components/schemas/main.Pet"}
+							}
+						}
+					}
+				}
+			}
+		},
+		"/users": {
+			"get": {
+				"responses": {"204": {"description": "no data"}}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"main.Pet": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"kind": {"type": "string", "enum": ["cat", "dog", "bird"]},
+					"parent": {"$ref": "#/components/schemas/main.Pet"}
+				}
+			}
+		}
+	}
+}`
+
+// the same schema is sent and received
+const diffTestDirectionDoc = `{
+	"openapi": "3.0.0",
+	"info": {"title": "", "version": ""},
+	"paths": {
+		"/toys": {
+			"post": {
+				"requestBody": {
+					"content": {
+						"application/json": {
+							"schema": {"$ref": "#/components/schemas/main.Toy"}
+						}
+					}
+				},
+				"responses": {
+					"201": {
+						"description": "",
+						"content": {
+							"application/json": {
+								"schema": {"$ref": "#/components/schemas/main.Toy"}
+							}
+						}
+					}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"main.Toy": {
+				"type": "object",
+				"required": ["color"],
+				"properties": {
+					"color": {"type": "string", "enum": ["red", "green", "blue"]},
+					"size": {"type": "string", "enum": ["small", "large"]}
+				}
+			}
+		}
+	}
+}`
+
+func TestDiff(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Diff", func() {
+		g.It("should report no changes for identical documents", func() {
+			report, err := Diff(diffTestDoc(g, diffTestBaseDoc), diffTestDoc(g, diffTestBaseDoc))
+			require.NoError(g, err)
+
+			assert.Empty(g, report.Changes)
+			assert.False(g, report.HasBreakingChanges())
+		})
+
+		g.It("should detect breaking changes", func() {
+			newDoc := diffTestDoc(g, `{
+				"openapi": "3.0.0",
+				"info": {"title": "", "version": ""},
+				"paths": {
+					"/pets/{Id}": {
+						"get": {
+							"parameters": [
+								{"in": "path", "name": "Id", "required": true, "schema": {"type": "string"}}
+							],
+							"responses": {
+								"200": {
+									"description": "",
+									"content": {
+										"application/json": {
+											"schema": {"$ref": "#/components/schemas/main.Pet"}
+										}
+									}
+								}
+							}
+						}
+					}
+				},
+				"components": {
+					"schemas": {
+						"main.Pet": {
+							"type": "object",
+							"required": ["kind"],
+							"properties": {
+								"kind": {"type": "string", "enum": ["dog", "cat"]},
+								"parent": {"$ref": "#/components/schemas/main.Pet"}
+							}
+						}
+					}
+				}
+			}`)
+
+			report, err := Diff(diffTestDoc(g, diffTestBaseDoc), newDoc)
+			require.NoError(g, err)
+
+			assert.True(g, report.HasBreakingChanges())
+			assert.Equal(g, []Change{
+				{Breaking: true, Location: "GET /pets/{Id} parameter path.Id", Message: "type changed from integer to string"},
+				{Breaking: true, Location: "GET /pets/{Id} parameter query.count", Message: "parameter removed"},
+				{Location: "GET /pets/{Id} response 200 application/json.kind", Message: "field is now required"},
+				{Location: "GET /pets/{Id} response 200 application/json.kind", Message: "enum value bird removed"},
+				{Breaking: true, Location: "GET /pets/{Id} response 200 application/json.name", Message: "field removed"},
+				{Breaking: true, Location: "/users", Message: "path removed"},
+			}, report.Changes)
+		})
+
+		g.It("should detect non breaking changes", func() {
+			newDoc := diffTestDoc(g, diffTestBaseDoc)
+			pet := newDoc.Components.Schemas["main.Pet"].Value
+			pet.Description = "a pet"
+			pet.Properties["age"] = openapi3.NewSchemaRef("", openapi3.NewIntegerSchema())
+			pet.Properties["kind"].Value.Enum = []interface{}{"bird", "dog", "cat"}
+			newDoc.AddOperation("/shops", "POST", openapi3.NewOperation())
+
+			report, err := Diff(diffTestDoc(g, diffTestBaseDoc), newDoc)
+			require.NoError(g, err)
+
+			assert.False(g, report.HasBreakingChanges())
+			assert.Equal(g, []Change{
+				{Location: "GET /pets/{Id} response 200 application/json", Message: "description changed"},
+				{Location: "GET /pets/{Id} response 200 application/json.age", Message: "optional field added"},
+				{Location: "/shops", Message: "path added"},
+			}, report.Changes)
+		})
+
+		g.It("should reverse the rules of the responses", func() {
+			oldDoc := diffTestDoc(g, diffTestDirectionDoc)
+			newDoc := diffTestDoc(g, diffTestDirectionDoc)
+			toy := newDoc.Components.Schemas["main.Toy"].Value
+			toy.Required = []string{"size", "age"}
+			toy.Properties["color"].Value.Enum = []interface{}{"red", "green"}
+			toy.Properties["size"].Value.Enum = []interface{}{"small", "large", "huge"}
+			toy.Properties["age"] = openapi3.NewSchemaRef("", openapi3.NewIntegerSchema())
+
+			report, err := Diff(oldDoc, newDoc)
+			require.NoError(g, err)
+
+			assert.Equal(g, []Change{
+				{Breaking: true, Location: "POST /toys request body application/json.color", Message: "enum value blue removed"},
+				{Breaking: true, Location: "POST /toys request body application/json.size", Message: "field is now required"},
+				{Location: "POST /toys request body application/json.size", Message: "enum value huge added"},
+				{Breaking: true, Location: "POST /toys request body application/json.age", Message: "required field added"},
+				{Breaking: true, Location: "POST /toys response 201 application/json.color", Message: "field is no longer required"},
+				{Location: "POST /toys response 201 application/json.color", Message: "enum value blue removed"},
+				{Location: "POST /toys response 201 application/json.size", Message: "field is now required"},
+				{Breaking: true, Location: "POST /toys response 201 application/json.size", Message: "enum value huge added"},
+				{Location: "POST /toys response 201 application/json.age", Message: "required field added"},
+			}, report.Changes)
+		})
+
+		g.It("should report the enum restrictions by direction", func() {
+			oldDoc := diffTestDoc(g, diffTestDirectionDoc)
+			newDoc := diffTestDoc(g, diffTestDirectionDoc)
+			toy := newDoc.Components.Schemas["main.Toy"].Value
+			toy.Properties["color"].Value.Enum = nil
+			toy.Properties["size"].Value.Enum = nil
+			toy.Properties["name"] = openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithEnum("rex"))
+			oldDoc.Components.Schemas["main.Toy"].Value.Properties["name"] = openapi3.NewSchemaRef("", openapi3.NewStringSchema())
+
+			report, err := Diff(oldDoc, newDoc)
+			require.NoError(g, err)
+
+			assert.Equal(g, []Change{
+				{Location: "POST /toys request body application/json.color", Message: "enum restriction removed"},
+				{Breaking: true, Location: "POST /toys request body application/json.name", Message: "enum restriction added"},
+				{Location: "POST /toys request body application/json.size", Message: "enum restriction removed"},
+				{Breaking: true, Location: "POST /toys response 201 application/json.color", Message: "enum restriction removed"},
+				{Location: "POST /toys response 201 application/json.name", Message: "enum restriction added"},
+				{Breaking: true, Location: "POST /toys response 201 application/json.size", Message: "enum restriction removed"},
+			}, report.Changes)
+		})
+
+		g.It("should export the report as json", func() {
+			report := Report{Changes: []Change{
+				{Breaking: true, Location: "/users", Message: "path removed"},
+			}}
+
+			data, err := report.JSON()
+			require.NoError(g, err)
+
+			assert.JSONEq(g, `{"changes": [{"breaking": true, "location": "/users", "message": "path removed"}]}`, string(data))
+			assert.Equal(g, "[breaking] /users: path removed", report.String())
+		})
+
+		g.It("should compare the builder with a saved document", func() {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			err = b.Get(router, "/pets/{Id}", &diffTestRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			var doc map[string]interface{}
+			require.NoError(g, json.Unmarshal(data, &doc))
+			delete(doc["paths"].(map[string]interface{}), "/pets/{Id}")
+			data, err = json.Marshal(doc)
+			require.NoError(g, err)
+
+			path := filepath.Join(t.TempDir(), "doc.json")
+			require.NoError(g, os.WriteFile(path, data, 0600))
+
+			report, err := b.DiffAgainstFile(context.Background(), path)
+			require.NoError(g, err)
+
+			assert.Equal(g, []Change{
+				{Location: "/pets/{Id}", Message: "path added"},
+			}, report.Changes)
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed docs/*.html
+var _docsFS embed.FS
+
+var _docsTemplates = template.Must(template.ParseFS(_docsFS, "docs/*.html"))
+
+// DocsUI selects the page served by DocsHandler
+type DocsUI string
+
+const (
+	SwaggerUI DocsUI = "swagger-ui"
+	Redoc     DocsUI = "redoc"
+)
+
+// DocsHandler returns a handler serving a browsable documentation page
+// loading the document from specURL (ex: "/doc.json"), the page title is
+// the title of the api
+func (b *Builder) DocsHandler(specURL string, ui DocsUI) (http.HandlerFunc, error) {
+	tmpl := _docsTemplates.Lookup(string(ui) + ".html")
+	if tmpl == nil {
+		return nil, errors.Errorf("unknown documentation ui %q", ui)
+	}
+
+	title := "API documentation"
+	if (b.swagger.Info != nil) && (b.swagger.Info.Title != "") {
+		title = b.swagger.Info.Title
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Title   string
+		SpecURL string
+	}{title, specURL})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	page := buf.Bytes()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}, nil
+}
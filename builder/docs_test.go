@@ -0,0 +1,55 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsHandler(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("DocsHandler", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+			b, err = New(chi.NewRouter(), &openapi3.Info{Title: "Pets <api>"})
+			require.NoError(g, err)
+		})
+
+		serve := func(ui DocsUI) string {
+			handler, err := b.DocsHandler("/doc.json", ui)
+			require.NoError(g, err)
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/docs", nil))
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+			return w.Body.String()
+		}
+
+		g.It("should serve swagger ui", func() {
+			page := serve(SwaggerUI)
+			assert.Contains(g, page, "<title>Pets &lt;api&gt;</title>")
+			assert.Contains(g, page, `url: "/doc.json"`)
+			assert.Contains(g, page, "SwaggerUIBundle")
+		})
+
+		g.It("should serve redoc", func() {
+			page := serve(Redoc)
+			assert.Contains(g, page, `<redoc spec-url="/doc.json">`)
+		})
+
+		g.It("should reject unknown pages", func() {
+			_, err := b.DocsHandler("/doc.json", "rapidoc")
+			assert.Error(g, err)
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+)
+
+var _problemType = reflect.TypeOf(response.Problem{})
+
+// ErrorResponder can be implemented by request objects to document their error
+// responses by status code, the values are prototypes of the returned bodies
+// (nil for no body), problems are documented as application/problem+json
+// and the other bodies (ex: chipi.Error) as application/json
+type ErrorResponder interface {
+	ErrorResponses() map[int]interface{}
+}
+
+// SetDefaultErrorResponse documents body as the "default" response of
+// every operation (ex: &chipi.Error{}), nil to disable it
+func (b *Builder) SetDefaultErrorResponse(body interface{}) {
+	b.defaultError = body
+}
+
+func (b *Builder) generateErrorResponsesDoc(ctx context.Context, swagger *openapi3.T, responses openapi3.Responses, requestObject interface{}, filterObject shared.FilterInterface) error {
+	if b.defaultError != nil {
+		resp, err := b.errorResponse(ctx, swagger, "Error", b.defaultError, filterObject)
+		if err != nil {
+			return err
+		}
+
+		responses["default"] = &openapi3.ResponseRef{
+			Value: resp,
+		}
+	}
+
+	responder, ok := requestObject.(ErrorResponder)
+	if !ok {
+		return nil
+	}
+
+	for status, body := range responder.ErrorResponses() {
+		key := strconv.Itoa(status)
+		if _, exists := responses[key]; exists {
+			return errors.Errorf("error response %d is already documented", status)
+		}
+
+		resp, err := b.errorResponse(ctx, swagger, http.StatusText(status), body, filterObject)
+		if err != nil {
+			return err
+		}
+
+		responses[key] = &openapi3.ResponseRef{
+			Value: resp,
+		}
+	}
+
+	return nil
+}
+
+// errorResponse documents an error response with the schema of body
+func (b *Builder) errorResponse(ctx context.Context, swagger *openapi3.T, description string, body interface{}, filterObject shared.FilterInterface) (*openapi3.Response, error) {
+	resp := openapi3.NewResponse().WithDescription(description)
+
+	if body == nil {
+		return resp, nil
+	}
+
+	typ := reflect.TypeOf(body)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	bodySchema, err := b.schema.GenerateFilteredSchemaFor(ctx, swagger, typ, filterObject)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/json"
+	if typ == _problemType {
+		contentType = response.ProblemContentType
+	}
+
+	resp.Content = openapi3.Content{
+		contentType: &openapi3.MediaType{
+			Schema: bodySchema,
+		},
+	}
+
+	return resp, nil
+}
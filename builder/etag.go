@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// generateETagDoc documents the conditional header, the ETag header of
+// the successful responses and the 304 or 412 response of the request
+// objects implementing wrapper.ETagger
+func (b *Builder) generateETagDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, method string, filterObject shared.FilterInterface) error {
+	if _, ok := requestObject.(wrapper.ETagger); !ok {
+		return nil
+	}
+
+	header, description := "If-Match", "ETag of the version of the resource which can be changed"
+	status, body := http.StatusPreconditionFailed, interface{}(&response.Problem{})
+	if wrapper.IsSafeMethod(method) {
+		header, description = "If-None-Match", "ETag of the cached versions of the resource"
+		status, body = http.StatusNotModified, nil
+	}
+
+	if op.Parameters.GetByInAndName(openapi3.ParameterInHeader, header) == nil {
+		op.AddParameter(openapi3.NewHeaderParameter(header).
+			WithDescription(description).
+			WithSchema(openapi3.NewStringSchema()))
+	}
+
+	for key, resp := range op.Responses {
+		if !strings.HasPrefix(key, "2") || (resp.Value == nil) {
+			continue
+		}
+
+		if resp.Value.Headers == nil {
+			resp.Value.Headers = openapi3.Headers{}
+		}
+
+		resp.Value.Headers["ETag"] = &openapi3.HeaderRef{
+			Value: &openapi3.Header{
+				Parameter: openapi3.Parameter{
+					Description: "version of the resource",
+					Schema:      openapi3.NewStringSchema().NewRef(),
+				},
+			},
+		}
+	}
+
+	key := strconv.Itoa(status)
+	if _, exists := op.Responses[key]; exists {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, http.StatusText(status), body, filterObject)
+	if err != nil {
+		return err
+	}
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
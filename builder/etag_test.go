@@ -0,0 +1,69 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type etagTestRequest struct {
+	Path     struct{} `example:"/pets"`
+	Response struct {
+		Name string
+	}
+}
+
+func (r *etagTestRequest) ETag(ctx context.Context) string {
+	return "v1"
+}
+
+func (r *etagTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestETagDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("conditional requests documentation", func() {
+		var swagger *openapi3.T
+
+		g.BeforeEach(func() {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/pets", &etagTestRequest{}))
+			require.NoError(g, b.Put(router, "/pets", &etagTestRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			swagger = convertToSwagger(g, data)
+		})
+
+		g.It("should document If-None-Match and the 304 of the reads", func() {
+			op := swagger.Paths["/pets"].Get
+			require.NotNil(g, op)
+
+			assert.NotNil(g, op.Parameters.GetByInAndName("header", "If-None-Match"))
+			assert.NotNil(g, op.Responses["304"])
+			assert.Contains(g, op.Responses["200"].Value.Headers, "ETag")
+		})
+
+		g.It("should document If-Match and the 412 of the updates", func() {
+			op := swagger.Paths["/pets"].Put
+			require.NotNil(g, op)
+
+			assert.NotNil(g, op.Parameters.GetByInAndName("header", "If-Match"))
+			resp := op.Responses["412"]
+			require.NotNil(g, resp)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+			assert.Contains(g, op.Responses["200"].Value.Headers, "ETag")
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var (
+	_sortType     = reflect.TypeOf(wrapper.Sort{})
+	_filterType   = reflect.TypeOf(wrapper.Filter{})
+	_fieldsetType = reflect.TypeOf(wrapper.Fieldset{})
+)
+
+// applyFieldsDoc documents the fields accepted by a Sort or a Fieldset (as a
+// pattern) or by a Filter (as properties) listed by the `fields` tag of f,
+// the Fieldsets accept the fields of the Response by default
+func applyFieldsDoc(s *openapi3.Schema, requestObjectType reflect.Type, f reflect.StructField) {
+	fields := wrapper.DescribeParam("Query", f).Fields
+	if responseField, found := requestObjectType.FieldByName("Response"); found && (fields == nil) && (sectionType(f.Type) == _fieldsetType) {
+		fields = wrapper.ResponseFieldNames(responseField.Type)
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+
+	switch sectionType(f.Type) {
+	case _sortType:
+		item := "-?(" + strings.Join(quoted, "|") + ")"
+		s.Pattern = "^" + item + "(," + item + ")*$"
+		s.Description = "comma separated fields among " + strings.Join(fields, ", ") + ", prefixed with - for the descending order"
+
+	case _fieldsetType:
+		item := "(" + strings.Join(quoted, "|") + ")"
+		s.Pattern = "^" + item + "(," + item + ")*$"
+		s.Description = "comma separated fields of the response among " + strings.Join(fields, ", ") + ", all of them by default"
+
+	case _filterType:
+		allowed := false
+		s.AdditionalProperties = nil
+		s.AdditionalPropertiesAllowed = &allowed
+		s.Properties = openapi3.Schemas{}
+		for _, field := range fields {
+			s.Properties[field] = openapi3.NewStringSchema().NewRef()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchCatsRequest struct {
+	Path  struct{} `example:"/cats/search"`
+	Query struct {
+		Sort   wrapper.Sort   `json:"sort" fields:"created_at,name"`
+		Filter wrapper.Filter `json:"filter" fields:"status,color"`
+		Any    wrapper.Sort   `json:"any"`
+	}
+	Response []string
+}
+
+func (r *searchCatsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type fieldsetCat struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type listFieldsetCatsRequest struct {
+	Path  struct{} `example:"/cats/fields"`
+	Query struct {
+		Fields wrapper.Fieldset `json:"fields"`
+	}
+	Response []fieldsetCat
+}
+
+func (r *listFieldsetCatsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestFilteringDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("sort and filter documentation", func() {
+		var op *openapi3.Operation
+		var swagger *openapi3.T
+
+		g.BeforeEach(func() {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/cats/search", &searchCatsRequest{}))
+			require.NoError(g, b.Get(router, "/cats/fields", &listFieldsetCatsRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			swagger = convertToSwagger(g, data)
+			op = swagger.Paths["/cats/search"].Get
+		})
+
+		g.It("should document the sortable fields", func() {
+			sort := op.Parameters.GetByInAndName(openapi3.ParameterInQuery, "sort")
+			require.NotNil(g, sort)
+			assert.Equal(g, "string", sort.Schema.Value.Type)
+			assert.Equal(g, "^-?(created_at|name)(,-?(created_at|name))*$", sort.Schema.Value.Pattern)
+
+			any := op.Parameters.GetByInAndName(openapi3.ParameterInQuery, "any")
+			require.NotNil(g, any)
+			assert.Empty(g, any.Schema.Value.Pattern)
+		})
+
+		g.It("should document the filterable fields", func() {
+			filter := op.Parameters.GetByInAndName(openapi3.ParameterInQuery, "filter")
+			require.NotNil(g, filter)
+			assert.Equal(g, openapi3.SerializationDeepObject, filter.Style)
+
+			assert.Contains(g, filter.Schema.Value.Properties, "status")
+			assert.Contains(g, filter.Schema.Value.Properties, "color")
+			require.NotNil(g, filter.Schema.Value.AdditionalPropertiesAllowed)
+			assert.False(g, *filter.Schema.Value.AdditionalPropertiesAllowed)
+		})
+
+		g.It("should document the fields of the response accepted by a Fieldset", func() {
+			fields := swagger.Paths["/cats/fields"].Get.Parameters.GetByInAndName(openapi3.ParameterInQuery, "fields")
+			require.NotNil(g, fields)
+			assert.Equal(g, "^(id|name)(,(id|name))*$", fields.Schema.Value.Pattern)
+			assert.Contains(g, fields.Schema.Value.Description, "among id, name")
+		})
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/wrapper"
 )
 
 func (b *Builder) generateHeadersDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type) error {
@@ -14,13 +15,15 @@ func (b *Builder) generateHeadersDoc(ctx context.Context, swagger *openapi3.T, o
 		return nil
 	}
 
-	headerStructType := headerField.Type
+	headerStructType := sectionType(headerField.Type)
 	if headerStructType.Kind() != reflect.Struct {
 		return errors.New("expected struct for Header")
 	}
 
-	for i := 0; i < headerStructType.NumField(); i++ {
-		field := headerStructType.Field(i)
+	for _, field := range wrapper.SectionFields(headerStructType) {
+		if !isDocumentedParam(field) {
+			continue
+		}
 
 		schema, err := b.schema.GenerateSchemaFor(ctx, swagger, field.Type)
 		if err != nil {
@@ -30,11 +33,16 @@ func (b *Builder) generateHeadersDoc(ctx context.Context, swagger *openapi3.T, o
 		param := openapi3.NewHeaderParameter(field.Name).
 			WithSchema(schema.Value)
 
-		err = fillParamFromTags(requestObjectType, param, field, "Header")
+		err = b.fillParamFromTags(requestObjectType, param, field, "Header")
 		if err != nil {
 			return err
 		}
 
+		// annotations are keyed by field name, rename once they are applied
+		if name := field.Tag.Get("name"); name != "" {
+			param.Name = name
+		}
+
 		op.AddParameter(param)
 	}
 
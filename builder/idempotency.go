@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// generateIdempotencyDoc documents the Idempotency-Key header, the replayed
+// responses and the 409 response of the unsafe operations when the
+// Idempotency wrapper option is set
+func (b *Builder) generateIdempotencyDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, method string, filterObject shared.FilterInterface) error {
+	if (b.wrapperOptions == nil) || (b.wrapperOptions.Idempotency == nil) || wrapper.IsSafeMethod(method) {
+		return nil
+	}
+
+	if _, ok := requestObject.(wrapper.Streamer); ok {
+		return nil
+	}
+
+	if op.Parameters.GetByInAndName(openapi3.ParameterInHeader, wrapper.IdempotencyKeyHeader) == nil {
+		schema := openapi3.NewStringSchema().WithMaxLength(wrapper.MaxIdempotencyKeyLength)
+		op.AddParameter(openapi3.NewHeaderParameter(wrapper.IdempotencyKeyHeader).
+			WithDescription("unique key of the request, the response is replayed when it is sent again with the same key").
+			WithSchema(schema))
+	}
+
+	for key, resp := range op.Responses {
+		if !strings.HasPrefix(key, "2") || (resp.Value == nil) {
+			continue
+		}
+
+		if resp.Value.Headers == nil {
+			resp.Value.Headers = openapi3.Headers{}
+		}
+
+		resp.Value.Headers[wrapper.IdempotentReplayedHeader] = &openapi3.HeaderRef{
+			Value: &openapi3.Header{
+				Parameter: openapi3.Parameter{
+					Description: "true if the response was recorded for a previous request with the same key",
+					Schema:      openapi3.NewBoolSchema().NewRef(),
+				},
+			},
+		}
+	}
+
+	descriptions := map[int]string{
+		http.StatusConflict:            "a request with the same " + wrapper.IdempotencyKeyHeader + " is in progress",
+		http.StatusUnprocessableEntity: wrapper.IdempotencyKeyHeader + " already used for another request",
+	}
+
+	for status, description := range descriptions {
+		key := strconv.Itoa(status)
+		if op.Responses[key] != nil {
+			continue
+		}
+
+		resp, err := b.errorResponse(ctx, swagger, description, &response.Problem{}, filterObject)
+		if err != nil {
+			return err
+		}
+
+		op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+	}
+
+	return nil
+}
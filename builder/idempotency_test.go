@@ -0,0 +1,75 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createOrderRequest struct {
+	Path     struct{} `example:"/orders"`
+	Response struct {
+		Id string
+	}
+}
+
+func (r *createOrderRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestIdempotencyDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("idempotency documentation", func() {
+		generate := func(opts *wrapper.Options) *openapi3.T {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.SetWrapperOptions(opts)
+			require.NoError(g, b.Post(router, "/orders", &createOrderRequest{}))
+			require.NoError(g, b.Get(router, "/orders", &createOrderRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should document the key of the unsafe operations", func() {
+			swagger := generate(&wrapper.Options{Idempotency: &wrapper.IdempotencyOptions{}})
+			op := swagger.Paths["/orders"].Post
+
+			param := op.Parameters.GetByInAndName(openapi3.ParameterInHeader, wrapper.IdempotencyKeyHeader)
+			require.NotNil(g, param)
+			assert.False(g, param.Required)
+			require.NotNil(g, param.Schema.Value.MaxLength)
+			assert.Equal(g, uint64(wrapper.MaxIdempotencyKeyLength), *param.Schema.Value.MaxLength)
+
+			resp := op.Responses["409"]
+			require.NotNil(g, resp)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+			require.NotNil(g, op.Responses["422"])
+			assert.Equal(g, wrapper.IdempotencyKeyHeader+" already used for another request", *op.Responses["422"].Value.Description)
+			assert.Contains(g, op.Responses["200"].Value.Headers, wrapper.IdempotentReplayedHeader)
+
+			get := swagger.Paths["/orders"].Get
+			assert.Nil(g, get.Parameters.GetByInAndName(openapi3.ParameterInHeader, wrapper.IdempotencyKeyHeader))
+			assert.Nil(g, get.Responses["409"])
+		})
+
+		g.It("should not document the key without option", func() {
+			swagger := generate(nil)
+			op := swagger.Paths["/orders"].Post
+
+			assert.Nil(g, op.Parameters.GetByInAndName(openapi3.ParameterInHeader, wrapper.IdempotencyKeyHeader))
+			assert.Nil(g, op.Responses["409"])
+		})
+	})
+}
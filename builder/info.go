@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SetInfo replaces the info block given to New
+func (b *Builder) SetInfo(info *openapi3.Info) {
+	if info == nil {
+		info = &openapi3.Info{}
+	}
+
+	b.swagger.Info = info
+}
+
+// SetTitle sets the title and the version of the api, both are required
+// by OpenAPI
+func (b *Builder) SetTitle(title string, version string) {
+	b.swagger.Info.Title = title
+	b.swagger.Info.Version = version
+}
+
+// SetDescription sets the description of the api (CommonMark)
+func (b *Builder) SetDescription(description string) {
+	b.swagger.Info.Description = description
+}
+
+// SetTermsOfService sets the url of the terms of service of the api
+func (b *Builder) SetTermsOfService(url string) {
+	b.swagger.Info.TermsOfService = url
+}
+
+// SetContact sets the contact information of the api, empty values are omitted
+func (b *Builder) SetContact(name string, url string, email string) {
+	b.swagger.Info.Contact = &openapi3.Contact{
+		Name:  name,
+		URL:   url,
+		Email: email,
+	}
+}
+
+// SetLicense sets the license of the api
+func (b *Builder) SetLicense(name string, url string) {
+	b.swagger.Info.License = &openapi3.License{
+		Name: name,
+		URL:  url,
+	}
+}
+
+// SetExternalDocs links the document to an additional documentation
+func (b *Builder) SetExternalDocs(description string, url string) {
+	b.swagger.ExternalDocs = &openapi3.ExternalDocs{
+		Description: description,
+		URL:         url,
+	}
+}
@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfo(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Info", func() {
+		g.It("should document the api", func() {
+			b, err := New(chi.NewRouter(), nil)
+			require.NoError(g, err)
+
+			b.SetTitle("Pets", "1.2.0")
+			b.SetDescription("Manage the *pets*")
+			b.SetTermsOfService("https://example.com/terms")
+			b.SetContact("Support", "https://example.com/support", "support@example.com")
+			b.SetLicense("MIT", "https://opensource.org/licenses/MIT")
+			b.SetExternalDocs("Guides", "https://example.com/guides")
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			require.NoError(g, doc.Info.Validate(context.Background()))
+
+			assert.Equal(g, "Pets", doc.Info.Title)
+			assert.Equal(g, "1.2.0", doc.Info.Version)
+			assert.Equal(g, "Manage the *pets*", doc.Info.Description)
+			assert.Equal(g, "https://example.com/terms", doc.Info.TermsOfService)
+			assert.Equal(g, "support@example.com", doc.Info.Contact.Email)
+			assert.Equal(g, "MIT", doc.Info.License.Name)
+			assert.Equal(g, "https://example.com/guides", doc.ExternalDocs.URL)
+		})
+	})
+}
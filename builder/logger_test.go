@@ -0,0 +1,71 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggedFailingRequest struct {
+	Path struct{} `example:"/fail"`
+}
+
+func (r *loggedFailingRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return errors.New("failed")
+}
+
+func TestLogger(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("SetLogger", func() {
+		var b *Builder
+		var router *chi.Mux
+		var buf *bytes.Buffer
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			buf = &bytes.Buffer{}
+			b.SetLogger(shared.NewStdLogger(log.New(buf, "", 0), shared.LevelDebug))
+		})
+
+		g.It("should log the registered routes", func() {
+			err := b.Get(router, "/fail", &loggedFailingRequest{})
+			require.NoError(g, err)
+
+			assert.Equal(g, "DEBUG route registered method=GET pattern=/fail operation=loggedFailingRequest\n", buf.String())
+		})
+
+		g.It("should be used by the routes", func() {
+			err := b.Get(router, "/fail", &loggedFailingRequest{})
+			require.NoError(g, err)
+			buf.Reset()
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+			assert.Equal(g, "ERROR unhandled error operation=loggedFailingRequest error=failed\n", buf.String())
+		})
+
+		g.It("should filter the messages by level", func() {
+			b.SetLogger(shared.NewStdLogger(log.New(buf, "", 0), shared.LevelWarn))
+
+			err := b.Get(router, "/fail", &loggedFailingRequest{})
+			require.NoError(g, err)
+			assert.Empty(g, buf.String())
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// MergeError lists the conflicts found while merging documents
+type MergeError struct {
+	Conflicts []string
+}
+
+func (e *MergeError) Error() string {
+	return fmt.Sprintf("cannot merge the documents:\n- %s", strings.Join(e.Conflicts, "\n- "))
+}
+
+// Merge adds the operations, components and tags of other to the generated
+// documents (ex: the documents of the other modules of a gateway), identical
+// components are only kept once while an operation or a component defined
+// differently on both sides is reported in a *MergeError
+func (b *Builder) Merge(other *openapi3.T) error {
+	// the conflicts with the routes are only known once generated
+	doc := &openapi3.T{}
+	for _, merged := range b.merged {
+		mergeDocument(doc, merged)
+	}
+
+	if conflicts := mergeDocument(doc, other); len(conflicts) > 0 {
+		return &MergeError{Conflicts: conflicts}
+	}
+
+	b.merged = append(b.merged, other)
+	return nil
+}
+
+// mergeMerged merges the documents given to Merge in doc
+func (b *Builder) mergeMerged(doc *openapi3.T) error {
+	if len(b.merged) == 0 {
+		return nil
+	}
+
+	var conflicts []string
+	for _, merged := range b.merged {
+		conflicts = append(conflicts, mergeDocument(doc, merged)...)
+	}
+
+	if len(conflicts) > 0 {
+		return &MergeError{Conflicts: conflicts}
+	}
+
+	return nil
+}
+
+// mergeDocument merges src in dst and returns the conflicts, the maps of
+// dst are copied before being modified
+func mergeDocument(dst *openapi3.T, src *openapi3.T) []string {
+	var conflicts []string
+
+	paths := openapi3.Paths{}
+	for path, item := range dst.Paths {
+		paths[path] = item
+	}
+
+	for _, path := range sortedKeys(src.Paths) {
+		srcItem := src.Paths[path]
+
+		item := &openapi3.PathItem{}
+		if existing, found := paths[path]; found {
+			copied := *existing
+			item = &copied
+		}
+
+		operations := srcItem.Operations()
+		for _, method := range sortedKeys(operations) {
+			if item.GetOperation(method) != nil {
+				conflicts = append(conflicts, fmt.Sprintf("operation %s %s is defined twice", method, path))
+				continue
+			}
+
+			item.SetOperation(method, operations[method])
+		}
+
+		paths[path] = item
+	}
+	dst.Paths = paths
+
+	c := &dst.Components
+	conflicts = append(conflicts, mergeComponents("schemas", &c.Schemas, src.Components.Schemas)...)
+	conflicts = append(conflicts, mergeComponents("parameters", &c.Parameters, src.Components.Parameters)...)
+	conflicts = append(conflicts, mergeComponents("headers", &c.Headers, src.Components.Headers)...)
+	conflicts = append(conflicts, mergeComponents("requestBodies", &c.RequestBodies, src.Components.RequestBodies)...)
+	conflicts = append(conflicts, mergeComponents("responses", &c.Responses, src.Components.Responses)...)
+	conflicts = append(conflicts, mergeComponents("securitySchemes", &c.SecuritySchemes, src.Components.SecuritySchemes)...)
+	conflicts = append(conflicts, mergeComponents("examples", &c.Examples, src.Components.Examples)...)
+	conflicts = append(conflicts, mergeComponents("links", &c.Links, src.Components.Links)...)
+	conflicts = append(conflicts, mergeComponents("callbacks", &c.Callbacks, src.Components.Callbacks)...)
+
+	tags := append(openapi3.Tags{}, dst.Tags...)
+	for _, tag := range src.Tags {
+		if tags.Get(tag.Name) == nil {
+			tags = append(tags, tag)
+		}
+	}
+	dst.Tags = tags
+
+	return conflicts
+}
+
+// mergeComponents adds the components of src missing from dst, the
+// components defined on both sides must be identical
+func mergeComponents[M ~map[string]V, V any](kind string, dst *M, src M) []string {
+	if len(src) == 0 {
+		return nil
+	}
+
+	var conflicts []string
+
+	merged := M{}
+	for name, value := range *dst {
+		merged[name] = value
+	}
+
+	for _, name := range sortedKeys(map[string]V(src)) {
+		value := src[name]
+
+		if existing, found := merged[name]; found {
+			if !sameJson(existing, value) {
+				conflicts = append(conflicts, fmt.Sprintf("component %s/%s is defined differently", kind, name))
+			}
+			continue
+		}
+
+		merged[name] = value
+	}
+
+	*dst = merged
+	return conflicts
+}
+
+func sameJson(a interface{}, b interface{}) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+
+	return (aErr == nil) && (bErr == nil) && bytes.Equal(aData, bData)
+}
@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MergedOwner struct {
+	Name string
+}
+
+type mergedPetsRequest struct {
+	Path     struct{} `example:"/pets"`
+	Response MergedOwner
+}
+
+func (r *mergedPetsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type mergedUsersRequest struct {
+	Path     struct{} `example:"/users"`
+	Response MergedOwner
+}
+
+func (r *mergedUsersRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestMerge(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Merge", func() {
+		var b *Builder
+
+		// moduleDoc returns the document of another module
+		moduleDoc := func(pattern string, obj interface{}) *openapi3.T {
+			router := chi.NewRouter()
+			other, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			other.AddTag(&openapi3.Tag{Name: "users"})
+			require.NoError(g, other.Get(router, pattern, obj))
+
+			data, err := other.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc, err := openapi3.NewLoader().LoadFromData(data)
+			require.NoError(g, err)
+			return doc
+		}
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/pets", &mergedPetsRequest{}))
+		})
+
+		g.It("should merge the operations and components", func() {
+			require.NoError(g, b.Merge(moduleDoc("/users", &mergedUsersRequest{})))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			assert.Contains(g, doc.Paths, "/pets")
+			assert.Contains(g, doc.Paths, "/users")
+			assert.Len(g, doc.Components.Schemas, 1)
+			assert.Contains(g, doc.Components.Schemas, "builder.MergedOwner")
+			assert.NotNil(g, doc.Tags.Get("users"))
+		})
+
+		g.It("should report the operations defined twice", func() {
+			require.NoError(g, b.Merge(moduleDoc("/pets", &mergedPetsRequest{})))
+
+			_, err := b.GenerateJson(context.Background(), nil)
+			require.IsType(g, &MergeError{}, err)
+			assert.Equal(g, []string{"operation GET /pets is defined twice"}, err.(*MergeError).Conflicts)
+		})
+
+		g.It("should report the conflicting components", func() {
+			doc := moduleDoc("/users", &mergedUsersRequest{})
+			doc.Components.Schemas["builder.MergedOwner"].Value.Description = "another owner"
+
+			require.NoError(g, b.Merge(doc))
+
+			_, err := b.GenerateJson(context.Background(), nil)
+			require.IsType(g, &MergeError{}, err)
+			assert.Equal(g, []string{"component schemas/builder.MergedOwner is defined differently"}, err.(*MergeError).Conflicts)
+		})
+
+		g.It("should report the conflicts between merged documents", func() {
+			require.NoError(g, b.Merge(moduleDoc("/users", &mergedUsersRequest{})))
+
+			err := b.Merge(moduleDoc("/users", &mergedUsersRequest{}))
+			require.IsType(g, &MergeError{}, err)
+			assert.Equal(g, []string{"operation GET /users is defined twice"}, err.(*MergeError).Conflicts)
+		})
+	})
+}
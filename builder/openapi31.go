@@ -0,0 +1,143 @@
+package builder
+
+import (
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+)
+
+// OpenAPIVersion selects the version of the generated document
+type OpenAPIVersion string
+
+const (
+	OpenAPI30 OpenAPIVersion = "3.0.3"
+	OpenAPI31 OpenAPIVersion = "3.1.0"
+)
+
+const jsonSchemaDialect = "https://spec.openapis.org/oas/3.1/dialect/base"
+
+// SetOpenAPIVersion selects the version of the documents returned by GenerateJson
+// and ServeSchema (3.0 by default), the 3.1 documents use the JSON Schema
+// dialect (ex: `type: ["string", "null"]` instead of `nullable`) and
+// include the webhooks
+func (b *Builder) SetOpenAPIVersion(version OpenAPIVersion) error {
+	switch version {
+	case OpenAPI30, OpenAPI31:
+		b.version = version
+		return nil
+	}
+
+	return errors.Errorf("unsupported OpenAPI version %q", version)
+}
+
+// AddWebhook documents a request sent by the api to its clients, webhooks
+// are only part of the OpenAPI 3.1 documents
+func (b *Builder) AddWebhook(name string, item *openapi3.PathItem) {
+	if b.webhooks == nil {
+		b.webhooks = map[string]*openapi3.PathItem{}
+	}
+
+	b.webhooks[name] = item
+}
+
+// convertTo31 rewrites a 3.0 document as a 3.1 one
+func convertTo31(data []byte, webhooks map[string]*openapi3.PathItem) ([]byte, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	convertSchemasTo31(doc)
+
+	doc["openapi"] = string(OpenAPI31)
+	doc["jsonSchemaDialect"] = jsonSchemaDialect
+
+	if len(webhooks) > 0 {
+		// marshaled on their own to be converted too
+		webhooksData, err := json.Marshal(webhooks)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var converted map[string]interface{}
+		err = json.Unmarshal(webhooksData, &converted)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		convertSchemasTo31(converted)
+		doc["webhooks"] = converted
+	}
+
+	ret, err := json.Marshal(doc)
+	return ret, errors.WithStack(err)
+}
+
+// convertSchemasTo31 walks the document and rewrites the keywords
+// which changed with JSON Schema
+func convertSchemasTo31(value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			convertSchemasTo31(item)
+		}
+
+	case map[string]interface{}:
+		for _, item := range v {
+			convertSchemasTo31(item)
+		}
+
+		convertNullable(v)
+		convertExclusiveBound(v, "exclusiveMinimum", "minimum")
+		convertExclusiveBound(v, "exclusiveMaximum", "maximum")
+	}
+}
+
+// convertNullable replaces `nullable: true` by a "null" type
+func convertNullable(s map[string]interface{}) {
+	if nullable, ok := s["nullable"].(bool); !ok || !nullable {
+		return
+	}
+	delete(s, "nullable")
+
+	if typ, ok := s["type"].(string); ok {
+		s["type"] = []interface{}{typ, "null"}
+		return
+	}
+
+	// references are wrapped in an allOf by the schema generator
+	var schema interface{}
+	if allOf, ok := s["allOf"].([]interface{}); ok && (len(allOf) == 1) && (len(s) <= 2) {
+		schema = allOf[0]
+		delete(s, "allOf")
+	} else {
+		inner := map[string]interface{}{}
+		for key, value := range s {
+			if key != "description" {
+				inner[key] = value
+				delete(s, key)
+			}
+		}
+		schema = inner
+	}
+
+	s["anyOf"] = []interface{}{schema, map[string]interface{}{"type": "null"}}
+}
+
+// convertExclusiveBound replaces the boolean `exclusiveMinimum` (or maximum)
+// by the bound itself
+func convertExclusiveBound(s map[string]interface{}, exclusiveKey string, boundKey string) {
+	exclusive, ok := s[exclusiveKey].(bool)
+	if !ok {
+		return
+	}
+
+	if bound, found := s[boundKey]; found && exclusive {
+		s[exclusiveKey] = bound
+		delete(s, boundKey)
+	} else {
+		delete(s, exclusiveKey)
+	}
+}
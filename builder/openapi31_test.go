@@ -0,0 +1,139 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type VersionedOwner struct {
+	Name string
+}
+
+type versionedPet struct {
+	Nickname *string
+	Owner    *VersionedOwner `description:"the owner"`
+}
+
+type versionedPetRequest struct {
+	Path     struct{} `example:"/pets"`
+	Response versionedPet
+}
+
+func (r *versionedPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestOpenAPIVersion(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("OpenAPI version", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			err = b.Get(router, "/pets", &versionedPetRequest{})
+			require.NoError(g, err)
+		})
+
+		generate := func() map[string]interface{} {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			var doc map[string]interface{}
+			require.NoError(g, json.Unmarshal(data, &doc))
+			return doc
+		}
+
+		petSchema := func(doc map[string]interface{}) string {
+			schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+			data, err := json.Marshal(schemas["builder.versionedPet"])
+			require.NoError(g, err)
+			return string(data)
+		}
+
+		g.It("should generate 3.0 documents by default", func() {
+			doc := generate()
+			assert.Equal(g, "3.0.3", doc["openapi"])
+			assert.NotContains(g, doc, "webhooks")
+
+			assert.JSONEq(g, `{
+				"type": "object",
+				"properties": {
+					"Nickname": {"type": "string", "nullable": true},
+					"Owner": {"description": "the owner", "nullable": true, "allOf": [{"$ref": "#/components/schemas/builder.VersionedOwner"}]}
+				}
+			}`, petSchema(doc))
+		})
+
+		g.It("should generate 3.1 documents", func() {
+			require.NoError(g, b.SetOpenAPIVersion(OpenAPI31))
+			b.AddWebhook("petAdopted", &openapi3.PathItem{
+				Post: &openapi3.Operation{
+					Responses: openapi3.Responses{"200": &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("received")}},
+				},
+			})
+
+			doc := generate()
+			assert.Equal(g, "3.1.0", doc["openapi"])
+			assert.Equal(g, jsonSchemaDialect, doc["jsonSchemaDialect"])
+			assert.Contains(g, doc["webhooks"], "petAdopted")
+
+			assert.JSONEq(g, `{
+				"type": "object",
+				"properties": {
+					"Nickname": {"type": ["string", "null"]},
+					"Owner": {"description": "the owner", "anyOf": [{"$ref": "#/components/schemas/builder.VersionedOwner"}, {"type": "null"}]}
+				}
+			}`, petSchema(doc))
+		})
+
+		g.It("should reject unknown versions", func() {
+			assert.Error(g, b.SetOpenAPIVersion("2.0"))
+		})
+
+		g.It("should generate YAML documents", func() {
+			require.NoError(g, b.SetOpenAPIVersion(OpenAPI31))
+
+			data, err := b.GenerateYAML(context.Background(), nil)
+			require.NoError(g, err)
+			assert.Contains(g, string(data), "openapi: 3.1.0\n")
+
+			var doc map[string]interface{}
+			require.NoError(g, yaml.Unmarshal(data, &doc))
+			assert.Equal(g, generate(), doc)
+		})
+
+		g.It("should serve YAML documents", func() {
+			w := httptest.NewRecorder()
+			b.ServeSchemaYAML(w, httptest.NewRequest("GET", "/doc.yaml", nil))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/yaml", w.Header().Get("Content-Type"))
+			assert.Contains(g, w.Body.String(), "openapi: 3.0.3\n")
+		})
+	})
+
+	g.Describe("exclusive bounds", func() {
+		g.It("should use the bounds as values", func() {
+			s := map[string]interface{}{"minimum": 1.0, "exclusiveMinimum": true, "maximum": 5.0, "exclusiveMaximum": false}
+			convertSchemasTo31(s)
+			assert.Equal(g, map[string]interface{}{"exclusiveMinimum": 1.0, "maximum": 5.0}, s)
+		})
+	})
+}
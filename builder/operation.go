@@ -2,19 +2,124 @@ package builder
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/schema"
 )
 
-func generateOperationDoc(op *openapi3.Operation, requestObjectType reflect.Type) error {
+// OperationInfo describes an operation, the empty fields are left untouched
+type OperationInfo struct {
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Internal operations are removed from the public documents (see GeneratePublic)
+	Internal bool
+	// Versions are the api versions of the operation (see GenerateSpec)
+	Versions []string
+}
+
+// Describer can be implemented by request objects to describe their operation,
+// the returned informations take precedence over the comments
+type Describer interface {
+	Describe() OperationInfo
+}
+
+func (b *Builder) generateOperationDoc(op *openapi3.Operation, requestObject interface{}, requestObjectType reflect.Type) error {
 	err := fillOperationFromComments(requestObjectType, op)
 	if err != nil {
 		return err
 	}
 
+	if op.Description == "" {
+		op.Description = b.comments.typeDoc(requestObjectType)
+	}
+
+	// the Path field holds the tags of the operation
+	if pathField, found := requestObjectType.FieldByName("Path"); found {
+		if deprecated := schema.ParseJsonTag(pathField).Deprecated; (deprecated != nil) && *deprecated {
+			op.Deprecated = true
+		}
+
+		err = schema.ApplyTagExtensions(&op.ExtensionProps, pathField.Tag)
+		if err != nil {
+			return errors.Wrapf(err, "invalid extensions for %s", requestObjectType.Name())
+		}
+
+		if pathField.Tag.Get("internal") == "true" {
+			markInternal(op)
+		}
+	}
+
+	if describer, ok := requestObject.(Describer); ok {
+		fillOperationFromInfo(op, describer.Describe())
+	}
+
 	return nil
 }
 
+// MountTags enables (the default) or disables the tag given to the operations
+// without tags registered on a sub router, it is the last static segment
+// of the mount point (ex: "users" for the routes of r.Route("/v1/users", ...))
+func (b *Builder) MountTags(enabled bool) {
+	b.noMountTags = !enabled
+}
+
+// mountTag returns the default tag of a route from its full pattern and
+// the pattern it was registered with, routes of the root router have none
+func mountTag(fullPattern string, pattern string) string {
+	fullPattern = strings.TrimSuffix(fullPattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if !strings.HasSuffix(fullPattern, pattern) {
+		return ""
+	}
+
+	segments := strings.Split(strings.TrimSuffix(fullPattern, pattern), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if (segments[i] != "") && !strings.HasPrefix(segments[i], "{") {
+			return segments[i]
+		}
+	}
+
+	return ""
+}
+
+func fillOperationFromInfo(op *openapi3.Operation, info OperationInfo) {
+	if info.ID != "" {
+		op.OperationID = info.ID
+	}
+
+	if info.Summary != "" {
+		op.Summary = info.Summary
+	}
+
+	if info.Description != "" {
+		op.Description = info.Description
+	}
+
+	if len(info.Tags) > 0 {
+		op.Tags = info.Tags
+	}
+
+	if info.Deprecated {
+		op.Deprecated = true
+	}
+
+	if info.Internal {
+		markInternal(op)
+	}
+}
+
+func markInternal(op *openapi3.Operation) {
+	if op.Extensions == nil {
+		op.Extensions = map[string]interface{}{}
+	}
+	op.Extensions[internalExtension] = true
+}
+
 func fillOperationFromComments(requestObjectType reflect.Type, op *openapi3.Operation) error {
 	nilValue := reflect.New(requestObjectType)
 
@@ -0,0 +1,220 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describedTestRequest struct {
+	Path struct{} `example:"/described"`
+}
+
+func (r *describedTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *describedTestRequest) Describe() OperationInfo {
+	return OperationInfo{
+		ID:          "listThings",
+		Summary:     "list the things",
+		Description: "returns every *thing*",
+		Tags:        []string{"things"},
+	}
+}
+
+type undescribedTestRequest struct {
+	Path struct{} `example:"/undescribed"`
+}
+
+func (r *undescribedTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type deprecatedTestRequest struct {
+	Path  struct{} `example:"/deprecated" deprecated:"true"`
+	Query struct {
+		Old string `deprecated:"true"`
+		New string
+	}
+}
+
+func (r *deprecatedTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type extendedTestRequest struct {
+	Path  struct{} `example:"/extended" chipi-ext:"x-internal,x-rate-limit=100"`
+	Query struct {
+		Debug bool `chipi-ext:"x-hidden=true"`
+	}
+}
+
+func (r *extendedTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type mountedUserRequest struct {
+	Path struct {
+		Id int
+	} `example:"/v1/users/3"`
+}
+
+func (r *mountedUserRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type mountedUsersRequest struct {
+	Path struct{} `example:"/v1/users"`
+}
+
+func (r *mountedUsersRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type mountedProfileRequest struct {
+	Path struct{} `example:"/v1/users/me"`
+}
+
+func (r *mountedProfileRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *mountedProfileRequest) Describe() OperationInfo {
+	return OperationInfo{Tags: []string{"profile"}}
+}
+
+func TestOperation(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("operation documentation", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+		})
+
+		g.It("should use the informations from Describe", func() {
+			err := b.Get(router, "/described", &describedTestRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			op := convertToSwagger(g, data).Paths["/described"].Get
+			require.NotNil(g, op)
+
+			assert.Equal(g, "listThings", op.OperationID)
+			assert.Equal(g, "list the things", op.Summary)
+			assert.Equal(g, "returns every *thing*", op.Description)
+			assert.Equal(g, []string{"things"}, op.Tags)
+			assert.False(g, op.Deprecated)
+		})
+
+		g.It("should default the operation id to the type name", func() {
+			err := b.Get(router, "/undescribed", &undescribedTestRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			op := convertToSwagger(g, data).Paths["/undescribed"].Get
+			require.NotNil(g, op)
+			assert.Equal(g, "undescribedTestRequest", op.OperationID)
+		})
+
+		g.It("should mark deprecated operations and parameters", func() {
+			err := b.Get(router, "/deprecated", &deprecatedTestRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			op := convertToSwagger(g, data).Paths["/deprecated"].Get
+			require.NotNil(g, op)
+			assert.True(g, op.Deprecated)
+
+			param := op.Parameters.GetByInAndName("query", "old")
+			require.NotNil(g, param)
+			assert.True(g, param.Deprecated)
+
+			param = op.Parameters.GetByInAndName("query", "new")
+			require.NotNil(g, param)
+			assert.False(g, param.Deprecated)
+		})
+
+		g.It("should add the extensions", func() {
+			err := b.Get(router, "/extended", &extendedTestRequest{})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			var doc struct {
+				Paths map[string]struct {
+					Get map[string]interface{}
+				}
+			}
+			require.NoError(g, json.Unmarshal(data, &doc))
+
+			op := doc.Paths["/extended"].Get
+			assert.Equal(g, true, op["x-internal"])
+			assert.Equal(g, 100.0, op["x-rate-limit"])
+
+			params := op["parameters"].([]interface{})
+			require.Len(g, params, 1)
+			assert.Equal(g, true, params[0].(map[string]interface{})["x-hidden"])
+		})
+
+		g.Describe("mount tags", func() {
+			g.BeforeEach(func() {
+				router.Route("/v1/users", func(r chi.Router) {
+					require.NoError(g, b.Get(r, "/", &mountedUsersRequest{}))
+					require.NoError(g, b.Get(r, "/me", &mountedProfileRequest{}))
+					require.NoError(g, b.Get(r, "/{Id}", &mountedUserRequest{}))
+				})
+				require.NoError(g, b.Get(router, "/undescribed", &undescribedTestRequest{}))
+			})
+
+			generate := func() openapi3.Paths {
+				data, err := b.GenerateJson(context.Background(), nil)
+				require.NoError(g, err)
+				return convertToSwagger(g, data).Paths
+			}
+
+			g.It("should tag the routes of sub routers", func() {
+				paths := generate()
+				assert.Equal(g, []string{"users"}, paths["/v1/users"].Get.Tags)
+				assert.Equal(g, []string{"users"}, paths["/v1/users/{Id}"].Get.Tags)
+				assert.Equal(g, []string{"profile"}, paths["/v1/users/me"].Get.Tags)
+				assert.Empty(g, paths["/undescribed"].Get.Tags)
+			})
+
+			g.It("should be disabled with MountTags", func() {
+				b.MountTags(false)
+
+				paths := generate()
+				assert.Empty(g, paths["/v1/users/{Id}"].Get.Tags)
+			})
+		})
+
+		g.It("should use the last static segment of the mount point", func() {
+			assert.Equal(g, "members", mountTag("/orgs/{Org}/members/{Id}", "/{Id}"))
+			assert.Equal(g, "orgs", mountTag("/orgs/{Org}/{Id}", "/{Id}"))
+			assert.Equal(g, "", mountTag("/pets", "/pets"))
+			assert.Equal(g, "", mountTag("/", "/"))
+		})
+	})
+}
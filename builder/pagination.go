@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var (
+	_pagerType       = reflect.TypeOf((*wrapper.Pager)(nil)).Elem()
+	_pageQueryType   = reflect.TypeOf(wrapper.PageQuery{})
+	_cursorPagerType = reflect.TypeOf((*wrapper.CursorPager)(nil)).Elem()
+	_cursorQueryType = reflect.TypeOf(wrapper.CursorQuery{})
+)
+
+// generatePaginationDoc documents the Link header of the operations listing
+// a page of items selected by a PageQuery or a CursorQuery
+func (b *Builder) generatePaginationDoc(op *openapi3.Operation, requestObjectType reflect.Type) {
+	responseField, found := requestObjectType.FieldByName("Response")
+	if !found {
+		return
+	}
+
+	queryField, found := requestObjectType.FieldByName("Query")
+	if !found {
+		return
+	}
+
+	description := `links to the first, prev, next and last pages (ex: </pets?page=2&per_page=20>; rel="next")`
+	switch queryType := sectionType(queryField.Type); {
+	case responseField.Type.Implements(_cursorPagerType) && embeds(queryType, _cursorQueryType):
+		description = `links to the first and next pages (ex: </pets?cursor=eyJpZCI6NDJ9&limit=20>; rel="next")`
+	case responseField.Type.Implements(_pagerType) && embeds(queryType, _pageQueryType):
+	default:
+		return
+	}
+
+	resp := op.Responses.Get(http.StatusOK)
+	if (resp == nil) || (resp.Value == nil) {
+		return
+	}
+
+	if resp.Value.Headers == nil {
+		resp.Value.Headers = openapi3.Headers{}
+	}
+
+	resp.Value.Headers["Link"] = &openapi3.HeaderRef{
+		Value: &openapi3.Header{
+			Parameter: openapi3.Parameter{
+				Description: description,
+				Schema:      openapi3.NewStringSchema().NewRef(),
+			},
+		},
+	}
+}
+
+// embeds returns true if the structure st embeds t
+func embeds(st reflect.Type, t reflect.Type) bool {
+	if st.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < st.NumField(); i++ {
+		if f := st.Field(i); f.Anonymous && (f.Type == t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type docPage[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+func (p docPage[T]) PageSize() (int, int) {
+	return len(p.Items), p.Total
+}
+
+type listCatsRequest struct {
+	Path  struct{} `example:"/cats"`
+	Query struct {
+		wrapper.PageQuery
+		Name string `json:"name"`
+	}
+	Response docPage[string]
+}
+
+func (r *listCatsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type docCursorPage struct {
+	Items []string       `json:"items"`
+	Next  wrapper.Cursor `json:"next_cursor,omitempty"`
+}
+
+func (p docCursorPage) NextCursor() wrapper.Cursor {
+	return p.Next
+}
+
+type listCatsAfterRequest struct {
+	Path  struct{} `example:"/cats/after"`
+	Query struct {
+		wrapper.CursorQuery
+	}
+	Response docCursorPage
+}
+
+func (r *listCatsAfterRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestPaginationDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("pagination documentation", func() {
+		var swagger *openapi3.T
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/cats", &listCatsRequest{}))
+			require.NoError(g, b.Get(router, "/cats/after", &listCatsAfterRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			swagger = convertToSwagger(g, data)
+		})
+
+		g.It("should document the parameters of the embedded PageQuery", func() {
+			op := swagger.Paths["/cats"].Get
+
+			for _, name := range []string{"page", "per_page", "limit", "offset", "name"} {
+				assert.NotNil(g, op.Parameters.GetByInAndName(openapi3.ParameterInQuery, name), name)
+			}
+
+			perPage := op.Parameters.GetByInAndName(openapi3.ParameterInQuery, "per_page")
+			require.NotNil(g, perPage.Schema.Value.Max)
+			assert.Equal(g, 100.0, *perPage.Schema.Value.Max)
+			assert.Equal(g, "number of items per page", perPage.Description)
+		})
+
+		g.It("should document the Link header and name the generic responses", func() {
+			resp := swagger.Paths["/cats"].Get.Responses["200"].Value
+			assert.Contains(g, resp.Headers, "Link")
+
+			assert.Contains(g, swagger.Components.Schemas, "builder.docPage_string")
+		})
+
+		g.It("should document the cursors", func() {
+			op := swagger.Paths["/cats/after"].Get
+
+			cursor := op.Parameters.GetByInAndName(openapi3.ParameterInQuery, "cursor")
+			require.NotNil(g, cursor)
+			assert.Equal(g, "string", cursor.Schema.Value.Type)
+			assert.NotEmpty(g, cursor.Schema.Value.Pattern)
+
+			link := op.Responses["200"].Value.Headers["Link"]
+			require.NotNil(g, link)
+			assert.Contains(g, link.Value.Description, "first and next")
+		})
+
+		g.It("should flatten the embedded PageQuery in the TypeScript client", func() {
+			var buf bytes.Buffer
+			require.NoError(g, b.GenerateTypeScriptClient(&buf))
+
+			assert.Contains(g, buf.String(), "export interface docPage_string {")
+			assert.Contains(g, buf.String(), "    PerPage?: number;\n")
+			assert.NotContains(g, buf.String(), "PageQuery?:")
+		})
+	})
+}
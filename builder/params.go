@@ -10,6 +10,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
 	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
 )
 
 func (b *Builder) generateParametersDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type, method string, routeContext *chi.Context) error {
@@ -18,13 +19,14 @@ func (b *Builder) generateParametersDoc(ctx context.Context, swagger *openapi3.T
 		return errors.New("wrong struct, Path field expected")
 	}
 
+	pathStructType := sectionType(pathField.Type)
 	for _, key := range routeContext.URLParams.Keys {
 		if key == "*" {
 			continue
 		}
 
 		// pathStruct must contain all defined keys
-		paramField, found := pathField.Type.FieldByName(key)
+		paramField, found := pathStructType.FieldByName(key)
 		if !found {
 			return errors.Errorf("wrong path struct, field %s expected", key)
 		}
@@ -37,7 +39,7 @@ func (b *Builder) generateParametersDoc(ctx context.Context, swagger *openapi3.T
 		param := openapi3.NewPathParameter(key).
 			WithSchema(schema.Value)
 
-		err = fillParamFromTags(requestObjectType, param, paramField, "Path")
+		err = b.fillParamFromTags(requestObjectType, param, paramField, "Path")
 		if err != nil {
 			return err
 		}
@@ -48,6 +50,27 @@ func (b *Builder) generateParametersDoc(ctx context.Context, swagger *openapi3.T
 	return nil
 }
 
+// sectionType returns the structure type of a request section (Path, Query, Header)
+// which may be declared as a pointer
+func sectionType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// isDocumentedParam returns false for the fields of a section which are never
+// bound: unexported fields and fields tagged `json:"-"` or `chipi:"ignore"`
+func isDocumentedParam(f reflect.StructField) bool {
+	if !f.IsExported() {
+		return false
+	}
+
+	ignored := schema.ParseJsonTag(f).Ignored
+	return (ignored == nil) || !*ignored
+}
+
 func prepareExample(t reflect.Type, val string) (interface{}, error) {
 	var ex interface{}
 
@@ -66,7 +89,7 @@ func prepareExample(t reflect.Type, val string) (interface{}, error) {
 	return ex, nil
 }
 
-func fillParamFromTags(requestObjectType reflect.Type, param *openapi3.Parameter, f reflect.StructField, location string) error {
+func (b *Builder) fillParamFromTags(requestObjectType reflect.Type, param *openapi3.Parameter, f reflect.StructField, location string) error {
 	var err error
 	nilValue := reflect.New(requestObjectType)
 	pathMethod, hasPathAnnotations := reflect.PtrTo(requestObjectType).MethodByName(fmt.Sprintf("CHIPI_%s_Annotations", location))
@@ -102,16 +125,50 @@ func fillParamFromTags(requestObjectType reflect.Type, param *openapi3.Parameter
 		}
 	}
 
+	if val, found := f.Tag.Lookup("default"); found && (param.Schema != nil) && (param.Schema.Value != nil) {
+		param.Schema.Value.Default, err = wrapper.ParseValue(f.Type, val)
+		if err != nil {
+			return errors.Wrapf(err, "invalid default value for %s", f.Name)
+		}
+	}
+
+	if (param.Schema != nil) && (param.Schema.Value != nil) {
+		err = schema.ApplyTagEnum(param.Schema.Value, f)
+		if err != nil {
+			return errors.Wrapf(err, "invalid enum for %s", f.Name)
+		}
+
+		err = schema.ApplyTagConstraints(param.Schema.Value, f)
+		if err != nil {
+			return errors.Wrapf(err, "invalid constraints for %s", f.Name)
+		}
+
+		applyFieldsDoc(param.Schema.Value, requestObjectType, f)
+	}
+
+	err = schema.ApplyTagExtensions(&param.ExtensionProps, f.Tag)
+	if err != nil {
+		return errors.Wrapf(err, "invalid extensions for %s", f.Name)
+	}
+
 	if tag.Description != nil {
 		param.Description = *tag.Description
 	}
 
+	if param.Description == "" {
+		param.Description = b.comments.fieldDoc(requestObjectType, location+"."+f.Name)
+	}
+
 	if tag.Style != nil {
 		param.Style = *tag.Style
 	}
 
 	if tag.Explode != nil {
 		param.Explode = tag.Explode
+	} else if (location == "Query") && (wrapper.DescribeParam(location, f).Separator != "") {
+		// the delimited styles are only defined without explode
+		explode := false
+		param.Explode = &explode
 	}
 
 	if tag.Deprecated != nil {
@@ -20,6 +20,30 @@ type testPathRequest struct {
 	} `example:"/pet/43/Fido"`
 }
 
+type testPointerPath struct {
+	Id int
+}
+
+type testPointerQuery struct {
+	Count int
+}
+
+type testPointerHeader struct {
+	ApiKey    string
+	RequestId string `name:"X-Request-Id"`
+}
+
+type testPointerCookie struct {
+	Session string `name:"session_id"`
+}
+
+type testPointerSectionsRequest struct {
+	Path   *testPointerPath `example:"/pet/43"`
+	Query  *testPointerQuery
+	Header *testPointerHeader
+	Cookie *testPointerCookie
+}
+
 func emptyHandler(w http.ResponseWriter, r *http.Request) {
 
 }
@@ -125,6 +149,54 @@ func TestParams(t *testing.T) {
 			})
 
 		})
+
+		g.Describe("pointer sections", func() {
+			var op openapi3.Operation
+
+			g.BeforeEach(func() {
+				var err error
+				router = chi.NewRouter()
+				ctx = context.Background()
+
+				router.Get("/pet/{Id}", emptyHandler)
+				b, err = New(router, &openapi3.Info{})
+				require.NoError(g, err)
+
+				op = openapi3.Operation{}
+				tt := reflect.TypeOf(testPointerSectionsRequest{})
+				routeContext := chi.NewRouteContext()
+				require.True(g, router.Match(routeContext, "GET", "/pet/43"))
+
+				err = b.generateParametersDoc(ctx, b.swagger, &op, tt, "GET", routeContext)
+				require.NoError(g, err)
+
+				err = b.generateQueryParametersDoc(ctx, b.swagger, &op, tt)
+				require.NoError(g, err)
+
+				err = b.generateHeadersDoc(ctx, b.swagger, &op, tt)
+				require.NoError(g, err)
+
+				err = b.generateCookiesDoc(ctx, b.swagger, &op, tt)
+				require.NoError(g, err)
+			})
+
+			g.It("should document path parameters", func() {
+				assert.NotNil(g, op.Parameters.GetByInAndName("path", "Id"))
+			})
+
+			g.It("should document query parameters", func() {
+				assert.NotNil(g, op.Parameters.GetByInAndName("query", "count"))
+			})
+
+			g.It("should document header parameters", func() {
+				assert.NotNil(g, op.Parameters.GetByInAndName("header", "ApiKey"))
+				assert.NotNil(g, op.Parameters.GetByInAndName("header", "X-Request-Id"))
+			})
+
+			g.It("should document cookie parameters", func() {
+				assert.NotNil(g, op.Parameters.GetByInAndName("cookie", "session_id"))
+			})
+		})
 	})
 
 }
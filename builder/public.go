@@ -0,0 +1,141 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/shared"
+)
+
+// OperationFilter returns false to remove an operation from a document
+type OperationFilter func(method string, pattern string, op *openapi3.Operation) bool
+
+const (
+	internalExtension = "x-internal"
+	schemasRefPrefix  = "#/components/schemas/"
+)
+
+// IsPublicOperation keeps the operations which are not internal, they are marked
+// with the `internal:"true"` tag of their Path field, OperationInfo.Internal or
+// the x-internal extension
+func IsPublicOperation(method string, pattern string, op *openapi3.Operation) bool {
+	internal, _ := op.Extensions[internalExtension].(bool)
+	return !internal
+}
+
+// GenerateWith returns the document like GenerateJson without the operations
+// rejected by keep, the schemas only used by them are removed too
+func (b *Builder) GenerateWith(ctx context.Context, filterObject shared.FilterInterface, keep OperationFilter) ([]byte, error) {
+	return b.generateJson(ctx, documentOptions{filterObject: filterObject, keep: keep})
+}
+
+// GeneratePublic returns the document without the internal operations
+func (b *Builder) GeneratePublic(ctx context.Context, filterObject shared.FilterInterface) ([]byte, error) {
+	return b.GenerateWith(ctx, filterObject, IsPublicOperation)
+}
+
+// ServePublicSchema is the same as ServeSchema without the internal operations
+func (b *Builder) ServePublicSchema(w http.ResponseWriter, r *http.Request) {
+	data, err := b.GeneratePublic(r.Context(), nil)
+	if err != nil {
+		b.log().Error(r.Context(), "failed to generate the document", shared.Field{Key: "error", Value: err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	serveDocument(w, r, &b.servedPublic, "application/json", data)
+}
+
+// pruneSchemas removes the schemas which are not referenced by the operations,
+// the other components or the webhooks (directly or by another schema)
+func pruneSchemas(doc *openapi3.T, webhooks map[string]*openapi3.PathItem) error {
+	if len(doc.Components.Schemas) == 0 {
+		return nil
+	}
+
+	roots := []interface{}{
+		doc.Paths,
+		doc.Components.Parameters,
+		doc.Components.Headers,
+		doc.Components.RequestBodies,
+		doc.Components.Responses,
+		doc.Components.Callbacks,
+		webhooks,
+	}
+
+	referenced := map[string]bool{}
+	var pending []string
+
+	addRefs := func(value interface{}) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var decoded interface{}
+		err = json.Unmarshal(data, &decoded)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, name := range schemaRefs(decoded, nil) {
+			if !referenced[name] {
+				referenced[name] = true
+				pending = append(pending, name)
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := addRefs(root); err != nil {
+			return err
+		}
+	}
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+
+		if schema, found := doc.Components.Schemas[name]; found {
+			if err := addRefs(schema); err != nil {
+				return err
+			}
+		}
+	}
+
+	schemas := openapi3.Schemas{}
+	for name, schema := range doc.Components.Schemas {
+		if referenced[name] {
+			schemas[name] = schema
+		}
+	}
+	doc.Components.Schemas = schemas
+
+	return nil
+}
+
+// schemaRefs returns the names of the schemas referenced in value
+func schemaRefs(value interface{}, names []string) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			names = schemaRefs(item, names)
+		}
+
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, schemasRefPrefix) {
+			names = append(names, strings.TrimPrefix(ref, schemasRefPrefix))
+		}
+
+		for _, item := range v {
+			names = schemaRefs(item, names)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publicPet struct {
+	Name string `json:"name"`
+}
+
+type internalStats struct {
+	Count int `json:"count"`
+}
+
+type internalAudit struct {
+	Stats internalStats `json:"stats"`
+}
+
+type publicListRequest struct {
+	Path     struct{} `example:"/pets"`
+	Response []publicPet
+}
+
+func (r *publicListRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type internalAuditRequest struct {
+	Path     struct{} `example:"/audit" internal:"true"`
+	Response internalAudit
+}
+
+func (r *internalAuditRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type internalStatsRequest struct {
+	Path struct{} `example:"/stats"`
+}
+
+func (r *internalStatsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *internalStatsRequest) Describe() OperationInfo {
+	return OperationInfo{Internal: true}
+}
+
+func TestPublicDocument(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("public documents", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{Title: "pets", Version: "1.0"})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/pets", &publicListRequest{}))
+			require.NoError(g, b.Get(router, "/audit", &internalAuditRequest{}))
+			require.NoError(g, b.Get(router, "/stats", &internalStatsRequest{}))
+		})
+
+		g.It("should keep everything in the full document", func() {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			assert.Contains(g, doc.Paths, "/audit")
+			assert.Contains(g, doc.Paths, "/stats")
+			assert.Contains(g, doc.Paths["/audit"].Get.Extensions, "x-internal")
+			assert.Contains(g, doc.Components.Schemas, "builder.internalAudit")
+		})
+
+		g.It("should remove the internal operations and their schemas", func() {
+			data, err := b.GeneratePublic(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			assert.Contains(g, doc.Paths, "/pets")
+			assert.NotContains(g, doc.Paths, "/audit")
+			assert.NotContains(g, doc.Paths, "/stats")
+
+			assert.Contains(g, doc.Components.Schemas, "builder.publicPet")
+			assert.NotContains(g, doc.Components.Schemas, "builder.internalAudit")
+			assert.NotContains(g, doc.Components.Schemas, "builder.internalStats")
+		})
+
+		g.It("should use a custom filter", func() {
+			data, err := b.GenerateWith(context.Background(), nil, func(method string, pattern string, op *openapi3.Operation) bool {
+				return pattern == "/audit"
+			})
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			assert.Equal(g, []string{"/audit"}, sortedKeys(map[string]*openapi3.PathItem(doc.Paths)))
+			assert.Contains(g, doc.Components.Schemas, "builder.internalAudit")
+			assert.Contains(g, doc.Components.Schemas, "builder.internalStats")
+			assert.NotContains(g, doc.Components.Schemas, "builder.publicPet")
+		})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/schmurfy/chipi/schema"
 	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
 )
 
 func (b *Builder) generateQueryParametersDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type) error {
@@ -16,27 +17,43 @@ func (b *Builder) generateQueryParametersDoc(ctx context.Context, swagger *opena
 		return nil
 	}
 
-	queryStructType := pathField.Type
+	queryStructType := sectionType(pathField.Type)
 	if queryStructType.Kind() != reflect.Struct {
 		return errors.New("expected struct for Query")
 	}
 
-	for i := 0; i < queryStructType.NumField(); i++ {
-		field := queryStructType.Field(i)
+	for _, field := range wrapper.SectionFields(queryStructType) {
+		if !isDocumentedParam(field) {
+			continue
+		}
+
+		tag := schema.ParseJsonTag(field)
 
 		fieldSchema, err := b.schema.GenerateSchemaFor(ctx, swagger, field.Type)
 		if err != nil {
 			return err
 		}
 
-		name := schema.ParseJsonTag(field).Name
+		name := tag.Name
 		if name == field.Name {
 			name = shared.ToSnakeCase(field.Name)
 		}
 
 		param := openapi3.NewQueryParameter(name)
 
-		if (fieldSchema.Ref != "") || (fieldSchema.Value.Type == "object") {
+		if (tag.Rest != nil) && *tag.Rest {
+			// catch-all field: free-form parameters
+			explode := true
+			param.Style = openapi3.SerializationForm
+			param.Explode = &explode
+			param = param.WithSchema(fieldSchema.Value)
+		} else if sectionType(field.Type).Kind() == reflect.Map {
+			// maps are sent as filter[key]=value
+			explode := true
+			param.Style = openapi3.SerializationDeepObject
+			param.Explode = &explode
+			param = param.WithSchema(fieldSchema.Value)
+		} else if (fieldSchema.Ref != "") || (fieldSchema.Value.Type == "object") {
 			// we need to wrap the schema
 			param.Content = openapi3.Content{
 				"application/json": &openapi3.MediaType{
@@ -48,7 +65,7 @@ func (b *Builder) generateQueryParametersDoc(ctx context.Context, swagger *opena
 			param = param.WithSchema(fieldSchema.Value)
 		}
 
-		err = fillParamFromTags(requestObjectType, param, field, "Query")
+		err = b.fillParamFromTags(requestObjectType, param, field, "Query")
 		if err != nil {
 			return err
 		}
@@ -17,10 +17,20 @@ type testQueryRequest struct {
 	Query struct {
 		Name                  string `chipi:"required"`
 		NoJsonTag             string
-		SnakeCaseWithJsonTag  string `json:"overrided_name_with_tag"`
-		PascalCaseWithJsonTag string `json:"PascalCaseWithJsonTag"`
-		CamelCaseWithJsonTag  string `json:"camelCaseWithJsonTag"`
-		PascalCaseWithNameTag string `name:"PascalCaseWithNameTag"`
+		SnakeCaseWithJsonTag  string              `json:"overrided_name_with_tag"`
+		PascalCaseWithJsonTag string              `json:"PascalCaseWithJsonTag"`
+		CamelCaseWithJsonTag  string              `json:"camelCaseWithJsonTag"`
+		PascalCaseWithNameTag string              `name:"PascalCaseWithNameTag"`
+		Filters               map[string][]string `chipi:"rest"`
+		Limit                 int                 `default:"20"`
+		Fields                []string            `default:"id,name"`
+		Range                 map[string]int
+		Sort                  string   `chipi:"enum=asc,desc"`
+		Page                  int      `min:"1"`
+		Colors                []string `style:"pipeDelimited"`
+		Internal              string   `chipi:"ignore"`
+		Skipped               string   `json:"-"`
+		hidden                string
 	}
 }
 
@@ -90,6 +100,72 @@ func TestQueryParams(t *testing.T) {
 					assert.True(g, param.Required)
 				})
 			})
+
+			g.It("should document default values", func() {
+				param := op.Parameters.GetByInAndName("query", "limit")
+				require.NotNil(g, param)
+				assert.Equal(g, 20, param.Schema.Value.Default)
+
+				param = op.Parameters.GetByInAndName("query", "fields")
+				require.NotNil(g, param)
+				assert.Equal(g, []string{"id", "name"}, param.Schema.Value.Default)
+			})
+
+			g.It("should document the delimited styles without explode", func() {
+				param := op.Parameters.GetByInAndName("query", "colors")
+				require.NotNil(g, param)
+
+				assert.Equal(g, "pipeDelimited", param.Style)
+				require.NotNil(g, param.Explode)
+				assert.False(g, *param.Explode)
+			})
+
+			g.It("should document maps as deepObject parameters", func() {
+				param := op.Parameters.GetByInAndName("query", "range")
+				require.NotNil(g, param)
+
+				assert.Equal(g, "deepObject", param.Style)
+				require.NotNil(g, param.Explode)
+				assert.True(g, *param.Explode)
+
+				require.NotNil(g, param.Schema)
+				assert.Equal(g, "object", param.Schema.Value.Type)
+				assert.Equal(g, "integer", param.Schema.Value.AdditionalProperties.Value.Type)
+			})
+
+			g.It("should document catch-all field as free-form parameter", func() {
+				param := op.Parameters.GetByInAndName("query", "filters")
+				require.NotNil(g, param)
+
+				assert.Equal(g, "form", param.Style)
+				require.NotNil(g, param.Explode)
+				assert.True(g, *param.Explode)
+
+				require.NotNil(g, param.Schema)
+				assert.Equal(g, "object", param.Schema.Value.Type)
+				require.NotNil(g, param.Schema.Value.AdditionalProperties)
+				assert.Equal(g, "array", param.Schema.Value.AdditionalProperties.Value.Type)
+			})
+
+			g.It("should document enums", func() {
+				param := op.Parameters.GetByInAndName("query", "sort")
+				require.NotNil(g, param)
+				assert.Equal(g, []interface{}{"asc", "desc"}, param.Schema.Value.Enum)
+			})
+
+			g.It("should document constraints", func() {
+				param := op.Parameters.GetByInAndName("query", "page")
+				require.NotNil(g, param)
+				require.NotNil(g, param.Schema.Value.Min)
+				assert.Equal(g, 1.0, *param.Schema.Value.Min)
+			})
+
+			g.It("should not document ignored and unexported fields", func() {
+				assert.Nil(g, op.Parameters.GetByInAndName("query", "internal"))
+				assert.Nil(g, op.Parameters.GetByInAndName("query", "Skipped"))
+				assert.Nil(g, op.Parameters.GetByInAndName("query", "skipped"))
+				assert.Nil(g, op.Parameters.GetByInAndName("query", "hidden"))
+			})
 		})
 	})
 }
@@ -0,0 +1,81 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var _rateLimitHeaders = []struct {
+	name        string
+	description string
+}{
+	{"RateLimit-Limit", "number of requests allowed in the window"},
+	{"RateLimit-Remaining", "number of requests left in the window"},
+	{"RateLimit-Reset", "seconds until the window ends"},
+}
+
+// generateRateLimitDoc documents the RateLimit headers and the 429 response
+// of the operations with a `rate-limit` tag or the RateLimit wrapper option
+func (b *Builder) generateRateLimitDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObjectType reflect.Type, filterObject shared.FilterInterface) error {
+	limit := wrapper.OperationRateLimit(requestObjectType)
+	if (limit == nil) && (b.wrapperOptions != nil) && (b.wrapperOptions.RateLimit != nil) {
+		limit = b.wrapperOptions.RateLimit.Limit
+	}
+
+	if limit == nil {
+		return nil
+	}
+
+	for key, resp := range op.Responses {
+		if strings.HasPrefix(key, "2") && (resp.Value != nil) {
+			addRateLimitHeaders(resp.Value)
+		}
+	}
+
+	key := strconv.Itoa(http.StatusTooManyRequests)
+	if op.Responses[key] != nil {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, fmt.Sprintf("more than %d requests in %s", limit.Requests, limit.Window), &response.Problem{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	addRateLimitHeaders(resp)
+	resp.Headers["Retry-After"] = integerHeader("seconds to wait before sending a new request")
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
+
+func addRateLimitHeaders(resp *openapi3.Response) {
+	if resp.Headers == nil {
+		resp.Headers = openapi3.Headers{}
+	}
+
+	for _, header := range _rateLimitHeaders {
+		resp.Headers[header.name] = integerHeader(header.description)
+	}
+}
+
+func integerHeader(description string) *openapi3.HeaderRef {
+	return &openapi3.HeaderRef{
+		Value: &openapi3.Header{
+			Parameter: openapi3.Parameter{
+				Description: description,
+				Schema:      openapi3.NewIntegerSchema().NewRef(),
+			},
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type limitedSearchRequest struct {
+	Path     struct{} `example:"/search" rate-limit:"10/1m"`
+	Response struct {
+		Results []string
+	}
+}
+
+func (r *limitedSearchRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestRateLimitDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("rate limit documentation", func() {
+		generate := func(opts *wrapper.Options) *openapi3.T {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.SetWrapperOptions(opts)
+			require.NoError(g, b.Get(router, "/search", &limitedSearchRequest{}))
+			require.NoError(g, b.Get(router, "/pets", &quickPetRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should document the 429 and the headers of the limited operations", func() {
+			swagger := generate(nil)
+			op := swagger.Paths["/search"].Get
+
+			resp := op.Responses["429"]
+			require.NotNil(g, resp)
+			assert.Equal(g, "more than 10 requests in 1m0s", *resp.Value.Description)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+			assert.Contains(g, resp.Value.Headers, "Retry-After")
+			assert.Contains(g, op.Responses["200"].Value.Headers, "RateLimit-Remaining")
+
+			assert.Nil(g, swagger.Paths["/pets"].Get.Responses["429"])
+		})
+
+		g.It("should document the default limit", func() {
+			swagger := generate(&wrapper.Options{RateLimit: &wrapper.RateLimitOptions{
+				Limit: &wrapper.RateLimit{Requests: 100, Window: time.Hour},
+			}})
+
+			assert.Equal(g, "more than 100 requests in 1h0m0s", *swagger.Paths["/pets"].Get.Responses["429"].Value.Description)
+		})
+	})
+}
@@ -15,39 +15,86 @@ func (b *Builder) generateResponseDoc(ctx context.Context, swagger *openapi3.T,
 	responses := make(openapi3.Responses)
 
 	responseField, found := requestObjectType.FieldByName("Response")
-	if found {
-		resp := openapi3.NewResponse()
-
-		// check that a body decoder is available
-		if _, ok := requestObject.(wrapper.ResponseEncoder); !ok {
-			return fmt.Errorf("%s must implement ResponseEncoder", requestObjectType.Name())
+	if _, ok := requestObject.(wrapper.Streamer); ok {
+		description := "event stream"
+		responses["200"] = &openapi3.ResponseRef{
+			Value: &openapi3.Response{
+				Description: &description,
+				Content: openapi3.Content{
+					wrapper.EventStreamContentType: &openapi3.MediaType{
+						Schema: openapi3.NewSchemaRef("", openapi3.NewStringSchema()),
+					},
+				},
+			},
 		}
+	} else if wrapper.IsWebsocketHandler(requestObject) {
+		description := "websocket connection"
+		responses["101"] = &openapi3.ResponseRef{
+			Value: &openapi3.Response{
+				Description: &description,
+			},
+		}
+	} else if found {
+		resp := openapi3.NewResponse()
 
 		contentType, hasContentType := responseField.Tag.Lookup("content-type")
 		if !hasContentType {
 			contentType = "application/json"
 		}
 
+		// check that an encoder is available for custom content types
+		if _, ok := requestObject.(wrapper.ResponseEncoder); !ok && !wrapper.HasDefaultResponseEncoding(responseField) {
+			return fmt.Errorf("%s must implement ResponseEncoder for %s responses", requestObjectType.Name(), contentType)
+		}
+
 		typ := responseField.Type
 		if typ.Kind() == reflect.Ptr {
 			typ = typ.Elem()
 		}
 
-		err := fillResponseFromTags(requestObjectType, resp, responseField)
+		if description := b.comments.fieldDoc(requestObjectType, "Response"); description != "" {
+			resp.Description = &description
+		}
+
+		example, err := fillResponseFromTags(requestObjectType, resp, responseField, typ)
 		if err != nil {
 			return err
 		}
 
-		if typ.Kind() == reflect.Struct {
+		if wrapper.IsStreamedResponse(responseField) {
+			resp.Content = openapi3.Content{
+				wrapper.StreamedResponseContentType(responseField): &openapi3.MediaType{
+					Schema: openapi3.NewSchemaRef("", &openapi3.Schema{
+						Type:   "string",
+						Format: "binary",
+					}),
+				},
+			}
+
+			if wrapper.IsFileResponse(responseField) {
+				resp.Headers = openapi3.Headers{
+					"Content-Disposition": &openapi3.HeaderRef{
+						Value: &openapi3.Header{
+							Parameter: openapi3.Parameter{
+								Description: "name of the downloaded file",
+								Schema:      openapi3.NewStringSchema().NewRef(),
+							},
+						},
+					},
+				}
+			}
+		} else if hasResponseSchema(typ) {
 			responseSchema, err := b.schema.GenerateFilteredSchemaFor(ctx, swagger, typ, filterObject)
 			if err != nil {
 				return err
 			}
 
-			resp.Content = openapi3.Content{
-				contentType: &openapi3.MediaType{
-					Schema: responseSchema,
-				},
+			resp.Content = openapi3.Content{}
+			for _, mediaType := range b.responseMediaTypes(requestObject, contentType, hasContentType) {
+				resp.Content[mediaType] = &openapi3.MediaType{
+					Schema:  responseSchema,
+					Example: example,
+				}
 			}
 		}
 
@@ -64,12 +111,48 @@ func (b *Builder) generateResponseDoc(ctx context.Context, swagger *openapi3.T,
 		}
 	}
 
+	err := b.generateErrorResponsesDoc(ctx, swagger, responses, requestObject, filterObject)
+	if err != nil {
+		return err
+	}
+
 	op.Responses = responses
 
 	return nil
 }
 
-func fillResponseFromTags(requestObjectType reflect.Type, resp *openapi3.Response, f reflect.StructField) error {
+// responseMediaTypes returns the media types the response can be encoded with,
+// all the registered encoders are available with content negotiation
+func (b *Builder) responseMediaTypes(requestObject interface{}, contentType string, hasContentType bool) []string {
+	if _, ok := requestObject.(wrapper.ResponseEncoder); ok || hasContentType {
+		return []string{contentType}
+	}
+
+	mediaTypes := wrapper.NegotiatedMediaTypes(requestObject, b.wrapperOptions)
+	if len(mediaTypes) == 0 {
+		return []string{contentType}
+	}
+
+	return mediaTypes
+}
+
+// hasResponseSchema returns true if the schema of the response can be generated,
+// raw bytes and interfaces without registered implementations are only
+// described by their content type
+func hasResponseSchema(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return schema.ImplementationsOf(typ) != nil
+	case reflect.Slice:
+		return typ.Elem().Kind() != reflect.Uint8
+	}
+
+	return true
+}
+
+// fillResponseFromTags sets the description of the response and returns
+// its example (if any)
+func fillResponseFromTags(requestObjectType reflect.Type, resp *openapi3.Response, f reflect.StructField, typ reflect.Type) (example interface{}, err error) {
 	nilValue := reflect.New(requestObjectType)
 
 	opMethod, hasOperationAnnotations := reflect.PtrTo(requestObjectType).MethodByName("CHIPI_Response_Annotations")
@@ -83,6 +166,13 @@ func fillResponseFromTags(requestObjectType reflect.Type, resp *openapi3.Respons
 			if p.Description != "" {
 				resp.Description = &p.Description
 			}
+
+			if p.Example != nil {
+				example, err = prepareExample(typ, p.Example.(string))
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
@@ -92,5 +182,12 @@ func fillResponseFromTags(requestObjectType reflect.Type, resp *openapi3.Respons
 		resp.Description = tag.Description
 	}
 
-	return nil
+	if tag.Example != nil {
+		example, err = prepareExample(typ, *tag.Example)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return example, nil
 }
@@ -3,6 +3,8 @@ package builder
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"net/http"
 	"reflect"
 	"testing"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/wrapper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,6 +28,31 @@ type Inline struct {
 	Field2 int `json:"field2"`
 }
 
+type responseTestStreamer struct {
+	Path struct{}
+}
+
+func (r *responseTestStreamer) Stream(ctx context.Context, events chan<- wrapper.Event) error {
+	return nil
+}
+
+type responseTestConflict struct {
+	Existing string `json:"existing"`
+}
+
+type responseTestErrors struct {
+	Path     struct{}
+	Response Parent
+}
+
+func (r *responseTestErrors) ErrorResponses() map[int]interface{} {
+	return map[int]interface{}{
+		http.StatusBadRequest: &response.Problem{},
+		http.StatusNotFound:   nil,
+		http.StatusConflict:   responseTestConflict{},
+	}
+}
+
 func TestResponse(t *testing.T) {
 	g := goblin.Goblin(t)
 
@@ -44,13 +72,22 @@ func TestResponse(t *testing.T) {
 
 			op = openapi3.NewOperation()
 		})
-		g.It("should return an error if structure does not implemenent ResponseEncoder", func() {
+		g.It("should not require a ResponseEncoder for json responses", func() {
 			req := struct {
 				Response struct {
 					Name string
 				}
 			}{}
 
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+		})
+
+		g.It("should return an error if structure does not implemenent ResponseEncoder for custom content types", func() {
+			req := struct {
+				Response []byte `content-type:"image/png"`
+			}{}
+
 			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
 			require.Error(g, err)
 			assert.Contains(g, err.Error(), "must implement ResponseEncoder")
@@ -121,6 +158,55 @@ func TestResponse(t *testing.T) {
 				}`, string(data))
 		})
 
+		g.It("should document slice responses", func() {
+			req := struct {
+				Response []Inline
+			}{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			mediaType := op.Responses["200"].Value.Content.Get("application/json")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "array", mediaType.Schema.Value.Type)
+			assert.Equal(g, "#/components/schemas/"+reflect.TypeOf(Inline{}).String(), mediaType.Schema.Value.Items.Ref)
+		})
+
+		g.It("should add the example from tags", func() {
+			req := struct {
+				Response Parent `example:"{\"Field3\": \"three\", \"field4\": 4}"`
+			}{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			mediaType := op.Responses["200"].Value.Content.Get("application/json")
+			require.NotNil(g, mediaType)
+
+			data, err := json.Marshal(mediaType.Example)
+			require.NoError(g, err)
+			assert.JSONEq(g, `{"Field1": "", "field2": 0, "Field3": "three", "field4": 4}`, string(data))
+		})
+
+		g.It("should list the negotiated media types", func() {
+			b.wrapperOptions = &wrapper.Options{Encoders: wrapper.DefaultEncoders()}
+
+			req := struct {
+				Response struct {
+					Name string
+				}
+			}{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			resp, found := op.Responses["200"]
+			require.True(g, found)
+
+			assert.NotNil(g, resp.Value.Content.Get("application/json"))
+			assert.NotNil(g, resp.Value.Content.Get("application/xml"))
+		})
+
 		g.It("should handle binary response", func() {
 			req := struct {
 				response.JsonEncoder
@@ -139,6 +225,99 @@ func TestResponse(t *testing.T) {
 			require.Nil(g, mediaType)
 		})
 
+		g.It("should document streamed responses as binary", func() {
+			req := struct {
+				Response io.ReadCloser `content-type:"text/csv"`
+			}{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			resp, found := op.Responses["200"]
+			require.True(g, found)
+
+			mediaType := resp.Value.Content.Get("text/csv")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "binary", mediaType.Schema.Value.Format)
+		})
+
+		g.It("should document file responses as binary with their disposition", func() {
+			req := struct {
+				Response *response.FileResponse `content-type:"application/pdf"`
+			}{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			resp := op.Responses["200"]
+			require.NotNil(g, resp)
+
+			mediaType := resp.Value.Content.Get("application/pdf")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "binary", mediaType.Schema.Value.Format)
+			assert.Contains(g, resp.Value.Headers, "Content-Disposition")
+		})
+
+		g.It("should document event streams", func() {
+			req := responseTestStreamer{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			resp, found := op.Responses["200"]
+			require.True(g, found)
+			require.NotNil(g, resp.Value.Content.Get("text/event-stream"))
+		})
+
+		g.It("should document the error responses", func() {
+			req := responseTestErrors{}
+
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			require.Len(g, op.Responses, 4)
+			require.NotNil(g, op.Responses["200"])
+
+			badRequest := op.Responses["400"]
+			require.NotNil(g, badRequest)
+			assert.Equal(g, "Bad Request", *badRequest.Value.Description)
+			mediaType := badRequest.Value.Content.Get("application/problem+json")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "#/components/schemas/response.Problem", mediaType.Schema.Ref)
+
+			notFound := op.Responses["404"]
+			require.NotNil(g, notFound)
+			assert.Equal(g, "Not Found", *notFound.Value.Description)
+			assert.Empty(g, notFound.Value.Content)
+
+			conflict := op.Responses["409"]
+			require.NotNil(g, conflict)
+			mediaType = conflict.Value.Content.Get("application/json")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "#/components/schemas/"+reflect.TypeOf(responseTestConflict{}).String(), mediaType.Schema.Ref)
+		})
+
+		g.It("should document the default error response", func() {
+			b.SetDefaultErrorResponse(&response.Error{})
+
+			req := responseTestErrors{}
+			err := b.generateResponseDoc(ctx, b.swagger, op, &req, reflect.TypeOf(req), nil)
+			require.NoError(g, err)
+
+			require.Len(g, op.Responses, 5)
+
+			defaultError := op.Responses["default"]
+			require.NotNil(g, defaultError)
+			mediaType := defaultError.Value.Content.Get("application/json")
+			require.NotNil(g, mediaType)
+			assert.Equal(g, "#/components/schemas/response.Error", mediaType.Schema.Ref)
+
+			errorSchema := b.swagger.Components.Schemas["response.Error"]
+			require.NotNil(g, errorSchema)
+			assert.Contains(g, errorSchema.Value.Properties, "code")
+			assert.Contains(g, errorSchema.Value.Properties, "details")
+		})
+
 		g.It("should embed Inline struct", func() {
 			req := struct {
 				response.JsonEncoder
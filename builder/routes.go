@@ -0,0 +1,35 @@
+package builder
+
+import (
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// isRouteOf returns true if the full pattern of a route ends with the
+// pattern it was registered with on its router
+func isRouteOf(fullPattern string, pattern string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(fullPattern, "/"), strings.TrimSuffix(pattern, "/"))
+}
+
+// routeContext returns the context chi would build when matching route,
+// the values of the parameters are unknown
+func routeContext(route string) *chi.Context {
+	tctx := chi.NewRouteContext()
+	tctx.RoutePatterns = []string{route}
+
+	for _, segment := range strings.Split(route, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		key := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if idx := strings.Index(key, ":"); idx >= 0 {
+			key = key[:idx]
+		}
+
+		tctx.URLParams.Add(key, "")
+	}
+
+	return tctx
+}
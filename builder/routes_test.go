@@ -0,0 +1,170 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nestedMemberRequest struct {
+	Path struct {
+		Org int
+		Id  int
+	} `example:"/api/orgs/5/members/3"`
+}
+
+func (r *nestedMemberRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type nestedProfileRequest struct {
+	Path struct {
+		Org int
+	} `example:"/api/orgs/5/members/me"`
+}
+
+func (r *nestedProfileRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+// the example matches the route of nestedProfileRequest
+type staleExampleRequest struct {
+	Path struct {
+		Org  int
+		Name string
+	} `example:"/api/orgs/5/members/me"`
+}
+
+func (r *staleExampleRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type noExampleMemberRequest struct {
+	Path struct {
+		Org int
+		Id  int
+	}
+}
+
+func (r *noExampleMemberRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type noExampleIndexRequest struct {
+	Path struct {
+		Org int
+	}
+}
+
+func (r *noExampleIndexRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestNestedRoutes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("nested routers", func() {
+		var b *Builder
+		var router *chi.Mux
+		var members *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			api := chi.NewRouter()
+			orgs := chi.NewRouter()
+			members = chi.NewRouter()
+
+			orgs.Mount("/members", members)
+			api.Mount("/orgs/{Org}", orgs)
+			router.Mount("/api", api)
+		})
+
+		generate := func() openapi3.Paths {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data).Paths
+		}
+
+		g.It("should document the routes of mounted routers", func() {
+			require.NoError(g, b.Get(members, "/{Id}", &nestedMemberRequest{}))
+
+			item := generate()["/api/orgs/{Org}/members/{Id}"]
+			require.NotNil(g, item)
+			require.NotNil(g, item.Get)
+			assert.NotNil(g, item.Get.Parameters.GetByInAndName("path", "Org"))
+			assert.NotNil(g, item.Get.Parameters.GetByInAndName("path", "Id"))
+		})
+
+		g.It("should walk the routers when the example matches another route", func() {
+			require.NoError(g, b.Get(members, "/me", &nestedProfileRequest{}))
+			require.NoError(g, b.Get(members, "/{Name}", &staleExampleRequest{}))
+
+			paths := generate()
+			require.NotNil(g, paths["/api/orgs/{Org}/members/me"])
+			item := paths["/api/orgs/{Org}/members/{Name}"]
+			require.NotNil(g, item)
+			require.NotNil(g, item.Get)
+			assert.Equal(g, "staleExampleRequest", item.Get.OperationID)
+			assert.NotNil(g, item.Get.Parameters.GetByInAndName("path", "Name"))
+		})
+	})
+
+	g.Describe("routes without example", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+		})
+
+		g.It("should find the route from its pattern", func() {
+			router.Route("/orgs/{Org}/members", func(r chi.Router) {
+				require.NoError(g, b.Get(r, "/{Id}", &noExampleMemberRequest{}))
+			})
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			item := convertToSwagger(g, data).Paths["/orgs/{Org}/members/{Id}"]
+			require.NotNil(g, item)
+			require.NotNil(g, item.Get)
+			assert.NotNil(g, item.Get.Parameters.GetByInAndName("path", "Id"))
+		})
+
+		g.It("should report ambiguous patterns", func() {
+			router.Route("/orgs/{Org}/members", func(r chi.Router) {
+				require.NoError(g, b.Get(r, "/", &noExampleIndexRequest{}))
+			})
+			router.Route("/orgs/{Org}/teams", func(r chi.Router) {
+				require.NoError(g, b.Get(r, "/", &noExampleIndexRequest{}))
+			})
+
+			_, err := b.GenerateJson(context.Background(), nil)
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), "is ambiguous")
+		})
+	})
+
+	g.Describe("routeContext", func() {
+		g.It("should extract the parameters", func() {
+			tctx := routeContext("/orgs/{Org}/members/{Id:[0-9]+}")
+			assert.Equal(g, []string{"Org", "Id"}, tctx.URLParams.Keys)
+			assert.Equal(g, "/orgs/{Org}/members/{Id:[0-9]+}", tctx.RoutePattern())
+		})
+	})
+}
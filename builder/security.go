@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// Secured can be implemented by request objects to list the security schemes
+// (see AddSecurityScheme) accepted by the operation, any of them is enough,
+// an empty list documents a public operation when a global requirement is set
+type Secured interface {
+	Security() []string
+}
+
+func (b *Builder) generateSecurityDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, filterObject shared.FilterInterface) error {
+	if _, ok := requestObject.(wrapper.Authenticator); ok {
+		err := b.generateAuthenticationDoc(ctx, swagger, op, requestObject, filterObject)
+		if err != nil {
+			return err
+		}
+	}
+
+	if secured, ok := requestObject.(Secured); ok {
+		requirements := openapi3.NewSecurityRequirements()
+		for _, name := range secured.Security() {
+			if _, found := b.swagger.Components.SecuritySchemes[name]; !found {
+				return errors.Errorf("unknown security scheme %q", name)
+			}
+
+			requirements.With(openapi3.NewSecurityRequirement().Authenticate(name))
+		}
+
+		op.Security = requirements
+	}
+
+	if permissions := wrapper.OperationPermissions(reflect.TypeOf(requestObject).Elem()); len(permissions) > 0 {
+		return b.generatePermissionsDoc(ctx, swagger, op, permissions, filterObject)
+	}
+
+	return nil
+}
+
+// generatePermissionsDoc documents the permissions of the `permissions` tag
+// as the scopes of the security requirements (the global ones or any of the
+// security schemes if the operation has none) and the 403 response
+func (b *Builder) generatePermissionsDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, permissions []string, filterObject shared.FilterInterface) error {
+	requirements := op.Security
+	if requirements == nil {
+		if len(b.swagger.Security) > 0 {
+			requirements = &b.swagger.Security
+		} else {
+			requirements = b.anySecurityScheme()
+		}
+	}
+
+	scoped := openapi3.NewSecurityRequirements()
+	for _, requirement := range *requirements {
+		scopedRequirement := openapi3.NewSecurityRequirement()
+		for name := range requirement {
+			scopedRequirement[name] = append([]string{}, permissions...)
+		}
+		scoped.With(scopedRequirement)
+	}
+
+	if len(*scoped) > 0 {
+		op.Security = scoped
+	}
+
+	key := strconv.Itoa(http.StatusForbidden)
+	if op.Responses[key] != nil {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, "missing permissions "+strings.Join(permissions, ", "), &response.Problem{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
+
+// anySecurityScheme returns the requirements accepting any of the security
+// schemes, sorted by name
+func (b *Builder) anySecurityScheme() *openapi3.SecurityRequirements {
+	names := make([]string, 0, len(b.swagger.Components.SecuritySchemes))
+	for name := range b.swagger.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	requirements := openapi3.NewSecurityRequirements()
+	for _, name := range names {
+		requirements.With(openapi3.NewSecurityRequirement().Authenticate(name))
+	}
+
+	return requirements
+}
+
+// generateAuthenticationDoc documents the 401 response of the request objects
+// implementing wrapper.Authenticator, without Secured method nor global
+// requirement any of the security schemes is required
+func (b *Builder) generateAuthenticationDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, filterObject shared.FilterInterface) error {
+	if _, secured := requestObject.(Secured); !secured && (len(b.swagger.Security) == 0) && (len(b.swagger.Components.SecuritySchemes) > 0) {
+		op.Security = b.anySecurityScheme()
+	}
+
+	key := strconv.Itoa(http.StatusUnauthorized)
+	if op.Responses[key] != nil {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, "authentication failed", &response.Problem{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
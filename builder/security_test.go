@@ -0,0 +1,202 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type securityTestRequest struct {
+	Path struct{} `example:"/secured"`
+
+	schemes []string
+}
+
+func (r *securityTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *securityTestRequest) Security() []string {
+	return r.schemes
+}
+
+type authenticatedTestRequest struct {
+	Path struct{} `example:"/secured"`
+}
+
+func (r *authenticatedTestRequest) Authenticate(ctx context.Context, req *http.Request) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *authenticatedTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type securedAuthenticatedTestRequest struct {
+	authenticatedTestRequest
+}
+
+func (r *securedAuthenticatedTestRequest) Security() []string {
+	return []string{"bearer"}
+}
+
+type permissionsTestRequest struct {
+	Path struct{} `example:"/secured" permissions:"users:read,users:write"`
+}
+
+func (r *permissionsTestRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type securedPermissionsTestRequest struct {
+	permissionsTestRequest
+}
+
+func (r *securedPermissionsTestRequest) Security() []string {
+	return []string{"bearer"}
+}
+
+func TestSecurity(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("security", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.AddSecurityScheme("bearer", &openapi3.SecurityScheme{Type: "http", Scheme: "bearer"})
+			b.AddSecurityScheme("api_key", &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Api-Key"})
+		})
+
+		generate := func() *openapi3.Operation {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			swagger := convertToSwagger(g, data)
+			require.NotNil(g, swagger.Components.SecuritySchemes["bearer"])
+
+			op := swagger.Paths["/secured"].Get
+			require.NotNil(g, op)
+			return op
+		}
+
+		g.It("should document the accepted schemes", func() {
+			err := b.Get(router, "/secured", &securityTestRequest{schemes: []string{"bearer", "api_key"}})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{
+				{"bearer": []string{}},
+				{"api_key": []string{}},
+			}, *op.Security)
+		})
+
+		g.It("should document public operations", func() {
+			err := b.Get(router, "/secured", &securityTestRequest{schemes: []string{}})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Empty(g, *op.Security)
+		})
+
+		g.It("should reject unknown schemes", func() {
+			err := b.Get(router, "/secured", &securityTestRequest{schemes: []string{"oauth"}})
+			require.NoError(g, err)
+
+			_, err = b.GenerateJson(context.Background(), nil)
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), `unknown security scheme "oauth"`)
+		})
+
+		g.It("should require any scheme for the authenticated operations", func() {
+			err := b.Get(router, "/secured", &authenticatedTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{
+				{"api_key": []string{}},
+				{"bearer": []string{}},
+			}, *op.Security)
+
+			resp := op.Responses["401"]
+			require.NotNil(g, resp)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+		})
+
+		g.It("should keep the schemes of the authenticated operations", func() {
+			err := b.Get(router, "/secured", &securedAuthenticatedTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{{"bearer": []string{}}}, *op.Security)
+			assert.NotNil(g, op.Responses["401"])
+		})
+
+		g.It("should use the global requirement for the authenticated operations", func() {
+			b.AddSecurityRequirement(openapi3.NewSecurityRequirement().Authenticate("bearer"))
+
+			err := b.Get(router, "/secured", &authenticatedTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			assert.Nil(g, op.Security)
+			assert.NotNil(g, op.Responses["401"])
+		})
+
+		g.It("should document the permissions as scopes", func() {
+			err := b.Get(router, "/secured", &securedPermissionsTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{
+				{"bearer": []string{"users:read", "users:write"}},
+			}, *op.Security)
+
+			resp := op.Responses["403"]
+			require.NotNil(g, resp)
+			assert.Equal(g, "missing permissions users:read, users:write", *resp.Value.Description)
+		})
+
+		g.It("should scope the global requirement with the permissions", func() {
+			b.AddSecurityRequirement(openapi3.NewSecurityRequirement().Authenticate("api_key"))
+
+			err := b.Get(router, "/secured", &permissionsTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{
+				{"api_key": []string{"users:read", "users:write"}},
+			}, *op.Security)
+		})
+
+		g.It("should scope any scheme without requirement", func() {
+			err := b.Get(router, "/secured", &permissionsTestRequest{})
+			require.NoError(g, err)
+
+			op := generate()
+			require.NotNil(g, op.Security)
+			assert.Equal(g, openapi3.SecurityRequirements{
+				{"api_key": []string{"users:read", "users:write"}},
+				{"bearer": []string{"users:read", "users:write"}},
+			}, *op.Security)
+		})
+	})
+}
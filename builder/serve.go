@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// servedDocument remembers when the served documents last changed
+type servedDocument struct {
+	mu           sync.Mutex
+	etag         string
+	lastModified time.Time
+}
+
+// update returns the ETag and modification date of data
+func (d *servedDocument) update(data []byte) (string, time.Time) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if etag != d.etag {
+		d.etag = etag
+		d.lastModified = time.Now().UTC().Truncate(time.Second)
+	}
+
+	return d.etag, d.lastModified
+}
+
+// serveDocument writes data with ETag and Last-Modified headers, the
+// conditional requests (If-None-Match, If-Modified-Since) get a 304
+func serveDocument(w http.ResponseWriter, r *http.Request, d *servedDocument, contentType string, data []byte) {
+	etag, lastModified := d.update(data)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", lastModified, bytes.NewReader(data))
+}
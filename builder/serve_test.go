@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeSchema(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("ServeSchema", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			err = b.Get(router, "/pets", &versionedPetRequest{})
+			require.NoError(g, err)
+		})
+
+		serve := func(headers map[string]string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("GET", "/doc.json", nil)
+			for name, value := range headers {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			b.ServeSchema(w, r)
+			return w
+		}
+
+		g.It("should serve the document with cache headers", func() {
+			w := serve(nil)
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/json", w.Header().Get("Content-Type"))
+			assert.NotEmpty(g, w.Header().Get("ETag"))
+			assert.NotEmpty(g, w.Header().Get("Last-Modified"))
+			assert.Contains(g, w.Body.String(), `"/pets"`)
+		})
+
+		g.It("should answer conditional requests", func() {
+			first := serve(nil)
+
+			w := serve(map[string]string{"If-None-Match": first.Header().Get("ETag")})
+			assert.Equal(g, http.StatusNotModified, w.Code)
+			assert.Empty(g, w.Body.String())
+
+			w = serve(map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")})
+			assert.Equal(g, http.StatusNotModified, w.Code)
+		})
+
+		g.It("should change the ETag with the document", func() {
+			first := serve(nil)
+
+			b.AddTag(&openapi3.Tag{Name: "pets"})
+
+			w := serve(map[string]string{"If-None-Match": first.Header().Get("ETag")})
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.NotEqual(g, first.Header().Get("ETag"), w.Header().Get("ETag"))
+		})
+	})
+}
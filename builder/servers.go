@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"context"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+)
+
+// AddServerURL declares a server of the api, the url can be templated
+// (ex: "https://{region}.example.com/{basePath}") in which case every
+// variable must be declared with a default value
+func (b *Builder) AddServerURL(url string, description string, variables map[string]*openapi3.ServerVariable) error {
+	server := &openapi3.Server{
+		URL:         url,
+		Description: description,
+		Variables:   variables,
+	}
+
+	err := server.Validate(context.Background())
+	if err != nil {
+		return errors.Wrapf(err, "invalid server %s", url)
+	}
+
+	for name, v := range variables {
+		if (len(v.Enum) > 0) && !containsString(v.Enum, v.Default) {
+			return errors.Errorf("invalid server %s: default %q of %s is not one of %v", url, v.Default, name, v.Enum)
+		}
+	}
+
+	b.AddServer(server)
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
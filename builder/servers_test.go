@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddServerURL(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("AddServerURL", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+			b, err = New(chi.NewRouter(), &openapi3.Info{})
+			require.NoError(g, err)
+		})
+
+		g.It("should document the servers", func() {
+			err := b.AddServerURL("https://api.example.com/v1", "production", nil)
+			require.NoError(g, err)
+
+			err = b.AddServerURL("https://{region}.example.com/v1", "regional", map[string]*openapi3.ServerVariable{
+				"region": {Default: "eu", Enum: []string{"eu", "us"}},
+			})
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			require.Len(g, doc.Servers, 2)
+			assert.Equal(g, "production", doc.Servers[0].Description)
+			assert.Equal(g, "https://{region}.example.com/v1", doc.Servers[1].URL)
+			assert.Equal(g, "eu", doc.Servers[1].Variables["region"].Default)
+		})
+
+		g.It("should reject undeclared variables", func() {
+			err := b.AddServerURL("https://{region}.example.com", "", nil)
+			assert.Error(g, err)
+		})
+
+		g.It("should require a default value", func() {
+			err := b.AddServerURL("https://{region}.example.com", "", map[string]*openapi3.ServerVariable{
+				"region": {},
+			})
+			assert.Error(g, err)
+		})
+
+		g.It("should reject defaults outside of the enum", func() {
+			err := b.AddServerURL("https://{region}.example.com", "", map[string]*openapi3.ServerVariable{
+				"region": {Default: "asia", Enum: []string{"eu", "us"}},
+			})
+			assert.EqualError(g, err, `invalid server https://{region}.example.com: default "asia" of region is not one of [eu us]`)
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SpecValidationError lists every problem found by ValidateSpec, the problems
+// of the operations are prefixed by their route
+type SpecValidationError struct {
+	Problems []string
+}
+
+func (e *SpecValidationError) Error() string {
+	return fmt.Sprintf("invalid OpenAPI document:\n- %s", strings.Join(e.Problems, "\n- "))
+}
+
+// ValidateSpec generates the document of every registered route and validates it,
+// it returns a *SpecValidationError listing all the problems so it can be
+// called from a test to catch invalid tags before they reach the clients
+func (b *Builder) ValidateSpec(ctx context.Context) error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	swagger := *b.swagger
+	for _, m := range b.methods {
+		err := b.generateOperation(ctx, &swagger, m, documentOptions{})
+		if err != nil {
+			addf("%s %s: %s", m.method, m.pattern, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SpecValidationError{Problems: problems}
+	}
+
+	data, err := swagger.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	// loading resolves the components references
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return &SpecValidationError{Problems: []string{err.Error()}}
+	}
+
+	if err := doc.Components.Validate(ctx); err != nil {
+		addf("components: %s", err)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		operations := doc.Paths[path].Operations()
+
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			if err := operations[method].Validate(ctx); err != nil {
+				addf("%s %s: %s", method, path, err)
+			}
+		}
+	}
+
+	// the remaining checks (info, path parameters, servers, ...) stop at
+	// their first problem
+	if len(problems) == 0 {
+		if err := doc.Validate(ctx); err != nil {
+			addf("%s", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SpecValidationError{Problems: problems}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type specValidPetRequest struct {
+	Path struct {
+		Id int
+	} `example:"/pets/1"`
+}
+
+func (r *specValidPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type specBadStyleRequest struct {
+	Path  struct{} `example:"/pets"`
+	Query struct {
+		Name string `style:"matrix"`
+	}
+}
+
+func (r *specBadStyleRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type specUnknownAnimal interface {
+	Sound() string
+}
+
+type specBadBodyRequest struct {
+	Path struct{} `example:"/animals"`
+	Body specUnknownAnimal
+}
+
+func (r *specBadBodyRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestValidateSpec(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("ValidateSpec", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{Title: "pets", Version: "1.0"})
+			require.NoError(g, err)
+
+			err = b.Get(router, "/pets/{Id}", &specValidPetRequest{})
+			require.NoError(g, err)
+		})
+
+		g.It("should accept a valid document", func() {
+			assert.NoError(g, b.ValidateSpec(context.Background()))
+		})
+
+		g.It("should report the generation errors of every route", func() {
+			err := b.Post(router, "/animals", &specBadBodyRequest{})
+			require.NoError(g, err)
+
+			err = b.ValidateSpec(context.Background())
+			require.IsType(g, &SpecValidationError{}, err)
+			problems := err.(*SpecValidationError).Problems
+			require.Len(g, problems, 1)
+			assert.Contains(g, problems[0], "POST /animals: ")
+		})
+
+		g.It("should report the invalid operations", func() {
+			err := b.Get(router, "/pets", &specBadStyleRequest{})
+			require.NoError(g, err)
+
+			err = b.ValidateSpec(context.Background())
+			require.IsType(g, &SpecValidationError{}, err)
+			problems := err.(*SpecValidationError).Problems
+			require.Len(g, problems, 1)
+			assert.Contains(g, problems[0], "GET /pets: ")
+			assert.Contains(g, problems[0], "matrix")
+		})
+
+		g.It("should report the document errors", func() {
+			b.swagger.Info.Version = ""
+			err := b.ValidateSpec(context.Background())
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), "invalid info")
+		})
+	})
+}
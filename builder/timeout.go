@@ -0,0 +1,41 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// generateTimeoutDoc documents the 504 response of the operations with a
+// `timeout` tag or the Timeout wrapper option
+func (b *Builder) generateTimeoutDoc(ctx context.Context, swagger *openapi3.T, op *openapi3.Operation, requestObject interface{}, requestObjectType reflect.Type, filterObject shared.FilterInterface) error {
+	key := strconv.Itoa(http.StatusGatewayTimeout)
+	if _, isStreamer := requestObject.(wrapper.Streamer); isStreamer || (op.Responses[key] != nil) {
+		return nil
+	}
+
+	timeout := wrapper.HandlerTimeout(requestObjectType)
+	if (timeout == 0) && (b.wrapperOptions != nil) {
+		timeout = b.wrapperOptions.Timeout
+	}
+
+	if timeout <= 0 {
+		return nil
+	}
+
+	resp, err := b.errorResponse(ctx, swagger, fmt.Sprintf("not handled within %s", timeout), &response.Problem{}, filterObject)
+	if err != nil {
+		return err
+	}
+
+	op.Responses[key] = &openapi3.ResponseRef{Value: resp}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/wrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowReportRequest struct {
+	Path struct{} `example:"/reports" timeout:"30s"`
+}
+
+func (r *slowReportRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type quickPetRequest struct {
+	Path struct{} `example:"/pets"`
+}
+
+func (r *quickPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestTimeoutDoc(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("handler timeout documentation", func() {
+		generate := func(opts *wrapper.Options) *openapi3.T {
+			router := chi.NewRouter()
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.SetWrapperOptions(opts)
+			require.NoError(g, b.Get(router, "/reports", &slowReportRequest{}))
+			require.NoError(g, b.Get(router, "/pets", &quickPetRequest{}))
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should document the 504 of the routes with a timeout", func() {
+			swagger := generate(nil)
+
+			resp := swagger.Paths["/reports"].Get.Responses["504"]
+			require.NotNil(g, resp)
+			assert.Equal(g, "not handled within 30s", *resp.Value.Description)
+			assert.NotNil(g, resp.Value.Content.Get("application/problem+json"))
+
+			assert.Nil(g, swagger.Paths["/pets"].Get.Responses["504"])
+		})
+
+		g.It("should document the global timeout", func() {
+			swagger := generate(&wrapper.Options{Timeout: 5 * time.Second})
+
+			assert.Equal(g, "not handled within 5s", *swagger.Paths["/pets"].Get.Responses["504"].Value.Description)
+			assert.Equal(g, "not handled within 30s", *swagger.Paths["/reports"].Get.Responses["504"].Value.Description)
+		})
+	})
+}
@@ -0,0 +1,649 @@
+package builder
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var (
+	_tsTimeType          = reflect.TypeOf(time.Time{})
+	_tsRawMessageType    = reflect.TypeOf(json.RawMessage{})
+	_tsTextMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// the packages of the type arguments of the generic types
+	_tsQualifierPattern     = regexp.MustCompile(`[\w.\-/]+\.`)
+	_tsTypeArgumentReplacer = strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "", " ", "")
+)
+
+type tsParam struct {
+	Name string
+	Expr string
+
+	// map sent as name[key]=value
+	DeepObject bool
+	// separator of the array items, a comma if empty
+	Separator string
+}
+
+type tsMethod struct {
+	Name        string
+	HTTPMethod  string
+	RequestType string
+
+	// empty if the request has no Response field
+	ResponseType string
+	RawResponse  bool
+
+	PathExpr string
+
+	Query     []tsParam
+	QueryRest string
+	Header    []tsParam
+	Cookie    []tsParam
+
+	BodyExpr string
+	FormBody bool
+}
+
+var tsClientTemplate = template.Must(template.New("ts_client_template").Parse(`// Code generated by chipi. DO NOT EDIT.
+{{ range .Types }}
+{{ . }}
+{{ end }}
+export class ClientError extends Error {
+  readonly status: number;
+  readonly body: string;
+
+  constructor(status: number, body: string) {
+    super(` + "`request failed with status ${status}: ${body.trim()}`" + `);
+    this.status = status;
+    this.body = body;
+  }
+}
+
+function chipiFormat(v: unknown, separator = ","): string {
+  if (Array.isArray(v)) {
+    return v.map((item) => chipiFormat(item)).join(separator);
+  }
+  if ((v !== null) && (typeof v === "object")) {
+    return JSON.stringify(v);
+  }
+  return String(v);
+}
+
+function chipiQuery(query: URLSearchParams, name: string, v: unknown, deepObject: boolean, separator = ",") {
+  if ((v === undefined) || (v === null)) {
+    return;
+  }
+  if (deepObject) {
+    for (const [key, value] of Object.entries(v as Record<string, unknown>)) {
+      query.set(name + "[" + key + "]", chipiFormat(value));
+    }
+    return;
+  }
+  query.set(name, chipiFormat(v, separator));
+}
+
+function chipiRest(query: URLSearchParams, rest: Record<string, string[]> | undefined) {
+  for (const [key, values] of Object.entries(rest ?? {})) {
+    for (const value of values) {
+      query.append(key, value);
+    }
+  }
+}
+
+function chipiHeader(headers: Headers, name: string, v: unknown) {
+  if ((v !== undefined) && (v !== null)) {
+    headers.set(name, chipiFormat(v));
+  }
+}
+
+function chipiCookie(headers: Headers, name: string, v: unknown) {
+  if ((v !== undefined) && (v !== null)) {
+    headers.append("Cookie", name + "=" + encodeURIComponent(chipiFormat(v)));
+  }
+}
+
+function chipiForm(body: unknown): URLSearchParams {
+  const form = new URLSearchParams();
+  for (const [key, value] of Object.entries(body as Record<string, unknown>)) {
+    chipiQuery(form, key, value, (value !== null) && (typeof value === "object") && !Array.isArray(value));
+  }
+  return form;
+}
+
+// Client calls the api endpoints
+export class Client {
+  private readonly baseURL: string;
+  private readonly fetchFn: typeof fetch;
+
+  constructor(baseURL: string, fetchFn: typeof fetch = fetch) {
+    this.baseURL = baseURL.replace(/\/+$/, "");
+    this.fetchFn = fetchFn;
+  }
+
+  private async do(method: string, path: string, query: URLSearchParams, headers: Headers, body: unknown, form: boolean): Promise<Response> {
+    let url = this.baseURL + path;
+    if (query.toString() !== "") {
+      url += "?" + query.toString();
+    }
+
+    let data: string | URLSearchParams | undefined;
+    if ((body !== undefined) && (body !== null)) {
+      if (form) {
+        data = chipiForm(body);
+        headers.set("Content-Type", "application/x-www-form-urlencoded");
+      } else {
+        data = JSON.stringify(body);
+        headers.set("Content-Type", "application/json");
+      }
+    }
+
+    const resp = await this.fetchFn(url, { method, headers, body: data });
+    if (resp.status >= 400) {
+      throw new ClientError(resp.status, await resp.text());
+    }
+
+    return resp;
+  }
+{{ range .Methods }}
+  async {{ .Name }}(req: {{ .RequestType }}): Promise<{{ if .ResponseType }}{{ .ResponseType }}{{ else }}void{{ end }}> {
+    const query = new URLSearchParams();
+    const headers = new Headers();
+    {{- range .Query }}
+    chipiQuery(query, {{ printf "%q" .Name }}, {{ .Expr }}, {{ .DeepObject }}{{ if .Separator }}, {{ printf "%q" .Separator }}{{ end }});
+    {{- end }}
+    {{- if .QueryRest }}
+    chipiRest(query, {{ .QueryRest }});
+    {{- end }}
+    {{- range .Header }}
+    chipiHeader(headers, {{ printf "%q" .Name }}, {{ .Expr }});
+    {{- end }}
+    {{- range .Cookie }}
+    chipiCookie(headers, {{ printf "%q" .Name }}, {{ .Expr }});
+    {{- end }}
+
+    {{ if .ResponseType }}const resp = {{ end }}await this.do({{ printf "%q" .HTTPMethod }}, {{ .PathExpr }}, query, headers, {{ if .BodyExpr }}{{ .BodyExpr }}{{ else }}undefined{{ end }}, {{ .FormBody }});
+    {{- if .RawResponse }}
+    return await resp.arrayBuffer();
+    {{- else if .ResponseType }}
+    return (await resp.json()) as {{ .ResponseType }};
+    {{- end }}
+  }
+{{ end -}}
+}
+`))
+
+// tsTypes collects the TypeScript interfaces of the named structures,
+// they keep the name of their go type
+type tsTypes struct {
+	names map[string]reflect.Type
+	decls map[string]string
+}
+
+func newTSTypes() *tsTypes {
+	return &tsTypes{
+		names: map[string]reflect.Type{},
+		decls: map[string]string{},
+	}
+}
+
+// list returns the declarations sorted by name
+func (ts *tsTypes) list() []string {
+	names := sortedKeys(ts.decls)
+
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, ts.decls[name])
+	}
+	return ret
+}
+
+// declare registers the interface name for the structure t, fields returns
+// its members
+func (ts *tsTypes) declare(name string, t reflect.Type, fields func() (string, error)) error {
+	if existing, found := ts.names[name]; found {
+		if existing != t {
+			return errors.Errorf("%s and %s are both named %s in the TypeScript client", existing, t, name)
+		}
+		return nil
+	}
+	ts.names[name] = t
+
+	members, err := fields()
+	if err != nil {
+		return err
+	}
+
+	ts.decls[name] = fmt.Sprintf("export interface %s %s", name, members)
+	return nil
+}
+
+// typeExpr returns the TypeScript type of a value of type t encoded in json
+func (ts *tsTypes) typeExpr(t reflect.Type) (string, error) {
+	if t.Kind() == reflect.Ptr {
+		expr, err := ts.typeExpr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return expr + " | null", nil
+	}
+
+	if enum := schema.TypeEnum(t); len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, value := range enum {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			values = append(values, string(data))
+		}
+		return strings.Join(values, " | "), nil
+	}
+
+	if (t == _tsTimeType) || reflect.PtrTo(t).Implements(_tsTextMarshalerType) {
+		return "string", nil
+	}
+
+	if t == _tsRawMessageType {
+		return "unknown", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+
+	case reflect.Bool:
+		return "boolean", nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded in base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string", nil
+		}
+
+		expr, err := ts.typeExpr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(expr, " ") {
+			expr = "(" + expr + ")"
+		}
+		return expr + "[]", nil
+
+	case reflect.Map:
+		expr, err := ts.typeExpr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "Record<string, " + expr + ">", nil
+
+	case reflect.Interface:
+		impls := schema.ImplementationsOf(t)
+		if impls == nil {
+			return "unknown", nil
+		}
+
+		exprs := []string{}
+		for _, value := range impls.Values() {
+			expr, err := ts.typeExpr(impls.Types[value])
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, strings.TrimSuffix(expr, " | null"))
+		}
+		return strings.Join(exprs, " | "), nil
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return ts.structMembers(t, "")
+		}
+
+		name := tsTypeName(t)
+		err := ts.declare(name, t, func() (string, error) {
+			return ts.structMembers(t, "")
+		})
+		return name, err
+	}
+
+	return "", errors.Errorf("unsupported type %s in the TypeScript client", t)
+}
+
+// structMembers returns the properties of the structure t encoded in json
+func (ts *tsTypes) structMembers(t reflect.Type, indent string) (string, error) {
+	lines, err := ts.structLines(t, indent+"  ")
+	if err != nil {
+		return "", err
+	}
+
+	return "{\n" + strings.Join(lines, "") + indent + "}", nil
+}
+
+func (ts *tsTypes) structLines(t reflect.Type, indent string) ([]string, error) {
+	lines := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := schema.ParseJsonTag(f)
+
+		if (tag.Ignored != nil) && *tag.Ignored {
+			continue
+		}
+
+		// encoding/json promotes the fields of embedded structures
+		_, named := f.Tag.Lookup("json")
+		if f.Anonymous && !named && (sectionType(f.Type).Kind() == reflect.Struct) {
+			embedded, err := ts.structLines(sectionType(f.Type), indent)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, embedded...)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		expr, err := ts.typeExpr(f.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		if (tag.String != nil) && *tag.String {
+			expr = "string"
+		}
+
+		modifier := ""
+		if (tag.ReadOnly != nil) && *tag.ReadOnly {
+			modifier = "readonly "
+		}
+
+		optional := ""
+		if ((tag.OmitEmpty != nil) && *tag.OmitEmpty) || (f.Type.Kind() == reflect.Ptr) {
+			optional = "?"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s%s%s%s: %s;\n", indent, modifier, tsPropertyName(tag.Name), optional, expr))
+	}
+
+	return lines, nil
+}
+
+// sectionMembers returns the fields of a parameters section (Path, Query,
+// Header or Cookie), they keep their go names
+func (ts *tsTypes) sectionMembers(section string, t reflect.Type) (string, error) {
+	lines := []string{}
+
+	for _, f := range wrapper.SectionFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := schema.ParseJsonTag(f)
+		if (tag.Ignored != nil) && *tag.Ignored {
+			continue
+		}
+
+		var expr string
+		var err error
+		if (tag.Rest != nil) && *tag.Rest {
+			expr = "Record<string, string[]>"
+		} else {
+			expr, err = ts.typeExpr(sectionType(f.Type))
+			if err != nil {
+				return "", err
+			}
+		}
+
+		optional := "?"
+		if (section == "Path") || ((tag.Required != nil) && *tag.Required) {
+			optional = ""
+		}
+
+		lines = append(lines, fmt.Sprintf("    %s%s: %s;\n", tsPropertyName(f.Name), optional, expr))
+	}
+
+	return "{\n" + strings.Join(lines, "") + "  }", nil
+}
+
+// tsTypeName returns the name of the interface of the structure t, the
+// generic types are named with their type arguments (ex: PagedResponse_Pet)
+func tsTypeName(t reflect.Type) string {
+	name := t.Name()
+	if !strings.Contains(name, "[") {
+		return name
+	}
+
+	return _tsTypeArgumentReplacer.Replace(_tsQualifierPattern.ReplaceAllString(name, ""))
+}
+
+func tsPropertyName(name string) string {
+	for i, c := range name {
+		isLetter := (c == '_') || (c == '$') || ((c >= 'a') && (c <= 'z')) || ((c >= 'A') && (c <= 'Z'))
+		isDigit := (c >= '0') && (c <= '9')
+		if !isLetter && ((i == 0) || !isDigit) {
+			return strconv.Quote(name)
+		}
+	}
+
+	return name
+}
+
+// tsPathExpr converts a route pattern to a template literal building the path
+func tsPathExpr(pattern string) string {
+	ret := ""
+	for _, segment := range patternSegments(pattern) {
+		if segment.Param != "" {
+			ret += fmt.Sprintf("${encodeURIComponent(chipiFormat(req.Path.%s))}", segment.Param)
+		} else {
+			ret += strings.NewReplacer("`", "\\`", "$", "\\$").Replace(segment.Literal)
+		}
+	}
+
+	return "`" + ret + "`"
+}
+
+func tsMethodName(typ reflect.Type) string {
+	name := clientMethodName(typ)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// tsParams returns the parameters of a section, expr is the section
+// expression in the generated code
+func tsParams(section string, st reflect.Type, expr string, paramName func(reflect.StructField) string) ([]tsParam, string) {
+	params := []tsParam{}
+	rest := ""
+
+	for _, f := range wrapper.SectionFields(st) {
+		if !f.IsExported() {
+			continue
+		}
+
+		fieldExpr := expr + "." + tsPropertyName(f.Name)
+		if strings.HasPrefix(tsPropertyName(f.Name), `"`) {
+			fieldExpr = expr + "[" + tsPropertyName(f.Name) + "]"
+		}
+
+		tag := schema.ParseJsonTag(f)
+		if (tag.Rest != nil) && *tag.Rest {
+			rest = fieldExpr
+			continue
+		}
+
+		param := tsParam{
+			Name:       paramName(f),
+			Expr:       fieldExpr,
+			DeepObject: (section == "Query") && (sectionType(f.Type).Kind() == reflect.Map),
+		}
+		if section == "Query" {
+			param.Separator = wrapper.DescribeParam(section, f).Separator
+		}
+
+		params = append(params, param)
+	}
+
+	return params, rest
+}
+
+func (b *Builder) tsMethod(ts *tsTypes, m *Method) (*tsMethod, error) {
+	typ := reflect.TypeOf(m.reqObject).Elem()
+
+	if clientSkipped(m) {
+		return nil, nil
+	}
+
+	routeContext, err := b.findRoute(typ, m.method, m.pattern)
+	if routeContext == nil {
+		return nil, err
+	}
+
+	tm := &tsMethod{
+		Name:        tsMethodName(typ),
+		HTTPMethod:  m.method,
+		RequestType: typ.Name(),
+		PathExpr:    tsPathExpr(routeContext.RoutePattern()),
+	}
+
+	// the request interface only has the sections sent by the client
+	requestMembers := []string{}
+
+	for _, section := range []string{"Path", "Query", "Header", "Cookie"} {
+		field, found := typ.FieldByName(section)
+		if !found {
+			continue
+		}
+
+		st := sectionType(field.Type)
+		members, err := ts.sectionMembers(section, st)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s.%s", typ.Name(), section)
+		}
+
+		optional := ""
+		expr := "req." + section
+		if (section != "Path") && (field.Type.Kind() == reflect.Ptr) {
+			optional = "?"
+			expr += "?"
+		}
+
+		requestMembers = append(requestMembers, fmt.Sprintf("  %s%s: %s;\n", section, optional, members))
+
+		switch section {
+		case "Query":
+			tm.Query, tm.QueryRest = tsParams(section, st, expr, wrapper.QueryParamName)
+		case "Header":
+			tm.Header, _ = tsParams(section, st, expr, wrapper.HeaderParamName)
+		case "Cookie":
+			tm.Cookie, _ = tsParams(section, st, expr, wrapper.CookieParamName)
+		}
+	}
+
+	if bodyField, found := typ.FieldByName("Body"); found {
+		expr, err := ts.typeExpr(bodyField.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s.Body", typ.Name())
+		}
+
+		optional := ""
+		if bodyField.Type.Kind() == reflect.Ptr {
+			optional = "?"
+			expr = strings.TrimSuffix(expr, " | null")
+		}
+
+		requestMembers = append(requestMembers, fmt.Sprintf("  Body%s: %s;\n", optional, expr))
+		tm.BodyExpr = "req.Body"
+		tm.FormBody = (bodyField.Tag.Get("content-type") == wrapper.FormContentType)
+	}
+
+	ts.decls[typ.Name()] = fmt.Sprintf("export interface %s {\n%s}", typ.Name(), strings.Join(requestMembers, ""))
+
+	if responseField, found := typ.FieldByName("Response"); found {
+		responseType := sectionType(responseField.Type)
+
+		switch {
+		case wrapper.IsStreamedResponse(responseField),
+			(responseType.Kind() == reflect.Slice) && (responseType.Elem().Kind() == reflect.Uint8):
+			// raw bodies and streams are read whole
+			tm.ResponseType = "ArrayBuffer"
+			tm.RawResponse = true
+
+		default:
+			tm.ResponseType, err = ts.typeExpr(responseType)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s.Response", typ.Name())
+			}
+		}
+	}
+
+	return tm, nil
+}
+
+// GenerateTypeScriptClient writes the source of a TypeScript client for every
+// registered route, each request type becomes an interface and a method of
+// the Client class (`GetPetRequest` becomes `getPet(req)`), the structures
+// keep their go names (multipart uploads, event streams and websockets are
+// skipped)
+func (b *Builder) GenerateTypeScriptClient(w io.Writer) error {
+	ts := newTSTypes()
+
+	methods := []*tsMethod{}
+	requests := map[string]bool{}
+
+	for _, m := range b.methods {
+		typ := reflect.TypeOf(m.reqObject).Elem()
+		if requests[typ.Name()] {
+			return errors.Errorf("duplicate client method %s", tsMethodName(typ))
+		}
+
+		tm, err := b.tsMethod(ts, m)
+		if err != nil {
+			return err
+		}
+
+		if tm == nil {
+			continue
+		}
+
+		if _, found := ts.names[typ.Name()]; found {
+			return errors.Errorf("%s is both a request and a structure in the TypeScript client", typ.Name())
+		}
+		requests[typ.Name()] = true
+
+		methods = append(methods, tm)
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	buffer := bytes.NewBufferString("")
+	err := tsClientTemplate.Execute(buffer, map[string]interface{}{
+		"Types":   ts.list(),
+		"Methods": methods,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
@@ -0,0 +1,102 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TSClientTestOwner struct {
+	Name  string             `json:"name"`
+	Pets  []*TSClientTestPet `json:"pets,omitempty"`
+	Notes map[string]string  `json:"notes"`
+}
+
+type TSClientTestPet struct {
+	ID    string             `json:"id" chipi:"readonly"`
+	Name  string             `json:"name" chipi:"name=pet_name"`
+	Owner *TSClientTestOwner `json:"owner"`
+	Age   int                `json:"age,string"`
+}
+
+type UpdateTSClientPetRequest struct {
+	Path struct {
+		Id int
+	} `example:"/pets/43"`
+
+	Body *TSClientTestPet
+
+	Response []byte
+}
+
+func (r *UpdateTSClientPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("GenerateTypeScriptClient", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			router := chi.NewRouter()
+
+			var err error
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			petsRoute := chi.NewRouter()
+			router.Mount("/pets", petsRoute)
+
+			require.NoError(g, b.Get(petsRoute, "/{Id}", &GetClientPetRequest{}))
+			require.NoError(g, b.Delete(petsRoute, "/{Id}", &DeleteClientPetRequest{}))
+			require.NoError(g, b.Put(petsRoute, "/{Id}", &UpdateTSClientPetRequest{}))
+		})
+
+		generate := func() string {
+			buffer := bytes.NewBufferString("")
+			err := b.GenerateTypeScriptClient(buffer)
+			require.NoError(g, err)
+			return buffer.String()
+		}
+
+		g.It("should generate a method per route", func() {
+			source := generate()
+			assert.Contains(g, source, "async getClientPet(req: GetClientPetRequest): Promise<ClientTestPet> {")
+			assert.Contains(g, source, "async deleteClientPet(req: DeleteClientPetRequest): Promise<void> {")
+			assert.Contains(g, source, "async updateTSClientPet(req: UpdateTSClientPetRequest): Promise<ArrayBuffer> {")
+			assert.Contains(g, source, "await this.do(\"GET\", `/pets/${encodeURIComponent(chipiFormat(req.Path.Id))}`, query, headers, undefined, false);")
+			assert.Contains(g, source, `chipiQuery(query, "count", req.Query.Count, false);`)
+			assert.Contains(g, source, `chipiQuery(query, "range", req.Query.Range, true);`)
+			assert.Contains(g, source, `chipiQuery(query, "colors", req.Query.Colors, false, "|");`)
+			assert.Contains(g, source, `chipiRest(query, req.Query.Extra);`)
+			assert.Contains(g, source, `chipiHeader(headers, "X-Api-Key", req.Header.ApiKey);`)
+			assert.Contains(g, source, `chipiCookie(headers, "session_id", req.Cookie.Session);`)
+		})
+
+		g.It("should declare the request interfaces with the go names", func() {
+			source := generate()
+			assert.Contains(g, source, "export interface GetClientPetRequest {\n  Path: {\n    Id: number;\n  };\n  Query: {\n    Count?: number;\n    Tags?: string[];\n    Colors?: string[];\n    Range?: Record<string, number>;\n    Extra?: Record<string, string[]>;\n  };\n  Header: {\n    ApiKey: string;\n  };")
+			assert.Contains(g, source, "export interface DeleteClientPetRequest {\n  Path: {\n    Id: number;\n  };\n  Body?: ClientTestPet;\n}")
+		})
+
+		g.It("should declare the structures with their json properties", func() {
+			source := generate()
+			assert.Contains(g, source, "export interface ClientTestPet {\n  name: string;\n}")
+			assert.Contains(g, source, "export interface TSClientTestPet {\n  readonly id: string;\n  pet_name: string;\n  owner?: TSClientTestOwner | null;\n  age: string;\n}")
+			assert.Contains(g, source, "export interface TSClientTestOwner {\n  name: string;\n  pets?: (TSClientTestPet | null)[];\n  notes: Record<string, string>;\n}")
+		})
+
+		g.It("should be deterministic", func() {
+			assert.Equal(g, generate(), generate())
+		})
+	})
+}
@@ -0,0 +1,274 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/response"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// validationSpec is the loaded specification used to validate the requests,
+// it is generated on the first request when all the routes are registered
+type validationSpec struct {
+	once sync.Once
+	doc  *openapi3.T
+	err  error
+}
+
+// ValidateResponses enables the validation of the responses against the
+// generated specification for the routes registered after this call, meant
+// to catch mismatches during development: invalid responses are replaced by a 500
+func (b *Builder) ValidateResponses(enabled bool) {
+	b.validateResponses = enabled
+}
+
+// ValidateRequests enables the validation of the incoming requests against
+// the generated specification for the routes registered after this call
+func (b *Builder) ValidateRequests(enabled bool) {
+	b.validateRequests = enabled
+}
+
+// routeOptions returns the wrapper options of a new route
+func (b *Builder) routeOptions() *wrapper.Options {
+	useLogger := (b.logger != nil) && ((b.wrapperOptions == nil) || (b.wrapperOptions.Logger == nil))
+	if !b.validateRequests && !b.validateResponses && !useLogger {
+		return b.wrapperOptions
+	}
+
+	opts := wrapper.Options{}
+	if b.wrapperOptions != nil {
+		opts = *b.wrapperOptions
+	}
+
+	if useLogger {
+		opts.Logger = b.logger
+	}
+
+	if b.validateRequests {
+		opts.RequestValidator = b.validateRequest
+	}
+
+	if b.validateResponses {
+		opts.ResponseValidator = b.validateResponse
+	}
+
+	return &opts
+}
+
+func (b *Builder) validationDoc(ctx context.Context) (*openapi3.T, error) {
+	b.validation.once.Do(func() {
+		data, err := b.generateSpec(ctx, nil)
+		if err != nil {
+			b.validation.err = err
+			return
+		}
+
+		// loading resolves the components references
+		b.validation.doc, b.validation.err = openapi3.NewLoader().LoadFromData(data)
+	})
+
+	return b.validation.doc, b.validation.err
+}
+
+// findDocumentedRoute returns the documented route matching the request, nil if
+// the route is not documented
+func (b *Builder) findDocumentedRoute(r *http.Request) (*routers.Route, error) {
+	doc, err := b.validationDoc(r.Context())
+	if err != nil {
+		return nil, response.NewProblem(http.StatusInternalServerError, errors.Wrap(err, "failed to generate specification").Error())
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil, nil
+	}
+
+	pattern := rctx.RoutePattern()
+	pathItem := doc.Paths.Find(pattern)
+	if pathItem == nil {
+		return nil, nil
+	}
+
+	operation := pathItem.GetOperation(r.Method)
+	if operation == nil {
+		return nil, nil
+	}
+
+	return &routers.Route{
+		Spec:      doc,
+		Path:      pattern,
+		PathItem:  pathItem,
+		Method:    r.Method,
+		Operation: operation,
+	}, nil
+}
+
+func (b *Builder) validateRequest(r *http.Request) error {
+	route, err := b.findDocumentedRoute(r)
+	if (err != nil) || (route == nil) {
+		return err
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	pathParams := map[string]string{}
+	for i, key := range rctx.URLParams.Keys {
+		pathParams[key] = rctx.URLParams.Values[i]
+	}
+
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			MultiError:         true,
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			// streamed bodies are read by the handler
+			ExcludeRequestBody: isStreamedBodyDoc(route.Operation.RequestBody),
+		},
+	})
+	if err != nil {
+		return requestValidationProblem(err)
+	}
+
+	return nil
+}
+
+// isStreamedBodyDoc returns true if the body is documented as binary data
+func isStreamedBodyDoc(body *openapi3.RequestBodyRef) bool {
+	if (body == nil) || (body.Value == nil) || (len(body.Value.Content) == 0) {
+		return false
+	}
+
+	for _, mediaType := range body.Value.Content {
+		if (mediaType.Schema == nil) || (mediaType.Schema.Value == nil) || (mediaType.Schema.Value.Format != "binary") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateResponse checks the json representation of the Response
+// against the documented schema
+func (b *Builder) validateResponse(r *http.Request, obj interface{}) error {
+	route, err := b.findDocumentedRoute(r)
+	if (err != nil) || (route == nil) {
+		return err
+	}
+
+	responseRef := route.Operation.Responses.Get(http.StatusOK)
+	if (responseRef == nil) || (responseRef.Value == nil) {
+		return nil
+	}
+
+	// all the media types share the same schema
+	var schemaRef *openapi3.SchemaRef
+	for _, mediaType := range responseRef.Value.Content {
+		if mediaType.Schema != nil {
+			schemaRef = mediaType.Schema
+			break
+		}
+	}
+
+	if (schemaRef == nil) || (schemaRef.Value == nil) {
+		return nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return response.NewProblem(http.StatusInternalServerError, err.Error())
+	}
+
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return response.NewProblem(http.StatusInternalServerError, err.Error())
+	}
+
+	err = schemaRef.Value.VisitJSON(value, openapi3.VisitAsResponse(), openapi3.MultiErrors())
+	if err != nil {
+		problem := response.NewProblem(http.StatusInternalServerError, "the response does not match the specification")
+
+		errs := openapi3.MultiError{err}
+		if multi, ok := err.(openapi3.MultiError); ok {
+			errs = multi
+		}
+
+		for _, e := range errs {
+			problem.InvalidParams = append(problem.InvalidParams, invalidParam("response", "", e))
+		}
+
+		return problem
+	}
+
+	return nil
+}
+
+// requestValidationProblem lists the validation errors with the same names
+// as the binding errors
+func requestValidationProblem(err error) *response.Problem {
+	problem := response.NewProblem(http.StatusBadRequest, "the request does not match the specification")
+	problem.InvalidParams = invalidParams(err)
+
+	return problem
+}
+
+func invalidParams(err error) []response.InvalidParam {
+	if multi, ok := err.(openapi3.MultiError); ok {
+		ret := []response.InvalidParam{}
+		for _, e := range multi {
+			ret = append(ret, invalidParams(e)...)
+		}
+		return ret
+	}
+
+	requestErr, ok := err.(*openapi3filter.RequestError)
+	if !ok {
+		return []response.InvalidParam{{Name: "request", Reason: err.Error()}}
+	}
+
+	name := "request"
+	switch {
+	case requestErr.Parameter != nil:
+		name += "." + requestErr.Parameter.In + "." + requestErr.Parameter.Name
+	case requestErr.RequestBody != nil:
+		name += ".body"
+	}
+
+	// body schema errors are all reported with the MultiError option
+	if multi, ok := requestErr.Err.(openapi3.MultiError); ok {
+		ret := []response.InvalidParam{}
+		for _, e := range multi {
+			ret = append(ret, invalidParam(name, requestErr.Reason, e))
+		}
+		return ret
+	}
+
+	return []response.InvalidParam{invalidParam(name, requestErr.Reason, requestErr.Err)}
+}
+
+func invalidParam(name string, reason string, err error) response.InvalidParam {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			name += "." + strings.Join(pointer, ".")
+		}
+		reason = schemaErr.Reason
+	} else if err != nil {
+		if reason != "" {
+			reason += ": "
+		}
+		reason += err.Error()
+	}
+
+	return response.InvalidParam{Name: name, Reason: reason}
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ValidatedPet struct {
+	Name string `chipi:"required"`
+	Age  int
+}
+
+type validatedPetRequest struct {
+	response.ErrorEncoder
+
+	Path struct {
+		Id int
+	} `example:"/pets/43"`
+	Query struct {
+		Count int
+	}
+	Body *ValidatedPet
+}
+
+func (r *validatedPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type validatedUploadRequest struct {
+	response.ErrorEncoder
+
+	Path     struct{} `example:"/uploads"`
+	Body     io.ReadCloser
+	Response struct {
+		Data string
+	}
+}
+
+func (r *validatedUploadRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	data, err := io.ReadAll(r.Body)
+	r.Response.Data = string(data)
+	return err
+}
+
+type ValidatedOwner struct {
+	Name  string  `json:"name" minLength:"1"`
+	Email *string `json:"email"`
+}
+
+type validatedOwnerRequest struct {
+	response.ErrorEncoder
+
+	Path struct {
+		Id int
+	} `example:"/owners/43"`
+	Response ValidatedOwner
+}
+
+func (r *validatedOwnerRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	email := "rex@example.com"
+	if r.Path.Id == 1 {
+		r.Response = ValidatedOwner{Name: "rex", Email: &email}
+	}
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("request validation", func() {
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			router = chi.NewRouter()
+
+			b, err := New(router, &openapi3.Info{})
+			require.NoError(g, err)
+
+			b.ValidateRequests(true)
+
+			err = b.Post(router, "/pets/{Id}", &validatedPetRequest{})
+			require.NoError(g, err)
+
+			err = b.Post(router, "/uploads", &validatedUploadRequest{})
+			require.NoError(g, err)
+		})
+
+		send := func(query string, body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/pets/43?"+query, bytes.NewBufferString(body))
+			r.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, r)
+			return w
+		}
+
+		g.It("should accept valid requests", func() {
+			w := send("count=2", `{"Name": "rex", "Age": 3}`)
+			assert.Equal(g, http.StatusNoContent, w.Code)
+		})
+
+		g.It("should reject invalid requests", func() {
+			w := send("count=two", `{"Age": "old"}`)
+			require.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+
+			problem := response.Problem{}
+			err := json.Unmarshal(w.Body.Bytes(), &problem)
+			require.NoError(g, err)
+
+			names := []string{}
+			for _, param := range problem.InvalidParams {
+				names = append(names, param.Name)
+			}
+			assert.ElementsMatch(g, []string{"request.query.count", "request.body.Name", "request.body.Age"}, names, w.Body.String())
+		})
+
+		g.It("should leave the streamed bodies to the handler", func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/uploads", bytes.NewBufferString("raw data"))
+			r.Header.Set("Content-Type", "application/octet-stream")
+			router.ServeHTTP(w, r)
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"Data": "raw data"}`, w.Body.String())
+		})
+
+		g.Describe("responses", func() {
+			g.BeforeEach(func() {
+				router = chi.NewRouter()
+
+				b, err := New(router, &openapi3.Info{})
+				require.NoError(g, err)
+
+				b.ValidateResponses(true)
+
+				err = b.Get(router, "/owners/{Id}", &validatedOwnerRequest{})
+				require.NoError(g, err)
+			})
+
+			get := func(id string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest("GET", "/owners/"+id, nil))
+				return w
+			}
+
+			g.It("should send valid responses", func() {
+				w := get("1")
+				assert.Equal(g, http.StatusOK, w.Code)
+				assert.JSONEq(g, `{"name": "rex", "email": "rex@example.com"}`, w.Body.String())
+			})
+
+			g.It("should replace invalid responses", func() {
+				w := get("2")
+				require.Equal(g, http.StatusInternalServerError, w.Code)
+				assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+
+				problem := response.Problem{}
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				require.NoError(g, err)
+
+				names := []string{}
+				for _, param := range problem.InvalidParams {
+					names = append(names, param.Name)
+				}
+				// nil pointers are valid null values
+				assert.ElementsMatch(g, []string{"response.name"}, names, w.Body.String())
+			})
+		})
+	})
+}
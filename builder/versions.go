@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/schmurfy/chipi/shared"
+)
+
+// versions returns the api versions of the operation of m, empty if it
+// is part of every version
+func (m *Method) versions() []string {
+	if describer, ok := m.reqObject.(Describer); ok {
+		if versions := describer.Describe().Versions; len(versions) > 0 {
+			return versions
+		}
+	}
+
+	typ := reflect.TypeOf(m.reqObject).Elem()
+	if pathField, found := typ.FieldByName("Path"); found {
+		if val, found := pathField.Tag.Lookup("versions"); found {
+			var versions []string
+			for _, version := range strings.Split(val, ",") {
+				if version = strings.TrimSpace(version); version != "" {
+					versions = append(versions, version)
+				}
+			}
+			return versions
+		}
+	}
+
+	return nil
+}
+
+func (m *Method) inVersion(version string) bool {
+	versions := m.versions()
+	if len(versions) == 0 {
+		return true
+	}
+
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Versions returns the api versions declared by the registered operations
+func (b *Builder) Versions() []string {
+	found := map[string]bool{}
+	for _, m := range b.methods {
+		for _, version := range m.versions() {
+			found[version] = true
+		}
+	}
+
+	ret := make([]string, 0, len(found))
+	for version := range found {
+		ret = append(ret, version)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// GenerateSpec returns the document of an api version like GenerateJson, it
+// contains the operations declared in this version (with the `versions:"v1,v2"`
+// tag of their Path field or OperationInfo.Versions) and the ones without
+// versions, the version of the info block is replaced by version
+func (b *Builder) GenerateSpec(ctx context.Context, version string, filterObject shared.FilterInterface) ([]byte, error) {
+	return b.generateJson(ctx, documentOptions{filterObject: filterObject, apiVersion: version})
+}
+
+// ServeSpec returns a handler serving the document of an api version like ServeSchema
+func (b *Builder) ServeSpec(version string) http.HandlerFunc {
+	served := &servedDocument{}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := b.GenerateSpec(r.Context(), version, nil)
+		if err != nil {
+			b.log().Error(r.Context(), "failed to generate the document",
+				shared.Field{Key: "version", Value: version},
+				shared.Field{Key: "error", Value: err},
+			)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		serveDocument(w, r, served, "application/json", data)
+	}
+}
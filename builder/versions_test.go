@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type versionedListRequest struct {
+	Path struct{} `example:"/pets" versions:"v1"`
+}
+
+func (r *versionedListRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type versionedSearchRequest struct {
+	Path struct{} `example:"/animals"`
+}
+
+func (r *versionedSearchRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *versionedSearchRequest) Describe() OperationInfo {
+	return OperationInfo{Versions: []string{"v2", "v3"}}
+}
+
+type unversionedHealthRequest struct {
+	Path struct{} `example:"/health"`
+}
+
+func (r *unversionedHealthRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestVersions(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("api versions", func() {
+		var b *Builder
+
+		g.BeforeEach(func() {
+			var err error
+
+			router := chi.NewRouter()
+			b, err = New(router, &openapi3.Info{Title: "pets", Version: "dev"})
+			require.NoError(g, err)
+
+			require.NoError(g, b.Get(router, "/pets", &versionedListRequest{}))
+			require.NoError(g, b.Get(router, "/animals", &versionedSearchRequest{}))
+			require.NoError(g, b.Get(router, "/health", &unversionedHealthRequest{}))
+		})
+
+		generate := func(version string) *openapi3.T {
+			data, err := b.GenerateSpec(context.Background(), version, nil)
+			require.NoError(g, err)
+			return convertToSwagger(g, data)
+		}
+
+		g.It("should list the versions", func() {
+			assert.Equal(g, []string{"v1", "v2", "v3"}, b.Versions())
+		})
+
+		g.It("should only document the operations of the version", func() {
+			doc := generate("v1")
+			assert.Equal(g, "v1", doc.Info.Version)
+			assert.Contains(g, doc.Paths, "/pets")
+			assert.Contains(g, doc.Paths, "/health")
+			assert.NotContains(g, doc.Paths, "/animals")
+
+			doc = generate("v3")
+			assert.Equal(g, "v3", doc.Info.Version)
+			assert.NotContains(g, doc.Paths, "/pets")
+			assert.Contains(g, doc.Paths, "/animals")
+		})
+
+		g.It("should keep every operation in the default document", func() {
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			doc := convertToSwagger(g, data)
+			assert.Equal(g, "dev", doc.Info.Version)
+			assert.Len(g, doc.Paths, 3)
+		})
+
+		g.It("should serve the document of a version", func() {
+			w := httptest.NewRecorder()
+			b.ServeSpec("v2")(w, httptest.NewRequest("GET", "/v2/doc.json", nil))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			doc := convertToSwagger(g, w.Body.Bytes())
+			assert.Contains(g, doc.Paths, "/animals")
+			assert.NotContains(g, doc.Paths, "/pets")
+		})
+	})
+}
@@ -0,0 +1,34 @@
+package builder
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// Websocket registers a GET route upgraded to a websocket with upgrade,
+// the parameters are bound and documented like for the other routes
+func Websocket[C any](b *Builder, r chi.Router, pattern string, reqObject wrapper.WebsocketHandler[C], upgrade wrapper.UpgradeFunc[C]) error {
+	typ := reflect.TypeOf(reqObject)
+	if (typ == nil) || (typ.Kind() != reflect.Ptr) || (typ.Elem().Kind() != reflect.Struct) {
+		return errors.New("wrong type, pointer to struct expected")
+	}
+
+	err := wrapper.Verify(reqObject, http.MethodGet, pattern)
+	if err != nil {
+		return err
+	}
+
+	r.Method(http.MethodGet, pattern, wrapper.WrapWebsocketWithOptions(reqObject, upgrade, b.routeOptions()))
+
+	b.methods = append(b.methods, &Method{
+		pattern:   pattern,
+		method:    http.MethodGet,
+		reqObject: reqObject,
+	})
+
+	return nil
+}
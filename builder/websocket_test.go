@@ -0,0 +1,69 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type websocketTestRequest struct {
+	Path struct {
+		Room string
+	} `example:"/rooms/lobby"`
+	Query struct {
+		Nick string
+	}
+}
+
+func (r *websocketTestRequest) HandleWebsocket(ctx context.Context, conn http.ResponseWriter) error {
+	return nil
+}
+
+func upgradeTestWebsocket(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, error) {
+	w.WriteHeader(http.StatusSwitchingProtocols)
+	return w, nil
+}
+
+func TestWebsocket(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("websockets", func() {
+		var b *Builder
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			var err error
+
+			router = chi.NewRouter()
+			b, err = New(router, &openapi3.Info{})
+			require.NoError(g, err)
+		})
+
+		g.It("should document the route", func() {
+			err := Websocket[http.ResponseWriter](b, router, "/rooms/{Room}", &websocketTestRequest{}, upgradeTestWebsocket)
+			require.NoError(g, err)
+
+			data, err := b.GenerateJson(context.Background(), nil)
+			require.NoError(g, err)
+
+			swagger := convertToSwagger(g, data)
+			op := swagger.Paths["/rooms/{Room}"].Get
+			require.NotNil(g, op)
+
+			assert.NotNil(g, op.Parameters.GetByInAndName("path", "Room"))
+			assert.NotNil(g, op.Parameters.GetByInAndName("query", "nick"))
+			assert.NotNil(g, op.Responses["101"])
+		})
+
+		g.It("should verify the request object", func() {
+			err := Websocket[http.ResponseWriter](b, router, "/rooms/{Id}", &websocketTestRequest{}, upgradeTestWebsocket)
+			require.Error(g, err)
+		})
+	})
+}
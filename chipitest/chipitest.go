@@ -0,0 +1,315 @@
+// Package chipitest calls the chipi handlers from the tests with their
+// request objects instead of hand written http requests
+package chipitest
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+// TestingT is implemented by *testing.T and the other test frameworks (ex:
+// goblin)
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+// Option changes how Call builds the request
+type Option func(*config)
+
+type config struct {
+	method  string
+	target  string
+	options *wrapper.Options
+	modify  []func(r *http.Request)
+}
+
+// WithMethod sets the method of the request, GET by default (POST if
+// the request has a Body)
+func WithMethod(method string) Option {
+	return func(c *config) {
+		c.method = method
+	}
+}
+
+// WithURL sets the url of the request ("/" by default), the path parameters
+// are given to the handler by the route context
+func WithURL(target string) Option {
+	return func(c *config) {
+		c.target = target
+	}
+}
+
+// WithOptions wraps the handler with these options
+func WithOptions(opts *wrapper.Options) Option {
+	return func(c *config) {
+		c.options = opts
+	}
+}
+
+// WithRequest calls fn with the request before it is served (ex: to add
+// an authentication header)
+func WithRequest(fn func(r *http.Request)) Option {
+	return func(c *config) {
+		c.modify = append(c.modify, fn)
+	}
+}
+
+// Call sends req to handler wrapped with WrapRequest: the Path, Query,
+// Header, Cookie and Body sections are encoded like a client would and
+// the json Response is decoded in Resp (nil if the status code is an
+// error or without body, []byte receives the other bodies), the returned
+// response body can still be read
+func Call[Resp any, T wrapper.HandlerInterface](t TestingT, handler T, req T, options ...Option) (*Resp, *http.Response) {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	c := &config{target: "/"}
+	for _, option := range options {
+		option(c)
+	}
+
+	r, err := NewRequest(req, c.method, c.target)
+	if err != nil {
+		t.Errorf("failed to build the request: %s", err)
+		t.FailNow()
+	}
+
+	for _, fn := range c.modify {
+		fn(r)
+	}
+
+	w := httptest.NewRecorder()
+	if c.options != nil {
+		wrapper.WrapRequestWithOptions(handler, c.options)(w, r)
+	} else {
+		wrapper.WrapRequest(handler)(w, r)
+	}
+
+	resp := w.Result()
+	data := w.Body.Bytes()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	if (resp.StatusCode >= http.StatusBadRequest) || (len(data) == 0) {
+		return nil, resp
+	}
+
+	// the bodies which are not json are returned as is
+	ret := new(Resp)
+	if raw, ok := interface{}(ret).(*[]byte); ok && !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		*raw = append([]byte{}, data...)
+		return ret, resp
+	}
+
+	err = wrapper.DecodeResponseBody(data, ret)
+	if err != nil {
+		t.Errorf("failed to decode the response: %s (%s)", err, strings.TrimSpace(string(data)))
+		t.FailNow()
+	}
+
+	return ret, resp
+}
+
+// NewRequest returns the http request matching the sections of req, the
+// path parameters are set in a chi route context
+func NewRequest(req interface{}, method string, target string) (*http.Request, error) {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a request object", req)
+	}
+
+	rctx := chi.NewRouteContext()
+	query := url.Values{}
+	header := http.Header{}
+	cookies := []*http.Cookie{}
+
+	var body io.Reader
+	contentType := ""
+
+	for _, section := range []string{"Path", "Query", "Header", "Cookie"} {
+		sectionValue := v.FieldByName(section)
+		if sectionValue.IsValid() && (sectionValue.Kind() == reflect.Ptr) {
+			sectionValue = sectionValue.Elem()
+		}
+
+		if !sectionValue.IsValid() || (sectionValue.Kind() != reflect.Struct) {
+			continue
+		}
+
+		for _, f := range wrapper.SectionFields(sectionValue.Type()) {
+			tag := schema.ParseJsonTag(f)
+			if !f.IsExported() || ((tag.Ignored != nil) && *tag.Ignored) {
+				continue
+			}
+
+			fieldValue := sectionValue.FieldByIndex(f.Index)
+			p := wrapper.DescribeParam(section, f)
+
+			if (fieldValue.IsZero() && !p.Required) || ((fieldValue.Kind() == reflect.Ptr) && fieldValue.IsNil()) {
+				continue
+			}
+			fieldValue = reflect.Indirect(fieldValue)
+
+			switch {
+			case section == "Path":
+				rctx.URLParams.Add(p.Name, format(fieldValue))
+
+			case (section == "Query") && (tag.Rest != nil) && *tag.Rest:
+				iter := fieldValue.MapRange()
+				for iter.Next() {
+					for _, value := range iter.Value().Interface().([]string) {
+						query.Add(iter.Key().String(), value)
+					}
+				}
+
+			case (section == "Query") && (fieldValue.Kind() == reflect.Map):
+				iter := fieldValue.MapRange()
+				for iter.Next() {
+					query.Set(p.Name+"["+format(iter.Key())+"]", format(iter.Value()))
+				}
+
+			case (section == "Query") && (p.Separator != "") && (fieldValue.Kind() == reflect.Slice):
+				parts := make([]string, fieldValue.Len())
+				for i := range parts {
+					parts[i] = format(fieldValue.Index(i))
+				}
+				query.Set(p.Name, strings.Join(parts, p.Separator))
+
+			case section == "Query":
+				query.Set(p.Name, format(fieldValue))
+
+			case section == "Header":
+				header.Set(p.Name, format(fieldValue))
+
+			case section == "Cookie":
+				cookies = append(cookies, &http.Cookie{Name: p.Name, Value: format(fieldValue)})
+			}
+		}
+	}
+
+	if bodyField, found := v.Type().FieldByName("Body"); found {
+		bodyValue := v.FieldByIndex(bodyField.Index)
+
+		if wrapper.IsStreamedBody(bodyField) {
+			if !bodyValue.IsNil() {
+				body = bodyValue.Interface().(io.Reader)
+				contentType = wrapper.StreamedResponseContentType(bodyField)
+			}
+		} else if (bodyValue.Kind() != reflect.Ptr) || !bodyValue.IsNil() {
+			switch bodyField.Tag.Get("content-type") {
+			case wrapper.FormContentType:
+				body = strings.NewReader(formValues(reflect.Indirect(bodyValue)).Encode())
+				contentType = wrapper.FormContentType
+
+			case "", "application/json":
+				data, err := wrapper.EncodeRequestBody(bodyValue.Interface())
+				if err != nil {
+					return nil, err
+				}
+				body = bytes.NewReader(data)
+				contentType = "application/json"
+
+			default:
+				return nil, fmt.Errorf("cannot encode a %s body", bodyField.Tag.Get("content-type"))
+			}
+		}
+
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	r := httptest.NewRequest(method, target, body)
+	if len(query) > 0 {
+		r.URL.RawQuery = query.Encode()
+	}
+
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), nil
+}
+
+// format serializes a parameter the way chipi parses it
+func format(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		data, _ := m.MarshalText()
+		return string(data)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts[i] = format(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+
+	case reflect.Struct, reflect.Map:
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// formValues encodes a form body with the same names as the json decoding
+func formValues(v reflect.Value) url.Values {
+	values := url.Values{}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag := schema.ParseJsonTag(f)
+		if !f.IsExported() || ((tag.Ignored != nil) && *tag.Ignored) || v.Field(i).IsZero() {
+			continue
+		}
+
+		field := reflect.Indirect(v.Field(i))
+		if field.Kind() == reflect.Map {
+			iter := field.MapRange()
+			for iter.Next() {
+				values.Set(tag.Name+"["+format(iter.Key())+"]", format(iter.Value()))
+			}
+			continue
+		}
+
+		values.Set(tag.Name, format(field))
+	}
+
+	return values
+}
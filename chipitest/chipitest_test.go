@@ -0,0 +1,179 @@
+package chipitest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID    string `json:"id" chipi:"readonly"`
+	Name  string `json:"name" chipi:"name=full_name"`
+	Admin bool   `json:"admin"`
+}
+
+type userStore struct {
+	users map[string]user
+}
+
+type updateUserRequest struct {
+	response.ErrorEncoder
+
+	Store *userStore
+
+	Path struct {
+		ID string
+	}
+
+	Query struct {
+		Notify  bool
+		Filters map[string]string
+	}
+
+	Header struct {
+		Token string `name:"X-Token"`
+	}
+
+	Cookie struct {
+		Session string `name:"session_id"`
+	}
+
+	Body user
+
+	Response struct {
+		User    user   `json:"user"`
+		Notify  bool   `json:"notify"`
+		Filter  string `json:"filter"`
+		Token   string `json:"token"`
+		Session string `json:"session"`
+	}
+}
+
+func (r *updateUserRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	u, found := r.Store.users[r.Path.ID]
+	if !found {
+		return response.NewProblem(http.StatusNotFound, "unknown user")
+	}
+
+	u.Name = r.Body.Name
+	r.Store.users[r.Path.ID] = u
+
+	r.Response.User = u
+	r.Response.Notify = r.Query.Notify
+	r.Response.Filter = r.Query.Filters["role"]
+	r.Response.Token = r.Header.Token
+	r.Response.Session = r.Cookie.Session
+	return nil
+}
+
+type exportRequest struct {
+	Path     struct{}
+	Response []byte
+}
+
+func (r *exportRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Response == nil {
+		r.Response = []byte("id,name\n")
+	}
+	return nil
+}
+
+func TestCall(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Call", func() {
+		var handler *updateUserRequest
+
+		g.BeforeEach(func() {
+			handler = &updateUserRequest{
+				Store: &userStore{users: map[string]user{"1": {ID: "1", Name: "joe"}}},
+			}
+		})
+
+		newRequest := func(id string) *updateUserRequest {
+			req := &updateUserRequest{}
+			req.Path.ID = id
+			req.Query.Notify = true
+			req.Query.Filters = map[string]string{"role": "admin"}
+			req.Header.Token = "secret"
+			req.Cookie.Session = "abc"
+			req.Body = user{ID: "ignored", Name: "Joe Doe"}
+			return req
+		}
+
+		g.It("should send every section and decode the response", func() {
+			ret, resp := Call[struct {
+				User    user   `json:"user"`
+				Notify  bool   `json:"notify"`
+				Filter  string `json:"filter"`
+				Token   string `json:"token"`
+				Session string `json:"session"`
+			}](g, handler, newRequest("1"))
+
+			require.Equal(g, http.StatusOK, resp.StatusCode)
+			require.NotNil(g, ret)
+			assert.Equal(g, user{ID: "1", Name: "Joe Doe"}, ret.User)
+			assert.True(g, ret.Notify)
+			assert.Equal(g, "admin", ret.Filter)
+			assert.Equal(g, "secret", ret.Token)
+			assert.Equal(g, "abc", ret.Session)
+			assert.Equal(g, "Joe Doe", handler.Store.users["1"].Name)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(g, err)
+			assert.Contains(g, string(body), `"full_name":"Joe Doe"`)
+		})
+
+		g.It("should return the error responses", func() {
+			ret, resp := Call[user](g, handler, newRequest("2"))
+			assert.Nil(g, ret)
+			assert.Equal(g, http.StatusNotFound, resp.StatusCode)
+		})
+
+		g.It("should apply the options", func() {
+			_, resp := Call[user](g, handler, newRequest("1"),
+				WithMethod(http.MethodPut),
+				WithURL("/users/1"),
+				WithRequest(func(r *http.Request) {
+					assert.Equal(g, http.MethodPut, r.Method)
+					assert.Equal(g, "/users/1", r.URL.Path)
+				}),
+			)
+			assert.Equal(g, http.StatusOK, resp.StatusCode)
+		})
+
+		g.It("should decode byte slices", func() {
+			ret, resp := Call[[]byte](g, &exportRequest{}, &exportRequest{})
+			require.Equal(g, http.StatusOK, resp.StatusCode)
+			assert.Equal(g, "id,name\n", string(*ret))
+		})
+
+		g.It("should join the delimited query arrays", func() {
+			req := &struct {
+				Query struct {
+					Colors []string `style:"pipeDelimited"`
+					Tags   []string
+				}
+			}{}
+			req.Query.Colors = []string{"red", "blue"}
+			req.Query.Tags = []string{"a", "b"}
+
+			r, err := NewRequest(req, "", "/")
+			require.NoError(g, err)
+			assert.Equal(g, "red|blue", r.URL.Query().Get("colors"))
+			assert.Equal(g, "a,b", r.URL.Query().Get("tags"))
+		})
+
+		g.It("should reject values which are not request objects", func() {
+			_, err := NewRequest("pets", "", "/")
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), "not a request object")
+		})
+	})
+}
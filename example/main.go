@@ -14,6 +14,7 @@ import (
 	_ "embed"
 
 	"github.com/schmurfy/chipi"
+	"github.com/schmurfy/chipi/builder"
 )
 
 //go:embed index.html
@@ -67,6 +68,13 @@ func main() {
 	router.Use(cors.AllowAll().Handler)
 
 	router.Get("/doc.json", api.ServeSchema)
+
+	docs, err := api.DocsHandler("/doc.json", builder.SwaggerUI)
+	if err != nil {
+		panic(err)
+	}
+	router.Get("/docs", docs)
+
 	router.Get("/doc2.json", func(w http.ResponseWriter, r *http.Request) {
 		f, err := os.Open("/tmp/doc.json")
 		if err != nil {
@@ -0,0 +1,345 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/dave/dst"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/wrapper"
+)
+
+var (
+	paramSections = []string{"Path", "Query", "Header", "Cookie"}
+)
+
+var bindingFileHeader = `
+package %s
+
+import (
+	"github.com/schmurfy/chipi/wrapper"
+)
+`
+
+type boundParam struct {
+	Section string
+	Field   string
+	Param   wrapper.Param
+}
+
+// Values is the Binder call returning the request values of the parameter
+func (bp boundParam) Values() string {
+	return fmt.Sprintf("b.%sValues(%q)", bp.Section, bp.Param.Name)
+}
+
+// Literal is the wrapper.Param given to Bind
+func (bp boundParam) Literal() string {
+	fields := []string{
+		fmt.Sprintf("Name: %q", bp.Param.Name),
+		fmt.Sprintf("Path: %q", bp.Param.Path),
+	}
+
+	if bp.Param.Required {
+		fields = append(fields, "Required: true")
+	}
+
+	if bp.Param.Sensitive {
+		fields = append(fields, "Sensitive: true")
+	}
+
+	if bp.Param.HasDefault {
+		fields = append(fields, fmt.Sprintf("Default: %q", bp.Param.Default), "HasDefault: true")
+	}
+
+	if len(bp.Param.Enum) > 0 {
+		fields = append(fields, fmt.Sprintf("Enum: %#v", bp.Param.Enum))
+	}
+
+	if bp.Param.Fields != nil {
+		fields = append(fields, fmt.Sprintf("Fields: %#v", bp.Param.Fields))
+	}
+
+	if bp.Param.Separator != "" {
+		fields = append(fields, fmt.Sprintf("Separator: %q", bp.Param.Separator))
+	}
+
+	return "wrapper.Param{" + strings.Join(fields, ", ") + "}"
+}
+
+type boundStructure struct {
+	Name   string
+	Params []boundParam
+}
+
+var bindingTemplate = template.Must(template.New("binding_template").Parse(`
+	{{ range . }}
+	func (req *{{ .Name }}) FillFromRequest(b *wrapper.Binder) {
+		{{- range .Params }}
+		wrapper.Bind(b, &req.{{ .Section }}.{{ .Field }}, {{ .Values }}, {{ .Literal }})
+		{{- end }}
+	}
+	{{ end }}
+`))
+
+// GenerateBindings writes a FillFromRequest method for each request structure
+// whose parameters can be bound without reflection, the others are left
+// to the reflection based binding and the reason is written to diagnostics
+// (if not nil)
+func GenerateBindings(w io.Writer, f *dst.File, pkgName string, diagnostics io.Writer) error {
+	structures := []boundStructure{}
+	embedded := embeddedStructures(f)
+
+	for _, node := range f.Decls {
+		decl, ok := node.(*dst.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range decl.Specs {
+			tt, ok := spec.(*dst.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			st, ok := tt.Type.(*dst.StructType)
+			if !ok {
+				continue
+			}
+
+			params, skipped, err := bindableParams(f, st)
+			if err != nil {
+				return fmt.Errorf("%s: %w", tt.Name.Name, err)
+			}
+
+			// the generated method would be promoted to the embedding structures
+			if (skipped == "") && (len(params) > 0) && embedded[tt.Name.Name] {
+				skipped = "embedded in another structure"
+			}
+
+			if (skipped != "") && (diagnostics != nil) {
+				fmt.Fprintf(diagnostics, "%s.%s: %s, left to the reflection based binding\n", pkgName, tt.Name.Name, skipped)
+			}
+
+			if (skipped == "") && (len(params) > 0) {
+				structures = append(structures, boundStructure{
+					Name:   tt.Name.Name,
+					Params: params,
+				})
+			}
+		}
+	}
+
+	if len(structures) == 0 {
+		return nil
+	}
+
+	buffer := bytes.NewBufferString(fmt.Sprintf(bindingFileHeader, pkgName))
+	err := bindingTemplate.Execute(buffer, structures)
+	if err != nil {
+		return err
+	}
+
+	data, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// bindableParams returns the parameters of a request structure, skipped
+// gives the reason when the structure uses features only handled by the
+// reflection based binding (pointer or named sections, maps, catch-all
+// fields, embedded sections)
+func bindableParams(f *dst.File, st *dst.StructType) (params []boundParam, skipped string, err error) {
+	for _, sectionField := range st.Fields.List {
+		if len(sectionField.Names) == 0 {
+			// embedded fields only matter if they may hide a section
+			if name := embeddedName(sectionField.Type); isParamSection(name) || declaresSection(f, name, map[string]bool{}) {
+				return nil, fmt.Sprintf("embedded %s may contain a section", name), nil
+			}
+			continue
+		}
+
+		section := sectionField.Names[0].Name
+		if !isParamSection(section) {
+			continue
+		}
+
+		sectionStruct, isStruct := sectionField.Type.(*dst.StructType)
+		if !isStruct {
+			return nil, section + " is not an inline structure", nil
+		}
+
+		for _, field := range sectionStruct.Fields.List {
+			if len(field.Names) == 0 {
+				return nil, section + " embeds a structure", nil
+			}
+
+			if isMapType(field.Type) {
+				return nil, section + "." + field.Names[0].Name + " is a map", nil
+			}
+
+			tag, err := fieldTag(field)
+			if err != nil {
+				return nil, "", err
+			}
+
+			for _, name := range field.Names {
+				if !isExported(name.Name) {
+					return nil, section + "." + name.Name + " is unexported", nil
+				}
+
+				structField := reflect.StructField{Name: name.Name, Tag: tag}
+				if ignored := schema.ParseJsonTag(structField).Ignored; (ignored != nil) && *ignored {
+					continue
+				}
+
+				if rest := schema.ParseJsonTag(structField).Rest; (rest != nil) && *rest {
+					return nil, section + "." + name.Name + " is a catch-all field", nil
+				}
+
+				params = append(params, boundParam{
+					Section: section,
+					Field:   name.Name,
+					Param:   wrapper.DescribeParam(section, structField),
+				})
+			}
+		}
+	}
+
+	return params, "", nil
+}
+
+// embeddedStructures returns the names of the types embedded by the
+// structures of f
+func embeddedStructures(f *dst.File) map[string]bool {
+	ret := map[string]bool{}
+
+	dst.Inspect(f, func(node dst.Node) bool {
+		if st, ok := node.(*dst.StructType); ok {
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 {
+					ret[embeddedName(field.Type)] = true
+				}
+			}
+		}
+		return true
+	})
+
+	return ret
+}
+
+// embeddedName returns the name of the field promoted by an embedded type
+// (ex: ErrorEncoder for *response.ErrorEncoder)
+func embeddedName(expr dst.Expr) string {
+	if star, ok := expr.(*dst.StarExpr); ok {
+		expr = star.X
+	}
+
+	switch e := expr.(type) {
+	case *dst.Ident:
+		return e.Name
+	case *dst.SelectorExpr:
+		return e.Sel.Name
+	case *dst.IndexExpr:
+		return embeddedName(e.X)
+	}
+
+	return ""
+}
+
+// declaresSection returns true if name is a structure of f with a section
+// field (or embedding one), the types of the other files and packages are
+// expected to hold no section (ex: response.ErrorEncoder, chipi.BaseRequest)
+func declaresSection(f *dst.File, name string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+
+	for _, node := range f.Decls {
+		decl, ok := node.(*dst.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range decl.Specs {
+			tt, ok := spec.(*dst.TypeSpec)
+			if !ok || (tt.Name.Name != name) {
+				continue
+			}
+
+			st, ok := tt.Type.(*dst.StructType)
+			if !ok {
+				return false
+			}
+
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 {
+					embedded := embeddedName(field.Type)
+					if isParamSection(embedded) || declaresSection(f, embedded, visited) {
+						return true
+					}
+					continue
+				}
+
+				for _, fieldName := range field.Names {
+					if isParamSection(fieldName.Name) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func isParamSection(name string) bool {
+	for _, s := range paramSections {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isMapType(expr dst.Expr) bool {
+	if star, ok := expr.(*dst.StarExpr); ok {
+		expr = star.X
+	}
+
+	_, ok := expr.(*dst.MapType)
+	return ok
+}
+
+func isExported(name string) bool {
+	for _, c := range name {
+		return unicode.IsUpper(c)
+	}
+
+	return false
+}
+
+func fieldTag(field *dst.Field) (reflect.StructTag, error) {
+	if field.Tag == nil {
+		return "", nil
+	}
+
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", err
+	}
+
+	return reflect.StructTag(tag), nil
+}
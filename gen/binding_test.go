@@ -0,0 +1,139 @@
+package gen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/franela/goblin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindings(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Bindings", func() {
+		parse := func(data []byte) *dst.File {
+			f, err := decorator.ParseFile(token.NewFileSet(), "", data, parser.ParseComments)
+			require.NoError(g, err)
+			return f
+		}
+
+		g.It("should generate FillFromRequest for request structures", func() {
+			data, err := os.ReadFile("../internal/testdata/monster/monster.go")
+			require.NoError(g, err)
+
+			buffer := bytes.NewBufferString("")
+			err = GenerateBindings(buffer, parse(data), "monster", nil)
+			require.NoError(g, err)
+
+			code := buffer.String()
+			assert.Contains(g, code, "package monster")
+			assert.Contains(g, code, "func (req *GetMonsterRequest) FillFromRequest(b *wrapper.Binder) {")
+			assert.Contains(g, code, `wrapper.Bind(b, &req.Path.Id, b.PathValues("Id"), wrapper.Param{Name: "Id", Path: "request.path.Id"})`)
+			assert.Contains(g, code, `wrapper.Bind(b, &req.Query.Blocking, b.QueryValues("blocking"), wrapper.Param{Name: "blocking", Path: "request.query.blocking"})`)
+			assert.Contains(g, code, `wrapper.Bind(b, &req.Header.ApiKey, b.HeaderValues("ApiKey"), wrapper.Param{Name: "ApiKey", Path: "request.header.ApiKey"})`)
+			assert.NotContains(g, code, "QueryResponse")
+		})
+
+		g.It("should use the field tags", func() {
+			buffer := bytes.NewBufferString("")
+			err := GenerateBindings(buffer, parse([]byte("package pet\n"+
+				"type ListPetsRequest struct {\n"+
+				"	Query struct {\n"+
+				"		PageSize int `default:\"20\"`\n"+
+				"		Owner string `json:\"owner_id\" chipi:\"required\"`\n"+
+				"		Sort string `chipi:\"enum=asc,desc\"`\n"+
+				"		Ids []int `style:\"spaceDelimited\"`\n"+
+				"	}\n"+
+				"	Header struct {\n"+
+				"		Token string `name:\"X-Token\" chipi:\"sensitive\"`\n"+
+				"	}\n"+
+				"}\n",
+			)), "pet", nil)
+			require.NoError(g, err)
+
+			code := buffer.String()
+			assert.Contains(g, code, `b.QueryValues("page_size"), wrapper.Param{Name: "page_size", Path: "request.query.page_size", Default: "20", HasDefault: true})`)
+			assert.Contains(g, code, `b.QueryValues("owner_id"), wrapper.Param{Name: "owner_id", Path: "request.query.owner_id", Required: true})`)
+			assert.Contains(g, code, `b.QueryValues("sort"), wrapper.Param{Name: "sort", Path: "request.query.sort", Enum: []string{"asc", "desc"}})`)
+			assert.Contains(g, code, `b.QueryValues("ids"), wrapper.Param{Name: "ids", Path: "request.query.ids", Separator: " "})`)
+			assert.Contains(g, code, `b.HeaderValues("X-Token"), wrapper.Param{Name: "X-Token", Path: "request.header.Token", Sensitive: true})`)
+		})
+
+		g.It("should leave the other structures to the reflection based binding", func() {
+			buffer := bytes.NewBufferString("")
+			diagnostics := bytes.NewBufferString("")
+			err := GenerateBindings(buffer, parse([]byte(`package pet
+type Filters struct {
+	Name string
+}
+type NamedSectionRequest struct {
+	Query Filters
+}
+type PointerSectionRequest struct {
+	Query *struct {
+		Name string
+	}
+}
+type DeepObjectRequest struct {
+	Query struct {
+		Filter map[string]string
+	}
+}
+type BodyOnlyRequest struct {
+	Body struct {
+		Name string
+	}
+}
+type Common struct {
+	Header struct {
+		Token string
+	}
+}
+type EmbeddedSectionRequest struct {
+	Common
+	Query struct {
+		Name string
+	}
+}
+`)), "pet", diagnostics)
+			require.NoError(g, err)
+			assert.Empty(g, buffer.String())
+
+			assert.Contains(g, diagnostics.String(), "pet.NamedSectionRequest: Query is not an inline structure, left to the reflection based binding")
+			assert.Contains(g, diagnostics.String(), "pet.DeepObjectRequest: Query.Filter is a map")
+			assert.Contains(g, diagnostics.String(), "pet.EmbeddedSectionRequest: embedded Common may contain a section")
+			assert.Contains(g, diagnostics.String(), "pet.Common: embedded in another structure")
+			assert.NotContains(g, diagnostics.String(), "BodyOnlyRequest")
+		})
+
+		g.It("should ignore the embedded types without section", func() {
+			buffer := bytes.NewBufferString("")
+			diagnostics := bytes.NewBufferString("")
+			err := GenerateBindings(buffer, parse([]byte(`package pet
+import (
+	"github.com/schmurfy/chipi"
+	"github.com/schmurfy/chipi/response"
+)
+type GetPetRequest struct {
+	chipi.BaseRequest
+	response.JsonEncoder
+	Path struct {
+		Id int32
+	}
+}
+`)), "pet", diagnostics)
+			require.NoError(g, err)
+			assert.Empty(g, diagnostics.String())
+
+			assert.Contains(g, buffer.String(), "func (req *GetPetRequest) FillFromRequest(b *wrapper.Binder) {")
+			assert.Contains(g, buffer.String(), `wrapper.Bind(b, &req.Path.Id, b.PathValues("Id"), wrapper.Param{Name: "Id", Path: "request.path.Id"})`)
+		})
+	})
+}
@@ -80,8 +80,26 @@ func generateDataForFile(path string, noWrite bool) error {
 		return err
 	}
 
+	header := fmt.Sprintf(fileHeader, pkgName)
+
 	// write file (or log)
-	if len(buffer.String()) > 0 {
+	err = writeGenerated(generatedPath, buffer.String(), header, noWrite)
+	if err != nil {
+		return err
+	}
+
+	// the bindings need their own imports
+	bindings := bytes.NewBufferString("")
+	err = GenerateBindings(bindings, file, pkgName, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	return writeGenerated(path+".binding.generated.go", bindings.String(), "", noWrite)
+}
+
+func writeGenerated(generatedPath string, data string, header string, noWrite bool) error {
+	if len(data) > 0 {
 		if noWrite {
 			fmt.Printf("Would have written to %s\n", generatedPath)
 		} else {
@@ -90,13 +108,14 @@ func generateDataForFile(path string, noWrite bool) error {
 				return err
 			}
 
-			header := fmt.Sprintf(fileHeader, pkgName)
+			defer w.Close()
+
 			_, err = w.WriteString(header)
 			if err != nil {
 				return err
 			}
 
-			_, err = w.WriteString(buffer.String())
+			_, err = w.WriteString(data)
 			if err != nil {
 				return err
 			}
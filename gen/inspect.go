@@ -5,7 +5,7 @@ import (
 )
 
 var (
-	validFields = []string{"Path", "Query", "Header", "Body", "Response"}
+	validFields = []string{"Path", "Query", "Header", "Cookie", "Body", "Response"}
 )
 
 type inspectFunc func(parentStructName string, sectionName string, fieldName string, data map[string]string) error
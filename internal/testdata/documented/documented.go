@@ -0,0 +1,43 @@
+package documented
+
+import (
+	"context"
+	"net/http"
+)
+
+// Owner is the owner of a pet.
+type Owner struct {
+	// Name is the full name of the owner.
+	Name string
+
+	Email string `description:"from the tag"`
+}
+
+// GetOwnerRequest fetches the owner of a pet.
+type GetOwnerRequest struct {
+	Path struct {
+		// Id of the pet.
+		Id int32
+	} `example:"/pets/4/owner"`
+
+	Query struct {
+		Full bool // also return the contact details
+	}
+
+	// the owner
+	Response Owner
+}
+
+func (r *GetOwnerRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+// @summary
+// annotated for chipi-gen
+type AnnotatedRequest struct {
+	Path struct{} `example:"/annotated"`
+}
+
+func (r *AnnotatedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
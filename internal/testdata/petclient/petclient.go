@@ -0,0 +1,86 @@
+package petclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/schmurfy/chipi/request"
+	"github.com/schmurfy/chipi/response"
+)
+
+type Pet struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Nickname string `json:"nickname" chipi:"name=nick"`
+}
+
+type NotFound struct {
+	Missing string `json:"missing"`
+}
+
+type GetPetRequest struct {
+	response.ErrorEncoder
+	response.JsonEncoder
+
+	Path struct {
+		Id int
+	} `example:"/pets/43"`
+
+	Query struct {
+		Count  *int
+		Tags   []string `json:"tag"`
+		Colors []string `style:"pipeDelimited"`
+		Range  map[string]int
+		Extra  map[string][]string `chipi:"rest"`
+	}
+
+	Header struct {
+		ApiKey string `name:"x-api-key" chipi:"required"`
+	}
+
+	Cookie struct {
+		Session string `name:"session_id"`
+	}
+
+	Response Pet
+}
+
+func (r *GetPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *GetPetRequest) ErrorResponses() map[int]interface{} {
+	return map[int]interface{}{
+		http.StatusNotFound: &NotFound{},
+		http.StatusConflict: &response.Problem{},
+		http.StatusGone:     nil,
+	}
+}
+
+type CreatePetRequest struct {
+	response.ErrorEncoder
+	response.JsonEncoder
+	request.JsonBodyDecoder
+
+	Path struct{} `example:"/pets"`
+
+	Body *Pet
+
+	Response Pet
+}
+
+func (r *CreatePetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type DeletePetRequest struct {
+	response.ErrorEncoder
+
+	Path *struct {
+		Id int
+	} `example:"/pets/43"`
+}
+
+func (r *DeletePetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
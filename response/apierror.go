@@ -0,0 +1,76 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Error is a structured error which can be returned from Handle, it is
+// sent as a json body with its status code (500 by default)
+type Error struct {
+	Status  int                    `json:"status"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	// RequestID is set from the RequestIDHeader of the response if empty
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewError returns an error with the given status and code
+func NewError(status int, code string, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// WithDetail adds a detail to the error and returns it
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+
+	return e.Message
+}
+
+// StatusCode returns the status of the error, 500 if not set
+func (e *Error) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return e.Status
+}
+
+// Write sends the error as the response
+func (e *Error) Write(w http.ResponseWriter) {
+	status := e.StatusCode()
+
+	ret := *e
+	ret.Status = status
+	if ret.RequestID == "" {
+		ret.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	data, err := json.Marshal(&ret)
+	if err != nil {
+		http.Error(w, e.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
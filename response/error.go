@@ -2,11 +2,24 @@ package response
 
 import (
 	"context"
+	"errors"
 	"net/http"
 )
 
 type ErrorEncoder struct{}
 
 func (e *ErrorEncoder) HandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		problem.Write(w)
+		return
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		apiErr.Write(w)
+		return
+	}
+
 	http.Error(w, err.Error(), http.StatusBadRequest)
 }
@@ -0,0 +1,58 @@
+package response
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// FileResponse can be used as Response to send a file, it is streamed
+// with its Content-Disposition and Content-Length headers
+type FileResponse struct {
+	Reader io.Reader
+	// Filename is sent in the Content-Disposition header if set
+	Filename string
+	// ContentType defaults to the content-type tag of the Response field
+	// or application/octet-stream
+	ContentType string
+	// Size is sent as Content-Length if positive
+	Size int64
+	// Inline asks the browsers to display the file instead of downloading it
+	Inline bool
+}
+
+// Write sends the headers and copies the reader (closed if it implements
+// io.Closer), nothing is sent without reader
+func (f *FileResponse) Write(w http.ResponseWriter) error {
+	if f.Reader == nil {
+		return nil
+	}
+
+	if closer, ok := f.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	disposition := "attachment"
+	if f.Inline {
+		disposition = "inline"
+	}
+
+	if f.Filename != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": f.Filename})
+	}
+	w.Header().Set("Content-Disposition", disposition)
+
+	if f.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+
+	_, err := io.Copy(w, f.Reader)
+	return err
+}
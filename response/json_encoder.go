@@ -1,6 +1,7 @@
 package response
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -9,12 +10,14 @@ import (
 type JsonEncoder struct{}
 
 func (e *JsonEncoder) EncodeResponse(ctx context.Context, w http.ResponseWriter, obj interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-
-	err := json.NewEncoder(w).Encode(obj)
+	// encode first to be able to report a failure
+	buffer := &bytes.Buffer{}
+	err := json.NewEncoder(buffer).Encode(obj)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(buffer.Bytes())
 }
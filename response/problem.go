@@ -0,0 +1,73 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader is the response header holding the request id, the
+// problems and errors written after it was set include it
+const RequestIDHeader = "X-Request-Id"
+
+// InvalidParam describes one invalid parameter of a Problem
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem is an RFC 7807 error, it can be returned from Handle
+// to send a problem+json response
+type Problem struct {
+	Type          string         `json:"type,omitempty"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"`
+}
+
+// NewProblem returns a problem using the standard text of status as title
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+	}
+
+	return p.Title
+}
+
+// Write sends the problem as the response
+func (p *Problem) Write(w http.ResponseWriter) {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	ret := *p
+	if ret.RequestID == "" {
+		ret.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	data, err := json.Marshal(&ret)
+	if err != nil {
+		http.Error(w, p.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Constraints are the validation rules declared by the min, max, minLength,
+// maxLength and pattern tags of a field, they apply to the items of slices
+type Constraints struct {
+	Min       *float64
+	Max       *float64
+	MinLength *uint64
+	MaxLength *uint64
+	Pattern   *regexp.Regexp
+}
+
+// ParseConstraints returns the constraints declared by the tags of f,
+// nil if there is none
+func ParseConstraints(f reflect.StructField) (*Constraints, error) {
+	var ret Constraints
+	found := false
+
+	t := itemType(f.Type)
+	isNumber := false
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		isNumber = true
+	}
+	isString := (t.Kind() == reflect.String)
+
+	for _, name := range []string{"min", "max"} {
+		val, ok := f.Tag.Lookup(name)
+		if !ok {
+			continue
+		}
+
+		if !isNumber {
+			return nil, fmt.Errorf("%s requires a number, got %s", name, f.Type)
+		}
+
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, val, err)
+		}
+
+		if name == "min" {
+			ret.Min = &n
+		} else {
+			ret.Max = &n
+		}
+		found = true
+	}
+
+	for _, name := range []string{"minLength", "maxLength"} {
+		val, ok := f.Tag.Lookup(name)
+		if !ok {
+			continue
+		}
+
+		if !isString {
+			return nil, fmt.Errorf("%s requires a string, got %s", name, f.Type)
+		}
+
+		n, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, val, err)
+		}
+
+		if name == "minLength" {
+			ret.MinLength = &n
+		} else {
+			ret.MaxLength = &n
+		}
+		found = true
+	}
+
+	if val, ok := f.Tag.Lookup("pattern"); ok {
+		if !isString {
+			return nil, fmt.Errorf("pattern requires a string, got %s", f.Type)
+		}
+
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", val, err)
+		}
+
+		ret.Pattern = re
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &ret, nil
+}
+
+// Apply documents the constraints in the schema of their field
+func (c *Constraints) Apply(schema *openapi3.Schema) {
+	if c == nil {
+		return
+	}
+
+	if (schema.Type == "array") && (schema.Items != nil) {
+		if schema.Items.Value == nil {
+			return
+		}
+		schema = schema.Items.Value
+	}
+
+	schema.Min = c.Min
+	schema.Max = c.Max
+	schema.MaxLength = c.MaxLength
+
+	if c.MinLength != nil {
+		schema.MinLength = *c.MinLength
+	}
+
+	if c.Pattern != nil {
+		schema.Pattern = c.Pattern.String()
+	}
+}
+
+// ApplyTagConstraints documents the constraints declared by the tags of f
+// in its schema
+func ApplyTagConstraints(schema *openapi3.Schema, f reflect.StructField) error {
+	c, err := ParseConstraints(f)
+	if err != nil {
+		return err
+	}
+
+	c.Apply(schema)
+	return nil
+}
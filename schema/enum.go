@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Enumer can be implemented by types only accepting a fixed set of values,
+// Enum is called on the zero value
+type Enumer interface {
+	Enum() []interface{}
+}
+
+var _enumerType = reflect.TypeOf((*Enumer)(nil)).Elem()
+
+// TypeEnum returns the values accepted by t (or the type it points to)
+// if it implements Enumer, nil otherwise
+func TypeEnum(t reflect.Type) []interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !reflect.PtrTo(t).Implements(_enumerType) {
+		return nil
+	}
+
+	return reflect.New(t).Interface().(Enumer).Enum()
+}
+
+// EnumValues converts the values of a `chipi:"enum=..."` tag to the type of the
+// field (or of its items for slices)
+func EnumValues(t reflect.Type, values []string) ([]interface{}, error) {
+	t = itemType(t)
+
+	ret := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if (t.Kind() == reflect.String) || isTextType(t) {
+			ret = append(ret, value)
+			continue
+		}
+
+		v := reflect.New(t)
+		err := json.Unmarshal([]byte(value), v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum value %q for %s: %w", value, t, err)
+		}
+
+		ret = append(ret, v.Elem().Interface())
+	}
+
+	return ret, nil
+}
+
+// ApplyTagEnum sets the enum of the schema of a field from its tag, the
+// items of arrays get it instead
+func ApplyTagEnum(schema *openapi3.Schema, f reflect.StructField) error {
+	tag := ParseJsonTag(f)
+	if len(tag.Enum) == 0 {
+		return nil
+	}
+
+	enum, err := EnumValues(f.Type, tag.Enum)
+	if err != nil {
+		return err
+	}
+
+	if (schema.Type == "array") && (schema.Items != nil) {
+		if schema.Items.Value == nil {
+			return nil
+		}
+		schema = schema.Items.Value
+	}
+
+	schema.Enum = enum
+	return nil
+}
+
+func itemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if (t.Kind() == reflect.Slice) && (t.Elem().Kind() != reflect.Uint8) {
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ParseExtensions returns the OpenAPI extensions declared by the chipi-ext tag
+// (ex: `chipi-ext:"x-internal,x-rate-limit=100"`), the values are json
+// (strings otherwise) and extensions without one are true
+func ParseExtensions(tag reflect.StructTag) (map[string]interface{}, error) {
+	val, found := tag.Lookup("chipi-ext")
+	if !found {
+		return nil, nil
+	}
+
+	ret := map[string]interface{}{}
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(item, "=")
+		if !strings.HasPrefix(name, "x-") {
+			return nil, fmt.Errorf("invalid extension %q, the name must start with x-", name)
+		}
+
+		if !hasValue {
+			ret[name] = true
+			continue
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		ret[name] = decoded
+	}
+
+	return ret, nil
+}
+
+// ApplyTagExtensions adds the extensions declared by tag to props
+func ApplyTagExtensions(props *openapi3.ExtensionProps, tag reflect.StructTag) error {
+	extensions, err := ParseExtensions(tag)
+	if err != nil {
+		return err
+	}
+
+	addExtensions(props, extensions)
+	return nil
+}
+
+func addExtensions(props *openapi3.ExtensionProps, extensions map[string]interface{}) {
+	for name, value := range extensions {
+		if props.Extensions == nil {
+			props.Extensions = map[string]interface{}{}
+		}
+		props.Extensions[name] = value
+	}
+}
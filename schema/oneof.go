@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/schmurfy/chipi/shared"
+)
+
+// _implementations holds the registered implementations of the interfaces
+var _implementations sync.Map
+
+// Implementations are the concrete types of an interface, identified by
+// the value of their discriminator property
+type Implementations struct {
+	Property string
+	Types    map[string]reflect.Type
+}
+
+// RegisterImplementations declares the concrete types of the interface I
+// indexed by the value of their property (ex: "type") in json, fields of
+// this interface are then documented with oneOf and a discriminator
+func RegisterImplementations[I any](property string, implementations map[string]I) {
+	iface := reflect.TypeOf((*I)(nil)).Elem()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("%s is not an interface", iface))
+	}
+
+	impls := &Implementations{
+		Property: property,
+		Types:    map[string]reflect.Type{},
+	}
+
+	for value, impl := range implementations {
+		impls.Types[value] = reflect.TypeOf(impl)
+	}
+
+	_implementations.Store(iface, impls)
+}
+
+// ImplementationsOf returns the registered implementations of the
+// interface t, nil if there is none
+func ImplementationsOf(t reflect.Type) *Implementations {
+	if impls, found := _implementations.Load(t); found {
+		return impls.(*Implementations)
+	}
+
+	return nil
+}
+
+// Values returns the discriminator values in order
+func (impls *Implementations) Values() []string {
+	values := make([]string, 0, len(impls.Types))
+	for value := range impls.Types {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	return values
+}
+
+func (s *Schema) generateOneOfSchema(ctx context.Context, doc *openapi3.T, impls *Implementations, fieldInfo shared.AttributeInfo, filterObject shared.FilterInterface) (*openapi3.Schema, error) {
+	ret := &openapi3.Schema{
+		Discriminator: &openapi3.Discriminator{
+			PropertyName: impls.Property,
+			Mapping:      map[string]string{},
+		},
+	}
+
+	for _, value := range impls.Values() {
+		t := impls.Types[value]
+
+		implSchema, err := s.generateSchemaFor(ctx, doc, t, 0, fieldInfo, filterObject)
+		if err != nil {
+			return nil, err
+		}
+
+		if implSchema == nil {
+			continue
+		}
+
+		if implSchema.Ref == "" {
+			return nil, fmt.Errorf("implementation %q (%s) must be a named structure", value, t)
+		}
+
+		ret.OneOf = append(ret.OneOf, openapi3.NewSchemaRef(implSchema.Ref, nil))
+		ret.Discriminator.Mapping[value] = implSchema.Ref
+	}
+
+	return ret, nil
+}
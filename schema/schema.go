@@ -2,8 +2,12 @@ package schema
 
 import (
 	"context"
+	"encoding"
+	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -12,10 +16,70 @@ import (
 )
 
 var (
-	_timeType = reflect.TypeOf(time.Time{})
+	_timeType            = reflect.TypeOf(time.Time{})
+	_fileHeaderType      = reflect.TypeOf(multipart.FileHeader{})
+	_rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	_textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	_jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	_schemerType         = reflect.TypeOf((*Schemer)(nil)).Elem()
+
+	// the type arguments of the generic types (ex: chipi.PagedResponse[github.com/x/api.Pet])
+	_packagePathPattern   = regexp.MustCompile(`[\w.\-]+/`)
+	_typeArgumentReplacer = strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "", " ", "")
 )
 
+// Schemer can be implemented by types with a custom wire format (decimals,
+// money, ...) to publish their schema, OpenAPISchema is called on the zero value
+// and takes precedence over reflection
+type Schemer interface {
+	OpenAPISchema() *openapi3.Schema
+}
+
+func isTextType(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(_textMarshalerType) &&
+		pt.Implements(_textUnmarshalerType) &&
+		!pt.Implements(_jsonMarshalerType)
+}
+
+// isMapKeyType returns true if encoding/json accepts t as a map key
+func isMapKeyType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return reflect.PtrTo(t).Implements(_textMarshalerType)
+}
+
+// isEmptyInterface returns true for interface{} without registered implementations
+func isEmptyInterface(t reflect.Type) bool {
+	return (t.Kind() == reflect.Interface) && (t.NumMethod() == 0) && (ImplementationsOf(t) == nil)
+}
+
+// DescriptionFunc returns the description of the field f of t, or of t itself
+// if f is nil, an empty string if none is known
+type DescriptionFunc func(t reflect.Type, f *reflect.StructField) string
+
 type Schema struct {
+	descriptions DescriptionFunc
+}
+
+// SetDescriptions defines where the descriptions missing from the tags
+// are looked up (ex: doc comments)
+func (s *Schema) SetDescriptions(fn DescriptionFunc) {
+	s.descriptions = fn
+}
+
+func (s *Schema) describe(t reflect.Type, f *reflect.StructField) string {
+	if (s.descriptions == nil) || (t.Name() == "") {
+		return ""
+	}
+
+	return s.descriptions(t, f)
 }
 
 func New() (*Schema, error) {
@@ -51,6 +115,33 @@ func (s *Schema) generateSchemaFor(ctx context.Context, doc *openapi3.T, t refle
 		t = t.Elem()
 	}
 
+	if custom := customSchema(t); custom != nil {
+		schema.Value = custom
+		return schema, nil
+	}
+
+	// uploaded files (multipart bodies)
+	if t == _fileHeaderType {
+		schema.Value = &openapi3.Schema{
+			Type:   "string",
+			Format: "binary",
+		}
+		return schema, nil
+	}
+
+	// any json value
+	if t == _rawMessageType {
+		schema.Value = &openapi3.Schema{}
+		return schema, nil
+	}
+
+	// types marshaled as text by encoding/json (uuids, enums, ...)
+	if (t != _timeType) && isTextType(t) {
+		schema.Value = openapi3.NewStringSchema()
+		schema.Value.Enum = TypeEnum(t)
+		return schema, nil
+	}
+
 	switch t.Kind() {
 
 	// basic types
@@ -103,6 +194,19 @@ func (s *Schema) generateSchemaFor(ctx context.Context, doc *openapi3.T, t refle
 		}
 
 	case reflect.Map:
+		if !isMapKeyType(t.Key()) {
+			return nil, fmt.Errorf("unsupported map key type: %v", t.Key())
+		}
+
+		// free-form values (ex: map[string]interface{})
+		if isEmptyInterface(t.Elem()) {
+			schema.Value = &openapi3.Schema{
+				Type:                        "object",
+				AdditionalPropertiesAllowed: boolPtr(true),
+			}
+			break
+		}
+
 		additionalProperties, err := s.generateSchemaFor(ctx, doc, t.Elem(), 0, fieldInfo, filterObject)
 		if err != nil {
 			return nil, err
@@ -131,7 +235,8 @@ func (s *Schema) generateSchemaFor(ctx context.Context, doc *openapi3.T, t refle
 			return schema, err
 		}
 
-		// check if the structure already exists as component first
+		// check if the structure already exists as component first, types
+		// being generated are found too which stops the recursion
 		_, found := doc.Components.Schemas[fullName]
 		if !found {
 			var err error
@@ -140,28 +245,87 @@ func (s *Schema) generateSchemaFor(ctx context.Context, doc *openapi3.T, t refle
 			// forward declaration of the current type to handle recursion properly
 			doc.Components.Schemas[fullName] = ref
 
-			// fmt.Printf("%s - BEFORE: %+v\n", t.Name(), doc.Components.Schemas[t.Name()])
 			ref.Value, err = s.generateStructureSchema(ctx, doc, t, inlineLevel, fieldInfo, filterObject)
 			if err != nil {
+				delete(doc.Components.Schemas, fullName)
 				return nil, err
 			}
-			// fmt.Printf("%s - AFTER: %+v\n", t.Name(), doc.Components.Schemas[t.Name()])
+
+			// filtered structure
+			if ref.Value == nil {
+				delete(doc.Components.Schemas, fullName)
+				return nil, nil
+			}
 		}
 
 		schema.Ref = structReference(t)
 
+	// interfaces with registered implementations
+	case reflect.Interface:
+		impls := ImplementationsOf(t)
+		if impls == nil {
+			return nil, fmt.Errorf("unknown type: %v (see RegisterImplementations)", t)
+		}
+
+		var err error
+		schema.Value, err = s.generateOneOfSchema(ctx, doc, impls, fieldInfo, filterObject)
+		if err != nil {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("unknown type: %v", t.Kind())
 	}
 
+	if (schema.Value != nil) && (t.Kind() != reflect.Struct) {
+		schema.Value.Enum = TypeEnum(t)
+	}
+
 	return schema, nil
 }
 
+// customSchema returns a copy of the schema of t if it implements Schemer,
+// the field tags are then applied on it
+func customSchema(t reflect.Type) *openapi3.Schema {
+	if !reflect.PtrTo(t).Implements(_schemerType) {
+		return nil
+	}
+
+	custom := reflect.New(t).Interface().(Schemer).OpenAPISchema()
+	if custom == nil {
+		return nil
+	}
+
+	ret := *custom
+	return &ret
+}
+
 func typeName(t reflect.Type) string {
 	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
-	return t.String()
+
+	// the generic types are named with the names of their type arguments
+	// (ex: chipi.PagedResponse_api.Pet) to have valid component names
+	name := t.String()
+	if strings.Contains(name, "[") {
+		name = _typeArgumentReplacer.Replace(_packagePathPattern.ReplaceAllString(name, ""))
+	}
+
+	return name
+}
+
+// hasJsonName returns true if the json tag of f sets its name
+func hasJsonName(f reflect.StructField) bool {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	return (name != "") && (name != "-")
+}
+
+func derefKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
 }
 
 func structReference(t reflect.Type) string {
@@ -176,7 +340,8 @@ func pkgName(t reflect.Type) string {
 
 func (s *Schema) generateStructureSchema(ctx context.Context, doc *openapi3.T, t reflect.Type, inlineLevel int, fieldInfo shared.AttributeInfo, filterObject shared.FilterInterface) (*openapi3.Schema, error) {
 	ret := &openapi3.Schema{
-		Type: "object",
+		Type:        "object",
+		Description: s.describe(t, nil),
 	}
 
 	pkgName := shared.ToSnakeCase(pkgName(t))
@@ -203,6 +368,12 @@ func (s *Schema) generateStructureSchema(ctx context.Context, doc *openapi3.T, t
 			continue
 		}
 
+		// encoding/json only promotes the fields of embedded structures
+		embedded := f.Anonymous && !hasJsonName(f) && (derefKind(f.Type) == reflect.Struct)
+		if !f.IsExported() && !embedded {
+			continue
+		}
+
 		fieldName := shared.ToSnakeCase(f.Name)
 		fi := fieldInfo.
 			WithModelPath(pkgName + "." + structName + "." + fieldName).
@@ -218,8 +389,8 @@ func (s *Schema) generateStructureSchema(ctx context.Context, doc *openapi3.T, t
 		}
 
 		//Detect if field is anonymous, look into the schemas and use the same property
-		if f.Anonymous && fieldSchema.Ref != "" && doc.Components.Schemas[f.Type.String()] != nil && doc.Components.Schemas[f.Type.String()].Value != nil {
-			for name, property := range doc.Components.Schemas[f.Type.String()].Value.Properties {
+		if embedded && fieldSchema.Ref != "" && doc.Components.Schemas[typeName(f.Type)] != nil && doc.Components.Schemas[typeName(f.Type)].Value != nil {
+			for name, property := range doc.Components.Schemas[typeName(f.Type)].Value.Properties {
 				ret.WithPropertyRef(name, property)
 			}
 
@@ -227,42 +398,74 @@ func (s *Schema) generateStructureSchema(ctx context.Context, doc *openapi3.T, t
 			continue
 		}
 
-		if fieldSchema.Ref != "" {
-			if (tag.Nullable != nil) && *tag.Nullable {
-				// nullableSchemaRef := openapi3.NewSchemaRef("", &openapi3.Schema{
-				// 	OneOf: openapi3.SchemaRefs{
-				// 		openapi3.NewSchemaRef("", &openapi3.Schema{Type: "null"}),
-				// 		fieldSchema,
-				// 	},
-				// })
+		// nil pointers are encoded as null
+		nullable := ((tag.Nullable != nil) && *tag.Nullable) || (f.Type.Kind() == reflect.Ptr)
+
+		extensions, err := ParseExtensions(f.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
 
-				// fieldSchema = nullableSchemaRef
+		readOnly := (tag.ReadOnly != nil) && *tag.ReadOnly
+		writeOnly := (tag.WriteOnly != nil) && *tag.WriteOnly
 
-				fieldSchema.Value = openapi3.NewSchema()
-				fieldSchema.Value.Nullable = *tag.Nullable
+		if fieldSchema.Ref != "" {
+			if nullable || readOnly || writeOnly || (len(extensions) > 0) {
+				// siblings of $ref are ignored, the reference has to be wrapped
+				wrappedSchema := &openapi3.Schema{
+					Nullable:  nullable,
+					ReadOnly:  readOnly,
+					WriteOnly: writeOnly,
+					AllOf:     openapi3.SchemaRefs{fieldSchema},
+				}
+				wrappedSchema.Extensions = extensions
 
 				if tag.Description != nil {
-					fieldSchema.Value.Description = *tag.Description
+					wrappedSchema.Description = *tag.Description
 				}
-			}
 
-			// fmt.Printf("wtf: %s.%s (%s)\n", t.Name(), f.Name, fieldSchema.Ref)
-			// fieldSchema.Value = openapi3.NewSchema()
+				fieldSchema = openapi3.NewSchemaRef("", wrappedSchema)
+			}
 		} else {
-			fieldSchema.Value.ReadOnly = (tag.ReadOnly != nil) && *tag.ReadOnly
-			fieldSchema.Value.Nullable = (tag.Nullable != nil) && *tag.Nullable
+			// `json:",string"` encodes numbers and booleans in strings
+			if (tag.String != nil) && *tag.String {
+				switch fieldSchema.Value.Type {
+				case "integer", "number", "boolean":
+					fieldSchema.Value = openapi3.NewStringSchema()
+				}
+			}
+
+			fieldSchema.Value.ReadOnly = readOnly
+			fieldSchema.Value.WriteOnly = writeOnly
+			fieldSchema.Value.Nullable = nullable
 			fieldSchema.Value.Deprecated = (tag.Deprecated != nil) && *tag.Deprecated
 
 			if tag.Description != nil {
 				fieldSchema.Value.Description = *tag.Description
+			} else if description := s.describe(t, &f); description != "" {
+				fieldSchema.Value.Description = description
 			}
 
 			if tag.Example != nil {
 				fieldSchema.Value.Example = *tag.Example
 			}
 
-			if tag.Required != nil && *tag.Required {
-				ret.Required = append(fieldSchema.Value.Required, fieldName)
+			err = ApplyTagEnum(fieldSchema.Value, f)
+			if err != nil {
+				return nil, err
+			}
+
+			err = ApplyTagConstraints(fieldSchema.Value, f)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+			}
+
+			addExtensions(&fieldSchema.Value.ExtensionProps, extensions)
+
+			// omitted zero values cannot be required
+			omitEmpty := (tag.OmitEmpty != nil) && *tag.OmitEmpty
+			if tag.Required != nil && *tag.Required && !omitEmpty {
+				ret.Required = append(ret.Required, tag.Name)
 			}
 			// if f.Name == "Coordinates" {
 			// 	fmt.Printf("[DD] %s.%s : %+v\n", t.Name(), f.Name, tag)
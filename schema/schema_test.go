@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"reflect"
 	"testing"
 	"time"
@@ -28,6 +29,90 @@ type RecursiveUser struct {
 	Group *RecursiveGroup
 }
 
+type TreeNode struct {
+	Name     string
+	Parent   *TreeNode
+	Children []*TreeNode
+	Index    map[string]TreeNode
+}
+
+type BrokenNode struct {
+	Next     *BrokenNode
+	Callback func()
+}
+
+type Timestamps struct {
+	CreatedAt string `json:"created_at"`
+}
+
+type Labels struct {
+	Color string
+}
+
+type jsonTaggedRecord struct {
+	Timestamps
+	*Labels `json:"labels"`
+
+	Id     int64  `json:"id,string"`
+	Name   string `json:",omitempty" chipi:"required"`
+	Code   string `json:"code" chipi:"required"`
+	Hidden string `json:"-"`
+	Dash   string `json:"-,"`
+	secret string
+}
+
+type decimal struct {
+	mantissa int64
+	exponent int
+}
+
+func (d decimal) OpenAPISchema() *openapi3.Schema {
+	return &openapi3.Schema{Type: "string", Format: "decimal", Pattern: "^-?[0-9.]+$"}
+}
+
+type textId [4]byte
+
+func (id textId) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x", id[:])), nil
+}
+
+func (id *textId) UnmarshalText(data []byte) error {
+	_, err := fmt.Sscanf(string(data), "%x", id)
+	return err
+}
+
+type testColor string
+
+func (c testColor) Enum() []interface{} {
+	return []interface{}{"red", "green"}
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Type   string
+	Radius float64
+}
+
+func (c *Circle) Area() float64 {
+	return 3.14 * c.Radius * c.Radius
+}
+
+type Square struct {
+	Type string
+	Side float64
+}
+
+func (s Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type unregistered interface {
+	Unknown()
+}
+
 func checkGeneratedType(g *goblin.G, ctx context.Context, schemaPtr **Schema, docPtr **openapi3.T, value interface{}, expected string) {
 	g.It(fmt.Sprintf("should generate inline type for %T", value), func() {
 		s := *schemaPtr
@@ -232,6 +317,33 @@ func TestSchema(t *testing.T) {
 				require.NoError(g, err)
 			})
 
+			g.It("should reference self-referencing structures", func() {
+				g.Timeout(5 * time.Second)
+
+				schema, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(TreeNode{}))
+				require.NoError(g, err)
+				assert.Equal(g, "#/components/schemas/schema.TreeNode", schema.Ref)
+
+				data, err := json.Marshal(doc.Components.Schemas["schema.TreeNode"])
+				require.NoError(g, err)
+
+				assert.JSONEq(g, `{
+					"type": "object",
+					"properties": {
+						"Name": {"type": "string"},
+						"Parent": {"nullable": true, "allOf": [{"$ref": "#/components/schemas/schema.TreeNode"}]},
+						"Children": {"type": "array", "items": {"$ref": "#/components/schemas/schema.TreeNode"}},
+						"Index": {"type": "object", "additionalProperties": {"$ref": "#/components/schemas/schema.TreeNode"}}
+					}
+				}`, string(data))
+			})
+
+			g.It("should not keep the components which failed", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(BrokenNode{}))
+				require.Error(g, err)
+				assert.NotContains(g, doc.Components.Schemas, "schema.BrokenNode")
+			})
+
 			// type UploadResumeRequest struct {
 			// 	Path struct {
 			// 		Name string `example:"john"`
@@ -311,6 +423,225 @@ func TestSchema(t *testing.T) {
 				"type": "string",
 				"format": "date-time"
 			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, textId{}, `{
+				"type": "string"
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, &multipart.FileHeader{}, `{
+				"type": "string",
+				"format": "binary"
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Name  string `chipi:"required"`
+				Age   int    `json:"age" chipi:"required"`
+				Color string
+			}{}, `{
+				"type": "object",
+				"required": ["Name", "age"],
+				"properties": {
+					"Name": {"type": "string"},
+					"age": {"type": "integer", "format": "int64"},
+					"Color": {"type": "string"}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Status string   `chipi:"required,enum=active,closed"`
+				Level  int      `chipi:"enum=1,2,3"`
+				Tags   []string `chipi:"enum=a,b"`
+				Color  testColor
+			}{}, `{
+				"type": "object",
+				"required": ["Status"],
+				"properties": {
+					"Status": {"type": "string", "enum": ["active", "closed"]},
+					"Level": {"type": "integer", "format": "int64", "enum": [1, 2, 3]},
+					"Tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}},
+					"Color": {"type": "string", "enum": ["red", "green"]}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Price    decimal  `description:"the price"`
+				Discount *decimal `chipi:"readonly"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"Price": {"type": "string", "format": "decimal", "pattern": "^-?[0-9.]+$", "description": "the price"},
+					"Discount": {"type": "string", "format": "decimal", "pattern": "^-?[0-9.]+$", "nullable": true, "readOnly": true}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Nickname *string
+				Age      int
+				Owner    *Labels `description:"the owner"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"Nickname": {"type": "string", "nullable": true},
+					"Age": {"type": "integer", "format": "int64"},
+					"Owner": {
+						"description": "the owner",
+						"nullable": true,
+						"allOf": [{"$ref": "#/components/schemas/schema.Labels"}]
+					}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Age   int      `min:"0" max:"150"`
+				Name  string   `minLength:"2" maxLength:"20" pattern:"^[a-z]+$"`
+				Codes []string `maxLength:"3"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"Age": {"type": "integer", "format": "int64", "minimum": 0, "maximum": 150},
+					"Name": {"type": "string", "minLength": 2, "maxLength": 20, "pattern": "^[a-z]+$"},
+					"Codes": {"type": "array", "items": {"type": "string", "maxLength": 3}}
+				}
+			}`)
+
+			g.It("should reject constraints not matching the field type", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(struct {
+					Name string `min:"3"`
+				}{}))
+				require.Error(g, err)
+				assert.Contains(g, err.Error(), "min requires a number, got string")
+			})
+
+			g.It("should generate oneOf for interfaces with implementations", func() {
+				RegisterImplementations("Type", map[string]Shape{
+					"circle": &Circle{},
+					"square": Square{},
+				})
+
+				schema, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(struct {
+					Shape Shape
+				}{}))
+				require.NoError(g, err)
+
+				data, err := json.Marshal(schema.Value.Properties["Shape"])
+				require.NoError(g, err)
+
+				assert.JSONEq(g, `{
+					"oneOf": [
+						{"$ref": "#/components/schemas/schema.Circle"},
+						{"$ref": "#/components/schemas/schema.Square"}
+					],
+					"discriminator": {
+						"propertyName": "Type",
+						"mapping": {
+							"circle": "#/components/schemas/schema.Circle",
+							"square": "#/components/schemas/schema.Square"
+						}
+					}
+				}`, string(data))
+
+				assert.Contains(g, doc.Components.Schemas, "schema.Circle")
+				assert.Contains(g, doc.Components.Schemas, "schema.Square")
+			})
+
+			g.It("should reject interfaces without implementations", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf((*unregistered)(nil)).Elem())
+				require.Error(g, err)
+				assert.Contains(g, err.Error(), "see RegisterImplementations")
+			})
+
+			g.It("should follow the json tags", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(jsonTaggedRecord{}))
+				require.NoError(g, err)
+
+				data, err := json.Marshal(doc.Components.Schemas["schema.jsonTaggedRecord"])
+				require.NoError(g, err)
+
+				assert.JSONEq(g, `{
+					"type": "object",
+					"required": ["code"],
+					"properties": {
+						"created_at": {"type": "string"},
+						"labels": {"nullable": true, "allOf": [{"$ref": "#/components/schemas/schema.Labels"}]},
+						"id": {"type": "string"},
+						"Name": {"type": "string"},
+						"code": {"type": "string"},
+						"-": {"type": "string"}
+					}
+				}`, string(data))
+			})
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Secret string   `chipi-ext:"x-internal,x-rate-limit=100,x-owner=billing"`
+				Owner  TreeNode `chipi-ext:"x-internal"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"Secret": {"type": "string", "x-internal": true, "x-rate-limit": 100, "x-owner": "billing"},
+					"Owner": {"allOf": [{"$ref": "#/components/schemas/schema.TreeNode"}], "x-internal": true}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Name     string `json:"name" chipi:"name=full_name"`
+				Password string `json:"password" chipi:"-"`
+				Email    string `chipi:"name=email,required"`
+			}{}, `{
+				"type": "object",
+				"required": ["email"],
+				"properties": {
+					"full_name": {"type": "string"},
+					"email": {"type": "string"}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				ID       string   `chipi:"readonly"`
+				Password string   `chipi:"writeonly"`
+				Parent   TreeNode `chipi:"readonly"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"ID": {"type": "string", "readOnly": true},
+					"Password": {"type": "string", "writeOnly": true},
+					"Parent": {"allOf": [{"$ref": "#/components/schemas/schema.TreeNode"}], "readOnly": true}
+				}
+			}`)
+
+			checkGeneratedType(g, ctx, &s, &doc, struct {
+				Pets     map[string]TreeNode    `json:"pets"`
+				Counts   map[int]uint32         `json:"counts"`
+				Metadata map[string]interface{} `json:"metadata"`
+			}{}, `{
+				"type": "object",
+				"properties": {
+					"pets": {"type": "object", "additionalProperties": {"$ref": "#/components/schemas/schema.TreeNode"}},
+					"counts": {"type": "object", "additionalProperties": {"type": "integer", "format": "int64"}},
+					"metadata": {"type": "object", "additionalProperties": true}
+				}
+			}`)
+
+			g.It("should reject maps with unsupported keys", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(map[float64]string{}))
+				require.Error(g, err)
+				assert.Contains(g, err.Error(), "unsupported map key type: float64")
+			})
+
+			g.It("should reject extensions without the x- prefix", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(struct {
+					Name string `chipi-ext:"internal=true"`
+				}{}))
+				require.Error(g, err)
+				assert.Contains(g, err.Error(), `invalid extension "internal"`)
+			})
+
+			g.It("should reject invalid enum values", func() {
+				_, err := s.GenerateSchemaFor(ctx, doc, reflect.TypeOf(struct {
+					Level int `chipi:"enum=low,high"`
+				}{}))
+				require.Error(g, err)
+				assert.Contains(g, err.Error(), `invalid enum value "low"`)
+			})
 		})
 
 	})
@@ -6,7 +6,10 @@ import (
 )
 
 type jsonTag struct {
+	// documented name, from `chipi:"name=..."` or the json tag
 	Name string
+	// name used by encoding/json
+	JsonName string
 
 	// from json or chipi tag
 	OmitEmpty  *bool
@@ -16,37 +19,73 @@ type jsonTag struct {
 	Ignored    *bool
 	Deprecated *bool
 	Required   *bool
+	Sensitive  *bool
+	Rest       *bool
+	Inject     *bool
+
+	// from `chipi:"-"`, the field is also ignored by the binding
+	Excluded *bool
+
+	// from json tag only, the value is encoded in a string
+	String *bool
 
 	// self contained
 	Explode     *bool
 	Description *string
 	Example     *string
 	Style       *string
+
+	// from `chipi:"enum=a,b,c"`, must be the last value of the tag
+	Enum []string
 }
 
 func ParseJsonTag(f reflect.StructField) *jsonTag {
 	ret := &jsonTag{
-		Name: f.Name,
+		Name:     f.Name,
+		JsonName: f.Name,
 	}
 
+	// same rules as encoding/json: the name comes first and may be empty,
+	// "-" is only a name when followed by a comma
 	if tag, found := f.Tag.Lookup("json"); found {
 		values := strings.Split(tag, ",")
-		for _, value := range values {
+
+		switch {
+		case tag == "-":
+			ret.Ignored = boolPtr(true)
+		case values[0] != "":
+			ret.Name = values[0]
+		}
+		ret.JsonName = ret.Name
+
+		for _, value := range values[1:] {
 			switch value {
-			case "-":
-				ret.Ignored = boolPtr(true)
 			case "omitempty":
 				ret.OmitEmpty = boolPtr(true)
-			default:
-				ret.Name = value
+			case "string":
+				ret.String = boolPtr(true)
 			}
 		}
 	}
 
 	if tag, found := f.Tag.Lookup("chipi"); found {
 		values := strings.Split(tag, ",")
-		for _, value := range values {
+		for i, value := range values {
+			if strings.HasPrefix(value, "enum=") {
+				// the enum values are separated by commas too
+				ret.Enum = append([]string{strings.TrimPrefix(value, "enum=")}, values[i+1:]...)
+				break
+			}
+
+			if strings.HasPrefix(value, "name=") {
+				ret.Name = strings.TrimPrefix(value, "name=")
+				continue
+			}
+
 			switch value {
+			case "-":
+				ret.Ignored = boolPtr(true)
+				ret.Excluded = boolPtr(true)
 			case "ignore":
 				ret.Ignored = boolPtr(true)
 			case "readonly":
@@ -59,6 +98,12 @@ func ParseJsonTag(f reflect.StructField) *jsonTag {
 				ret.Deprecated = boolPtr(true)
 			case "required":
 				ret.Required = boolPtr(true)
+			case "sensitive":
+				ret.Sensitive = boolPtr(true)
+			case "rest":
+				ret.Rest = boolPtr(true)
+			case "inject":
+				ret.Inject = boolPtr(true)
 			}
 		}
 	}
@@ -75,6 +120,11 @@ func ParseJsonTag(f reflect.StructField) *jsonTag {
 		ret.Style = stringPtr(val)
 	}
 
+	if val, found := f.Tag.Lookup("deprecated"); found {
+		b := (val == "true")
+		ret.Deprecated = &b
+	}
+
 	if val, found := f.Tag.Lookup("explode"); found {
 		b := (val == "true")
 		ret.Explode = &b
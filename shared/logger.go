@@ -0,0 +1,103 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Field is a structured attribute of a log message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the messages of the builder and the wrapper, it can be
+// implemented on top of any logging library (zap, zerolog, logrus, ...)
+type Logger interface {
+	Debug(ctx context.Context, msg string, fields ...Field)
+	Info(ctx context.Context, msg string, fields ...Field)
+	Warn(ctx context.Context, msg string, fields ...Field)
+	Error(ctx context.Context, msg string, fields ...Field)
+}
+
+// NopLogger discards every message, this is the default logger
+type NopLogger struct{}
+
+func (NopLogger) Debug(ctx context.Context, msg string, fields ...Field) {}
+func (NopLogger) Info(ctx context.Context, msg string, fields ...Field)  {}
+func (NopLogger) Warn(ctx context.Context, msg string, fields ...Field)  {}
+func (NopLogger) Error(ctx context.Context, msg string, fields ...Field) {}
+
+// LogLevel orders the messages by severity
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+
+	return "ERROR"
+}
+
+// StdLogger writes the messages at or above its level to a standard
+// logger as `LEVEL message key=value ...`
+type StdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogger returns a logger writing to l (log.Default() if nil)
+func NewStdLogger(l *log.Logger, level LogLevel) *StdLogger {
+	if l == nil {
+		l = log.Default()
+	}
+
+	return &StdLogger{logger: l, level: level}
+}
+
+func (l *StdLogger) Debug(ctx context.Context, msg string, fields ...Field) {
+	l.log(LevelDebug, msg, fields)
+}
+
+func (l *StdLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l *StdLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	l.log(LevelWarn, msg, fields)
+}
+
+func (l *StdLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	l.log(LevelError, msg, fields)
+}
+
+func (l *StdLogger) log(level LogLevel, msg string, fields []Field) {
+	if level < l.level {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(level.String())
+	sb.WriteString(" ")
+	sb.WriteString(msg)
+
+	for _, f := range fields {
+		fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+	}
+
+	l.logger.Print(sb.String())
+}
@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiErrorRequest struct {
+	Path  struct{}
+	Query struct {
+		Status int
+	}
+}
+
+func (r *apiErrorRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	err := response.NewError(r.Query.Status, "pet_not_found", "no pet with this id").
+		WithDetail("id", 42)
+
+	return fmt.Errorf("wrapped: %w", err)
+}
+
+type apiErrorEncoderRequest struct {
+	response.ErrorEncoder
+	apiErrorRequest
+}
+
+func TestStructuredErrors(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("structured errors", func() {
+		newRequest := func(query string) *http.Request {
+			r := httptest.NewRequest("GET", "/?"+query, nil)
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.It("should write the errors returned by Handle with their status", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&apiErrorRequest{})(w, newRequest("status=404"))
+
+			assert.Equal(g, http.StatusNotFound, w.Code)
+			assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(g, `{
+				"status": 404,
+				"code": "pet_not_found",
+				"message": "no pet with this id",
+				"details": {"id": 42}
+			}`, w.Body.String())
+		})
+
+		g.It("should default to an internal server error", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&apiErrorRequest{})(w, newRequest(""))
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Contains(g, w.Body.String(), `"status":500`)
+		})
+
+		g.It("should be written by the ErrorEncoder", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&apiErrorEncoderRequest{})(w, newRequest("status=409"))
+
+			assert.Equal(g, http.StatusConflict, w.Code)
+			assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+			assert.Contains(g, w.Body.String(), `"code":"pet_not_found"`)
+		})
+	})
+}
@@ -0,0 +1,287 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// OperationState is the state of an asynchronous operation
+type OperationState string
+
+const (
+	OperationPending   OperationState = "pending"
+	OperationRunning   OperationState = "running"
+	OperationSucceeded OperationState = "succeeded"
+	OperationFailed    OperationState = "failed"
+)
+
+// Enum implements schema.Enumer
+func (OperationState) Enum() []interface{} {
+	return []interface{}{OperationPending, OperationRunning, OperationSucceeded, OperationFailed}
+}
+
+// OperationStatus is the progress of an asynchronous operation
+type OperationStatus struct {
+	ID string `json:"id"`
+	// name of the request object which started the operation
+	Operation string         `json:"operation"`
+	Status    OperationState `json:"status"`
+	// percentage reported by the operation
+	Progress int `json:"progress"`
+	// returned by the operation once succeeded
+	Result json.RawMessage `json:"result,omitempty"`
+	// reason of the failure
+	Error     *response.Problem `json:"error,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// OperationStore keeps the status of the asynchronous operations, it must
+// be shared by the instances of the api (see MemoryOperationStore)
+type OperationStore interface {
+	Save(ctx context.Context, status *OperationStatus) error
+	// Load returns nil if the operation is unknown
+	Load(ctx context.Context, id string) (*OperationStatus, error)
+}
+
+// AsyncOptions configures the asynchronous operations (see Async)
+type AsyncOptions struct {
+	Store OperationStore
+
+	// StatusPath is the path of the status endpoint, the id of the operation
+	// is appended to it in the Location header (default: /operations)
+	StatusPath string
+}
+
+// AsyncFunc is run in the background with the values of the context given to
+// Handle, progress saves the percentage done
+type AsyncFunc func(ctx context.Context, progress func(percent int)) (interface{}, error)
+
+// AsyncResult can be returned by Handle to continue the operation in the
+// background, the client gets a 202 with the Location of its status
+type AsyncResult struct {
+	Run AsyncFunc
+}
+
+// Async returns the AsyncResult running fn
+func Async(fn AsyncFunc) *AsyncResult {
+	return &AsyncResult{Run: fn}
+}
+
+func (a *AsyncResult) Error() string {
+	return "operation continued in the background"
+}
+
+type asyncOperations struct {
+	store      OperationStore
+	statusPath string
+}
+
+func newAsyncOperations(opts *AsyncOptions) *asyncOperations {
+	if (opts == nil) || (opts.Store == nil) {
+		return nil
+	}
+
+	ret := &asyncOperations{store: opts.Store, statusPath: "/operations"}
+	if opts.StatusPath != "" {
+		ret.statusPath = strings.TrimSuffix(opts.StatusPath, "/")
+	}
+
+	return ret
+}
+
+// start saves the pending operation, sends the 202 and runs it in the
+// background, the returned error is sent instead
+func (a *asyncOperations) start(ctx context.Context, w http.ResponseWriter, operation string, async *AsyncResult) error {
+	if a == nil {
+		return response.NewProblem(http.StatusInternalServerError, "asynchronous operations need wrapper.Options.Async")
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	now := time.Now()
+	status := &OperationStatus{
+		ID:        hex.EncodeToString(id),
+		Operation: operation,
+		Status:    OperationPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := a.store.Save(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", a.statusPath+"/"+status.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(status)
+
+	go a.run(detachedContext{ctx}, *status, async.Run)
+
+	return nil
+}
+
+func (a *asyncOperations) run(ctx context.Context, status OperationStatus, fn AsyncFunc) {
+	var mutex sync.Mutex
+	save := func(update func(s *OperationStatus)) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		update(&status)
+		status.UpdatedAt = time.Now()
+
+		saved := status
+		_ = a.store.Save(ctx, &saved)
+	}
+
+	save(func(s *OperationStatus) {
+		s.Status = OperationRunning
+	})
+
+	result, err := runAsync(ctx, fn, func(percent int) {
+		save(func(s *OperationStatus) {
+			s.Progress = percent
+		})
+	})
+
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(result)
+	}
+
+	save(func(s *OperationStatus) {
+		if err != nil {
+			s.Status = OperationFailed
+			s.Error = operationProblem(err)
+			return
+		}
+
+		s.Status = OperationSucceeded
+		s.Progress = 100
+		s.Result = data
+	})
+}
+
+// runAsync recovers the panics of fn as errors
+func runAsync(ctx context.Context, fn AsyncFunc, progress func(int)) (result interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = errors.New("operation panicked")
+		}
+	}()
+
+	return fn(ctx, progress)
+}
+
+// operationProblem returns the problem describing err, the details of the
+// errors without status are not sent
+func operationProblem(err error) *response.Problem {
+	var problem *response.Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var apiErr *response.Error
+	if errors.As(err, &apiErr) {
+		return response.NewProblem(apiErr.Status, apiErr.Message)
+	}
+
+	return response.NewProblem(http.StatusInternalServerError, "")
+}
+
+// detachedContext keeps the values of the request context without its
+// cancellation, the operation outlives the request
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// OperationStatusRequest serves the status of the asynchronous operations
+// saved in Store (ex: on GET /operations/{ID})
+type OperationStatusRequest struct {
+	Path struct {
+		ID string
+	}
+	Response OperationStatus
+
+	Store OperationStore `chipi:"ignore"`
+}
+
+func (req *OperationStatusRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if req.Store == nil {
+		return response.NewProblem(http.StatusInternalServerError, "the operation store is not set")
+	}
+
+	status, err := req.Store.Load(ctx, req.Path.ID)
+	if err != nil {
+		return err
+	}
+
+	if status == nil {
+		return response.NewProblem(http.StatusNotFound, "unknown operation")
+	}
+
+	req.Response = *status
+	return nil
+}
+
+// IsAsyncOperation returns true if the Path of typ has an `async:"true"` tag,
+// the operation may then answer with a 202 (see Async)
+func IsAsyncOperation(typ reflect.Type) bool {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return false
+	}
+
+	async, _ := strconv.ParseBool(pathField.Tag.Get("async"))
+	return async
+}
+
+// MemoryOperationStore keeps the operations in memory until the process
+// exits, for tests and the apis served by a single process
+type MemoryOperationStore struct {
+	mutex      sync.Mutex
+	operations map[string]OperationStatus
+}
+
+func NewMemoryOperationStore() *MemoryOperationStore {
+	return &MemoryOperationStore{operations: map[string]OperationStatus{}}
+}
+
+func (s *MemoryOperationStore) Save(ctx context.Context, status *OperationStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.operations[status.ID] = *status
+	return nil
+}
+
+func (s *MemoryOperationStore) Load(ctx context.Context, id string) (*OperationStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status, found := s.operations[id]
+	if !found {
+		return nil, nil
+	}
+
+	return &status, nil
+}
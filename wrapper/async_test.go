@@ -0,0 +1,138 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportPetsRequest struct {
+	Path  struct{} `async:"true"`
+	Query struct {
+		Fail bool `json:"fail"`
+	}
+}
+
+func (r *exportPetsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	fail := r.Query.Fail
+
+	return Async(func(ctx context.Context, progress func(int)) (interface{}, error) {
+		progress(50)
+		if fail {
+			return nil, response.NewProblem(http.StatusConflict, "nothing to export")
+		}
+
+		return map[string]string{"url": "/exports/1.csv"}, nil
+	})
+}
+
+func TestAsync(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("AsyncResult", func() {
+		var store *MemoryOperationStore
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			store = NewMemoryOperationStore()
+			opts := &Options{Async: &AsyncOptions{Store: store, StatusPath: "/api/operations"}}
+
+			router = chi.NewRouter()
+			router.Post("/api/exports", WrapRequestWithOptions(&exportPetsRequest{}, opts))
+			router.Get("/api/operations/{ID}", WrapRequestWithOptions(&OperationStatusRequest{Store: store}, opts))
+		})
+
+		send := func(method string, target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+			return w
+		}
+
+		// polls the status until the operation is done
+		wait := func(location string) OperationStatus {
+			var status OperationStatus
+
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+				w := send("GET", location)
+				require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+				require.NoError(g, json.Unmarshal(w.Body.Bytes(), &status))
+
+				if (status.Status == OperationSucceeded) || (status.Status == OperationFailed) {
+					return status
+				}
+			}
+
+			g.Fail("the operation is still " + string(status.Status))
+			return status
+		}
+
+		g.It("should accept the operation and serve its status", func() {
+			w := send("POST", "/api/exports")
+			require.Equal(g, http.StatusAccepted, w.Code, w.Body.String())
+
+			var accepted OperationStatus
+			require.NoError(g, json.Unmarshal(w.Body.Bytes(), &accepted))
+			assert.Equal(g, OperationPending, accepted.Status)
+			assert.Equal(g, "exportPetsRequest", accepted.Operation)
+			assert.Equal(g, "/api/operations/"+accepted.ID, w.Header().Get("Location"))
+
+			status := wait(w.Header().Get("Location"))
+			assert.Equal(g, OperationSucceeded, status.Status)
+			assert.Equal(g, 100, status.Progress)
+			assert.JSONEq(g, `{"url":"/exports/1.csv"}`, string(status.Result))
+		})
+
+		g.It("should record the failures", func() {
+			w := send("POST", "/api/exports?fail=true")
+			require.Equal(g, http.StatusAccepted, w.Code)
+
+			status := wait(w.Header().Get("Location"))
+			assert.Equal(g, OperationFailed, status.Status)
+			assert.Equal(g, 50, status.Progress)
+			require.NotNil(g, status.Error)
+			assert.Equal(g, "nothing to export", status.Error.Detail)
+		})
+
+		g.It("should report the unknown operations", func() {
+			w := send("GET", "/api/operations/unknown")
+			assert.Equal(g, http.StatusNotFound, w.Code)
+		})
+
+		g.It("should need the Async option", func() {
+			router := chi.NewRouter()
+			router.Post("/exports", WrapRequest(&exportPetsRequest{}))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/exports", nil))
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Contains(g, w.Body.String(), "wrapper.Options.Async")
+		})
+
+		g.It("should hide the details of the errors without status", func() {
+			problem := operationProblem(errors.New("database is down"))
+			assert.Equal(g, http.StatusInternalServerError, problem.Status)
+			assert.Empty(g, problem.Detail)
+		})
+
+		g.It("should reject the invalid async tags", func() {
+			err := Verify(&struct {
+				exportPetsRequest
+				Path struct{} `async:"yes"`
+			}{}, "POST", "/exports")
+
+			if assert.Error(g, err) {
+				assert.Contains(g, err.Error(), `invalid async "yes" on Path`)
+			}
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// Authenticator can be implemented by request objects to authenticate the
+// request once it is bound (before the pre handlers), the returned context
+// replaces the one given to Handle and the errors are sent as a 401
+type Authenticator interface {
+	Authenticate(ctx context.Context, r *http.Request) (context.Context, error)
+}
+
+// AuthenticationError wraps the errors returned by Authenticate, it unwraps
+// to a 401 Problem so the error handlers report it as unauthorized
+type AuthenticationError struct {
+	Err error
+}
+
+func (e *AuthenticationError) Error() string {
+	return fmt.Sprintf("authentication failed: %s", e.Err)
+}
+
+func (e *AuthenticationError) Unwrap() error {
+	return response.NewProblem(http.StatusUnauthorized, "")
+}
+
+// StatusCode implements StatusCoder
+func (e *AuthenticationError) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// authenticate calls the Authenticate method of req if any, the errors
+// with their own status (Problem, Error, StatusCoder) are returned as is
+func authenticate(ctx context.Context, req interface{}, r *http.Request) (context.Context, error) {
+	authenticator, ok := req.(Authenticator)
+	if !ok {
+		return ctx, nil
+	}
+
+	newCtx, err := authenticator.Authenticate(ctx, r)
+	if err != nil {
+		var problem *response.Problem
+		var apiErr *response.Error
+		var coder StatusCoder
+		if errors.As(err, &problem) || errors.As(err, &apiErr) || errors.As(err, &coder) {
+			return ctx, err
+		}
+
+		return ctx, &AuthenticationError{Err: err}
+	}
+
+	if newCtx == nil {
+		return ctx, nil
+	}
+
+	return newCtx, nil
+}
@@ -0,0 +1,93 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type userKey struct{}
+
+type authenticatedRequest struct {
+	Path     struct{}
+	Response struct {
+		User string `json:"user"`
+	}
+}
+
+func (r *authenticatedRequest) Authenticate(ctx context.Context, req *http.Request) (context.Context, error) {
+	switch req.Header.Get("Authorization") {
+	case "":
+		return nil, errors.New("missing token")
+	case "Bearer banned":
+		return nil, response.NewProblem(http.StatusForbidden, "banned")
+	}
+
+	return context.WithValue(ctx, userKey{}, "john"), nil
+}
+
+func (r *authenticatedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.User, _ = ctx.Value(userKey{}).(string)
+	return nil
+}
+
+type authenticatedBaseRequest struct {
+	BaseRequest
+	authenticatedRequest
+}
+
+func TestAuthenticate(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Authenticator", func() {
+		send := func(obj HandlerInterface, authorization string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/", nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(obj)(w, req)
+			return w
+		}
+
+		g.It("should give the authenticated context to Handle", func() {
+			w := send(&authenticatedRequest{}, "Bearer token")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"user": "john"}`, w.Body.String())
+		})
+
+		g.It("should send a 401 when the authentication fails", func() {
+			w := send(&authenticatedRequest{}, "")
+
+			assert.Equal(g, http.StatusUnauthorized, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+		})
+
+		g.It("should give the errors with a status as is", func() {
+			w := send(&authenticatedRequest{}, "Bearer banned")
+			assert.Equal(g, http.StatusForbidden, w.Code)
+		})
+
+		g.It("should give the error to HandleError", func() {
+			w := send(&authenticatedBaseRequest{}, "")
+			assert.Equal(g, http.StatusUnauthorized, w.Code)
+		})
+
+		g.It("should wrap the errors", func() {
+			err := &AuthenticationError{Err: errors.New("missing token")}
+
+			assert.Equal(g, "authentication failed: missing token", err.Error())
+			assert.Equal(g, http.StatusUnauthorized, err.StatusCode())
+		})
+	})
+}
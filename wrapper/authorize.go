@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// AuthorizerFunc checks that the authenticated request can access an
+// operation requiring permissions, it is called after Authenticate with
+// its context and the errors are sent as a 403
+type AuthorizerFunc func(ctx context.Context, req interface{}, permissions []string) error
+
+// AuthorizationError wraps the errors returned by the Authorizer, it unwraps
+// to a 403 Problem so the error handlers report it as forbidden
+type AuthorizationError struct {
+	Permissions []string
+	Err         error
+}
+
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("missing permissions %s: %s", strings.Join(e.Permissions, ", "), e.Err)
+}
+
+func (e *AuthorizationError) Unwrap() error {
+	return response.NewProblem(http.StatusForbidden, "")
+}
+
+// StatusCode implements StatusCoder
+func (e *AuthorizationError) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// OperationPermissions returns the permissions required by the `permissions`
+// tag of the Path field of the request object typ (ex: `permissions:"users:read"`)
+func OperationPermissions(typ reflect.Type) []string {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return nil
+	}
+
+	var ret []string
+	for _, permission := range strings.Split(pathField.Tag.Get("permissions"), ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			ret = append(ret, permission)
+		}
+	}
+
+	return ret
+}
+
+// authorize runs the authorizer for the operations requiring permissions,
+// they are rejected without authorizer
+func authorize(ctx context.Context, authorizer AuthorizerFunc, permissions []string, req interface{}) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	if authorizer == nil {
+		return response.NewProblem(http.StatusInternalServerError, "no authorizer for the permissions "+strings.Join(permissions, ", "))
+	}
+
+	err := authorizer(ctx, req, permissions)
+	if err != nil {
+		var problem *response.Problem
+		var apiErr *response.Error
+		var coder StatusCoder
+		if errors.As(err, &problem) || errors.As(err, &apiErr) || errors.As(err, &coder) {
+			return err
+		}
+
+		return &AuthorizationError{Permissions: permissions, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type authorizedRequest struct {
+	authenticatedRequest
+	Path struct{} `permissions:"users:read, users:write"`
+}
+
+type publicPermissionsRequest struct {
+	Path     struct{}
+	Response struct{}
+}
+
+func (r *publicPermissionsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type emptyPermissionsRequest struct {
+	publicPermissionsRequest
+	Path struct{} `permissions:" , "`
+}
+
+func TestAuthorize(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Authorizer", func() {
+		var required []string
+
+		authorizer := func(ctx context.Context, req interface{}, permissions []string) error {
+			required = permissions
+			if user, _ := ctx.Value(userKey{}).(string); user != "john" {
+				return errors.New("not an admin")
+			}
+			return nil
+		}
+
+		send := func(obj HandlerInterface, opts *Options) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(obj, opts)(w, req)
+			return w
+		}
+
+		g.BeforeEach(func() {
+			required = nil
+		})
+
+		g.It("should parse the permissions", func() {
+			assert.Equal(g, []string{"users:read", "users:write"}, OperationPermissions(typeOf(&authorizedRequest{})))
+			assert.Nil(g, OperationPermissions(typeOf(&publicPermissionsRequest{})))
+		})
+
+		g.It("should reject empty permissions", func() {
+			err := Verify(&emptyPermissionsRequest{}, "GET", "/")
+			if assert.Error(g, err) {
+				assert.Contains(g, err.Error(), "empty permissions on Path")
+			}
+		})
+
+		g.It("should give the permissions and the authenticated context to the authorizer", func() {
+			w := send(&authorizedRequest{}, &Options{Authorizer: authorizer})
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, []string{"users:read", "users:write"}, required)
+		})
+
+		g.It("should send a 403 when the authorizer fails", func() {
+			w := send(&authorizedRequest{}, &Options{Authorizer: func(ctx context.Context, req interface{}, permissions []string) error {
+				return errors.New("not an admin")
+			}})
+
+			assert.Equal(g, http.StatusForbidden, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+		})
+
+		g.It("should not call the authorizer without permissions", func() {
+			w := send(&publicPermissionsRequest{}, &Options{Authorizer: authorizer})
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Nil(g, required)
+		})
+
+		g.It("should reject the operations with permissions without authorizer", func() {
+			w := send(&authorizedRequest{}, nil)
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+		})
+
+		g.It("should wrap the errors", func() {
+			err := &AuthorizationError{Permissions: []string{"users:read"}, Err: errors.New("not an admin")}
+
+			assert.Equal(g, "missing permissions users:read: not an admin", err.Error())
+			assert.Equal(g, http.StatusForbidden, err.StatusCode())
+		})
+	})
+}
+
+func typeOf(obj interface{}) reflect.Type {
+	return reflect.TypeOf(obj).Elem()
+}
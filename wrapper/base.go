@@ -0,0 +1,52 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// BaseRequest can be embedded in the request objects to handle their
+// errors, the body and the response keep the default json encoding
+type BaseRequest struct{}
+
+// StatusCoder can be implemented by errors to choose the status of the
+// response written by BaseRequest
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// HandleError writes problems and structured errors as is, the errors
+// implementing StatusCoder are sent as a structured error with their
+// message and the others as a 500 without their message
+func (b *BaseRequest) HandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	var problem *response.Problem
+	if errors.As(err, &problem) {
+		problem.Write(w)
+		return
+	}
+
+	var apiErr *response.Error
+	if errors.As(err, &apiErr) {
+		apiErr.Write(w)
+		return
+	}
+
+	var coder StatusCoder
+	if errors.As(err, &coder) {
+		status := coder.StatusCode()
+		response.NewError(status, statusErrorCode(status), err.Error()).Write(w)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	response.NewError(status, statusErrorCode(status), http.StatusText(status)).Write(w)
+}
+
+// statusErrorCode returns the code of a status (ex: "not_found")
+func statusErrorCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,101 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type baseRequestTestError struct{}
+
+func (e *baseRequestTestError) Error() string   { return "too many pets" }
+func (e *baseRequestTestError) StatusCode() int { return http.StatusTooManyRequests }
+
+type baseRequest struct {
+	BaseRequest
+	Path  struct{}
+	Query struct {
+		Fail string
+	}
+	Response struct {
+		Name string `json:"name"`
+	}
+}
+
+func (r *baseRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	switch r.Query.Fail {
+	case "problem":
+		return response.NewProblem(http.StatusConflict, "already exists")
+	case "error":
+		return response.NewError(http.StatusNotFound, "pet_not_found", "no pet with this id")
+	case "status":
+		return &baseRequestTestError{}
+	case "other":
+		return errors.New("database is down")
+	}
+
+	r.Response.Name = "rex"
+	return nil
+}
+
+func TestBaseRequest(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("BaseRequest", func() {
+		serve := func(query string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("GET", "/?"+query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&baseRequest{})(w, r)
+			return w
+		}
+
+		g.It("should be a valid request object", func() {
+			require.NoError(g, Verify(&baseRequest{}, "GET", "/"))
+		})
+
+		g.It("should keep the default json response", func() {
+			w := serve("")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"name": "rex"}`, w.Body.String())
+		})
+
+		g.It("should write the problems", func() {
+			w := serve("fail=problem")
+
+			assert.Equal(g, http.StatusConflict, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should write the structured errors", func() {
+			w := serve("fail=error")
+
+			assert.Equal(g, http.StatusNotFound, w.Code)
+			assert.JSONEq(g, `{"status": 404, "code": "pet_not_found", "message": "no pet with this id"}`, w.Body.String())
+		})
+
+		g.It("should use the status of the errors implementing StatusCoder", func() {
+			w := serve("fail=status")
+
+			assert.Equal(g, http.StatusTooManyRequests, w.Code)
+			assert.JSONEq(g, `{"status": 429, "code": "too_many_requests", "message": "too many pets"}`, w.Body.String())
+		})
+
+		g.It("should hide the message of the other errors", func() {
+			w := serve("fail=other")
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.JSONEq(g, `{"status": 500, "code": "internal_server_error", "message": "Internal Server Error"}`, w.Body.String())
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+)
+
+// MaxBatchSize is the maximum number of requests of a batch
+const MaxBatchSize = 50
+
+// BatchHeaders are the headers of the batch request given to each of its
+// requests (authentication, negotiation and tracing), it can be extended
+// (ex: with an api key header), the others (ex: Accept-Encoding,
+// Idempotency-Key, If-Match) only apply to the batch itself
+var BatchHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Accept",
+	"Accept-Language",
+	"Traceparent",
+	"Tracestate",
+	"Baggage",
+	response.RequestIDHeader,
+}
+
+// BatchItem is a request of a batch
+type BatchItem struct {
+	Method string `json:"method" chipi:"required" example:"GET"`
+	// path of the request with its query (ex: /pets/1?fields=name)
+	Path string `json:"path" chipi:"required" example:"/pets/1"`
+	// headers added to the ones of the batch request
+	Header map[string]string `json:"headers,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResult is the response to a request of a batch
+type BatchResult struct {
+	Status int               `json:"status" example:"200"`
+	Header map[string]string `json:"headers,omitempty"`
+	// json bodies are sent as is, the others as a string
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchRequest can be mounted (ex: on POST /batch) to send several requests
+// at once, they are routed in order and in-process by the router serving the
+// batch with the BatchHeaders of the batch request and their responses are
+// returned in the same order
+type BatchRequest struct {
+	Path     struct{}
+	Body     []BatchItem
+	Response []BatchResult
+}
+
+func (req *BatchRequest) Handle(ctx context.Context, r *http.Request, w http.ResponseWriter) error {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return fmt.Errorf("batch requests must be served by a chi router")
+	}
+
+	router, ok := rctx.Routes.(http.Handler)
+	if !ok {
+		return fmt.Errorf("batch requests must be served by a chi router")
+	}
+
+	if len(req.Body) > MaxBatchSize {
+		return response.NewProblem(http.StatusBadRequest, fmt.Sprintf("a batch contains at most %d requests", MaxBatchSize))
+	}
+
+	for i, item := range req.Body {
+		switch {
+		case !isValidMethod(strings.ToUpper(item.Method)):
+			return response.NewProblem(http.StatusBadRequest, fmt.Sprintf("request %d: unknown method %q", i, item.Method))
+		case !strings.HasPrefix(item.Path, "/"):
+			return response.NewProblem(http.StatusBadRequest, fmt.Sprintf("request %d: path must start with /", i))
+		case strings.SplitN(item.Path, "?", 2)[0] == r.URL.Path:
+			return response.NewProblem(http.StatusBadRequest, fmt.Sprintf("request %d: batches cannot be nested", i))
+		}
+	}
+
+	req.Response = make([]BatchResult, 0, len(req.Body))
+	for _, item := range req.Body {
+		result, err := serveBatchItem(ctx, router, r, item)
+		if err != nil {
+			return err
+		}
+
+		req.Response = append(req.Response, result)
+	}
+
+	return nil
+}
+
+// serveBatchItem routes item with the BatchHeaders of the batch request r
+func serveBatchItem(ctx context.Context, router http.Handler, r *http.Request, item BatchItem) (BatchResult, error) {
+	// the route context of the batch must not be reused by the router
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, nil)
+
+	sub, err := http.NewRequestWithContext(ctx, strings.ToUpper(item.Method), item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		return BatchResult{}, response.NewProblem(http.StatusBadRequest, err.Error())
+	}
+
+	for _, name := range BatchHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			sub.Header[http.CanonicalHeaderKey(name)] = append([]string{}, values...)
+		}
+	}
+	if len(item.Body) > 0 {
+		sub.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range item.Header {
+		sub.Header.Set(name, value)
+	}
+	sub.Host = r.Host
+	sub.RemoteAddr = r.RemoteAddr
+
+	w := &batchWriter{header: http.Header{}}
+	router.ServeHTTP(w, sub)
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	result := BatchResult{Status: w.status}
+	for name := range w.header {
+		if result.Header == nil {
+			result.Header = map[string]string{}
+		}
+		result.Header[name] = w.header.Get(name)
+	}
+
+	body := bytes.TrimSpace(w.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case strings.Contains(w.header.Get("Content-Type"), "json") && json.Valid(body):
+		result.Body = body
+	default:
+		result.Body, err = json.Marshal(string(body))
+		if err != nil {
+			return BatchResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// batchWriter keeps the response of a request of a batch
+type batchWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *batchWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
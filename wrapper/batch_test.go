@@ -0,0 +1,155 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchPetRequest struct {
+	Path struct {
+		ID int
+	}
+	Header struct {
+		Authorization string
+	}
+	Response struct {
+		ID    int    `json:"id"`
+		Token string `json:"token"`
+	}
+}
+
+func (r *batchPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.ID = r.Path.ID
+	r.Response.Token = r.Header.Authorization
+	return nil
+}
+
+type batchCreatePetRequest struct {
+	Path struct{}
+	Body struct {
+		Name string `json:"name"`
+	}
+}
+
+func (r *batchCreatePetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	_, err := w.Write([]byte("created " + r.Body.Name))
+	return err
+}
+
+func TestBatch(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("BatchRequest", func() {
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			router = chi.NewRouter()
+			router.Route("/api", func(r chi.Router) {
+				r.Get("/pets/{ID}", WrapRequest(&batchPetRequest{}))
+				r.Post("/pets", WrapRequest(&batchCreatePetRequest{}))
+				r.Get("/gzip/{ID}", WrapRequestWithOptions(&batchPetRequest{}, &Options{Compression: &CompressionOptions{MinSize: 1}}))
+				r.Post("/idempotent", WrapRequestWithOptions(&batchCreatePetRequest{}, &Options{Idempotency: &IdempotencyOptions{}}))
+				r.Post("/batch", WrapRequestWithOptions(&BatchRequest{}, nil))
+			})
+		})
+
+		sendWithHeader := func(items []BatchItem, header map[string]string) *httptest.ResponseRecorder {
+			data, err := json.Marshal(items)
+			require.NoError(g, err)
+
+			req := httptest.NewRequest("POST", "/api/batch", bytes.NewReader(data))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "secret")
+			for name, value := range header {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		send := func(items []BatchItem) *httptest.ResponseRecorder {
+			return sendWithHeader(items, nil)
+		}
+
+		results := func(w *httptest.ResponseRecorder) []BatchResult {
+			require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+
+			var ret []BatchResult
+			require.NoError(g, json.Unmarshal(w.Body.Bytes(), &ret))
+			return ret
+		}
+
+		g.It("should route the requests in order", func() {
+			w := send([]BatchItem{
+				{Method: "GET", Path: "/api/pets/3"},
+				{Method: "post", Path: "/api/pets", Body: json.RawMessage(`{"name":"rex"}`)},
+				{Method: "GET", Path: "/api/pets/4", Header: map[string]string{"Authorization": "other"}},
+				{Method: "GET", Path: "/api/unknown"},
+			})
+			require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+
+			var results []BatchResult
+			require.NoError(g, json.Unmarshal(w.Body.Bytes(), &results))
+			require.Len(g, results, 4)
+
+			assert.Equal(g, http.StatusOK, results[0].Status)
+			assert.JSONEq(g, `{"id":3,"token":"secret"}`, string(results[0].Body))
+
+			assert.Equal(g, http.StatusCreated, results[1].Status)
+			assert.Equal(g, `"created rex"`, string(results[1].Body))
+			assert.Equal(g, "text/plain", results[1].Header["Content-Type"])
+
+			assert.JSONEq(g, `{"id":4,"token":"other"}`, string(results[2].Body))
+			assert.Equal(g, http.StatusNotFound, results[3].Status)
+		})
+
+		g.It("should not compress the responses of the requests", func() {
+			ret := results(sendWithHeader([]BatchItem{
+				{Method: "GET", Path: "/api/gzip/5"},
+			}, map[string]string{"Accept-Encoding": "gzip"}))
+			require.Len(g, ret, 1)
+
+			assert.Equal(g, http.StatusOK, ret[0].Status)
+			assert.Empty(g, ret[0].Header["Content-Encoding"])
+			assert.JSONEq(g, `{"id":5,"token":"secret"}`, string(ret[0].Body))
+		})
+
+		g.It("should not share the idempotency key of the batch", func() {
+			ret := results(sendWithHeader([]BatchItem{
+				{Method: "POST", Path: "/api/idempotent", Body: json.RawMessage(`{"name":"a"}`)},
+				{Method: "POST", Path: "/api/idempotent", Body: json.RawMessage(`{"name":"b"}`)},
+			}, map[string]string{IdempotencyKeyHeader: "key-1", "If-Match": `"v1"`}))
+			require.Len(g, ret, 2)
+
+			assert.Equal(g, `"created a"`, string(ret[0].Body))
+			assert.Equal(g, `"created b"`, string(ret[1].Body))
+			assert.Empty(g, ret[1].Header["Idempotent-Replayed"])
+		})
+
+		g.It("should reject the invalid batches", func() {
+			w := send([]BatchItem{{Method: "FETCH", Path: "/api/pets/1"}})
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), `unknown method \"FETCH\"`)
+
+			w = send([]BatchItem{{Method: "POST", Path: "/api/batch"}})
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), "batches cannot be nested")
+
+			w = send(make([]BatchItem, MaxBatchSize+1))
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+	})
+}
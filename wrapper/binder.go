@@ -0,0 +1,245 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestFiller is implemented by the code generated by chipi-gen, the
+// parameters are then bound without reflection
+type RequestFiller interface {
+	FillFromRequest(b *Binder)
+}
+
+// Param describes how a field of a request section is bound
+type Param struct {
+	// Name is the name of the parameter in the request
+	Name string
+	// Path identifies the parameter in errors and traces
+	Path string
+
+	Required  bool
+	Sensitive bool
+
+	Default    string
+	HasDefault bool
+
+	// Enum lists the accepted values, any value is accepted if empty
+	Enum []string
+
+	// Fields lists the fields accepted by a Sort or a Filter (`fields` tag),
+	// any field is accepted if nil
+	Fields []string
+
+	// Separator splits the items of the slices, set by the spaceDelimited
+	// and pipeDelimited styles of the query parameters
+	Separator string
+}
+
+// _styleSeparators are the separators of the query array styles
+var _styleSeparators = map[string]string{
+	"spaceDelimited": " ",
+	"pipeDelimited":  "|",
+}
+
+// DescribeParam returns how the field f of section (Path, Query, Header
+// or Cookie) is bound
+func DescribeParam(section string, f reflect.StructField) Param {
+	p := Param{
+		Name:      f.Name,
+		Path:      "request." + strings.ToLower(section) + "." + f.Name,
+		Required:  isRequiredField(f),
+		Sensitive: isSensitiveField(f),
+		Enum:      paramEnum(f),
+		Fields:    paramFields(f),
+	}
+	p.Default, p.HasDefault = f.Tag.Lookup("default")
+
+	switch section {
+	case "Path":
+		// path parameters are always present when the route matches
+		p.Required = false
+		p.Default, p.HasDefault = "", false
+	case "Query":
+		p.Name = QueryParamName(f)
+		p.Path = "request.query." + p.Name
+		p.Separator = _styleSeparators[f.Tag.Get("style")]
+	case "Header":
+		if name := f.Tag.Get("name"); name != "" {
+			p.Name = name
+		}
+	case "Cookie":
+		p.Name = CookieParamName(f)
+	}
+
+	return p
+}
+
+// Binder gives the request values to the generated FillFromRequest methods
+// and collects the parsing errors
+type Binder struct {
+	r             *http.Request
+	ctx           context.Context
+	routeContext  *chi.Context
+	query         url.Values
+	parsingErrors map[string]string
+	failed        bool
+}
+
+func newBinder(r *http.Request, parsingErrors map[string]string) *Binder {
+	return &Binder{
+		r:             r,
+		ctx:           r.Context(),
+		routeContext:  chi.RouteContext(r.Context()),
+		query:         r.URL.Query(),
+		parsingErrors: parsingErrors,
+	}
+}
+
+// PathValues returns the value of a path parameter, nil if absent
+func (b *Binder) PathValues(name string) []string {
+	if b.routeContext == nil {
+		return nil
+	}
+
+	for i, key := range b.routeContext.URLParams.Keys {
+		if key == name {
+			return []string{b.routeContext.URLParams.Values[i]}
+		}
+	}
+
+	return nil
+}
+
+// QueryValues returns the values of a query parameter
+func (b *Binder) QueryValues(name string) []string {
+	return b.query[name]
+}
+
+// HeaderValues returns the value of a header, nil if absent or empty
+func (b *Binder) HeaderValues(name string) []string {
+	if value := b.r.Header.Get(name); value != "" {
+		return []string{value}
+	}
+
+	return nil
+}
+
+// CookieValues returns the value of a cookie, nil if absent or empty
+func (b *Binder) CookieValues(name string) []string {
+	if cookie, err := b.r.Cookie(name); (err == nil) && (cookie.Value != "") {
+		return []string{cookie.Value}
+	}
+
+	return nil
+}
+
+func (b *Binder) fail(path string, message string) {
+	b.parsingErrors[path] = message
+	b.failed = true
+}
+
+// Bind parses values into target with the same rules as the reflection
+// based binding, absent values get the default value or are reported if required
+func Bind[V any](b *Binder, target *V, values []string, p Param) {
+	var value string
+
+	switch {
+	case len(values) == 1:
+		value = values[0]
+	case len(values) > 1:
+		value = delimitedQueryFieldValue(reflect.TypeOf(target).Elem(), values, p.Separator)
+	case p.HasDefault:
+		value = p.Default
+	case p.Required:
+		b.fail(p.Path, requiredParameterMessage)
+		return
+	default:
+		return
+	}
+
+	err := bindValue(target, value, p.Separator)
+	if err != nil {
+		b.fail(p.Path, err.Error())
+		return
+	}
+
+	// the generated code only knows the enums declared by tags
+	enum := p.Enum
+	if enum == nil {
+		enum = typeEnum(reflect.TypeOf(target).Elem())
+	}
+
+	err = checkEnum(reflect.ValueOf(target).Elem(), enum)
+	if err == nil {
+		err = checkFields(reflect.ValueOf(target).Elem(), p.Fields)
+	}
+	if err != nil {
+		b.fail(p.Path, err.Error())
+		return
+	}
+
+	if p.Sensitive {
+		value = RedactedValue
+	}
+
+	setParamAttribute(b.ctx, p.Path, value)
+}
+
+// bindValue handles the common types directly, convertValue is used for the others
+func bindValue[V any](target *V, value string, separator string) error {
+	switch t := any(target).(type) {
+	case *string:
+		*t = strings.Trim(value, `"`)
+
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*t = v
+
+	case *int:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = int(n)
+
+	case *int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = n
+
+	case *uint:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = uint(n)
+
+	case *float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*t = x
+
+	default:
+		v, err := convertDelimitedValue(reflect.TypeOf(target).Elem(), value, separator)
+		if err != nil {
+			return err
+		}
+		reflect.ValueOf(target).Elem().Set(v)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reflectedBindingRequest struct {
+	Path struct {
+		Id int
+	}
+
+	Query struct {
+		Count int `default:"5"`
+		Tags  []string
+		Name  string `chipi:"required"`
+	}
+
+	Header struct {
+		ApiKey string `name:"X-Api-Key" chipi:"sensitive"`
+	}
+
+	Cookie struct {
+		Session string
+	}
+}
+
+// generatedBindingRequest has the same fields with the method chipi-gen would write
+type generatedBindingRequest reflectedBindingRequest
+
+var _filled int
+
+func (req *generatedBindingRequest) FillFromRequest(b *Binder) {
+	_filled++
+	Bind(b, &req.Path.Id, b.PathValues("Id"), Param{Name: "Id", Path: "request.path.Id"})
+	Bind(b, &req.Query.Count, b.QueryValues("count"), Param{Name: "count", Path: "request.query.count", Default: "5", HasDefault: true})
+	Bind(b, &req.Query.Tags, b.QueryValues("tags"), Param{Name: "tags", Path: "request.query.tags"})
+	Bind(b, &req.Query.Name, b.QueryValues("name"), Param{Name: "name", Path: "request.query.name", Required: true})
+	Bind(b, &req.Header.ApiKey, b.HeaderValues("X-Api-Key"), Param{Name: "X-Api-Key", Path: "request.header.ApiKey", Sensitive: true})
+	Bind(b, &req.Cookie.Session, b.CookieValues("Session"), Param{Name: "Session", Path: "request.cookie.Session"})
+}
+
+func TestBinder(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Binder", func() {
+		newRequest := func(query string) *http.Request {
+			r := httptest.NewRequest("GET", "/pets/12?"+query, nil)
+			r.Header.Set("X-Api-Key", "secret")
+			r.AddCookie(&http.Cookie{Name: "Session", Value: "abc"})
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("Id", "12")
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		}
+
+		bind := func(obj interface{}, query string) (interface{}, map[string]string) {
+			parsingErrors := map[string]string{}
+			ret, _, _ := createFilledRequestObject(newRequest(query), obj, parsingErrors)
+			return ret.Interface(), parsingErrors
+		}
+
+		g.BeforeEach(func() {
+			_filled = 0
+		})
+
+		g.It("should prefer the generated binding", func() {
+			obj, errs := bind(&generatedBindingRequest{}, "name=rex&tags=a&tags=b")
+			require.Empty(g, errs)
+			assert.Equal(g, 1, _filled)
+
+			req := obj.(*generatedBindingRequest)
+			assert.Equal(g, 12, req.Path.Id)
+			assert.Equal(g, 5, req.Query.Count)
+			assert.Equal(g, []string{"a", "b"}, req.Query.Tags)
+			assert.Equal(g, "rex", req.Query.Name)
+			assert.Equal(g, "secret", req.Header.ApiKey)
+			assert.Equal(g, "abc", req.Cookie.Session)
+		})
+
+		g.It("should bind like the reflection based binding", func() {
+			for _, query := range []string{"name=rex&tags=a&tags=b", "name=rex&tags=a,b&count=3", "count=3", "name=rex&count=abc"} {
+				generated, generatedErrs := bind(&generatedBindingRequest{}, query)
+				reflected, reflectedErrs := bind(&reflectedBindingRequest{}, query)
+
+				assert.Equal(g, reflectedErrs, generatedErrs, query)
+				assert.Equal(g, *reflected.(*reflectedBindingRequest), reflectedBindingRequest(*generated.(*generatedBindingRequest)), query)
+			}
+		})
+
+		g.It("should describe the parameters like the binding plan", func() {
+			plan := planFor(reflect.TypeOf(reflectedBindingRequest{}))
+
+			for _, param := range plan.query.params {
+				p := DescribeParam("Query", param.field)
+				assert.Equal(g, param.name, p.Name)
+				assert.Equal(g, param.path, p.Path)
+				assert.Equal(g, param.required, p.Required)
+			}
+
+			p := DescribeParam("Header", plan.header.params[0].field)
+			assert.Equal(g, Param{Name: "X-Api-Key", Path: "request.header.ApiKey", Sensitive: true}, p)
+		})
+	})
+}
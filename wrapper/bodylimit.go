@@ -0,0 +1,93 @@
+package wrapper
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// bodyTooLargeMessage is the detail of the 413 problems
+const bodyTooLargeMessage = "request body is too large"
+
+// MaxBodyBytes returns the limit declared with the `max-bytes` tag of the
+// Body field of the request object typ, 0 if there is none
+func MaxBodyBytes(typ reflect.Type) int64 {
+	bodyField, found := typ.FieldByName("Body")
+	if !found {
+		return 0
+	}
+
+	// invalid limits are reported by Verify
+	limit, err := parseMaxBytes(bodyField)
+	if err != nil {
+		return 0
+	}
+
+	return limit
+}
+
+func parseMaxBytes(f reflect.StructField) (int64, error) {
+	value, found := f.Tag.Lookup("max-bytes")
+	if !found {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if (err == nil) && (limit <= 0) {
+		err = strconv.ErrRange
+	}
+
+	return limit, err
+}
+
+// limitedBody counts the bytes read through http.MaxBytesReader to know
+// if its error comes from the limit
+type limitedBody struct {
+	io.ReadCloser
+	read     int64
+	limit    int64
+	exceeded bool
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	l.read += int64(n)
+	if (err != nil) && (err != io.EOF) && (l.read >= l.limit) {
+		l.exceeded = true
+	}
+
+	return n, err
+}
+
+// limitBody limits the body of r to limit bytes, the bodies declaring a
+// larger Content-Length are rejected before being read
+func limitBody(w http.ResponseWriter, r *http.Request, limit int64) *limitedBody {
+	if (r.Body == nil) || (r.Body == http.NoBody) {
+		return nil
+	}
+
+	body := &limitedBody{
+		ReadCloser: http.MaxBytesReader(w, r.Body, limit),
+		limit:      limit,
+		exceeded:   r.ContentLength > limit,
+	}
+	r.Body = body
+
+	return body
+}
+
+// bodyTooLarge returns the problem sent when the body exceeded one of the
+// limits, nil otherwise
+func bodyTooLarge(limited *limitedBody, decompressed *decompressedBody) *response.Problem {
+	switch {
+	case (limited != nil) && limited.exceeded:
+		return response.NewProblem(http.StatusRequestEntityTooLarge, bodyTooLargeMessage)
+	case (decompressed != nil) && decompressed.exceeded:
+		return decompressionProblem(ErrDecompressedBodyTooLarge)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type limitedBodyRequest struct {
+	Path struct{}
+	Body struct {
+		Name string `json:"name"`
+	} `max-bytes:"32"`
+	Response struct {
+		Name string `json:"name"`
+	}
+}
+
+func (r *limitedBodyRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Name = r.Body.Name
+	return nil
+}
+
+type invalidLimitRequest struct {
+	Path struct{}
+	Body struct {
+		Name string `json:"name"`
+	} `max-bytes:"1MB"`
+}
+
+func (r *invalidLimitRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestBodyLimit(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("MaxBodyBytes", func() {
+		send := func(obj HandlerInterface, options *Options, body string, chunked bool) *httptest.ResponseRecorder {
+			var reader io.Reader = strings.NewReader(body)
+			if chunked {
+				// hides the length of the body
+				reader = io.MultiReader(reader)
+			}
+
+			req := httptest.NewRequest("POST", "/", reader)
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(obj, options)(w, req)
+			return w
+		}
+
+		large := `{"name": "` + strings.Repeat("a", 100) + `"}`
+
+		g.It("should accept the bodies within the limit", func() {
+			w := send(&decompressedRequest{}, &Options{MaxBodyBytes: 64}, `{"name": "rex"}`, false)
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"name": "rex"}`, w.Body.String())
+		})
+
+		g.It("should reject the bodies declaring a larger length", func() {
+			w := send(&decompressedRequest{}, &Options{MaxBodyBytes: 64}, large, false)
+
+			assert.Equal(g, http.StatusRequestEntityTooLarge, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should reject the larger bodies sent without length", func() {
+			w := send(&decompressedRequest{}, &Options{MaxBodyBytes: 64}, large, true)
+			assert.Equal(g, http.StatusRequestEntityTooLarge, w.Code)
+		})
+
+		g.It("should not limit the bodies by default", func() {
+			w := send(&decompressedRequest{}, nil, large, false)
+			assert.Equal(g, http.StatusOK, w.Code)
+		})
+
+		g.It("should use the limit of the route before the global one", func() {
+			w := send(&limitedBodyRequest{}, &Options{MaxBodyBytes: 1024}, `{"name": "a very long name for a pet"}`, true)
+			assert.Equal(g, http.StatusRequestEntityTooLarge, w.Code)
+
+			w = send(&limitedBodyRequest{}, nil, `{"name": "rex"}`, false)
+			assert.Equal(g, http.StatusOK, w.Code)
+		})
+
+		g.It("should report the invalid limits", func() {
+			err := Verify(&invalidLimitRequest{}, "POST", "/")
+			assert.Error(g, err)
+			assert.Contains(g, err.Error(), `invalid max-bytes "1MB"`)
+		})
+	})
+}
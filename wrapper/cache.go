@@ -0,0 +1,270 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheTTL returns how long the responses of the request object typ can be
+// cached, declared with the `cache` tag of its Path field (ex: `cache:"5m"`),
+// 0 if there is none
+func CacheTTL(typ reflect.Type) time.Duration {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return 0
+	}
+
+	// invalid durations are reported by Verify
+	ttl, err := parseCacheTTL(pathField)
+	if err != nil {
+		return 0
+	}
+
+	return ttl
+}
+
+func parseCacheTTL(f reflect.StructField) (time.Duration, error) {
+	value, found := f.Tag.Lookup("cache")
+	if !found {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if (err == nil) && (ttl < time.Second) {
+		err = fmt.Errorf("cache duration must be at least 1s")
+	}
+
+	return ttl, err
+}
+
+// CacheControl returns the Cache-Control header of the successful responses
+// of obj, the responses of the authenticated requests are private
+func CacheControl(obj interface{}) string {
+	ttl := CacheTTL(reflect.TypeOf(obj).Elem())
+	if ttl == 0 {
+		return ""
+	}
+
+	visibility := "public"
+	if _, ok := obj.(Authenticator); ok {
+		visibility = "private"
+	}
+
+	return visibility + ", max-age=" + strconv.Itoa(int(ttl/time.Second))
+}
+
+// operationKey identifies the request object type typ in the shared stores,
+// the types of different packages can have the same name
+func operationKey(typ reflect.Type) string {
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+// ResponseCache keeps the responses of the GET requests, it can be shared by
+// the instances of the api (ex: a Redis implementation)
+type ResponseCache interface {
+	// Get returns the response cached for key, nil if there is none
+	Get(ctx context.Context, key string) (*RecordedResponse, error)
+
+	// Set caches resp for ttl
+	Set(ctx context.Context, key string, resp *RecordedResponse, ttl time.Duration) error
+}
+
+// CacheOptions enable the caching of the responses of the operations with
+// a `cache` tag
+type CacheOptions struct {
+	// Store keeps the responses (default: a MemoryResponseCache)
+	Store ResponseCache
+
+	// Key scopes the cached responses by client (ex: the authenticated user
+	// of ctx), they are only keyed by operation and parameters by default so
+	// the responses of the request objects implementing Authenticator are not
+	// stored without it
+	Key func(ctx context.Context, r *http.Request) string
+}
+
+// responseCache serves the cached responses of an operation
+type responseCache struct {
+	operation    string
+	ttl          time.Duration
+	cacheControl string
+
+	// nil if the responses are only given a Cache-Control header
+	store ResponseCache
+	scope func(ctx context.Context, r *http.Request) string
+	// set if the Response is filtered by roles
+	roles RolesFunc
+}
+
+// newResponseCache returns nil if the responses of obj cannot be cached
+func newResponseCache(obj interface{}, opts *CacheOptions, roles RolesFunc) *responseCache {
+	typ := reflect.TypeOf(obj).Elem()
+
+	ttl := CacheTTL(typ)
+	if ttl == 0 {
+		return nil
+	}
+
+	ret := &responseCache{operation: operationKey(typ), ttl: ttl, cacheControl: CacheControl(obj)}
+	if opts != nil {
+		ret.store = opts.Store
+		ret.scope = opts.Key
+		if ret.store == nil {
+			ret.store = NewMemoryResponseCache()
+		}
+
+		// the responses of a client would be sent to the others
+		if _, ok := obj.(Authenticator); ok && (ret.scope == nil) {
+			ret.store = nil
+		}
+	}
+
+	if responseField, found := typ.FieldByName("Response"); found && (roles != nil) && hasRoleFields(responseField.Type) {
+		ret.roles = roles
+	}
+
+	return ret
+}
+
+// key identifies the responses by the bound Path and Query of the request
+// object req, by media type and by the roles filtering the Response
+func (c *responseCache) key(ctx context.Context, r *http.Request, req reflect.Value, mediaType string) (string, error) {
+	parts := []string{c.operation, mediaType}
+	if c.scope != nil {
+		parts = append(parts, c.scope(ctx, r))
+	}
+
+	if c.roles != nil {
+		roles := append([]string{}, c.roles(ctx)...)
+		sort.Strings(roles)
+		parts = append(parts, strings.Join(roles, ","))
+	}
+
+	for _, section := range []string{"Path", "Query"} {
+		value := req.FieldByName(section)
+		if !value.IsValid() {
+			continue
+		}
+
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(data))
+	}
+
+	return strings.Join(parts, ":"), nil
+}
+
+// start sends the cached response of the request object req for the context
+// ctx given to Handle, done is true once the response is written
+func (c *responseCache) start(ctx context.Context, w http.ResponseWriter, r *http.Request, req reflect.Value, mediaType string) (writer *cachedWriter, done bool, err error) {
+	writer = &cachedWriter{responseRecorder: newResponseRecorder(w), cache: c}
+	writer.onHeader = func(status int) {
+		if (status == http.StatusOK) && (writer.Header().Get("Cache-Control") == "") {
+			writer.Header().Set("Cache-Control", c.cacheControl)
+		}
+	}
+
+	if c.store == nil {
+		return writer, false, nil
+	}
+
+	writer.key, err = c.key(ctx, r, req, mediaType)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the clients can ask for a fresh response
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		return writer, false, nil
+	}
+
+	cached, err := c.store.Get(ctx, writer.key)
+	if err != nil {
+		return writer, false, err
+	}
+
+	if cached != nil {
+		cached.write(w)
+		return nil, true, nil
+	}
+
+	return writer, false, nil
+}
+
+// cachedWriter records the response of a GET request
+type cachedWriter struct {
+	*responseRecorder
+	cache *responseCache
+	key   string
+}
+
+// finish caches the successful responses
+func (w *cachedWriter) finish(ctx context.Context, method string) error {
+	recorded := w.recorded()
+	if (w.cache.store == nil) || (w.key == "") || (method != http.MethodGet) || (recorded == nil) || (recorded.Status != http.StatusOK) {
+		return nil
+	}
+
+	return w.cache.store.Set(ctx, w.key, recorded, w.cache.ttl)
+}
+
+// MemoryResponseCache keeps the responses in memory, they are only served
+// by the current process
+type MemoryResponseCache struct {
+	mu        sync.Mutex
+	entries   map[string]*cacheEntry
+	nextSweep time.Time
+}
+
+type cacheEntry struct {
+	response *RecordedResponse
+	expires  time.Time
+}
+
+// NewMemoryResponseCache returns an empty cache
+func NewMemoryResponseCache() *MemoryResponseCache {
+	return &MemoryResponseCache{entries: map[string]*cacheEntry{}}
+}
+
+// Get implements ResponseCache
+func (c *MemoryResponseCache) Get(ctx context.Context, key string) (*RecordedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found || !time.Now().Before(entry.expires) {
+		return nil, nil
+	}
+
+	return entry.response, nil
+}
+
+// Set implements ResponseCache
+func (c *MemoryResponseCache) Set(ctx context.Context, key string, resp *RecordedResponse, ttl time.Duration) error {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the expired responses are removed from time to time
+	if now.After(c.nextSweep) {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(time.Minute)
+	}
+
+	c.entries[key] = &cacheEntry{response: resp, expires: now.Add(ttl)}
+	return nil
+}
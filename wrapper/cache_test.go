@@ -0,0 +1,234 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/internal/testdata/pet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _cachedCalls int32
+
+type cachedPetRequest struct {
+	Path struct {
+		Id int
+	} `cache:"5m"`
+	Query struct {
+		Missing bool `json:"missing"`
+	}
+	Response struct {
+		Id    int `json:"id"`
+		Calls int `json:"calls"`
+	}
+}
+
+func (r *cachedPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	calls := atomic.AddInt32(&_cachedCalls, 1)
+	if r.Query.Missing {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+
+	w.Header().Set("X-Calls", "counted")
+	r.Response.Id = r.Path.Id
+	r.Response.Calls = int(calls)
+	return nil
+}
+
+type authenticatedCachedRequest struct {
+	cachedPetRequest
+}
+
+func (r *authenticatedCachedRequest) Authenticate(ctx context.Context, req *http.Request) (context.Context, error) {
+	return ctx, nil
+}
+
+type rolesCachedRequest struct {
+	Path struct {
+		Id int
+	} `cache:"5m"`
+	Header struct {
+		Role string `name:"X-Role"`
+	}
+	Response struct {
+		Calls   int `json:"calls"`
+		Balance int `json:"balance" roles:"admin"`
+	}
+}
+
+func (r *rolesCachedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Calls = int(atomic.AddInt32(&_cachedCalls, 1))
+	r.Response.Balance = 42
+	return nil
+}
+
+// same name as pet.Pet
+type Pet struct{}
+
+type invalidCacheRequest struct {
+	Path struct{} `cache:"100ms"`
+}
+
+func (r *invalidCacheRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Cache", func() {
+		var handler http.HandlerFunc
+
+		send := func(target string, id string, header http.Header) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			for name, values := range header {
+				req.Header[name] = values
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("Id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+
+		g.BeforeEach(func() {
+			atomic.StoreInt32(&_cachedCalls, 0)
+			handler = WrapRequestWithOptions(&cachedPetRequest{}, &Options{
+				Cache: &CacheOptions{},
+			})
+		})
+
+		g.It("should parse the ttl", func() {
+			assert.Equal(g, 5*time.Minute, CacheTTL(reflect.TypeOf(cachedPetRequest{})))
+			assert.Equal(g, time.Duration(0), CacheTTL(reflect.TypeOf(slowRequest{})))
+			assert.Equal(g, "public, max-age=300", CacheControl(&cachedPetRequest{}))
+			assert.Equal(g, "private, max-age=300", CacheControl(&authenticatedCachedRequest{}))
+		})
+
+		g.It("should serve the cached responses", func() {
+			first := send("/", "1", nil)
+			require.Equal(g, http.StatusOK, first.Code)
+			assert.JSONEq(g, `{"id": 1, "calls": 1}`, first.Body.String())
+			assert.Equal(g, "public, max-age=300", first.Header().Get("Cache-Control"))
+
+			second := send("/", "1", nil)
+			require.Equal(g, http.StatusOK, second.Code)
+			assert.JSONEq(g, `{"id": 1, "calls": 1}`, second.Body.String())
+			assert.Equal(g, "public, max-age=300", second.Header().Get("Cache-Control"))
+			assert.Equal(g, "counted", second.Header().Get("X-Calls"))
+			assert.Equal(g, "application/json", second.Header().Get("Content-Type"))
+		})
+
+		g.It("should key the responses by parameters", func() {
+			send("/", "1", nil)
+
+			w := send("/", "2", nil)
+			assert.JSONEq(g, `{"id": 2, "calls": 2}`, w.Body.String())
+
+			send("/?missing=true", "1", nil)
+			w = send("/?missing=true", "1", nil)
+			assert.Equal(g, http.StatusNotFound, w.Code)
+			assert.Empty(g, w.Header().Get("Cache-Control"))
+			assert.Equal(g, int32(4), atomic.LoadInt32(&_cachedCalls))
+		})
+
+		g.It("should call Handle for the requests without cache", func() {
+			send("/", "1", nil)
+
+			w := send("/", "1", http.Header{"Cache-Control": {"no-cache"}})
+			assert.JSONEq(g, `{"id": 1, "calls": 2}`, w.Body.String())
+
+			w = send("/", "1", nil)
+			assert.JSONEq(g, `{"id": 1, "calls": 2}`, w.Body.String())
+		})
+
+		g.It("should only send the header without option", func() {
+			handler = WrapRequest(&cachedPetRequest{})
+
+			send("/", "1", nil)
+			w := send("/", "1", nil)
+			assert.JSONEq(g, `{"id": 1, "calls": 2}`, w.Body.String())
+			assert.Equal(g, "public, max-age=300", w.Header().Get("Cache-Control"))
+		})
+
+		g.It("should only store the authenticated responses with a Key", func() {
+			handler = WrapRequestWithOptions(&authenticatedCachedRequest{}, &Options{
+				Cache: &CacheOptions{},
+			})
+
+			send("/", "1", nil)
+			w := send("/", "1", nil)
+			assert.JSONEq(g, `{"id": 1, "calls": 2}`, w.Body.String())
+			assert.Equal(g, "private, max-age=300", w.Header().Get("Cache-Control"))
+
+			handler = WrapRequestWithOptions(&authenticatedCachedRequest{}, &Options{
+				Cache: &CacheOptions{
+					Key: func(ctx context.Context, r *http.Request) string {
+						return r.Header.Get("X-User")
+					},
+				},
+			})
+
+			send("/", "1", http.Header{"X-User": {"john"}})
+			w = send("/", "1", http.Header{"X-User": {"john"}})
+			assert.JSONEq(g, `{"id": 1, "calls": 3}`, w.Body.String())
+
+			w = send("/", "1", http.Header{"X-User": {"jane"}})
+			assert.JSONEq(g, `{"id": 1, "calls": 4}`, w.Body.String())
+		})
+
+		g.It("should key the responses by roles after the pre handlers", func() {
+			var preHandlerCalls int32
+			handler = WrapRequestWithOptions(&rolesCachedRequest{}, &Options{
+				Cache: &CacheOptions{},
+				Roles: func(ctx context.Context) []string {
+					roles, _ := ctx.Value(roleKey{}).([]string)
+					return roles
+				},
+				PreHandlers: []PreHandlerFunc{
+					func(ctx context.Context, req interface{}) (context.Context, error) {
+						atomic.AddInt32(&preHandlerCalls, 1)
+						return context.WithValue(ctx, roleKey{}, []string{req.(*rolesCachedRequest).Header.Role}), nil
+					},
+				},
+			})
+
+			w := send("/", "1", http.Header{"X-Role": {"admin"}})
+			assert.JSONEq(g, `{"calls": 1, "balance": 42}`, w.Body.String())
+
+			w = send("/", "1", http.Header{"X-Role": {"guest"}})
+			assert.JSONEq(g, `{"calls": 2}`, w.Body.String())
+
+			w = send("/", "1", http.Header{"X-Role": {"admin"}})
+			assert.JSONEq(g, `{"calls": 1, "balance": 42}`, w.Body.String())
+			assert.Equal(g, int32(3), atomic.LoadInt32(&preHandlerCalls))
+		})
+
+		g.It("should key the operations by package", func() {
+			local := operationKey(reflect.TypeOf(Pet{}))
+			other := operationKey(reflect.TypeOf(pet.Pet{}))
+
+			assert.Equal(g, "github.com/schmurfy/chipi/wrapper.Pet", local)
+			assert.NotEqual(g, local, other)
+		})
+
+		g.It("should reject the invalid durations", func() {
+			err := Verify(&invalidCacheRequest{}, "GET", "/")
+			if assert.Error(g, err) {
+				assert.Contains(g, err.Error(), `invalid cache "100ms" on Path`)
+			}
+		})
+	})
+}
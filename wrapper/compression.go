@@ -0,0 +1,267 @@
+package wrapper
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultCompressionMinSize = 1024
+)
+
+var (
+	// content types which are either already compressed or streamed
+	_uncompressibleTypes = []string{
+		"image/",
+		"video/",
+		"audio/",
+		"font/woff",
+		"application/zip",
+		"application/gzip",
+		"application/x-gzip",
+		"application/x-bzip2",
+		"application/x-7z-compressed",
+		"application/x-rar-compressed",
+		"application/zstd",
+		"text/event-stream",
+	}
+)
+
+// CompressionOptions enable gzip compression of the responses
+// for clients sending `Accept-Encoding: gzip`
+type CompressionOptions struct {
+	// MinSize is the response size in bytes from which the response is compressed
+	// (default: 1KB)
+	MinSize int
+
+	// Level is the gzip compression level (default: gzip.DefaultCompression)
+	Level int
+}
+
+// CompressionBypasser can be implemented by request objects to disable
+// the compression of their responses (ex: streaming handlers)
+type CompressionBypasser interface {
+	BypassCompression() bool
+}
+
+type compressor struct {
+	minSize int
+	level   int
+	pool    sync.Pool
+}
+
+func newCompressor(opts *CompressionOptions) *compressor {
+	c := &compressor{
+		minSize: opts.MinSize,
+		level:   opts.Level,
+	}
+
+	if c.minSize <= 0 {
+		c.minSize = defaultCompressionMinSize
+	}
+
+	if c.level == 0 {
+		c.level = gzip.DefaultCompression
+	}
+
+	return c
+}
+
+func (c *compressor) get(w http.ResponseWriter) (*gzip.Writer, error) {
+	if gz, ok := c.pool.Get().(*gzip.Writer); ok {
+		gz.Reset(w)
+		return gz, nil
+	}
+
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (c *compressor) put(gz *gzip.Writer) {
+	c.pool.Put(gz)
+}
+
+// acceptsGzip returns true if the Accept-Encoding header allows gzip,
+// an explicit gzip entry takes precedence over the * wildcard
+func acceptsGzip(r *http.Request) bool {
+	gzipQ, wildcardQ := -1.0, -1.0
+
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if (encoding != "gzip") && (encoding != "*") {
+				continue
+			}
+
+			q := 1.0
+			if name, value, found := strings.Cut(strings.TrimSpace(params), "="); found && (strings.TrimSpace(name) == "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+
+			if encoding == "gzip" {
+				gzipQ = q
+			} else {
+				wildcardQ = q
+			}
+		}
+	}
+
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+
+	return wildcardQ > 0
+}
+
+func isCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	for _, prefix := range _uncompressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// gzipResponseWriter buffers the response until MinSize bytes are
+// written before deciding to compress it or not
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+
+	bypass  bool
+	decided bool
+	status  int
+	buffer  []byte
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) >= w.compressor.minSize {
+			err := w.start(true)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return len(data), nil
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gzipResponseWriter) canCompress() bool {
+	if w.bypass || (w.Header().Get("Content-Encoding") != "") {
+		return false
+	}
+
+	switch {
+	case (w.status != 0) && (w.status < http.StatusOK),
+		w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified:
+		return false
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buffer)
+	}
+
+	return isCompressibleType(contentType)
+}
+
+// start sends the headers and flushes the buffered data
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.decided = true
+
+	if compress && w.canCompress() {
+		gz, err := w.compressor.get(w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+
+		w.gz = gz
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buffer)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer)
+	}
+	w.buffer = nil
+
+	return err
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.start(false)
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes what remains in the buffer and releases the gzip writer
+func (w *gzipResponseWriter) Close() error {
+	if !w.decided {
+		err := w.start(false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.gz == nil {
+		return nil
+	}
+
+	err := w.gz.Close()
+	w.compressor.put(w.gz)
+	w.gz = nil
+
+	return err
+}
@@ -0,0 +1,156 @@
+package wrapper
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type compressedRequest struct {
+	Path  struct{}
+	Query struct {
+		Size        int
+		ContentType string
+	}
+}
+
+func (r *compressedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Query.ContentType != "" {
+		w.Header().Set("Content-Type", r.Query.ContentType)
+	}
+	_, err := w.Write([]byte(strings.Repeat("a", r.Query.Size)))
+	return err
+}
+
+type bypassedRequest struct {
+	compressedRequest
+}
+
+func (r *bypassedRequest) BypassCompression() bool {
+	return true
+}
+
+func TestCompression(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Compression", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(query string, acceptEncoding string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			if acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		decompress := func(body io.Reader) string {
+			gz, err := gzip.NewReader(body)
+			require.NoError(g, err)
+			data, err := io.ReadAll(gz)
+			require.NoError(g, err)
+			return string(data)
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should compress responses above the threshold", func() {
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 100},
+			})
+			handler(w, newRequest("size=200", "deflate, gzip"))
+
+			assert.Equal(g, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(g, strings.Repeat("a", 200), decompress(w.Body))
+		})
+
+		g.It("should not compress responses below the threshold", func() {
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 100},
+			})
+			handler(w, newRequest("size=50", "gzip"))
+
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(g, strings.Repeat("a", 50), w.Body.String())
+		})
+
+		g.It("should only compress when accepted by the client", func() {
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 10},
+			})
+			handler(w, newRequest("size=50", "gzip;q=0, br"))
+
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, strings.Repeat("a", 50), w.Body.String())
+		})
+
+		g.It("should prefer the explicit gzip entry to the wildcard", func() {
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 10},
+			})
+
+			handler(w, newRequest("size=50", "*;q=1, gzip;q=0"))
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+
+			w = httptest.NewRecorder()
+			handler(w, newRequest("size=50", "br;q=1, *;q=0.5"))
+			assert.Equal(g, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, "Accept-Encoding", w.Header().Get("Vary"))
+		})
+
+		g.It("should not compress already compressed content types", func() {
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 10},
+			})
+			handler(w, newRequest("size=50&content_type=image/png", "gzip"))
+
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, strings.Repeat("a", 50), w.Body.String())
+		})
+
+		g.It("should not compress when the request object asks for it", func() {
+			handler := WrapRequestWithOptions(&bypassedRequest{}, &Options{
+				Compression: &CompressionOptions{MinSize: 10},
+			})
+			handler(w, newRequest("size=50", "gzip"))
+
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, strings.Repeat("a", 50), w.Body.String())
+		})
+
+		g.It("should report the compressed size to the observer", func() {
+			observer := &testObserver{}
+			handler := WrapRequestWithOptions(&compressedRequest{}, &Options{
+				Observer:    observer,
+				Compression: &CompressionOptions{MinSize: 10},
+			})
+			handler(w, newRequest("size=5000", "gzip"))
+
+			assert.Equal(g, http.StatusOK, observer.status)
+			assert.Equal(g, w.Body.Len(), observer.size)
+			assert.Less(g, observer.size, 5000)
+		})
+
+		g.It("should not change anything when disabled", func() {
+			handler := WrapRequest(&compressedRequest{})
+			handler(w, newRequest("size=5000", "gzip"))
+
+			assert.Equal(g, "", w.Header().Get("Content-Encoding"))
+			assert.Equal(g, "", w.Header().Get("Vary"))
+			assert.Equal(g, 5000, w.Body.Len())
+		})
+	})
+}
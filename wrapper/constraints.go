@@ -0,0 +1,252 @@
+package wrapper
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+// _structConstraints caches the constrained fields of the body structures
+var _structConstraints sync.Map
+
+type constrainedField struct {
+	index       int
+	name        string
+	required    bool
+	constraints *schema.Constraints
+}
+
+// fieldConstraints returns the constraints of f, invalid tags are
+// reported by Verify and ignored here
+func fieldConstraints(f reflect.StructField) *schema.Constraints {
+	c, err := schema.ParseConstraints(f)
+	if err != nil {
+		return nil
+	}
+
+	return c
+}
+
+// structConstraints returns the constrained fields of t, the other
+// exported fields are returned too with nil constraints as they may
+// contain constrained structures
+func structConstraints(t reflect.Type) []constrainedField {
+	if fields, found := _structConstraints.Load(t); found {
+		return fields.([]constrainedField)
+	}
+
+	fields := []constrainedField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := schema.ParseJsonTag(f)
+
+		if (f.PkgPath != "") || ((tag.Ignored != nil) && *tag.Ignored) {
+			continue
+		}
+
+		fields = append(fields, constrainedField{
+			index:       i,
+			name:        tag.Name,
+			required:    (tag.Required != nil) && *tag.Required,
+			constraints: fieldConstraints(f),
+		})
+	}
+
+	cached, _ := _structConstraints.LoadOrStore(t, fields)
+	return cached.([]constrainedField)
+}
+
+// hasConstraints returns true if t or one of the structures it
+// contains declares constraints
+func hasConstraints(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for (t.Kind() == reflect.Ptr) || (t.Kind() == reflect.Slice) || (t.Kind() == reflect.Array) || (t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+
+	if (t.Kind() != reflect.Struct) || visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	for _, field := range structConstraints(t) {
+		if (field.constraints != nil) || hasConstraints(t.Field(field.index).Type, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateConstraints checks the parameters sent with r and the body of obj,
+// returns true if a value does not match the constraints of its field
+func validateConstraints(r *http.Request, obj reflect.Value, plan *bindingPlan, parsingErrors map[string]string) bool {
+	failed := false
+	query := r.URL.Query()
+
+	for _, section := range []*sectionPlan{plan.path, plan.query, plan.header, plan.cookie} {
+		sectionValue := section.value(obj, false)
+		if !sectionValue.IsValid() {
+			continue
+		}
+
+		for _, param := range section.params {
+			if (param.constraints == nil) || !section.sent(r, query, param) {
+				continue
+			}
+
+			if err := checkConstraints(sectionValue.FieldByIndex(param.index), param.constraints, true); err != nil {
+				parsingErrors[param.path] = err.Error()
+				failed = true
+			}
+		}
+	}
+
+	if plan.bodyConstraints {
+		if validateValue(obj.FieldByIndex(plan.body), "request.body", parsingErrors) {
+			failed = true
+		}
+	}
+
+	return failed
+}
+
+// sent returns true if the request has a value for param, the default
+// values are not checked
+func (s *sectionPlan) sent(r *http.Request, query url.Values, param *paramPlan) bool {
+	switch s.name {
+	case "Query":
+		_, found := query[param.name]
+		return found
+	case "Header":
+		return r.Header.Get(param.name) != ""
+	case "Cookie":
+		cookie, err := r.Cookie(param.name)
+		return (err == nil) && (cookie.Value != "")
+	}
+
+	return true
+}
+
+// validateValue walks the structures of v
+func validateValue(v reflect.Value, path string, parsingErrors map[string]string) bool {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	failed := false
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), parsingErrors) {
+				failed = true
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if validateValue(iter.Value(), fmt.Sprintf("%s.%v", path, iter.Key()), parsingErrors) {
+				failed = true
+			}
+		}
+
+	case reflect.Struct:
+		for _, field := range structConstraints(v.Type()) {
+			fieldPath := path + "." + field.name
+			f := v.Field(field.index)
+
+			if err := checkConstraints(f, field.constraints, field.required); err != nil {
+				parsingErrors[fieldPath] = err.Error()
+				failed = true
+				continue
+			}
+
+			if validateValue(f, fieldPath, parsingErrors) {
+				failed = true
+			}
+		}
+	}
+
+	return failed
+}
+
+// checkConstraints returns an error if the value of v (or one of its
+// items for slices) does not match c, zero values are considered absent
+// and only checked if required
+func checkConstraints(v reflect.Value, c *schema.Constraints, required bool) error {
+	if (c == nil) || (!required && v.IsZero()) {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var n float64
+
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			err := checkConstraints(v.Index(i), c, true)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.String:
+		s := v.String()
+		length := uint64(utf8.RuneCountInString(s))
+
+		if (c.MinLength != nil) && (length < *c.MinLength) {
+			return fmt.Errorf("must be at least %d characters long", *c.MinLength)
+		}
+
+		if (c.MaxLength != nil) && (length > *c.MaxLength) {
+			return fmt.Errorf("must be at most %d characters long", *c.MaxLength)
+		}
+
+		if (c.Pattern != nil) && !c.Pattern.MatchString(s) {
+			return fmt.Errorf("must match %s", c.Pattern)
+		}
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+
+	default:
+		return nil
+	}
+
+	if (c.Min != nil) && (n < *c.Min) {
+		return fmt.Errorf("must be greater than or equal to %v", *c.Min)
+	}
+
+	if (c.Max != nil) && (n > *c.Max) {
+		return fmt.Errorf("must be less than or equal to %v", *c.Max)
+	}
+
+	return nil
+}
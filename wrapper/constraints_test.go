@@ -0,0 +1,86 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type constrainedOwner struct {
+	Name string `json:"name" chipi:"required" minLength:"2"`
+}
+
+type constrainedRequest struct {
+	Path  struct{}
+	Query struct {
+		Page int    `min:"1" max:"100"`
+		Code string `pattern:"^[A-Z]{3}$"`
+	}
+
+	Body *struct {
+		Title  string              `json:"title" maxLength:"5"`
+		Scores []float64           `json:"scores" max:"10"`
+		Owners []*constrainedOwner `json:"owners"`
+
+		Teams map[string]constrainedOwner `json:"teams"`
+	}
+}
+
+func (r *constrainedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestConstraints(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Constraints", func() {
+		newRequest := func(query string, body string) *http.Request {
+			r := httptest.NewRequest("POST", "/?"+query, bytes.NewBufferString(body))
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.It("should accept valid values", func() {
+			errs := map[string]string{}
+			_, _, err := createFilledRequestObject(newRequest("page=3&code=ABC", `{"title": "hello", "scores": [1, 9.5], "owners": [{"name": "joe"}]}`), &constrainedRequest{}, errs)
+			require.NoError(g, err)
+			assert.Empty(g, errs)
+		})
+
+		g.It("should ignore absent values", func() {
+			errs := map[string]string{}
+			_, _, err := createFilledRequestObject(newRequest("", `{}`), &constrainedRequest{}, errs)
+			require.NoError(g, err)
+			assert.Empty(g, errs)
+		})
+
+		g.It("should report every invalid value", func() {
+			errs := map[string]string{}
+			_, _, err := createFilledRequestObject(newRequest("page=200&code=abc", `{"title": "too long", "scores": [11], "owners": [{"name": "joe"}, {}], "teams": {"red": {"name": "x"}}}`), &constrainedRequest{}, errs)
+			require.Error(g, err)
+
+			assert.Equal(g, map[string]string{
+				"request.query.page":          "must be less than or equal to 100",
+				"request.query.code":          "must match ^[A-Z]{3}$",
+				"request.body.title":          "must be at most 5 characters long",
+				"request.body.scores":         "must be less than or equal to 10",
+				"request.body.owners[1].name": "must be at least 2 characters long",
+				"request.body.teams.red.name": "must be at least 2 characters long",
+			}, errs)
+		})
+
+		g.It("should answer with a 400", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&constrainedRequest{})(w, newRequest("page=0", ""))
+
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), "request.query.page")
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package wrapper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Cursor is an opaque pagination token, it holds the json of the position
+// of the last item sent (ex: its sort key and id) encoded in base64url
+type Cursor string
+
+// EncodeCursor returns the cursor of position
+func EncodeCursor(position interface{}) (Cursor, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return Cursor(base64.RawURLEncoding.EncodeToString(data)), nil
+}
+
+// Decode sets position from the cursor, the empty cursor is the first page
+// and leaves position untouched
+func (c Cursor) Decode(position interface{}) error {
+	if c == "" {
+		return nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	err = json.Unmarshal(data, position)
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return nil
+}
+
+// UnmarshalText rejects the cursors which were not built by EncodeCursor
+func (c *Cursor) UnmarshalText(text []byte) error {
+	data, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil || !json.Valid(data) {
+		return fmt.Errorf("invalid cursor")
+	}
+
+	*c = Cursor(text)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+// OpenAPISchema implements schema.Schemer
+func (Cursor) OpenAPISchema() *openapi3.Schema {
+	ret := openapi3.NewStringSchema()
+	ret.Pattern = "^[A-Za-z0-9_-]*$"
+	ret.Description = "opaque pagination cursor"
+	return ret
+}
+
+// CursorQuery can be embedded in the Query of the request objects listing
+// items after a position (keyset pagination)
+type CursorQuery struct {
+	Cursor Cursor `json:"cursor" description:"cursor returned with the previous page, absent for the first one"`
+	Limit  int    `json:"limit" min:"1" max:"100" description:"maximum number of items"`
+}
+
+// PageLimit returns the maximum number of items of the page, DefaultPageSize
+// without limit
+func (q CursorQuery) PageLimit() int {
+	if q.Limit <= 0 {
+		return DefaultPageSize
+	}
+
+	return q.Limit
+}
+
+// cursorQuery is promoted to the Query sections embedding a CursorQuery
+func (q CursorQuery) cursorQuery() CursorQuery {
+	return q
+}
+
+type cursorQuerier interface {
+	cursorQuery() CursorQuery
+}
+
+// CursorPager is implemented by the Responses listing items after a cursor
+// (see chipi.CursorResponse), the Link header of the next page is sent with
+// them when the Query embeds a CursorQuery
+type CursorPager interface {
+	// NextCursor returns the cursor of the next page, empty for the last one
+	NextCursor() Cursor
+}
+
+// cursorLinks returns the first and next links (RFC 8288) of the page
+// selected by q in u
+func cursorLinks(u *url.URL, q CursorQuery, next Cursor) string {
+	link := func(cursor Cursor, rel string) string {
+		query := u.Query()
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", string(cursor))
+		}
+		query.Set("limit", strconv.Itoa(q.PageLimit()))
+
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return "<" + target.String() + `>; rel="` + rel + `"`
+	}
+
+	links := link("", "first")
+	if next != "" {
+		links += ", " + link(next, "next")
+	}
+
+	return links
+}
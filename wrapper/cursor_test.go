@@ -0,0 +1,96 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCursorPage struct {
+	Items []int  `json:"items"`
+	Next  Cursor `json:"next_cursor,omitempty"`
+}
+
+func (p testCursorPage) NextCursor() Cursor {
+	return p.Next
+}
+
+type petPosition struct {
+	ID int `json:"id"`
+}
+
+type listPetsAfterRequest struct {
+	Path  struct{}
+	Query struct {
+		CursorQuery
+	}
+	Response testCursorPage
+}
+
+func (r *listPetsAfterRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	var after petPosition
+	err := r.Query.Cursor.Decode(&after)
+	if err != nil {
+		return err
+	}
+
+	for id := after.ID + 1; (id <= after.ID+r.Query.PageLimit()) && (id <= 25); id++ {
+		r.Response.Items = append(r.Response.Items, id)
+	}
+
+	if last := len(r.Response.Items); (last > 0) && (r.Response.Items[last-1] < 25) {
+		r.Response.Next, err = EncodeCursor(petPosition{ID: r.Response.Items[last-1]})
+	}
+	return err
+}
+
+func TestCursor(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Cursor", func() {
+		send := func(target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&listPetsAfterRequest{})(w, req)
+			return w
+		}
+
+		g.It("should encode and decode the positions", func() {
+			cursor, err := EncodeCursor(petPosition{ID: 42})
+			require.NoError(g, err)
+			assert.Equal(g, Cursor("eyJpZCI6NDJ9"), cursor)
+
+			var position petPosition
+			require.NoError(g, cursor.Decode(&position))
+			assert.Equal(g, 42, position.ID)
+
+			assert.Error(g, Cursor("not a cursor").Decode(&position))
+		})
+
+		g.It("should bind the cursor and send the next link", func() {
+			w := send("/pets?limit=10")
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g,
+				`</pets?limit=10>; rel="first", </pets?cursor=eyJpZCI6MTB9&limit=10>; rel="next"`,
+				w.Header().Get("Link"))
+
+			w = send("/pets?cursor=eyJpZCI6MjB9&limit=10")
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Contains(g, w.Body.String(), `"items":[21,22,23,24,25]`)
+			assert.Equal(g, `</pets?limit=10>; rel="first"`, w.Header().Get("Link"))
+		})
+
+		g.It("should reject the invalid cursors", func() {
+			w := send("/pets?cursor=%7Bid%7D")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+	})
+}
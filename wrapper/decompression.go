@@ -0,0 +1,126 @@
+package wrapper
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+const (
+	defaultDecompressionMaxSize = 10 << 20
+)
+
+// DecompressionOptions enable the decompression of the request bodies
+// sent with `Content-Encoding: gzip` or `deflate`, the other encodings
+// are rejected with a 415
+type DecompressionOptions struct {
+	// MaxSize is the maximum size in bytes of a decompressed body, larger
+	// bodies are rejected with a 413 (default: 10MB)
+	MaxSize int64
+}
+
+var (
+	// ErrDecompressedBodyTooLarge is returned when reading a decompressed
+	// body larger than DecompressionOptions.MaxSize
+	ErrDecompressedBodyTooLarge = errors.New("decompressed body is too large")
+
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// decompressedBody reads the decompressed body and fails once more than
+// remaining bytes are read
+type decompressedBody struct {
+	reader    io.ReadCloser
+	body      io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (d *decompressedBody) Read(p []byte) (int, error) {
+	if d.exceeded {
+		return 0, ErrDecompressedBodyTooLarge
+	}
+
+	// read one more byte to detect the bodies larger than the limit
+	if int64(len(p)) > d.remaining+1 {
+		p = p[:d.remaining+1]
+	}
+
+	n, err := d.reader.Read(p)
+	if int64(n) > d.remaining {
+		d.exceeded = true
+		return int(d.remaining), ErrDecompressedBodyTooLarge
+	}
+	d.remaining -= int64(n)
+
+	return n, err
+}
+
+func (d *decompressedBody) Close() error {
+	d.reader.Close()
+	return d.body.Close()
+}
+
+func (o *DecompressionOptions) maxSize() int64 {
+	if o.MaxSize <= 0 {
+		return defaultDecompressionMaxSize
+	}
+
+	return o.MaxSize
+}
+
+// decompressBody replaces the body of r by its decompressed content,
+// returns nil if the body is not compressed
+func decompressBody(r *http.Request, opts *DecompressionOptions) (*decompressedBody, error) {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if (encoding == "") || (encoding == "identity") || (r.Body == nil) || (r.Body == http.NoBody) {
+		return nil, nil
+	}
+
+	var reader io.ReadCloser
+	var err error
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(r.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(r.Body)
+	default:
+		return nil, fmt.Errorf("%w %q", errUnsupportedEncoding, encoding)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s body: %w", encoding, err)
+	}
+
+	body := &decompressedBody{
+		reader:    reader,
+		body:      r.Body,
+		remaining: opts.maxSize(),
+	}
+
+	r.Body = body
+	r.ContentLength = -1
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+
+	return body, nil
+}
+
+// decompressionProblem reports the bodies which cannot be decompressed
+func decompressionProblem(err error) *response.Problem {
+	switch {
+	case errors.Is(err, errUnsupportedEncoding):
+		return response.NewProblem(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, ErrDecompressedBodyTooLarge):
+		return response.NewProblem(http.StatusRequestEntityTooLarge, err.Error())
+	}
+
+	return response.NewProblem(http.StatusBadRequest, err.Error())
+}
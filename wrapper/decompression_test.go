@@ -0,0 +1,134 @@
+package wrapper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type decompressedRequest struct {
+	Path struct{}
+	Body struct {
+		Name string `json:"name"`
+	}
+	Response struct {
+		Name string `json:"name"`
+	}
+}
+
+func (r *decompressedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Name = r.Body.Name
+	return nil
+}
+
+type decompressedDecoderRequest struct {
+	Path     struct{}
+	Body     string `content-type:"text/plain"`
+	Response string
+}
+
+func (r *decompressedDecoderRequest) DecodeBody(body io.ReadCloser, target interface{}, obj interface{}) error {
+	data, err := io.ReadAll(body)
+	*(target.(*string)) = string(data)
+	return err
+}
+
+func (r *decompressedDecoderRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = r.Body
+	return nil
+}
+
+func TestDecompression(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Decompression", func() {
+		opts := &Options{Decompression: &DecompressionOptions{MaxSize: 64}}
+
+		gzipped := func(data string) *bytes.Buffer {
+			buffer := &bytes.Buffer{}
+			gz := gzip.NewWriter(buffer)
+			_, _ = gz.Write([]byte(data))
+			_ = gz.Close()
+			return buffer
+		}
+
+		send := func(obj HandlerInterface, options *Options, body io.Reader, encoding string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/", body)
+			req.Header.Set("Content-Type", "application/json")
+			if encoding != "" {
+				req.Header.Set("Content-Encoding", encoding)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(obj, options)(w, req)
+			return w
+		}
+
+		g.It("should decode gzip bodies", func() {
+			w := send(&decompressedRequest{}, opts, gzipped(`{"name": "rex"}`), "gzip")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"name": "rex"}`, w.Body.String())
+		})
+
+		g.It("should decode deflate bodies", func() {
+			buffer := &bytes.Buffer{}
+			zw := zlib.NewWriter(buffer)
+			_, _ = zw.Write([]byte(`{"name": "rex"}`))
+			_ = zw.Close()
+
+			w := send(&decompressedRequest{}, opts, buffer, "deflate")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"name": "rex"}`, w.Body.String())
+		})
+
+		g.It("should give the plain body to the BodyDecoder", func() {
+			w := send(&decompressedDecoderRequest{}, opts, gzipped("some text"), "gzip")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, `"some text"`, strings.TrimSpace(w.Body.String()))
+		})
+
+		g.It("should accept the uncompressed bodies", func() {
+			w := send(&decompressedRequest{}, opts, strings.NewReader(`{"name": "rex"}`), "")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"name": "rex"}`, w.Body.String())
+		})
+
+		g.It("should reject the bodies larger than the limit", func() {
+			body := `{"name": "` + strings.Repeat("a", 100) + `"}`
+			w := send(&decompressedRequest{}, opts, gzipped(body), "gzip")
+
+			assert.Equal(g, http.StatusRequestEntityTooLarge, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should reject the unsupported encodings", func() {
+			w := send(&decompressedRequest{}, opts, strings.NewReader("data"), "br")
+			assert.Equal(g, http.StatusUnsupportedMediaType, w.Code)
+		})
+
+		g.It("should reject the invalid compressed bodies", func() {
+			w := send(&decompressedRequest{}, opts, strings.NewReader("not gzip"), "gzip")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+
+		g.It("should leave the bodies as is when disabled", func() {
+			w := send(&decompressedRequest{}, nil, gzipped(`{"name": "rex"}`), "gzip")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package wrapper
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+// deprecationHeaders returns the headers announcing the deprecation of the
+// operation (`deprecated:"true"` and `sunset` tags of the Path field),
+// nil if the operation is not deprecated
+func deprecationHeaders(typ reflect.Type) http.Header {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return nil
+	}
+
+	deprecated := schema.ParseJsonTag(pathField).Deprecated
+	if (deprecated == nil) || !*deprecated {
+		return nil
+	}
+
+	headers := http.Header{}
+	headers.Set("Deprecation", "true")
+
+	if sunset, found := pathField.Tag.Lookup("sunset"); found {
+		// invalid dates are reported by Verify
+		if t, err := parseTime(sunset); err == nil {
+			headers.Set("Sunset", t.UTC().Format(http.TimeFormat))
+		}
+	}
+
+	return headers
+}
@@ -0,0 +1,58 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type deprecatedRequest struct {
+	Path struct{} `deprecated:"true" sunset:"2030-01-01"`
+}
+
+func (r *deprecatedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestDeprecation(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Deprecation headers", func() {
+		var w *httptest.ResponseRecorder
+		var req *http.Request
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+			req = httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		})
+
+		g.It("should announce the deprecation", func() {
+			handler := WrapRequestWithOptions(&deprecatedRequest{}, &Options{DeprecationHeaders: true})
+			handler(w, req)
+
+			assert.Equal(g, "true", w.Header().Get("Deprecation"))
+			assert.Equal(g, "Tue, 01 Jan 2030 00:00:00 GMT", w.Header().Get("Sunset"))
+		})
+
+		g.It("should not add headers unless enabled", func() {
+			handler := WrapRequestWithOptions(&deprecatedRequest{}, nil)
+			handler(w, req)
+
+			assert.Empty(g, w.Header().Get("Deprecation"))
+			assert.Empty(g, w.Header().Get("Sunset"))
+		})
+
+		g.It("should not add headers to other operations", func() {
+			handler := WrapRequestWithOptions(&noPathRequest{}, &Options{DeprecationHeaders: true})
+			handler(w, req)
+
+			assert.Empty(g, w.Header().Get("Deprecation"))
+		})
+	})
+}
@@ -0,0 +1,24 @@
+package wrapper
+
+import (
+	"reflect"
+	"strings"
+)
+
+func isJsonMediaType(mediaType string) bool {
+	return (mediaType == "application/json") || strings.HasSuffix(mediaType, "+json")
+}
+
+// HasDefaultBodyDecoding returns true if chipi can decode the body
+// without a BodyDecoder (json, forms and streams)
+func HasDefaultBodyDecoding(f reflect.StructField) bool {
+	mediaType := bodyMediaType(f)
+	return (mediaType == "") || isJsonMediaType(mediaType) || isFormBody(f) || IsStreamedBody(f)
+}
+
+// HasDefaultResponseEncoding returns true if chipi can encode the response
+// without a ResponseEncoder (json and streams)
+func HasDefaultResponseEncoding(f reflect.StructField) bool {
+	mediaType := bodyMediaType(f)
+	return (mediaType == "") || isJsonMediaType(mediaType) || IsStreamedResponse(f)
+}
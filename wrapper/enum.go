@@ -0,0 +1,82 @@
+package wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+// paramEnum returns the values accepted by the parameter bound to f, from its
+// `chipi:"enum=..."` tag or from the Enum method of its type (see schema.Enumer)
+func paramEnum(f reflect.StructField) []string {
+	if tag := schema.ParseJsonTag(f); len(tag.Enum) > 0 {
+		return tag.Enum
+	}
+
+	// chipi-gen only knows the tags
+	if f.Type == nil {
+		return nil
+	}
+
+	return typeEnum(f.Type)
+}
+
+// typeEnum returns the values accepted by t (or by its items for slices)
+// formatted like checkEnum compares them, nil if any value is accepted
+func typeEnum(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if (t.Kind() == reflect.Slice) && (t.Elem().Kind() != reflect.Uint8) {
+		t = t.Elem()
+	}
+
+	values := schema.TypeEnum(t)
+	if values == nil {
+		return nil
+	}
+
+	ret := make([]string, len(values))
+	for i, value := range values {
+		ret[i] = fmt.Sprint(value)
+	}
+
+	return ret
+}
+
+// checkEnum returns an error if the value of v (or one of its items for
+// slices) is not allowed
+func checkEnum(v reflect.Value, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice) && (v.Type().Elem().Kind() != reflect.Uint8) {
+		for i := 0; i < v.Len(); i++ {
+			err := checkEnum(v.Index(i), allowed)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value := fmt.Sprint(v.Interface())
+	for _, a := range allowed {
+		if a == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+}
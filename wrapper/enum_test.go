@@ -0,0 +1,88 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type petKind string
+
+func (k petKind) Enum() []interface{} {
+	return []interface{}{"cat", "dog"}
+}
+
+type enumRequest struct {
+	Path  struct{}
+	Query struct {
+		Sort  string `chipi:"enum=asc,desc"`
+		Level int    `chipi:"enum=1,2,3"`
+		Kinds []petKind
+	}
+}
+
+func (r *enumRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+// generatedEnumRequest has the same fields with the method chipi-gen would write
+type generatedEnumRequest enumRequest
+
+func (req *generatedEnumRequest) FillFromRequest(b *Binder) {
+	Bind(b, &req.Query.Sort, b.QueryValues("sort"), Param{Name: "sort", Path: "request.query.sort", Enum: []string{"asc", "desc"}})
+	Bind(b, &req.Query.Level, b.QueryValues("level"), Param{Name: "level", Path: "request.query.level", Enum: []string{"1", "2", "3"}})
+	Bind(b, &req.Query.Kinds, b.QueryValues("kinds"), Param{Name: "kinds", Path: "request.query.kinds"})
+}
+
+func TestEnum(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Enum", func() {
+		newRequest := func(query string) *http.Request {
+			r := httptest.NewRequest("GET", "/?"+query, nil)
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		for name, obj := range map[string]interface{}{"reflection": &enumRequest{}, "generated": &generatedEnumRequest{}} {
+			obj := obj
+
+			g.Describe(name, func() {
+				g.It("should accept the declared values", func() {
+					errs := map[string]string{}
+					_, _, err := createFilledRequestObject(newRequest("sort=desc&level=2&kinds=cat,dog"), obj, errs)
+					require.NoError(g, err)
+					assert.Empty(g, errs)
+				})
+
+				g.It("should reject the other values", func() {
+					errs := map[string]string{}
+					_, _, err := createFilledRequestObject(newRequest("sort=random&level=4&kinds=cat,fish"), obj, errs)
+					require.Error(g, err)
+
+					assert.Equal(g, map[string]string{
+						"request.query.sort":  `"random" is not one of asc, desc`,
+						"request.query.level": `"4" is not one of 1, 2, 3`,
+						"request.query.kinds": `"fish" is not one of cat, dog`,
+					}, errs)
+				})
+			})
+		}
+
+		g.It("should report invalid enum tags", func() {
+			err := Verify(&struct {
+				enumRequest
+				Query struct {
+					Level int `chipi:"enum=low"`
+				}
+			}{}, "GET", "/")
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), `Query.Level: invalid enum value "low" for int`)
+		})
+	})
+}
@@ -0,0 +1,89 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// ETagger can be implemented by request objects to answer the conditional
+// requests, ETag is called once the request is bound (after the pre handlers)
+// with the tag of the current representation (empty if there is none): a
+// matching If-None-Match returns a 304 on GET and HEAD (412 otherwise) and
+// a failing If-Match returns a 412 without calling Handle, the tag is sent
+// with the response and computed again after Handle for the other methods
+type ETagger interface {
+	ETag(ctx context.Context) string
+}
+
+// IsSafeMethod returns true for the methods which do not change the
+// resource (GET and HEAD)
+func IsSafeMethod(method string) bool {
+	return (method == http.MethodGet) || (method == http.MethodHead)
+}
+
+// formatETag quotes the tags returned without quotes
+func formatETag(tag string) string {
+	if (tag == "") || strings.HasSuffix(tag, `"`) {
+		return tag
+	}
+
+	return `"` + tag + `"`
+}
+
+// matchETag returns true if header lists tag or is "*" with a current tag,
+// weak tags match their strong version unless strong is true
+func matchETag(header string, tag string, strong bool) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		switch {
+		case candidate == "*":
+			return true
+		case strong && (strings.HasPrefix(candidate, "W/") || strings.HasPrefix(tag, "W/")):
+			continue
+		case strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPreconditions returns the status answering the conditional headers
+// of r, 0 if the request must be handled
+func checkPreconditions(r *http.Request, tag string) int {
+	if header := r.Header.Get("If-Match"); (header != "") && !matchETag(header, tag, true) {
+		return http.StatusPreconditionFailed
+	}
+
+	if header := r.Header.Get("If-None-Match"); (header != "") && matchETag(header, tag, false) {
+		if IsSafeMethod(r.Method) {
+			return http.StatusNotModified
+		}
+
+		return http.StatusPreconditionFailed
+	}
+
+	return 0
+}
+
+// writePrecondition answers a conditional request without calling Handle
+func writePrecondition(w http.ResponseWriter, status int, tag string) {
+	if tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+
+	if status == http.StatusNotModified {
+		w.WriteHeader(status)
+		return
+	}
+
+	response.NewProblem(status, "the precondition of the request failed").Write(w)
+}
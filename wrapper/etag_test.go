@@ -0,0 +1,127 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type versionedPet struct {
+	version int
+	handled int
+	failing bool
+}
+
+type versionedPetRequest struct {
+	Pet *versionedPet
+
+	Path  struct{}
+	Query struct {
+		Update bool
+	}
+	Response struct {
+		Version int
+	}
+}
+
+func (r *versionedPetRequest) ETag(ctx context.Context) string {
+	return "v" + strconv.Itoa(r.Pet.version)
+}
+
+func (r *versionedPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Pet.handled++
+	if r.Pet.failing {
+		return errors.New("storage is down")
+	}
+
+	if r.Query.Update {
+		r.Pet.version++
+	}
+
+	r.Response.Version = r.Pet.version
+	return nil
+}
+
+func TestETag(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("conditional requests", func() {
+		var pet *versionedPet
+
+		g.BeforeEach(func() {
+			pet = &versionedPet{version: 1}
+		})
+
+		send := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, "/", nil)
+			if method == "PUT" {
+				r.URL.RawQuery = "update=true"
+			}
+			for name, value := range headers {
+				r.Header.Set(name, value)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&versionedPetRequest{Pet: pet})(w, r)
+			return w
+		}
+
+		g.It("should send the ETag with the response", func() {
+			w := send("GET", nil)
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, `"v1"`, w.Header().Get("ETag"))
+		})
+
+		g.It("should answer a matching If-None-Match with a 304", func() {
+			w := send("GET", map[string]string{"If-None-Match": `"v0", W/"v1"`})
+
+			assert.Equal(g, http.StatusNotModified, w.Code)
+			assert.Equal(g, `"v1"`, w.Header().Get("ETag"))
+			assert.Empty(g, w.Body.String())
+			assert.Equal(g, 0, pet.handled)
+		})
+
+		g.It("should handle the requests with an outdated If-None-Match", func() {
+			w := send("GET", map[string]string{"If-None-Match": `"v0"`})
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, 1, pet.handled)
+		})
+
+		g.It("should reject a failing If-Match with a 412", func() {
+			w := send("PUT", map[string]string{"If-Match": `"v0"`})
+
+			assert.Equal(g, http.StatusPreconditionFailed, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(g, 0, pet.handled)
+		})
+
+		g.It("should send the new ETag once changed", func() {
+			w := send("PUT", map[string]string{"If-Match": `"v1"`})
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, `"v2"`, w.Header().Get("ETag"))
+		})
+
+		g.It("should reject If-None-Match * on existing resources for the other methods", func() {
+			w := send("PUT", map[string]string{"If-None-Match": "*"})
+			assert.Equal(g, http.StatusPreconditionFailed, w.Code)
+		})
+
+		g.It("should not send the ETag with the errors", func() {
+			pet.failing = true
+			w := send("GET", nil)
+
+			assert.Empty(g, w.Header().Get("ETag"))
+		})
+	})
+}
@@ -0,0 +1,162 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var _fieldsetType = reflect.TypeOf(Fieldset{})
+
+// Fieldset is bound from a comma separated list of Response fields (ex:
+// ?fields=id,name), the default encoding then only sends these fields (or
+// these fields of each item for the lists), every field is sent without it
+type Fieldset []string
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *Fieldset) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*f = nil
+		return nil
+	}
+
+	ret := Fieldset{}
+	for _, field := range strings.Split(string(text), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return fmt.Errorf("invalid fields %q", text)
+		}
+
+		ret = append(ret, field)
+	}
+
+	*f = ret
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f Fieldset) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(f, ",")), nil
+}
+
+// OpenAPISchema implements schema.Schemer
+func (Fieldset) OpenAPISchema() *openapi3.Schema {
+	ret := openapi3.NewStringSchema()
+	ret.Description = "comma separated fields of the response, all of them by default"
+	return ret
+}
+
+func (f Fieldset) checkFields(allowed []string) error {
+	for _, field := range f {
+		if !contains(allowed, field) {
+			return fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(allowed, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ResponseFieldNames returns the names of the fields of t (or of its items
+// for the slices) which can be selected by a Fieldset, empty if t is not a
+// structure
+func ResponseFieldNames(t reflect.Type) []string {
+	t = derefType(t)
+	if (t.Kind() == reflect.Slice) || (t.Kind() == reflect.Array) {
+		t = derefType(t.Elem())
+	}
+
+	ret := []string{}
+	if t.Kind() != reflect.Struct {
+		return ret
+	}
+
+	for _, field := range renamedFields(t) {
+		switch {
+		case field.embedded:
+			ret = append(ret, ResponseFieldNames(t.Field(field.index).Type)...)
+		case !field.excluded && !field.writeOnly:
+			ret = append(ret, field.name)
+		}
+	}
+
+	return ret
+}
+
+// requestedFields returns the Fieldset bound in the Query of obj, nil if
+// every field is requested
+func (plan *bindingPlan) requestedFields(obj reflect.Value) Fieldset {
+	if plan.fieldset == nil {
+		return nil
+	}
+
+	query := plan.query.value(obj, false)
+	if !query.IsValid() {
+		return nil
+	}
+
+	fields := reflect.Indirect(query.FieldByIndex(plan.fieldset.index))
+	if !fields.IsValid() {
+		return nil
+	}
+
+	return fields.Interface().(Fieldset)
+}
+
+// hasFieldset returns true if the Query of the request object type typ
+// has a Fieldset parameter
+func hasFieldset(typ reflect.Type) bool {
+	queryField, found := typ.FieldByName("Query")
+	if !found {
+		return false
+	}
+
+	for _, f := range SectionFields(derefType(queryField.Type)) {
+		if derefType(f.Type) == _fieldsetType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectFields returns obj as encoded by encoding/json with only the
+// requested fields
+func projectFields(obj interface{}, fields Fieldset) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decodeJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := decoded.(type) {
+	case []interface{}:
+		for _, item := range v {
+			projectKeys(item, fields)
+		}
+
+	default:
+		projectKeys(v, fields)
+	}
+
+	return decoded, nil
+}
+
+func projectKeys(value interface{}, fields Fieldset) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key := range object {
+		if !contains(fields, key) {
+			delete(object, key)
+		}
+	}
+}
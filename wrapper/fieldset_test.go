@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fieldsetPet struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+	Owner  string `json:"owner" chipi:"name=owner_name"`
+}
+
+type listFieldsetPetsRequest struct {
+	Path  struct{}
+	Query struct {
+		Fields Fieldset `json:"fields"`
+	}
+	Response []fieldsetPet
+}
+
+func (r *listFieldsetPetsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = []fieldsetPet{
+		{ID: 9007199254740993, Name: "rex", Owner: "bob"},
+		{ID: 2, Name: "felix", Owner: "alice"},
+	}
+	return nil
+}
+
+func TestFieldset(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Fieldset", func() {
+		send := func(target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&listFieldsetPetsRequest{})(w, req)
+			return w
+		}
+
+		g.It("should list the fields of the response", func() {
+			names := ResponseFieldNames(reflect.TypeOf([]fieldsetPet{}))
+			assert.Equal(g, []string{"id", "name", "owner_name"}, names)
+		})
+
+		g.It("should only send the requested fields", func() {
+			w := send("/pets?fields=id,owner_name")
+			require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(g, `[{"id":9007199254740993,"owner_name":"bob"},{"id":2,"owner_name":"alice"}]`+"\n", w.Body.String())
+		})
+
+		g.It("should send every field without fields", func() {
+			w := send("/pets")
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Contains(g, w.Body.String(), `"name":"rex"`)
+		})
+
+		g.It("should reject the unknown fields", func() {
+			w := send("/pets?fields=id,age")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), `unknown field \"age\", expected one of id, name, owner_name`)
+		})
+	})
+}
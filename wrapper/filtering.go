@@ -0,0 +1,156 @@
+package wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SortField is an item of a Sort
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort is bound from a comma separated list of fields, prefixed with - for
+// the descending order (ex: ?sort=-created_at,name), the fields accepted are
+// listed by the `fields` tag
+type Sort []SortField
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Sort) UnmarshalText(text []byte) error {
+	ret := Sort{}
+
+	for _, item := range strings.Split(string(text), ",") {
+		item = strings.TrimSpace(item)
+
+		field := SortField{Field: strings.TrimPrefix(item, "-")}
+		field.Desc = field.Field != item
+
+		if field.Field == "" {
+			return fmt.Errorf("invalid sort %q", text)
+		}
+
+		ret = append(ret, field)
+	}
+
+	*s = ret
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Sort) MarshalText() ([]byte, error) {
+	items := make([]string, len(s))
+	for i, field := range s {
+		items[i] = field.Field
+		if field.Desc {
+			items[i] = "-" + field.Field
+		}
+	}
+
+	return []byte(strings.Join(items, ",")), nil
+}
+
+// OpenAPISchema implements schema.Schemer
+func (Sort) OpenAPISchema() *openapi3.Schema {
+	ret := openapi3.NewStringSchema()
+	ret.Description = "comma separated fields, prefixed with - for the descending order"
+	return ret
+}
+
+func (s Sort) checkFields(allowed []string) error {
+	for _, field := range s {
+		if !contains(allowed, field.Field) {
+			return fmt.Errorf("cannot sort by %q, expected one of %s", field.Field, strings.Join(allowed, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Filter is bound from the deepObject parameters (ex: ?filter[status]=active),
+// the fields accepted are listed by the `fields` tag
+type Filter map[string]string
+
+// OpenAPISchema implements schema.Schemer
+func (Filter) OpenAPISchema() *openapi3.Schema {
+	return openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewStringSchema())
+}
+
+func (f Filter) checkFields(allowed []string) error {
+	for _, field := range f.fields() {
+		if !contains(allowed, field) {
+			return fmt.Errorf("cannot filter by %q, expected one of %s", field, strings.Join(allowed, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (f Filter) fields() []string {
+	ret := make([]string, 0, len(f))
+	for field := range f {
+		ret = append(ret, field)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+type fieldsChecker interface {
+	checkFields(allowed []string) error
+}
+
+var _fieldsCheckerType = reflect.TypeOf((*fieldsChecker)(nil)).Elem()
+
+// paramFields returns the fields listed by the `fields` tag of f, nil if
+// any field is accepted
+func paramFields(f reflect.StructField) []string {
+	tag, found := f.Tag.Lookup("fields")
+	if !found {
+		return nil
+	}
+
+	ret := []string{}
+	for _, field := range strings.Split(tag, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			ret = append(ret, field)
+		}
+	}
+
+	return ret
+}
+
+// checkFields returns an error if the Sort or the Filter v uses a field
+// which is not allowed
+func checkFields(v reflect.Value, allowed []string) error {
+	if allowed == nil {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if checker, ok := v.Interface().(fieldsChecker); ok {
+		return checker.checkFields(allowed)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _lastSearch struct {
+	sort   Sort
+	filter Filter
+}
+
+type searchPetsRequest struct {
+	Path  struct{}
+	Query struct {
+		Sort   Sort   `json:"sort" fields:"created_at,name"`
+		Filter Filter `json:"filter" fields:"status"`
+	}
+	Response []string
+}
+
+func (r *searchPetsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	_lastSearch.sort = r.Query.Sort
+	_lastSearch.filter = r.Query.Filter
+	return nil
+}
+
+func TestFiltering(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Sort and Filter", func() {
+		send := func(target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&searchPetsRequest{})(w, req)
+			return w
+		}
+
+		g.It("should parse the sort and the filters", func() {
+			w := send("/pets?sort=-created_at,name&filter[status]=active")
+			require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+
+			assert.Equal(g, Sort{{Field: "created_at", Desc: true}, {Field: "name"}}, _lastSearch.sort)
+			assert.Equal(g, Filter{"status": "active"}, _lastSearch.filter)
+
+			text, err := _lastSearch.sort.MarshalText()
+			require.NoError(g, err)
+			assert.Equal(g, "-created_at,name", string(text))
+		})
+
+		g.It("should reject the fields which are not allowed", func() {
+			w := send("/pets?sort=-age")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), `cannot sort by \"age\", expected one of created_at, name`)
+
+			w = send("/pets?filter[owner]=bob")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Contains(g, w.Body.String(), `cannot filter by \"owner\", expected one of status`)
+
+			w = send("/pets?sort=name,,-created_at")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+
+		g.It("should only accept the fields tag on Sort and Filter", func() {
+			err := Verify(&struct {
+				searchPetsRequest
+				Query struct {
+					Name string `json:"name" fields:"a"`
+				}
+			}{}, "GET", "/pets")
+
+			if assert.Error(g, err) {
+				assert.Contains(g, err.Error(), "Query.Name: fields tag is only supported for Sort and Filter")
+			}
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+const (
+	FormContentType      = "application/x-www-form-urlencoded"
+	MultipartContentType = "multipart/form-data"
+
+	// parts above this size are stored in temporary files
+	multipartMaxMemory = 32 << 20
+)
+
+var (
+	_fileHeaderType  = reflect.TypeOf(&multipart.FileHeader{})
+	_fileHeadersType = reflect.TypeOf([]*multipart.FileHeader{})
+)
+
+func requestMediaType(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType
+}
+
+// bodyMediaType returns the media type declared with the content-type tag
+func bodyMediaType(f reflect.StructField) string {
+	mediaType, _, _ := mime.ParseMediaType(f.Tag.Get("content-type"))
+	return mediaType
+}
+
+// isFormBody returns true if the Body field is declared as a form
+// (urlencoded or multipart), those are decoded by chipi
+func isFormBody(f reflect.StructField) bool {
+	mediaType := bodyMediaType(f)
+	return (mediaType == FormContentType) || (mediaType == MultipartContentType)
+}
+
+// decodeFormBody fills the body structure from the form values, fields
+// are matched using the same names as the json decoding
+func decodeFormBody(ctx context.Context, r *http.Request, bodyValue reflect.Value, parsingErrors map[string]string) error {
+	err := r.ParseForm()
+	if err != nil {
+		parsingErrors["request.body"] = err.Error()
+		return err
+	}
+
+	return fillBodyFromValues(ctx, bodyValue, r.PostForm, nil, parsingErrors)
+}
+
+// decodeMultipartBody fills the body structure from the parts, file parts
+// are bound to *multipart.FileHeader (or []*multipart.FileHeader) fields
+func decodeMultipartBody(ctx context.Context, r *http.Request, bodyValue reflect.Value, parsingErrors map[string]string) error {
+	err := r.ParseMultipartForm(multipartMaxMemory)
+	if err != nil {
+		parsingErrors["request.body"] = err.Error()
+		return err
+	}
+
+	return fillBodyFromValues(ctx, bodyValue, url.Values(r.MultipartForm.Value), r.MultipartForm.File, parsingErrors)
+}
+
+func fillBodyFromValues(ctx context.Context, bodyValue reflect.Value, values url.Values, files map[string][]*multipart.FileHeader, parsingErrors map[string]string) error {
+	var err error
+
+	if bodyValue.Kind() == reflect.Ptr {
+		bodyValue = bodyValue.Elem()
+	}
+
+	if bodyValue.Kind() != reflect.Struct {
+		err = fmt.Errorf("form body must be a structure, got %s", bodyValue.Type())
+		parsingErrors["request.body"] = err.Error()
+		return err
+	}
+
+	hasErrors := false
+	for i := 0; i < bodyValue.NumField(); i++ {
+		structField := bodyValue.Type().Field(i)
+		tag := schema.ParseJsonTag(structField)
+		if (structField.PkgPath != "") || ((tag.Ignored != nil) && *tag.Ignored) {
+			continue
+		}
+
+		path := "request.body." + tag.Name
+
+		switch {
+		case structField.Type == _fileHeaderType:
+			if fileHeaders := files[tag.Name]; len(fileHeaders) > 0 {
+				bodyValue.Field(i).Set(reflect.ValueOf(fileHeaders[0]))
+			}
+
+		case structField.Type == _fileHeadersType:
+			if fileHeaders := files[tag.Name]; len(fileHeaders) > 0 {
+				bodyValue.Field(i).Set(reflect.ValueOf(fileHeaders))
+			}
+
+		case isDeepObjectField(structField):
+			_, err = setDeepObjectValue(ctx, path, bodyValue.Field(i), tag.Name, values, map[string]bool{}, isSensitiveField(structField))
+
+		default:
+			if v, ok := values[tag.Name]; ok {
+				err = setFValue(ctx, path, bodyValue.Field(i), queryFieldValue(structField.Type, v), isSensitiveField(structField))
+			}
+		}
+
+		if err != nil {
+			parsingErrors[path] = err.Error()
+			hasErrors = true
+			err = nil
+		}
+	}
+
+	if hasErrors {
+		return errors.New("form parsing error")
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package wrapper
+
+import (
+	"context"
+)
+
+// AfterHandlerFunc is invoked with the bound request object once the response
+// is written, err is the error returned by the pre handlers or Handle (if any)
+type AfterHandlerFunc func(ctx context.Context, req interface{}, err error)
+
+// AfterHandler can be implemented by request objects to run code once the
+// response is written, err is the error returned by the pre handlers or Handle
+type AfterHandler interface {
+	AfterHandle(ctx context.Context, err error)
+}
+
+// Before returns a pre handler calling fn with the typed request object, request
+// objects which are not a T are ignored which allows sharing the hook between
+// routes (T can be an interface implemented by some request objects)
+func Before[T any](fn func(ctx context.Context, req T) error) PreHandlerFunc {
+	return func(ctx context.Context, req interface{}) (context.Context, error) {
+		if typed, ok := req.(T); ok {
+			return ctx, fn(ctx, typed)
+		}
+
+		return ctx, nil
+	}
+}
+
+// After returns an after handler calling fn with the typed request object,
+// request objects which are not a T are ignored like with Before
+func After[T any](fn func(ctx context.Context, req T, err error)) AfterHandlerFunc {
+	return func(ctx context.Context, req interface{}, err error) {
+		if typed, ok := req.(T); ok {
+			fn(ctx, typed, err)
+		}
+	}
+}
+
+// runAfterHandlers invokes the AfterHandle method of the request object (if any)
+// followed by the hooks from the options in reverse order
+func runAfterHandlers(ctx context.Context, afterHandlers []AfterHandlerFunc, req interface{}, err error) {
+	if after, ok := req.(AfterHandler); ok {
+		after.AfterHandle(ctx, err)
+	}
+
+	for i := len(afterHandlers) - 1; i >= 0; i-- {
+		afterHandlers[i](ctx, req, err)
+	}
+}
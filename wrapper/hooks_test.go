@@ -0,0 +1,134 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type afterHandledRequest struct {
+	Path  struct{}
+	Query struct {
+		Fail bool
+	}
+
+	Calls *[]string
+}
+
+func (r *afterHandledRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	*r.Calls = append(*r.Calls, "Handle")
+	if r.Query.Fail {
+		return errors.New("rejected")
+	}
+	return nil
+}
+
+func (r *afterHandledRequest) HandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	*r.Calls = append(*r.Calls, "HandleError")
+	http.Error(w, err.Error(), http.StatusForbidden)
+}
+
+func (r *afterHandledRequest) AfterHandle(ctx context.Context, err error) {
+	*r.Calls = append(*r.Calls, "AfterHandle")
+}
+
+func TestHooks(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Hooks", func() {
+		var calls []string
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(query string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			calls = []string{}
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should give the typed request object to the hooks", func() {
+			handler := WrapRequestWithOptions(&preHandledRequest{Calls: &calls}, &Options{
+				PreHandlers: []PreHandlerFunc{
+					Before(func(ctx context.Context, req *preHandledRequest) error {
+						calls = append(calls, "before "+req.Query.Tenant)
+						return nil
+					}),
+				},
+				AfterHandlers: []AfterHandlerFunc{
+					After(func(ctx context.Context, req *preHandledRequest, err error) {
+						assert.NoError(g, err)
+						assert.Equal(g, "acme", ctx.Value(tenantKey{}))
+						calls = append(calls, "after "+w.Body.String())
+					}),
+				},
+			})
+			handler(w, newRequest("tenant=acme"))
+
+			assert.Equal(g, []string{"before acme", "PreHandle", "Handle", "after acme"}, calls)
+		})
+
+		g.It("should ignore the other request objects", func() {
+			handler := WrapRequestWithOptions(&preHandledRequest{Calls: &calls}, &Options{
+				PreHandlers: []PreHandlerFunc{
+					Before(func(ctx context.Context, req *pooledRequest) error {
+						return errors.New("should not be called")
+					}),
+				},
+				AfterHandlers: []AfterHandlerFunc{
+					After(func(ctx context.Context, req *pooledRequest, err error) {
+						calls = append(calls, "after")
+					}),
+				},
+			})
+			handler(w, newRequest("tenant=acme"))
+
+			assert.Equal(g, []string{"PreHandle", "Handle"}, calls)
+		})
+
+		g.It("should give the error to the after handlers", func() {
+			var handleErr error
+
+			handler := WrapRequestWithOptions(&afterHandledRequest{Calls: &calls}, &Options{
+				AfterHandlers: []AfterHandlerFunc{
+					func(ctx context.Context, req interface{}, err error) {
+						calls = append(calls, "first")
+						handleErr = err
+					},
+					func(ctx context.Context, req interface{}, err error) {
+						calls = append(calls, "second")
+					},
+				},
+			})
+			handler(w, newRequest("fail=true"))
+
+			assert.Equal(g, []string{"Handle", "HandleError", "AfterHandle", "second", "first"}, calls)
+			assert.EqualError(g, handleErr, "rejected")
+		})
+
+		g.It("should run the after handlers when Handle panics", func() {
+			var handleErr error
+
+			handler := WrapRequestWithOptions(&panickingRequest{}, &Options{
+				AfterHandlers: []AfterHandlerFunc{
+					func(ctx context.Context, req interface{}, err error) {
+						handleErr = err
+					},
+				},
+			})
+			handler(w, newRequest(""))
+
+			var panicErr *PanicError
+			assert.ErrorAs(g, handleErr, &panicErr)
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+		})
+	})
+}
@@ -0,0 +1,237 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength  = 255
+
+	defaultIdempotencyTTL = 24 * time.Hour
+)
+
+// ErrIdempotencyConflict is returned by the IdempotencyStore when a request
+// with the same key is still in progress
+var ErrIdempotencyConflict = errors.New("a request with the same idempotency key is in progress")
+
+// IdempotencyStore records the requests sent with an Idempotency-Key, it can
+// be shared by the instances of the api (ex: a Redis implementation)
+type IdempotencyStore interface {
+	// Start reserves key for ttl, it returns the response recorded for key if
+	// its request was completed and ErrIdempotencyConflict if it is in progress
+	Start(ctx context.Context, key string, ttl time.Duration) (*RecordedResponse, error)
+
+	// Complete records the response of the request started with key
+	Complete(ctx context.Context, key string, resp *RecordedResponse, ttl time.Duration) error
+
+	// Cancel releases key so that its request can be retried
+	Cancel(ctx context.Context, key string) error
+}
+
+// IdempotencyOptions enable the Idempotency-Key header on the unsafe methods
+type IdempotencyOptions struct {
+	// Store records the requests (default: a MemoryIdempotencyStore)
+	Store IdempotencyStore
+
+	// TTL is how long the responses are replayed (default: 24h)
+	TTL time.Duration
+
+	// Key scopes the idempotency keys by client (ex: the authenticated user
+	// of ctx), they are only scoped by operation by default
+	Key func(ctx context.Context, r *http.Request) string
+}
+
+// idempotency handles the Idempotency-Key header of an operation
+type idempotency struct {
+	operation string
+	store     IdempotencyStore
+	ttl       time.Duration
+	scope     func(ctx context.Context, r *http.Request) string
+}
+
+// newIdempotency returns nil if the requests of the request object typ cannot
+// be retried with an Idempotency-Key
+func newIdempotency(typ reflect.Type, opts *IdempotencyOptions) *idempotency {
+	if opts == nil {
+		return nil
+	}
+
+	ret := &idempotency{operation: operationKey(typ), store: opts.Store, ttl: opts.TTL, scope: opts.Key}
+	if ret.store == nil {
+		ret.store = NewMemoryIdempotencyStore()
+	}
+	if ret.ttl <= 0 {
+		ret.ttl = defaultIdempotencyTTL
+	}
+
+	return ret
+}
+
+// fingerprint hashes the method, path and query of r with the bound Body of
+// the request object req, a key cannot be reused for another request
+func fingerprint(r *http.Request, req reflect.Value) (string, error) {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	// the streamed bodies are read by Handle
+	if bodyField, found := req.Type().FieldByName("Body"); found && !IsStreamedBody(bodyField) {
+		data, err := json.Marshal(req.FieldByIndex(bodyField.Index).Interface())
+		if err != nil {
+			return "", err
+		}
+		hash.Write(data)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// start replays the recorded response or reserves the key of r for the
+// authenticated context ctx and the request object req, done is true once the
+// response is written and writer is nil without key
+func (i *idempotency) start(ctx context.Context, w http.ResponseWriter, r *http.Request, req reflect.Value) (writer *idempotentWriter, done bool, err error) {
+	value := r.Header.Get(IdempotencyKeyHeader)
+	if IsSafeMethod(r.Method) || (value == "") {
+		return nil, false, nil
+	}
+
+	if len(value) > MaxIdempotencyKeyLength {
+		response.NewProblem(http.StatusBadRequest, "invalid "+IdempotencyKeyHeader+" header").Write(w)
+		return nil, true, nil
+	}
+
+	key := i.operation + ":" + value
+	if i.scope != nil {
+		key = i.operation + ":" + i.scope(ctx, r) + ":" + value
+	}
+
+	requestFingerprint, err := fingerprint(r, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	recorded, err := i.store.Start(ctx, key, i.ttl)
+	switch {
+	case errors.Is(err, ErrIdempotencyConflict):
+		response.NewProblem(http.StatusConflict, err.Error()).Write(w)
+		return nil, true, nil
+
+	case err != nil:
+		return nil, false, err
+
+	case (recorded != nil) && (recorded.Fingerprint != "") && (recorded.Fingerprint != requestFingerprint):
+		response.NewProblem(http.StatusUnprocessableEntity, IdempotencyKeyHeader+" already used for another request").Write(w)
+		return nil, true, nil
+
+	case recorded != nil:
+		replayResponse(w, recorded)
+		return nil, true, nil
+	}
+
+	return &idempotentWriter{responseRecorder: newResponseRecorder(w), idempotency: i, key: key, fingerprint: requestFingerprint}, false, nil
+}
+
+func replayResponse(w http.ResponseWriter, recorded *RecordedResponse) {
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	recorded.write(w)
+}
+
+// idempotentWriter records the response sent for an idempotency key
+type idempotentWriter struct {
+	*responseRecorder
+	idempotency *idempotency
+	key         string
+	fingerprint string
+}
+
+// finish records the response, the key is released after a server error
+// (or a panic) for the request to be retried
+func (w *idempotentWriter) finish(ctx context.Context) error {
+	store := w.idempotency.store
+	recorded := w.recorded()
+	if (recorded == nil) || (recorded.Status >= http.StatusInternalServerError) {
+		return store.Cancel(ctx, w.key)
+	}
+
+	recorded.Fingerprint = w.fingerprint
+	return store.Complete(ctx, w.key, recorded, w.idempotency.ttl)
+}
+
+// MemoryIdempotencyStore records the requests in memory, the keys are only
+// known by the current process
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	nextSweep time.Time
+}
+
+type idempotencyEntry struct {
+	// nil while in progress
+	response *RecordedResponse
+	expires  time.Time
+}
+
+// NewMemoryIdempotencyStore returns an empty store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]*idempotencyEntry{}}
+}
+
+// Start implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Start(ctx context.Context, key string, ttl time.Duration) (*RecordedResponse, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the expired entries are removed from time to time
+	if now.After(s.nextSweep) {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	if entry, found := s.entries[key]; found && now.Before(entry.expires) {
+		if entry.response == nil {
+			return nil, ErrIdempotencyConflict
+		}
+		return entry.response, nil
+	}
+
+	s.entries[key] = &idempotencyEntry{expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Complete implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, resp *RecordedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{response: resp, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Cancel implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Cancel(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
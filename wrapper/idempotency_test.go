@@ -0,0 +1,256 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_idempotentCalls int32
+	_idempotentWait  chan struct{}
+)
+
+type idempotentRequest struct {
+	Path  struct{}
+	Query struct {
+		Status int `json:"status"`
+	}
+	Response struct {
+		Count int `json:"count"`
+	}
+}
+
+func (r *idempotentRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if _idempotentWait != nil {
+		<-_idempotentWait
+	}
+
+	count := atomic.AddInt32(&_idempotentCalls, 1)
+	if r.Query.Status != 0 {
+		w.WriteHeader(r.Query.Status)
+		return nil
+	}
+
+	w.Header().Set("Location", "/orders/1")
+	r.Response.Count = int(count)
+	return nil
+}
+
+type idempotentOrderRequest struct {
+	Path  struct{}
+	Query struct {
+		Priority int `json:"priority"`
+	}
+	Body struct {
+		Item string `json:"item"`
+	}
+	Response struct {
+		Count int `json:"count"`
+	}
+}
+
+func (r *idempotentOrderRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Count = int(atomic.AddInt32(&_idempotentCalls, 1))
+	return nil
+}
+
+type failingIdempotencyStore struct {
+	MemoryIdempotencyStore
+}
+
+func (s *failingIdempotencyStore) Start(ctx context.Context, key string, ttl time.Duration) (*RecordedResponse, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestIdempotency(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Idempotency", func() {
+		var handler http.HandlerFunc
+
+		calls := func() int {
+			return int(atomic.LoadInt32(&_idempotentCalls))
+		}
+
+		sendTo := func(method string, target string, key string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, target, strings.NewReader(""))
+			if key != "" {
+				req.Header.Set(IdempotencyKeyHeader, key)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+
+		send := func(method string, key string) *httptest.ResponseRecorder {
+			return sendTo(method, "/", key)
+		}
+
+		g.BeforeEach(func() {
+			atomic.StoreInt32(&_idempotentCalls, 0)
+			_idempotentWait = nil
+			handler = WrapRequestWithOptions(&idempotentRequest{}, &Options{
+				Idempotency: &IdempotencyOptions{},
+			})
+		})
+
+		g.It("should replay the responses", func() {
+			first := send("POST", "key-1")
+			require.Equal(g, http.StatusOK, first.Code)
+			assert.JSONEq(g, `{"count": 1}`, first.Body.String())
+
+			second := send("POST", "key-1")
+			require.Equal(g, http.StatusOK, second.Code)
+			assert.JSONEq(g, `{"count": 1}`, second.Body.String())
+			assert.Equal(g, "/orders/1", second.Header().Get("Location"))
+			assert.Equal(g, "true", second.Header().Get(IdempotentReplayedHeader))
+			assert.Equal(g, 1, calls())
+
+			third := send("POST", "key-2")
+			assert.JSONEq(g, `{"count": 2}`, third.Body.String())
+			assert.Empty(g, third.Header().Get(IdempotentReplayedHeader))
+		})
+
+		g.It("should reject the keys reused for another request", func() {
+			handler = WrapRequestWithOptions(&idempotentOrderRequest{}, &Options{
+				Idempotency: &IdempotencyOptions{},
+			})
+
+			order := func(target string, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest("POST", target, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(IdempotencyKeyHeader, "key-1")
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				w := httptest.NewRecorder()
+				handler(w, req)
+				return w
+			}
+
+			w := order("/", `{"item": "book"}`)
+			require.Equal(g, http.StatusOK, w.Code, w.Body.String())
+
+			w = order("/", `{ "item":"book" }`)
+			assert.Equal(g, "true", w.Header().Get(IdempotentReplayedHeader))
+
+			w = order("/", `{"item": "pen"}`)
+			assert.Equal(g, http.StatusUnprocessableEntity, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Empty(g, w.Header().Get(IdempotentReplayedHeader))
+
+			w = order("/?priority=1", `{"item": "book"}`)
+			assert.Equal(g, http.StatusUnprocessableEntity, w.Code)
+			assert.Equal(g, 1, calls())
+		})
+
+		g.It("should ignore the requests without key and the safe methods", func() {
+			send("POST", "")
+			send("POST", "")
+			send("GET", "key-1")
+			send("GET", "key-1")
+
+			assert.Equal(g, 4, calls())
+		})
+
+		g.It("should reject the requests in progress", func() {
+			wait := make(chan struct{})
+			_idempotentWait = wait
+
+			done := make(chan *httptest.ResponseRecorder)
+			go func() {
+				done <- send("POST", "key-1")
+			}()
+
+			// the first request holds the key until it is released
+			var conflict *httptest.ResponseRecorder
+			require.Eventually(g, func() bool {
+				conflict = send("POST", "key-1")
+				return conflict.Code == http.StatusConflict
+			}, time.Second, 5*time.Millisecond)
+
+			assert.Equal(g, "application/problem+json", conflict.Header().Get("Content-Type"))
+
+			close(wait)
+			assert.Equal(g, http.StatusOK, (<-done).Code)
+			_idempotentWait = nil
+		})
+
+		g.It("should release the key after a server error", func() {
+			sendTo("POST", "/?status=502", "key-1")
+			sendTo("POST", "/?status=502", "key-1")
+			assert.Equal(g, 2, calls())
+		})
+
+		g.It("should scope the keys", func() {
+			handler = WrapRequestWithOptions(&idempotentRequest{}, &Options{
+				Idempotency: &IdempotencyOptions{
+					Key: func(ctx context.Context, r *http.Request) string {
+						return r.Header.Get("X-User")
+					},
+				},
+			})
+
+			send("POST", "key-1")
+			w := send("POST", "key-1")
+			assert.Equal(g, "true", w.Header().Get(IdempotentReplayedHeader))
+
+			req := httptest.NewRequest("POST", "/", nil)
+			req.Header.Set(IdempotencyKeyHeader, "key-1")
+			req.Header.Set("X-User", "john")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+			w = httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Empty(g, w.Header().Get(IdempotentReplayedHeader))
+			assert.Equal(g, 2, calls())
+		})
+
+		g.It("should reject the keys too long", func() {
+			w := send("POST", strings.Repeat("a", MaxIdempotencyKeyLength+1))
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Equal(g, 0, calls())
+		})
+
+		g.It("should handle the requests when the store fails", func() {
+			logger := &recordingLogger{}
+			handler = WrapRequestWithOptions(&idempotentRequest{}, &Options{
+				Idempotency: &IdempotencyOptions{Store: &failingIdempotencyStore{}},
+				Logger:      logger,
+			})
+
+			send("POST", "key-1")
+			send("POST", "key-1")
+			assert.Equal(g, 2, calls())
+
+			require.Len(g, logger.messages, 2)
+			assert.Equal(g, "failed to start the idempotent request", logger.messages[0].msg)
+		})
+
+		g.It("should expire the responses", func() {
+			store := NewMemoryIdempotencyStore()
+
+			_, err := store.Start(context.Background(), "key", time.Millisecond)
+			require.NoError(g, err)
+			require.NoError(g, store.Complete(context.Background(), "key", &RecordedResponse{Status: 201}, time.Millisecond))
+
+			time.Sleep(5 * time.Millisecond)
+
+			recorded, err := store.Start(context.Background(), "key", time.Minute)
+			require.NoError(g, err)
+			assert.Nil(g, recorded)
+		})
+	})
+}
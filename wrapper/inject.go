@@ -0,0 +1,43 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// _providers holds the registered providers by type
+var _providers sync.Map
+
+type providerFunc func(ctx context.Context) reflect.Value
+
+// Provide registers fn as the provider of the T values injected in the fields
+// of the request objects tagged `chipi:"inject"`, fn is called for each request
+// once it is bound (before the pre handlers), a new call replaces the provider
+func Provide[T any](fn func(ctx context.Context) T) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	_providers.Store(typ, providerFunc(func(ctx context.Context) reflect.Value {
+		value := fn(ctx)
+		return reflect.ValueOf(&value).Elem()
+	}))
+}
+
+// injectDependencies fills the injected fields of obj, a missing provider
+// is reported as a 500
+func injectDependencies(ctx context.Context, obj reflect.Value, plan *bindingPlan) error {
+	for _, f := range plan.injected {
+		provider, found := _providers.Load(f.Type)
+		if !found {
+			return response.NewProblem(http.StatusInternalServerError, fmt.Sprintf("no provider registered for %s (%s)", f.Type, f.Name))
+		}
+
+		obj.Elem().FieldByIndex(f.Index).Set(provider.(providerFunc)(ctx))
+	}
+
+	return nil
+}
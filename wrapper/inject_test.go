@@ -0,0 +1,95 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type injectedStore struct {
+	tenant string
+}
+
+type injectedClock interface {
+	Now() string
+}
+
+type fixedClock string
+
+func (c fixedClock) Now() string {
+	return string(c)
+}
+
+type injectedRequest struct {
+	Path  struct{}
+	Query struct {
+		Name string
+	}
+
+	Store *injectedStore `chipi:"inject"`
+	Clock injectedClock  `chipi:"inject"`
+}
+
+func (r *injectedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	_, err := fmt.Fprintf(w, "%s/%s at %s", r.Store.tenant, r.Query.Name, r.Clock.Now())
+	return err
+}
+
+type missingProviderRequest struct {
+	Path struct{}
+
+	Missing *struct{ Name string } `chipi:"inject"`
+}
+
+func (r *missingProviderRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestInjection(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Injection", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(query string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should fill the tagged fields with the providers", func() {
+			var calls int
+			Provide(func(ctx context.Context) *injectedStore {
+				calls++
+				return &injectedStore{tenant: "acme"}
+			})
+			Provide[injectedClock](func(ctx context.Context) injectedClock {
+				return fixedClock("noon")
+			})
+
+			handler := WrapRequest(&injectedRequest{})
+			handler(w, newRequest("name=rex"))
+			handler(httptest.NewRecorder(), newRequest("name=fido"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "acme/rex at noon", w.Body.String())
+			assert.Equal(g, 2, calls)
+		})
+
+		g.It("should fail without provider", func() {
+			WrapRequest(&missingProviderRequest{})(w, newRequest(""))
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Contains(g, w.Body.String(), "no provider registered for *struct { Name string } (Missing)")
+		})
+	})
+}
@@ -6,20 +6,47 @@ import (
 	"net/http"
 )
 
-// BodyDecoder is required for structures with a `Body` field
+// BodyDecoder overrides the decoding of the `Body` field, json is
+// used by default
 type BodyDecoder interface {
 	DecodeBody(body io.ReadCloser, target interface{}, obj interface{}) error
 }
 
+// BodyMediaTyper can be implemented by body decoders (or their request object)
+// to list the media types they accept, they are documented instead of
+// application/json when the Body field has no content-type tag
+type BodyMediaTyper interface {
+	BodyMediaTypes() []string
+}
+
 // ResponseEncoder is required for structures with a `Response` field
 type ResponseEncoder interface {
 	EncodeResponse(ctx context.Context, out http.ResponseWriter, obj interface{})
 }
 
+// ContentTyper can be implemented by streamed responses (io.Reader) or by
+// their request object to set the Content-Type header
+type ContentTyper interface {
+	ContentType() string
+}
+
 type HandlerInterface interface {
 	Handle(context.Context, http.ResponseWriter) error
 }
 
+// Streamer can be implemented instead of HandlerInterface to send server-sent
+// events, ctx is canceled when the client disconnects and Stream must return then
+type Streamer interface {
+	Stream(ctx context.Context, events chan<- Event) error
+}
+
+// Resetter can be implemented by pooled request objects (see WrapRequestPooled),
+// Reset is called before the object is recycled to release what the copy of
+// the prototype does not overwrite (unexported fields, buffers, ...)
+type Resetter interface {
+	Reset()
+}
+
 type ErrorHandlerInterface interface {
 	HandleError(context.Context, http.ResponseWriter, error)
 }
@@ -27,3 +54,9 @@ type ErrorHandlerInterface interface {
 type HandlerWithRequestInterface interface {
 	Handle(context.Context, *http.Request, http.ResponseWriter) error
 }
+
+// PreHandler can be implemented by request objects to run checks once the
+// request is bound, the returned context replaces the one given to Handle
+type PreHandler interface {
+	PreHandle(ctx context.Context) (context.Context, error)
+}
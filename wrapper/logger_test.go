@@ -0,0 +1,133 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggedMessage struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []loggedMessage
+}
+
+func (l *recordingLogger) record(level string, msg string, fields []shared.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := loggedMessage{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, f := range fields {
+		m.fields[f.Key] = f.Value
+	}
+	l.messages = append(l.messages, m)
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...shared.Field) {
+	l.record("debug", msg, fields)
+}
+
+func (l *recordingLogger) Info(ctx context.Context, msg string, fields ...shared.Field) {
+	l.record("info", msg, fields)
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...shared.Field) {
+	l.record("warn", msg, fields)
+}
+
+func (l *recordingLogger) Error(ctx context.Context, msg string, fields ...shared.Field) {
+	l.record("error", msg, fields)
+}
+
+type unhandledErrorRequest struct {
+	Path struct{}
+}
+
+func (r *unhandledErrorRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return errors.New("database is down")
+}
+
+type loggedQueryRequest struct {
+	Path  struct{}
+	Query struct {
+		Count int
+	}
+}
+
+func (r *loggedQueryRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Logger", func() {
+		var w *httptest.ResponseRecorder
+		var logger *recordingLogger
+
+		newRequest := func(target string) *http.Request {
+			req := httptest.NewRequest("GET", target, nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+			logger = &recordingLogger{}
+		})
+
+		g.It("should log the unhandled errors", func() {
+			WrapRequestWithOptions(&unhandledErrorRequest{}, &Options{Logger: logger})(w, newRequest("/"))
+
+			require.Len(g, logger.messages, 1)
+			m := logger.messages[0]
+			assert.Equal(g, "error", m.level)
+			assert.Equal(g, "unhandled error", m.msg)
+			assert.Equal(g, "unhandledErrorRequest", m.fields["operation"])
+			assert.EqualError(g, m.fields["error"].(error), "database is down")
+		})
+
+		g.It("should log the panics", func() {
+			WrapRequestWithOptions(&panickingRequest{}, &Options{Logger: logger})(w, newRequest("/"))
+
+			require.Len(g, logger.messages, 1)
+			assert.Equal(g, "error", logger.messages[0].level)
+			assert.Equal(g, "request handler panicked", logger.messages[0].msg)
+		})
+
+		g.It("should log the invalid requests as debug", func() {
+			WrapRequestWithOptions(&loggedQueryRequest{}, &Options{Logger: logger})(w, newRequest("/?count=abc"))
+
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			require.Len(g, logger.messages, 1)
+			assert.Equal(g, "debug", logger.messages[0].level)
+			assert.Contains(g, logger.messages[0].fields["errors"], "request.query.count")
+		})
+
+		g.It("should not log the handled requests", func() {
+			WrapRequestWithOptions(&loggedQueryRequest{}, &Options{Logger: logger})(w, newRequest("/?count=2"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Empty(g, logger.messages)
+		})
+
+		g.It("should discard the messages by default", func() {
+			assert.NotPanics(g, func() {
+				WrapRequest(&unhandledErrorRequest{})(w, newRequest("/"))
+			})
+		})
+	})
+}
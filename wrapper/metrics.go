@@ -0,0 +1,79 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	metricRequests = "http.server.request.count"
+	metricErrors   = "http.server.error.count"
+	metricActive   = "http.server.active_requests"
+	metricDuration = "http.server.duration"
+)
+
+// Meter creates the instruments of the handler metrics, it has the shape
+// of the OpenTelemetry metric api (which chipi does not depend on) so an
+// adapter of an otel meter only forwards the calls
+type Meter interface {
+	Int64Counter(name string, description string) Int64Counter
+	Int64UpDownCounter(name string, description string) Int64Counter
+	Float64Histogram(name string, description string) Float64Histogram
+}
+
+// Int64Counter adds increments to a counter (negative ones too for an
+// up down counter)
+type Int64Counter interface {
+	Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue)
+}
+
+// Float64Histogram records the distribution of values
+type Float64Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...attribute.KeyValue)
+}
+
+// handlerMetrics holds the instruments of a wrapped handler
+type handlerMetrics struct {
+	requests Int64Counter
+	errors   Int64Counter
+	active   Int64Counter
+	duration Float64Histogram
+}
+
+func newHandlerMetrics(m Meter) *handlerMetrics {
+	return &handlerMetrics{
+		requests: m.Int64Counter(metricRequests, "number of requests"),
+		errors:   m.Int64Counter(metricErrors, "number of requests which failed"),
+		active:   m.Int64UpDownCounter(metricActive, "number of requests being handled"),
+		duration: m.Float64Histogram(metricDuration, "duration of the requests in milliseconds"),
+	}
+}
+
+// start counts r as being handled and returns the attributes of its metrics
+func (m *handlerMetrics) start(ctx context.Context, r *http.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.route", routePattern(r)),
+		attribute.String("http.method", r.Method),
+	}
+
+	m.active.Add(ctx, 1, attrs...)
+
+	return attrs
+}
+
+// finish records the response of a request started at start, failed is
+// true if an error was recorded on its span
+func (m *handlerMetrics) finish(ctx context.Context, attrs []attribute.KeyValue, start time.Time, status int, failed bool) {
+	m.active.Add(ctx, -1, attrs...)
+
+	attrs = append(attrs, attribute.Int("http.status_code", status))
+	m.requests.Add(ctx, 1, attrs...)
+	m.duration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), attrs...)
+
+	if failed || (status >= http.StatusInternalServerError) {
+		m.errors.Add(ctx, 1, attrs...)
+	}
+}
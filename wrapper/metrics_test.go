@@ -0,0 +1,129 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type measurement struct {
+	value float64
+	attrs attribute.Set
+}
+
+// testMeter keeps the measurements of its instruments by name
+type testMeter struct {
+	mu           sync.Mutex
+	measurements map[string][]measurement
+}
+
+type testInstrument struct {
+	meter *testMeter
+	name  string
+}
+
+func (i *testInstrument) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.Record(ctx, float64(incr), attrs...)
+}
+
+func (i *testInstrument) Record(ctx context.Context, value float64, attrs ...attribute.KeyValue) {
+	i.meter.mu.Lock()
+	defer i.meter.mu.Unlock()
+
+	i.meter.measurements[i.name] = append(i.meter.measurements[i.name], measurement{value: value, attrs: attribute.NewSet(attrs...)})
+}
+
+func (m *testMeter) Int64Counter(name string, description string) Int64Counter {
+	return &testInstrument{meter: m, name: name}
+}
+
+func (m *testMeter) Int64UpDownCounter(name string, description string) Int64Counter {
+	return &testInstrument{meter: m, name: name}
+}
+
+func (m *testMeter) Float64Histogram(name string, description string) Float64Histogram {
+	return &testInstrument{meter: m, name: name}
+}
+
+type measuredRequest struct {
+	Path struct {
+		Id int32
+	}
+	Response struct{}
+}
+
+func (r *measuredRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Path.Id == 0 {
+		return errors.New("no pet")
+	}
+	return nil
+}
+
+func TestMetrics(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Metrics", func() {
+		var meter *testMeter
+		var router *chi.Mux
+
+		g.BeforeEach(func() {
+			meter = &testMeter{measurements: map[string][]measurement{}}
+			router = chi.NewRouter()
+			router.Get("/pets/{Id}", WrapRequestWithOptions(&measuredRequest{}, &Options{Meter: meter}))
+		})
+
+		send := func(target string) {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+		}
+
+		g.It("should count the requests by route, method and status", func() {
+			send("/pets/1")
+
+			requests := meter.measurements[metricRequests]
+			if assert.Len(g, requests, 1) {
+				assert.Equal(g, 1.0, requests[0].value)
+
+				route, _ := requests[0].attrs.Value("http.route")
+				assert.Equal(g, "/pets/{Id}", route.AsString())
+				method, _ := requests[0].attrs.Value("http.method")
+				assert.Equal(g, "GET", method.AsString())
+				status, _ := requests[0].attrs.Value("http.status_code")
+				assert.Equal(g, int64(200), status.AsInt64())
+			}
+
+			assert.Len(g, meter.measurements[metricDuration], 1)
+			assert.Empty(g, meter.measurements[metricErrors])
+		})
+
+		g.It("should track the requests being handled", func() {
+			send("/pets/1")
+
+			active := meter.measurements[metricActive]
+			if assert.Len(g, active, 2) {
+				assert.Equal(g, 1.0, active[0].value)
+				assert.Equal(g, -1.0, active[1].value)
+			}
+		})
+
+		g.It("should count the errors", func() {
+			send("/pets/0")
+			assert.Len(g, meter.measurements[metricErrors], 1)
+
+			// invalid parameter
+			send("/pets/abc")
+			errs := meter.measurements[metricErrors]
+			if assert.Len(g, errs, 2) {
+				status, _ := errs[1].attrs.Value("http.status_code")
+				assert.Equal(g, int64(400), status.AsInt64())
+			}
+		})
+	})
+}
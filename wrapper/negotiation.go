@@ -0,0 +1,214 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// EncoderFunc writes obj to w in a given media type
+type EncoderFunc func(w io.Writer, obj interface{}) error
+
+// Encoders is a registry of response encoders keyed by media type, the
+// first registered type is used when the client accepts anything
+type Encoders struct {
+	mediaTypes []string
+	encoders   map[string]EncoderFunc
+}
+
+// NewEncoders returns an empty registry
+func NewEncoders() *Encoders {
+	return &Encoders{
+		encoders: map[string]EncoderFunc{},
+	}
+}
+
+// DefaultEncoders returns a registry with json and xml encoders
+func DefaultEncoders() *Encoders {
+	return NewEncoders().
+		Register("application/json", func(w io.Writer, obj interface{}) error {
+			return json.NewEncoder(w).Encode(obj)
+		}).
+		Register("application/xml", func(w io.Writer, obj interface{}) error {
+			return xml.NewEncoder(w).Encode(obj)
+		})
+}
+
+// Register adds (or replaces) the encoder for mediaType
+func (e *Encoders) Register(mediaType string, encoder EncoderFunc) *Encoders {
+	if _, exists := e.encoders[mediaType]; !exists {
+		e.mediaTypes = append(e.mediaTypes, mediaType)
+	}
+	e.encoders[mediaType] = encoder
+
+	return e
+}
+
+// MediaTypes returns the registered media types in registration order
+func (e *Encoders) MediaTypes() []string {
+	return append([]string{}, e.mediaTypes...)
+}
+
+// jsonOnly returns the json encoders of the registry, nil if there are none
+func (e *Encoders) jsonOnly() *Encoders {
+	ret := NewEncoders()
+	for _, mediaType := range e.mediaTypes {
+		if isJsonMediaType(mediaType) {
+			ret.Register(mediaType, e.encoders[mediaType])
+		}
+	}
+
+	if len(ret.mediaTypes) == 0 {
+		return nil
+	}
+
+	return ret
+}
+
+// negotiatedEncoders returns the encoders available for the Response of obj
+// (nil without content negotiation), the responses rewritten as json (renamed,
+// write only or role fields, Fieldset) cannot be sent in the other formats
+func negotiatedEncoders(obj interface{}, opts *Options) *Encoders {
+	encoders := opts.encoders()
+	if encoders == nil {
+		return nil
+	}
+
+	// content negotiation only applies to the default encoding
+	if _, ok := obj.(ResponseEncoder); ok {
+		return nil
+	}
+
+	typ := reflect.TypeOf(obj).Elem()
+	responseField, hasResponse := typ.FieldByName("Response")
+	if !hasResponse || !HasDefaultResponseEncoding(responseField) || IsStreamedResponse(responseField) {
+		return nil
+	}
+
+	if hasRenamedFields(responseField.Type) || ((opts.roles() != nil) && hasRoleFields(responseField.Type)) || hasFieldset(typ) {
+		return encoders.jsonOnly()
+	}
+
+	return encoders
+}
+
+// NegotiatedMediaTypes returns the media types the Response of obj can be
+// sent with, nil without content negotiation
+func NegotiatedMediaTypes(obj interface{}, opts *Options) []string {
+	encoders := negotiatedEncoders(obj, opts)
+	if encoders == nil {
+		return nil
+	}
+
+	return encoders.MediaTypes()
+}
+
+type acceptedRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []acceptedRange {
+	ret := []acceptedRange{}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && (strings.TrimSpace(name) == "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		ret = append(ret, acceptedRange{mediaType: mediaType, q: q})
+	}
+
+	return ret
+}
+
+// quality returns the weight given by the client to mediaType, the most
+// specific matching range wins
+func quality(ranges []acceptedRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	q := 0.0
+	specificity := -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.mediaType == mediaType:
+			s = 2
+		case r.mediaType == mainType+"/*":
+			s = 1
+		case r.mediaType == "*/*":
+			s = 0
+		}
+
+		if s > specificity {
+			specificity = s
+			q = r.q
+		}
+	}
+
+	return q
+}
+
+// negotiate picks the encoder matching the Accept header best
+func (e *Encoders) negotiate(accept string) (string, EncoderFunc, bool) {
+	if len(e.mediaTypes) == 0 {
+		return "", nil, false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return e.mediaTypes[0], e.encoders[e.mediaTypes[0]], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best := ""
+	bestQ := 0.0
+	for _, mediaType := range e.mediaTypes {
+		if q := quality(ranges, mediaType); q > bestQ {
+			best = mediaType
+			bestQ = q
+		}
+	}
+
+	if best == "" {
+		return "", nil, false
+	}
+
+	return best, e.encoders[best], true
+}
+
+func writeNotAcceptable(w http.ResponseWriter, encoders *Encoders) {
+	response.NewProblem(http.StatusNotAcceptable, "supported media types: "+strings.Join(encoders.mediaTypes, ", ")).Write(w)
+}
+
+func writeNegotiatedResponse(w http.ResponseWriter, mediaType string, encoder EncoderFunc, obj interface{}) {
+	// encode first to be able to report a failure
+	buffer := &bytes.Buffer{}
+	err := encoder(buffer, obj)
+	if err != nil {
+		response.NewProblem(http.StatusInternalServerError, err.Error()).Write(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	_, _ = w.Write(buffer.Bytes())
+}
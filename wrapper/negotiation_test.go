@@ -0,0 +1,121 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type customEncoderRequest struct {
+	response.JsonEncoder
+	defaultEncoderRequest
+}
+
+func TestNegotiation(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Content negotiation", func() {
+		var w *httptest.ResponseRecorder
+		var opts *Options
+
+		newRequest := func(accept string) *http.Request {
+			req := httptest.NewRequest("GET", "/", nil)
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+			opts = &Options{Encoders: DefaultEncoders()}
+		})
+
+		g.It("should use the first encoder without Accept header", func() {
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest(""))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "Accept", w.Result().Header.Get("Vary"))
+			assert.JSONEq(g, `{"N": 7, "Str": "created"}`, w.Body.String())
+		})
+
+		g.It("should use the requested encoder", func() {
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("application/xml"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/xml", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "<someData><N>7</N><Str>created</Str></someData>", w.Body.String())
+		})
+
+		g.It("should honor quality values and wildcards", func() {
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("application/json;q=0.5, application/*;q=0.8"))
+
+			assert.Equal(g, "application/xml", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should exclude media types with a null quality", func() {
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("*/*, application/json;q=0"))
+
+			assert.Equal(g, "application/xml", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should return 406 if no encoder matches", func() {
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("text/csv"))
+
+			assert.Equal(g, http.StatusNotAcceptable, w.Code)
+			assert.Equal(g, response.ProblemContentType, w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(g, `{
+				"type": "about:blank",
+				"title": "Not Acceptable",
+				"status": 406,
+				"detail": "supported media types: application/json, application/xml"
+			}`, w.Body.String())
+		})
+
+		g.It("should return a problem if the encoder fails", func() {
+			opts.Encoders = NewEncoders().Register("text/csv", func(w io.Writer, obj interface{}) error {
+				return errors.New("csv failure")
+			})
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("text/csv"))
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Equal(g, response.ProblemContentType, w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(g, `{
+				"type": "about:blank",
+				"title": "Internal Server Error",
+				"status": 500,
+				"detail": "csv failure"
+			}`, w.Body.String())
+		})
+
+		g.It("should use custom encoders", func() {
+			opts.Encoders = NewEncoders().Register("text/csv", func(w io.Writer, obj interface{}) error {
+				d := obj.(someData)
+				_, err := fmt.Fprintf(w, "%d,%s", d.N, d.Str)
+				return err
+			})
+			WrapRequestWithOptions(&defaultEncoderRequest{}, opts)(w, newRequest("text/csv"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "text/csv", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "7,created", w.Body.String())
+		})
+
+		g.It("should not negotiate with a ResponseEncoder", func() {
+			WrapRequestWithOptions(&customEncoderRequest{}, opts)(w, newRequest("text/csv"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package wrapper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// statusRecorder keeps track of the status code and the number of bytes
+// written to the underlying ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w}
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Status returns the status code sent to the client (200 if nothing was written yet)
+func (w *statusRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// newAccessEntry describes a request handled since start, the response is
+// only given if withResponse is true
+func newAccessEntry(operation string, r *http.Request, start time.Time, status int, req reflect.Value, response reflect.Value, withResponse bool) *AccessEntry {
+	entry := &AccessEntry{
+		Operation: operation,
+		Method:    r.Method,
+		Route:     routePattern(r),
+		Status:    status,
+		Latency:   time.Since(start),
+	}
+
+	if req.IsValid() {
+		entry.Request = Redact(req.Interface())
+	}
+
+	if withResponse && response.IsValid() {
+		entry.Response = Redact(response.Interface())
+	}
+
+	return entry
+}
+
+// bodyCapture keeps a copy of the first bytes read from the body
+type bodyCapture struct {
+	io.ReadCloser
+	limit  int
+	buffer bytes.Buffer
+}
+
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if remaining := c.limit - c.buffer.Len(); remaining > 0 {
+		if n < remaining {
+			remaining = n
+		}
+		c.buffer.Write(p[:remaining])
+	}
+	return n, err
+}
+
+func (c *bodyCapture) Bytes() []byte {
+	return c.buffer.Bytes()
+}
@@ -0,0 +1,254 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/request"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testObserver struct {
+	operation string
+	req       interface{}
+	body      []byte
+	status    int
+	size      int
+	entry     *AccessEntry
+}
+
+func (o *testObserver) OnRequestBound(ctx context.Context, operation string, req interface{}) {
+	o.operation = operation
+	o.req = req
+}
+
+func (o *testObserver) OnResponseEncoded(ctx context.Context, operation string, status int, size int) {
+	o.status = status
+	o.size = size
+}
+
+func (o *testObserver) OnRequestHandled(ctx context.Context, entry *AccessEntry) {
+	o.entry = entry
+}
+
+func (o *testObserver) OnRequestBody(ctx context.Context, operation string, body []byte) {
+	o.body = append([]byte{}, body...)
+}
+
+type observedRequest struct {
+	request.JsonBodyDecoder
+	response.JsonEncoder
+
+	Path   struct{}
+	Header struct {
+		Token string `chipi:"sensitive"`
+	}
+	Body struct {
+		Name string
+	}
+	Response struct {
+		Name string
+	}
+}
+
+func (r *observedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Name = r.Body.Name
+	return nil
+}
+
+type sensitiveBodyRequest struct {
+	request.JsonBodyDecoder
+
+	Path struct{}
+	Body struct {
+		Password string `chipi:"sensitive"`
+	}
+}
+
+func (r *sensitiveBodyRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestObserver(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Observer", func() {
+		var observer *testObserver
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(body string) *http.Request {
+			rctx := chi.NewRouteContext()
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+			r := httptest.NewRequest("POST", "/", strings.NewReader(body)).WithContext(ctx)
+			r.Header.Set("Token", "secret")
+			return r
+		}
+
+		g.BeforeEach(func() {
+			observer = &testObserver{}
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should receive the redacted request object", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{Observer: observer})
+			handler(w, newRequest(`{"Name": "john"}`))
+
+			assert.Equal(g, "observedRequest", observer.operation)
+
+			req, ok := observer.req.(*observedRequest)
+			require.True(g, ok)
+			assert.Equal(g, RedactedValue, req.Header.Token)
+			assert.Equal(g, "john", req.Body.Name)
+		})
+
+		g.It("should receive the status and size of the response", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{Observer: observer})
+			handler(w, newRequest(`{"Name": "john"}`))
+
+			assert.Equal(g, http.StatusOK, observer.status)
+			assert.Equal(g, w.Body.Len(), observer.size)
+		})
+
+		g.It("should capture the beginning of the body", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{
+				Observer:         observer,
+				BodyCaptureLimit: 5,
+			})
+			handler(w, newRequest(`{"Name": "john"}`))
+
+			assert.Equal(g, `{"Nam`, string(observer.body))
+		})
+
+		g.It("should not capture bodies with sensitive fields", func() {
+			handler := WrapRequestWithOptions(&sensitiveBodyRequest{}, &Options{Observer: observer})
+			handler(w, newRequest(`{"Password": "1234"}`))
+
+			assert.Nil(g, observer.body)
+			assert.Equal(g, http.StatusOK, observer.status)
+		})
+
+		g.It("should describe the handled request", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{Observer: observer})
+			handler(w, newRequest(`{"Name": "john"}`))
+
+			entry := observer.entry
+			require.NotNil(g, entry)
+			assert.Equal(g, "observedRequest", entry.Operation)
+			assert.Equal(g, "POST", entry.Method)
+			assert.Equal(g, http.StatusOK, entry.Status)
+			assert.True(g, entry.Latency > 0)
+
+			req, ok := entry.Request.(*observedRequest)
+			require.True(g, ok)
+			assert.Equal(g, RedactedValue, req.Header.Token)
+
+			assert.Equal(g, struct{ Name string }{Name: "john"}, entry.Response)
+		})
+
+		g.It("should describe the invalid requests without response", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{Observer: observer})
+			handler(w, newRequest(`{"Name": `))
+
+			entry := observer.entry
+			require.NotNil(g, entry)
+			assert.Equal(g, http.StatusBadRequest, entry.Status)
+			assert.Nil(g, entry.Response)
+
+			req, ok := entry.Request.(*observedRequest)
+			require.True(g, ok)
+			assert.Equal(g, RedactedValue, req.Header.Token)
+		})
+
+		g.It("should describe the requests rejected before being bound", func() {
+			handler := WrapRequestWithOptions(&observedRequest{}, &Options{
+				Observer:     observer,
+				MaxBodyBytes: 4,
+			})
+			handler(w, newRequest(`{"Name": "john"}`))
+
+			entry := observer.entry
+			require.NotNil(g, entry)
+			assert.Equal(g, http.StatusRequestEntityTooLarge, entry.Status)
+			assert.Nil(g, entry.Request)
+		})
+	})
+
+	g.Describe("Redact", func() {
+		type nested struct {
+			Secret string `chipi:"sensitive"`
+			Public string
+		}
+
+		type st struct {
+			Nested   nested
+			Ptr      *nested
+			List     []nested
+			Count    int     `chipi:"sensitive"`
+			Password *string `chipi:"sensitive"`
+		}
+
+		g.It("should mask sensitive fields without changing the original", func() {
+			password := "1234"
+			obj := &st{
+				Nested:   nested{Secret: "a", Public: "b"},
+				Ptr:      &nested{Secret: "c", Public: "d"},
+				List:     []nested{{Secret: "e", Public: "f"}},
+				Count:    42,
+				Password: &password,
+			}
+
+			ret, ok := Redact(obj).(*st)
+			require.True(g, ok)
+
+			assert.Equal(g, nested{Secret: RedactedValue, Public: "b"}, ret.Nested)
+			assert.Equal(g, nested{Secret: RedactedValue, Public: "d"}, *ret.Ptr)
+			assert.Equal(g, []nested{{Secret: RedactedValue, Public: "f"}}, ret.List)
+			assert.Equal(g, 0, ret.Count)
+			assert.Nil(g, ret.Password)
+
+			assert.Equal(g, "a", obj.Nested.Secret)
+			assert.Equal(g, "c", obj.Ptr.Secret)
+			assert.Equal(g, "e", obj.List[0].Secret)
+			assert.Equal(g, 42, obj.Count)
+		})
+
+		g.It("should return values without sensitive fields as is", func() {
+			obj := &someData{N: 2}
+			assert.Same(g, obj, Redact(obj))
+		})
+
+		g.It("should mask the fields with a sensitive name", func() {
+			type credentials struct {
+				Login  string
+				Key    string `json:"api_key"`
+				Header string `name:"X-Api-Secret"`
+			}
+
+			type checked struct {
+				Email string
+			}
+
+			// checked before the name is added
+			assert.False(g, hasSensitiveFields(reflect.TypeOf(checked{})))
+
+			AddSensitiveNames("API_KEY", "x-api-secret", "email")
+
+			ret, ok := Redact(&credentials{Login: "john", Key: "1234", Header: "5678"}).(*credentials)
+			require.True(g, ok)
+			assert.Equal(g, credentials{Login: "john", Key: RedactedValue, Header: RedactedValue}, *ret)
+
+			assert.Equal(g, checked{Email: RedactedValue}, Redact(checked{Email: "john@example.com"}))
+
+			f, _ := reflect.TypeOf(credentials{}).FieldByName("Header")
+			assert.True(g, DescribeParam("Header", f).Sensitive)
+		})
+	})
+}
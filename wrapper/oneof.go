@@ -0,0 +1,68 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+// implementationsOf returns the registered implementations of v, nil
+// if v is not an interface
+func implementationsOf(v reflect.Value) *schema.Implementations {
+	if v.Kind() != reflect.Interface {
+		return nil
+	}
+
+	return schema.ImplementationsOf(v.Type())
+}
+
+// decodeImplementation decodes a json body into a new value of the
+// implementation of the interface v selected by the discriminator
+func decodeImplementation(body io.Reader, v reflect.Value, impls *schema.Implementations) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	var properties map[string]json.RawMessage
+	err = json.Unmarshal(data, &properties)
+	if err != nil {
+		return err
+	}
+
+	raw, found := properties[impls.Property]
+	if !found {
+		return fmt.Errorf("missing %s property", impls.Property)
+	}
+
+	var value string
+	err = json.Unmarshal(raw, &value)
+	if err != nil {
+		return fmt.Errorf("invalid %s property: %w", impls.Property, err)
+	}
+
+	t, found := impls.Types[value]
+	if !found {
+		return fmt.Errorf("unknown %s %q", impls.Property, value)
+	}
+
+	var impl reflect.Value
+	if t.Kind() == reflect.Ptr {
+		impl = reflect.New(t.Elem())
+		err = json.Unmarshal(data, impl.Interface())
+	} else {
+		ptr := reflect.New(t)
+		err = json.Unmarshal(data, ptr.Interface())
+		impl = ptr.Elem()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	v.Set(impl)
+	return nil
+}
@@ -0,0 +1,88 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notification interface {
+	Recipient() string
+}
+
+type emailNotification struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+}
+
+func (n *emailNotification) Recipient() string {
+	return n.Address
+}
+
+type smsNotification struct {
+	Kind  string `json:"kind"`
+	Phone string `json:"phone"`
+}
+
+func (n smsNotification) Recipient() string {
+	return n.Phone
+}
+
+type notifyRequest struct {
+	Path struct{}
+	Body notification
+}
+
+func (r *notifyRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestImplementations(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Implementations", func() {
+		schema.RegisterImplementations("kind", map[string]notification{
+			"email": &emailNotification{},
+			"sms":   smsNotification{},
+		})
+
+		newRequest := func(body string) *http.Request {
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		decode := func(body string) (*notifyRequest, map[string]string) {
+			errs := map[string]string{}
+			obj, _, _ := createFilledRequestObject(newRequest(body), &notifyRequest{}, errs)
+			return obj.Interface().(*notifyRequest), errs
+		}
+
+		g.It("should decode the implementation selected by the discriminator", func() {
+			req, errs := decode(`{"kind": "email", "address": "joe@example.com"}`)
+			require.Empty(g, errs)
+			require.IsType(g, &emailNotification{}, req.Body)
+			assert.Equal(g, "joe@example.com", req.Body.Recipient())
+
+			req, errs = decode(`{"kind": "sms", "phone": "555-1234"}`)
+			require.Empty(g, errs)
+			require.IsType(g, smsNotification{}, req.Body)
+			assert.Equal(g, "555-1234", req.Body.Recipient())
+		})
+
+		g.It("should report unknown implementations", func() {
+			_, errs := decode(`{"kind": "pigeon"}`)
+			assert.Equal(g, map[string]string{"request.body": `unknown kind "pigeon"`}, errs)
+
+			_, errs = decode(`{"address": "joe@example.com"}`)
+			assert.Equal(g, map[string]string{"request.body": "missing kind property"}, errs)
+		})
+	})
+}
@@ -0,0 +1,375 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/schmurfy/chipi/shared"
+)
+
+const (
+	defaultBodyCaptureLimit = 4096
+)
+
+// Observer receives notifications about each request handled by the wrapper,
+// the request object given to OnRequestBound is redacted (see Redact)
+type Observer interface {
+	OnRequestBound(ctx context.Context, operation string, req interface{})
+	OnResponseEncoded(ctx context.Context, operation string, status int, size int)
+}
+
+// BodyObserver can be implemented by an Observer to also receive the first
+// bytes of the raw request body, bodies declaring sensitive fields are never captured
+type BodyObserver interface {
+	OnRequestBody(ctx context.Context, operation string, body []byte)
+}
+
+// AccessObserver can be implemented by an Observer to receive an AccessEntry
+// once each request is handled, to write the access logs
+type AccessObserver interface {
+	OnRequestHandled(ctx context.Context, entry *AccessEntry)
+}
+
+// AccessEntry describes a handled request, the request and response
+// objects are redacted (see Redact)
+type AccessEntry struct {
+	Operation string
+	Method    string
+	// Route is the chi pattern of the route (ex: "/pets/{id}")
+	Route   string
+	Status  int
+	Latency time.Duration
+
+	// Request is the bound request object (partially bound for the invalid
+	// requests), nil if the request was rejected before being bound
+	Request interface{}
+	// Response is the Response field, nil on errors and for streamed responses
+	Response interface{}
+}
+
+// PreHandlerFunc is invoked with the bound request object before Handle,
+// the returned context replaces the one given to the next hooks and Handle
+type PreHandlerFunc func(ctx context.Context, req interface{}) (context.Context, error)
+
+// RequestValidatorFunc checks the raw request before it is bound, a returned
+// *response.Problem is written as is, other errors are reported as a 400
+type RequestValidatorFunc func(r *http.Request) error
+
+// ResponseValidatorFunc checks the Response before it is encoded, a returned
+// *response.Problem is written instead of the response, other errors are reported as a 500
+type ResponseValidatorFunc func(r *http.Request, obj interface{}) error
+
+// Options alter the behavior of the handler returned by WrapRequestWithOptions,
+// a nil *Options is valid and uses the defaults
+type Options struct {
+	// Observer is notified once the request is bound and once the response is written
+	// (and once the request is handled if it implements AccessObserver)
+	Observer Observer
+
+	// Meter, when set, records the number of requests, errors and requests
+	// being handled and the duration of the requests by route and method
+	Meter Meter
+
+	// Authorizer checks the permissions of the operations with a `permissions`
+	// tag on their Path, they are rejected with a 500 without Authorizer
+	Authorizer AuthorizerFunc
+
+	// Roles, when set, returns the roles of the caller to filter the Response
+	// fields with a `roles` (omitted) or `unmasked-roles` (masked) tag
+	Roles RolesFunc
+
+	// RequestID, when set, gives an id to each request (see RequestIDOptions)
+	RequestID *RequestIDOptions
+
+	// DisableParamAttributes, when set, does not record the values of the
+	// parameters on the spans
+	DisableParamAttributes bool
+
+	// ParamAttributes, when set, transforms the span attributes of the values
+	// of the parameters (ex: to hash them or drop the high cardinality ones)
+	ParamAttributes ParamAttributeFunc
+
+	// BodyCaptureLimit is the maximum number of body bytes given to a BodyObserver
+	// (default: 4KB)
+	BodyCaptureLimit int
+
+	// PreHandlers are run in order before the PreHandle method of the request object
+	// (if any), the first error stops the chain and is given to HandleError
+	PreHandlers []PreHandlerFunc
+
+	// AfterHandlers are run in reverse order after the AfterHandle method of the
+	// request object (if any) once the response is written
+	AfterHandlers []AfterHandlerFunc
+
+	// Compression, when set, enables gzip compression of the responses
+	Compression *CompressionOptions
+
+	// Decompression, when set, decompresses the gzip and deflate request bodies
+	// before they are validated and decoded
+	Decompression *DecompressionOptions
+
+	// MaxBodyBytes, when set, is the maximum size in bytes of the request bodies,
+	// larger bodies are rejected with a 413 (the `max-bytes` tag of the Body
+	// field sets the limit of a route)
+	MaxBodyBytes int64
+
+	// Timeout, when set, is the deadline of the context given to Handle, the
+	// errors returned once it expired are sent as a 504 (the `timeout` tag of
+	// the Path field sets the timeout of a route)
+	Timeout time.Duration
+
+	// RateLimit, when set, limits the number of requests of each client to the
+	// operations with a `rate-limit` tag on their Path (or to all of them with
+	// RateLimitOptions.Limit), the other requests are rejected with a 429
+	RateLimit *RateLimitOptions
+
+	// Idempotency, when set, replays the responses of the unsafe requests sent
+	// again with the same Idempotency-Key header, the requests with the key of
+	// a request in progress are rejected with a 409
+	Idempotency *IdempotencyOptions
+
+	// Cache, when set, serves the GET requests of the operations with a `cache`
+	// tag on their Path from the responses cached for their parameters without
+	// calling Handle (the Cache-Control header is sent without this option)
+	Cache *CacheOptions
+
+	// Async, when set, answers with a 202 when Handle returns an AsyncResult
+	// and runs it in the background, its status is kept in AsyncOptions.Store
+	Async *AsyncOptions
+
+	// Heartbeat is the interval of the comments sent on idle event
+	// streams (see Streamer) to keep the connection open (default: 15s)
+	Heartbeat time.Duration
+
+	// DeprecationHeaders, when set, adds the Deprecation (and Sunset) headers to the
+	// responses of the operations deprecated with the `deprecated:"true"` tag of their Path
+	DeprecationHeaders bool
+
+	// Encoders, when set, enables content negotiation (Accept header) for the
+	// responses of request objects not implementing ResponseEncoder
+	Encoders *Encoders
+
+	// RequestValidator, when set, is run before the request object is bound
+	RequestValidator RequestValidatorFunc
+
+	// ResponseValidator, when set, is run before the Response is encoded
+	ResponseValidator ResponseValidatorFunc
+
+	// PanicHandler, when set, is called instead of HandleError
+	// when the request handling panics
+	PanicHandler PanicHandlerFunc
+
+	// Logger receives the errors which cannot be returned to the client
+	// (panics, unhandled errors, interrupted streams, ...), they are
+	// discarded by default
+	Logger shared.Logger
+}
+
+func (o *Options) observer() Observer {
+	if o == nil {
+		return nil
+	}
+
+	return o.Observer
+}
+
+func (o *Options) metrics() *handlerMetrics {
+	if (o == nil) || (o.Meter == nil) {
+		return nil
+	}
+
+	return newHandlerMetrics(o.Meter)
+}
+
+func (o *Options) authorizer() AuthorizerFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.Authorizer
+}
+
+func (o *Options) roles() RolesFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.Roles
+}
+
+func (o *Options) requestID() *RequestIDOptions {
+	if o == nil {
+		return nil
+	}
+
+	return o.RequestID
+}
+
+func (o *Options) paramAttributes() ParamAttributeFunc {
+	switch {
+	case o == nil:
+		return nil
+	case o.DisableParamAttributes:
+		return omitParamAttribute
+	}
+
+	return o.ParamAttributes
+}
+
+func (o *Options) bodyCaptureLimit() int {
+	if (o == nil) || (o.BodyCaptureLimit <= 0) {
+		return defaultBodyCaptureLimit
+	}
+
+	return o.BodyCaptureLimit
+}
+
+func (o *Options) compressor() *compressor {
+	if (o == nil) || (o.Compression == nil) {
+		return nil
+	}
+
+	return newCompressor(o.Compression)
+}
+
+func (o *Options) decompression() *DecompressionOptions {
+	if o == nil {
+		return nil
+	}
+
+	return o.Decompression
+}
+
+// maxBodyBytes returns the body limit of the request object typ, its
+// `max-bytes` tag has priority over the global limit
+func (o *Options) maxBodyBytes(typ reflect.Type) int64 {
+	if limit := MaxBodyBytes(typ); limit > 0 {
+		return limit
+	}
+
+	if o == nil {
+		return 0
+	}
+
+	return o.MaxBodyBytes
+}
+
+// timeout returns the handler timeout of the request object typ, its
+// `timeout` tag has priority over the global timeout
+func (o *Options) timeout(typ reflect.Type) time.Duration {
+	if timeout := HandlerTimeout(typ); timeout > 0 {
+		return timeout
+	}
+
+	if o == nil {
+		return 0
+	}
+
+	return o.Timeout
+}
+
+func (o *Options) rateLimiter(typ reflect.Type) *rateLimiter {
+	if o == nil {
+		return nil
+	}
+
+	return newRateLimiter(typ, o.RateLimit)
+}
+
+func (o *Options) idempotency(typ reflect.Type) *idempotency {
+	if o == nil {
+		return nil
+	}
+
+	return newIdempotency(typ, o.Idempotency)
+}
+
+func (o *Options) responseCache(obj interface{}) *responseCache {
+	if o == nil {
+		return newResponseCache(obj, nil, nil)
+	}
+
+	return newResponseCache(obj, o.Cache, o.Roles)
+}
+
+func (o *Options) asyncOperations() *asyncOperations {
+	if o == nil {
+		return nil
+	}
+
+	return newAsyncOperations(o.Async)
+}
+
+func (o *Options) preHandlers() []PreHandlerFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.PreHandlers
+}
+
+func (o *Options) afterHandlers() []AfterHandlerFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.AfterHandlers
+}
+
+func (o *Options) deprecationHeaders(typ reflect.Type) http.Header {
+	if (o == nil) || !o.DeprecationHeaders {
+		return nil
+	}
+
+	return deprecationHeaders(typ)
+}
+
+func (o *Options) encoders() *Encoders {
+	if o == nil {
+		return nil
+	}
+
+	return o.Encoders
+}
+
+func (o *Options) logger() shared.Logger {
+	if (o == nil) || (o.Logger == nil) {
+		return shared.NopLogger{}
+	}
+
+	return o.Logger
+}
+
+func (o *Options) panicHandler() PanicHandlerFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.PanicHandler
+}
+
+func (o *Options) requestValidator() RequestValidatorFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.RequestValidator
+}
+
+func (o *Options) responseValidator() ResponseValidatorFunc {
+	if o == nil {
+		return nil
+	}
+
+	return o.ResponseValidator
+}
+
+func (o *Options) heartbeat() time.Duration {
+	if (o == nil) || (o.Heartbeat <= 0) {
+		return defaultHeartbeat
+	}
+
+	return o.Heartbeat
+}
@@ -0,0 +1,140 @@
+package wrapper
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of items of the pages selected without
+// per_page nor limit
+const DefaultPageSize = 20
+
+// PageQuery can be embedded in the Query of the request objects listing items,
+// the pages are selected with page and per_page or with limit and offset
+type PageQuery struct {
+	Page    int `json:"page" min:"1" description:"number of the page, from 1"`
+	PerPage int `json:"per_page" min:"1" max:"100" description:"number of items per page"`
+	Limit   int `json:"limit" min:"1" max:"100" description:"maximum number of items, instead of per_page"`
+	Offset  int `json:"offset" min:"0" description:"number of items to skip, instead of page"`
+}
+
+// Bounds returns the number of items to skip and the maximum number of items
+// of the selected page
+func (q PageQuery) Bounds() (offset int, limit int) {
+	if q.usesOffset() {
+		limit = q.Limit
+		if limit <= 0 {
+			limit = DefaultPageSize
+		}
+
+		return q.Offset, limit
+	}
+
+	limit = q.PerPage
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+
+	return (page - 1) * limit, limit
+}
+
+func (q PageQuery) usesOffset() bool {
+	return (q.Limit > 0) || (q.Offset > 0)
+}
+
+// pageQuery is promoted to the Query sections embedding a PageQuery
+func (q PageQuery) pageQuery() PageQuery {
+	return q
+}
+
+type pageQuerier interface {
+	pageQuery() PageQuery
+}
+
+// Pager is implemented by the Responses listing a page of items (see
+// chipi.PagedResponse), the Link headers of the other pages are sent with
+// them when the Query embeds a PageQuery
+type Pager interface {
+	// PageSize returns the number of items of the page and the total number
+	// of items, 0 if it is unknown
+	PageSize() (count int, total int)
+}
+
+// setPageLinks sets the Link header of the pages around the one selected by
+// the Query of req if the response lists a page
+func setPageLinks(w http.ResponseWriter, r *http.Request, req reflect.Value, response reflect.Value) {
+	query := reflect.Indirect(reflect.Indirect(req).FieldByName("Query"))
+	if !query.IsValid() {
+		return
+	}
+
+	if pager, ok := response.Interface().(CursorPager); ok {
+		if querier, ok := query.Interface().(cursorQuerier); ok {
+			w.Header().Set("Link", cursorLinks(r.URL, querier.cursorQuery(), pager.NextCursor()))
+		}
+		return
+	}
+
+	pager, ok := response.Interface().(Pager)
+	if !ok {
+		return
+	}
+
+	querier, ok := query.Interface().(pageQuerier)
+	if !ok {
+		return
+	}
+
+	count, total := pager.PageSize()
+	if links := pageLinks(r.URL, querier.pageQuery(), count, total); links != "" {
+		w.Header().Set("Link", links)
+	}
+}
+
+// pageLinks returns the first, prev, next and last links (RFC 8288) of the
+// page selected by q in u, last is only given if the total is known
+func pageLinks(u *url.URL, q PageQuery, count int, total int) string {
+	offset, limit := q.Bounds()
+
+	link := func(offset int, rel string) string {
+		query := u.Query()
+		if q.usesOffset() {
+			query.Set("offset", strconv.Itoa(offset))
+			query.Set("limit", strconv.Itoa(limit))
+		} else {
+			query.Set("page", strconv.Itoa(offset/limit+1))
+			query.Set("per_page", strconv.Itoa(limit))
+		}
+
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return "<" + target.String() + `>; rel="` + rel + `"`
+	}
+
+	links := []string{link(0, "first")}
+
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(prev, "prev"))
+	}
+
+	if ((total > 0) && (offset+count < total)) || ((total <= 0) && (count >= limit)) {
+		links = append(links, link(offset+limit, "next"))
+	}
+
+	if total > 0 {
+		links = append(links, link((total-1)/limit*limit, "last"))
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,105 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPage[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+func (p testPage[T]) PageSize() (int, int) {
+	return len(p.Items), p.Total
+}
+
+type listPetsRequest struct {
+	Path  struct{}
+	Query struct {
+		PageQuery
+		Name string `json:"name"`
+	}
+	Response testPage[string]
+}
+
+func (r *listPetsRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	offset, limit := r.Query.Bounds()
+
+	for i := offset; (i < offset+limit) && (i < 45); i++ {
+		r.Response.Items = append(r.Response.Items, r.Query.Name)
+	}
+	r.Response.Total = 45
+	return nil
+}
+
+func TestPagination(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("PageQuery", func() {
+		send := func(target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&listPetsRequest{})(w, req)
+			return w
+		}
+
+		g.It("should select the pages", func() {
+			offset, limit := PageQuery{}.Bounds()
+			assert.Equal(g, []int{0, DefaultPageSize}, []int{offset, limit})
+
+			offset, limit = PageQuery{Page: 3, PerPage: 10}.Bounds()
+			assert.Equal(g, []int{20, 10}, []int{offset, limit})
+
+			offset, limit = PageQuery{Offset: 5, Limit: 15}.Bounds()
+			assert.Equal(g, []int{5, 15}, []int{offset, limit})
+		})
+
+		g.It("should bind the embedded query and send the links", func() {
+			w := send("/pets?name=rex&page=2&per_page=10")
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Contains(g, w.Body.String(), `"total":45`)
+
+			assert.Equal(g,
+				`</pets?name=rex&page=1&per_page=10>; rel="first", `+
+					`</pets?name=rex&page=1&per_page=10>; rel="prev", `+
+					`</pets?name=rex&page=3&per_page=10>; rel="next", `+
+					`</pets?name=rex&page=5&per_page=10>; rel="last"`,
+				w.Header().Get("Link"))
+		})
+
+		g.It("should keep the offset mode", func() {
+			w := send("/pets?offset=40&limit=20")
+			require.Equal(g, http.StatusOK, w.Code)
+
+			assert.Equal(g,
+				`</pets?limit=20&offset=0>; rel="first", `+
+					`</pets?limit=20&offset=20>; rel="prev", `+
+					`</pets?limit=20&offset=40>; rel="last"`,
+				w.Header().Get("Link"))
+		})
+
+		g.It("should reject the pages too large", func() {
+			w := send("/pets?per_page=500")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+		})
+
+		g.It("should send the next link without total while the pages are full", func() {
+			u, _ := url.Parse("/pets")
+
+			assert.Contains(g, pageLinks(u, PageQuery{}, DefaultPageSize, 0), `rel="next"`)
+			assert.NotContains(g, pageLinks(u, PageQuery{}, 3, 0), `rel="next"`)
+			assert.NotContains(g, pageLinks(u, PageQuery{}, 3, 0), `rel="last"`)
+		})
+	})
+}
@@ -0,0 +1,217 @@
+package wrapper
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+// _plans caches the binding plan of each request type
+var _plans sync.Map
+
+// bindingPlan is what createFilledRequestObject needs to know about a request
+// type, it is computed once per type instead of on every request
+type bindingPlan struct {
+	path   *sectionPlan
+	query  *sectionPlan
+	header *sectionPlan
+	cookie *sectionPlan
+
+	body        []int
+	bodyField   reflect.StructField
+	response    []int
+	hasBody     bool
+	hasResponse bool
+
+	// true if the body declares constraints (see schema.Constraints)
+	bodyConstraints bool
+	// true if the body is given as is to the handler (see IsStreamedBody)
+	streamedBody bool
+
+	// fields tagged `chipi:"inject"`
+	injected []reflect.StructField
+
+	// the Fieldset of the query, nil if none
+	fieldset *paramPlan
+}
+
+// sectionPlan describes a request section (Path, Query, Header, Cookie)
+type sectionPlan struct {
+	name  string
+	index []int
+
+	// true if a field is required or has a default value
+	needsValue bool
+
+	params []*paramPlan
+	byName map[string]*paramPlan
+
+	// index of the catch-all field of the query, nil if none
+	rest []int
+}
+
+type paramPlan struct {
+	index []int
+	field reflect.StructField
+
+	// name of the parameter in the request and path used in errors and traces
+	name string
+	path string
+
+	sensitive  bool
+	required   bool
+	deepObject bool
+	separator  string
+
+	defaultValue string
+	hasDefault   bool
+
+	enum        []string
+	fields      []string
+	constraints *schema.Constraints
+}
+
+func planFor(typ reflect.Type) *bindingPlan {
+	if plan, found := _plans.Load(typ); found {
+		return plan.(*bindingPlan)
+	}
+
+	plan, _ := _plans.LoadOrStore(typ, newBindingPlan(typ))
+	return plan.(*bindingPlan)
+}
+
+func newBindingPlan(typ reflect.Type) *bindingPlan {
+	plan := &bindingPlan{
+		path:   newSectionPlan(typ, "Path"),
+		query:  newSectionPlan(typ, "Query"),
+		header: newSectionPlan(typ, "Header"),
+		cookie: newSectionPlan(typ, "Cookie"),
+	}
+
+	if f, found := typ.FieldByName("Body"); found {
+		plan.hasBody = true
+		plan.body = f.Index
+		plan.bodyField = f
+		plan.bodyConstraints = hasConstraints(f.Type, map[reflect.Type]bool{})
+		plan.streamedBody = IsStreamedBody(f)
+	}
+
+	if f, found := typ.FieldByName("Response"); found {
+		plan.hasResponse = true
+		plan.response = f.Index
+	}
+
+	if plan.query != nil {
+		for _, param := range plan.query.params {
+			if derefType(param.field.Type) != _fieldsetType {
+				continue
+			}
+
+			// the fields of the Response are accepted by default
+			plan.fieldset = param
+			if (param.fields == nil) && plan.hasResponse {
+				param.fields = ResponseFieldNames(typ.FieldByIndex(plan.response).Type)
+			}
+		}
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if inject := schema.ParseJsonTag(f).Inject; (inject != nil) && *inject && f.IsExported() {
+			plan.injected = append(plan.injected, f)
+		}
+	}
+
+	return plan
+}
+
+func newSectionPlan(typ reflect.Type, name string) *sectionPlan {
+	f, found := typ.FieldByName(name)
+	if !found {
+		return nil
+	}
+
+	section := &sectionPlan{
+		name:   name,
+		index:  f.Index,
+		byName: map[string]*paramPlan{},
+	}
+
+	st, ok := sectionStructType(f.Type)
+	if !ok {
+		return section
+	}
+
+	for _, structField := range SectionFields(st) {
+		tag := schema.ParseJsonTag(structField)
+
+		if (tag.Ignored != nil) && *tag.Ignored {
+			continue
+		}
+
+		if (name == "Query") && (tag.Rest != nil) && *tag.Rest {
+			section.rest = structField.Index
+			continue
+		}
+
+		p := DescribeParam(name, structField)
+		param := &paramPlan{
+			index:        structField.Index,
+			field:        structField,
+			name:         p.Name,
+			path:         p.Path,
+			sensitive:    p.Sensitive,
+			required:     p.Required,
+			deepObject:   isDeepObjectField(structField),
+			separator:    p.Separator,
+			defaultValue: p.Default,
+			hasDefault:   p.HasDefault,
+			enum:         p.Enum,
+			fields:       p.Fields,
+			constraints:  fieldConstraints(structField),
+		}
+
+		if param.required || param.hasDefault {
+			section.needsValue = true
+		}
+
+		section.params = append(section.params, param)
+		section.byName[param.name] = param
+	}
+
+	return section
+}
+
+// SectionFields returns the fields of the section structure st (Path, Query,
+// Header or Cookie), the fields of the structures embedded without json name
+// (ex: PageQuery) are promoted with the index of their path in st
+func SectionFields(st reflect.Type) []reflect.StructField {
+	ret := []reflect.StructField{}
+
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+
+		_, named := f.Tag.Lookup("json")
+		if f.Anonymous && !named && (f.Type.Kind() == reflect.Struct) {
+			for _, promoted := range SectionFields(f.Type) {
+				promoted.Index = append([]int{i}, promoted.Index...)
+				ret = append(ret, promoted)
+			}
+			continue
+		}
+
+		ret = append(ret, f)
+	}
+
+	return ret
+}
+
+// value returns the section structure of obj, see sectionValue
+func (s *sectionPlan) value(obj reflect.Value, allocate bool) reflect.Value {
+	if s == nil {
+		return _noValue
+	}
+
+	return sectionValue(obj.FieldByIndex(s.index), allocate)
+}
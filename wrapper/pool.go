@@ -0,0 +1,40 @@
+package wrapper
+
+import (
+	"reflect"
+	"sync"
+)
+
+// requestPool recycles the request objects of a route, see WrapRequestPooled
+type requestPool struct {
+	pool      sync.Pool
+	prototype reflect.Value
+}
+
+func newRequestPool(obj interface{}) *requestPool {
+	return &requestPool{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return reflect.New(reflect.TypeOf(obj).Elem())
+			},
+		},
+		prototype: reflect.ValueOf(obj),
+	}
+}
+
+// get returns a request object initialized from the prototype,
+// exactly like a newly allocated one
+func (p *requestPool) get() reflect.Value {
+	ret := p.pool.Get().(reflect.Value)
+	copyPrototype(ret, p.prototype)
+
+	return ret
+}
+
+func (p *requestPool) put(obj reflect.Value) {
+	if resetter, ok := obj.Interface().(Resetter); ok {
+		resetter.Reset()
+	}
+
+	p.pool.Put(obj)
+}
@@ -0,0 +1,84 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type pooledRequest struct {
+	Path  struct{}
+	Query struct {
+		Name string
+	}
+
+	Prefix string
+
+	resets  *int
+	visited bool
+}
+
+func (r *pooledRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.visited {
+		return fmt.Errorf("the request object was not reset")
+	}
+	r.visited = true
+
+	_, err := fmt.Fprintf(w, "%s%s", r.Prefix, r.Query.Name)
+	return err
+}
+
+func (r *pooledRequest) Reset() {
+	r.visited = false
+	if r.resets != nil {
+		*r.resets++
+	}
+}
+
+func TestRequestPool(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Pooled requests", func() {
+		newRequest := func(query string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.It("should initialize recycled objects from the prototype", func() {
+			handler := WrapRequestPooled(&pooledRequest{Prefix: "hello "}, nil)
+
+			for _, query := range []string{"name=rex", "", "name=fido"} {
+				w := httptest.NewRecorder()
+				handler(w, newRequest(query))
+
+				assert.Equal(g, http.StatusOK, w.Code)
+				assert.Equal(g, "hello "+newRequest(query).URL.Query().Get("name"), w.Body.String())
+			}
+		})
+
+		g.It("should reset the objects before recycling them", func() {
+			resets := 0
+			pool := newRequestPool(&pooledRequest{Prefix: "hello "})
+
+			obj := pool.get().Interface().(*pooledRequest)
+			obj.Query.Name = "rex"
+			obj.resets = &resets
+			obj.visited = true
+			pool.put(reflect.ValueOf(obj))
+			assert.Equal(g, 1, resets)
+
+			// unexported fields are not copied from the prototype
+			obj = pool.get().Interface().(*pooledRequest)
+			assert.Equal(g, "hello ", obj.Prefix)
+			assert.Empty(g, obj.Query.Name)
+			assert.False(g, obj.visited)
+		})
+	})
+}
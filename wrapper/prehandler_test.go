@@ -0,0 +1,115 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+type preHandledRequest struct {
+	Path  struct{}
+	Query struct {
+		Tenant string
+		Fail   bool
+	}
+
+	Calls *[]string
+}
+
+func (r *preHandledRequest) PreHandle(ctx context.Context) (context.Context, error) {
+	*r.Calls = append(*r.Calls, "PreHandle")
+	if r.Query.Fail {
+		return nil, errors.New("rejected")
+	}
+	return context.WithValue(ctx, tenantKey{}, r.Query.Tenant), nil
+}
+
+func (r *preHandledRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	*r.Calls = append(*r.Calls, "Handle")
+	_, err := w.Write([]byte(ctx.Value(tenantKey{}).(string)))
+	return err
+}
+
+func (r *preHandledRequest) HandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	*r.Calls = append(*r.Calls, "HandleError")
+	http.Error(w, err.Error(), http.StatusForbidden)
+}
+
+func TestPreHandlers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("PreHandlers", func() {
+		var calls []string
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(query string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		hook := func(name string, fail bool) PreHandlerFunc {
+			return func(ctx context.Context, req interface{}) (context.Context, error) {
+				r, ok := req.(*preHandledRequest)
+				require.True(g, ok)
+				require.Equal(g, "acme", r.Query.Tenant)
+
+				calls = append(calls, name)
+				if fail {
+					return ctx, errors.New(name + " failed")
+				}
+				return ctx, nil
+			}
+		}
+
+		g.BeforeEach(func() {
+			calls = []string{}
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should run hooks in order before Handle", func() {
+			handler := WrapRequestWithOptions(&preHandledRequest{Calls: &calls}, &Options{
+				PreHandlers: []PreHandlerFunc{hook("first", false), hook("second", false)},
+			})
+			handler(w, newRequest("tenant=acme"))
+
+			assert.Equal(g, []string{"first", "second", "PreHandle", "Handle"}, calls)
+		})
+
+		g.It("should give the returned context to Handle", func() {
+			handler := WrapRequest(&preHandledRequest{Calls: &calls})
+			handler(w, newRequest("tenant=acme"))
+
+			assert.Equal(g, "acme", w.Body.String())
+		})
+
+		g.It("should stop at the first hook error", func() {
+			handler := WrapRequestWithOptions(&preHandledRequest{Calls: &calls}, &Options{
+				PreHandlers: []PreHandlerFunc{hook("first", true), hook("second", false)},
+			})
+			handler(w, newRequest("tenant=acme"))
+
+			assert.Equal(g, []string{"first", "HandleError"}, calls)
+			assert.Equal(g, http.StatusForbidden, w.Code)
+			assert.Equal(g, "first failed\n", w.Body.String())
+		})
+
+		g.It("should stop when PreHandle fails", func() {
+			handler := WrapRequestWithOptions(&preHandledRequest{Calls: &calls}, &Options{
+				PreHandlers: []PreHandlerFunc{hook("first", false)},
+			})
+			handler(w, newRequest("tenant=acme&fail=true"))
+
+			assert.Equal(g, []string{"first", "PreHandle", "HandleError"}, calls)
+			assert.Equal(g, http.StatusForbidden, w.Code)
+		})
+	})
+}
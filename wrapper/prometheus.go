@@ -0,0 +1,242 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultPrometheusBuckets are the upper bounds of the histograms, in
+// milliseconds for the durations
+var DefaultPrometheusBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusMeter is a Meter keeping the metrics in memory and serving them
+// in the Prometheus text format, for the apis not exporting otel metrics
+type PrometheusMeter struct {
+	mu       sync.Mutex
+	buckets  []float64
+	families map[string]*promFamily
+}
+
+type promFamily struct {
+	name   string
+	help   string
+	kind   string
+	series map[string]*promSeries
+}
+
+type promSeries struct {
+	labels  string
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+// NewPrometheusMeter returns an empty meter, the histograms use buckets
+// (DefaultPrometheusBuckets if none)
+func NewPrometheusMeter(buckets ...float64) *PrometheusMeter {
+	if len(buckets) == 0 {
+		buckets = DefaultPrometheusBuckets
+	}
+
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusMeter{
+		buckets:  sorted,
+		families: map[string]*promFamily{},
+	}
+}
+
+// MountMetrics serves the metrics of meter on GET /metrics
+func MountMetrics(r chi.Router, meter *PrometheusMeter) {
+	r.Method(http.MethodGet, "/metrics", meter)
+}
+
+// Int64Counter implements Meter, the counters are suffixed with _total
+func (m *PrometheusMeter) Int64Counter(name string, description string) Int64Counter {
+	name = prometheusName(name)
+	if !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+
+	return &promInstrument{meter: m, family: m.family(name, description, "counter")}
+}
+
+// Int64UpDownCounter implements Meter with a gauge
+func (m *PrometheusMeter) Int64UpDownCounter(name string, description string) Int64Counter {
+	return &promInstrument{meter: m, family: m.family(prometheusName(name), description, "gauge")}
+}
+
+// Float64Histogram implements Meter
+func (m *PrometheusMeter) Float64Histogram(name string, description string) Float64Histogram {
+	return &promInstrument{meter: m, family: m.family(prometheusName(name), description, "histogram")}
+}
+
+// family returns the metric named name, created if needed
+func (m *PrometheusMeter) family(name string, help string, kind string) *promFamily {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, found := m.families[name]
+	if !found {
+		f = &promFamily{name: name, help: help, kind: kind, series: map[string]*promSeries{}}
+		m.families[name] = f
+	}
+
+	return f
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (m *PrometheusMeter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_, _ = w.Write([]byte(m.String()))
+}
+
+// String returns the metrics in the Prometheus text format
+func (m *PrometheusMeter) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := m.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			m.writeSeries(&b, f, f.series[key])
+		}
+	}
+
+	return b.String()
+}
+
+func (m *PrometheusMeter) writeSeries(b *strings.Builder, f *promFamily, s *promSeries) {
+	if f.kind != "histogram" {
+		fmt.Fprintf(b, "%s%s %s\n", f.name, braces(s.labels), formatFloat(s.value))
+		return
+	}
+
+	for i, bound := range m.buckets {
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, braces(joinLabels(s.labels, `le="`+formatFloat(bound)+`"`)), s.buckets[i])
+	}
+	fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, braces(joinLabels(s.labels, `le="+Inf"`)), s.count)
+	fmt.Fprintf(b, "%s_sum%s %s\n", f.name, braces(s.labels), formatFloat(s.value))
+	fmt.Fprintf(b, "%s_count%s %d\n", f.name, braces(s.labels), s.count)
+}
+
+// promInstrument implements the counters and histograms of a family
+type promInstrument struct {
+	meter  *PrometheusMeter
+	family *promFamily
+}
+
+func (i *promInstrument) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.Record(ctx, float64(incr), attrs...)
+}
+
+func (i *promInstrument) Record(ctx context.Context, value float64, attrs ...attribute.KeyValue) {
+	labels := prometheusLabels(attrs)
+
+	i.meter.mu.Lock()
+	defer i.meter.mu.Unlock()
+
+	s, found := i.family.series[labels]
+	if !found {
+		s = &promSeries{labels: labels}
+		if i.family.kind == "histogram" {
+			s.buckets = make([]uint64, len(i.meter.buckets))
+		}
+		i.family.series[labels] = s
+	}
+
+	s.value += value
+	if i.family.kind != "histogram" {
+		return
+	}
+
+	s.count++
+	for n, bound := range i.meter.buckets {
+		if value <= bound {
+			s.buckets[n]++
+		}
+	}
+}
+
+// prometheusName replaces the characters not allowed in the names (ex: ".")
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if ((r >= 'a') && (r <= 'z')) || ((r >= 'A') && (r <= 'Z')) || ((r >= '0') && (r <= '9')) || (r == '_') || (r == ':') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// prometheusLabels formats the attributes sorted by name
+func prometheusLabels(attrs []attribute.KeyValue) string {
+	set := attribute.NewSet(attrs...)
+
+	labels := make([]string, 0, set.Len())
+	iter := set.Iter()
+	for iter.Next() {
+		attr := iter.Attribute()
+		labels = append(labels, prometheusName(string(attr.Key))+`="`+escapeLabel(attr.Value.Emit())+`"`)
+	}
+
+	return strings.Join(labels, ",")
+}
+
+func joinLabels(labels string, label string) string {
+	if labels == "" {
+		return label
+	}
+
+	return labels + "," + label
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func escapeHelp(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
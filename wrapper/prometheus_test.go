@@ -0,0 +1,70 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestPrometheusMeter(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("PrometheusMeter", func() {
+		g.It("should serve the metrics of the handlers", func() {
+			meter := NewPrometheusMeter()
+
+			router := chi.NewRouter()
+			router.Get("/pets/{Id}", WrapRequestWithOptions(&measuredRequest{}, &Options{Meter: meter}))
+			MountMetrics(router, meter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/2", nil))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, prometheusContentType, w.Header().Get("Content-Type"))
+
+			body := w.Body.String()
+			assert.Contains(g, body, "# TYPE http_server_request_count_total counter\n")
+			assert.Contains(g, body, `http_server_request_count_total{http_method="GET",http_route="/pets/{Id}",http_status_code="200"} 2`)
+			assert.Contains(g, body, "# TYPE http_server_active_requests gauge\n")
+			assert.Contains(g, body, `http_server_active_requests{http_method="GET",http_route="/pets/{Id}"} 0`)
+			assert.Contains(g, body, "# TYPE http_server_duration histogram\n")
+			assert.Contains(g, body, `http_server_duration_bucket{http_method="GET",http_route="/pets/{Id}",http_status_code="200",le="+Inf"} 2`)
+			assert.Contains(g, body, `http_server_duration_count{http_method="GET",http_route="/pets/{Id}",http_status_code="200"} 2`)
+		})
+
+		g.It("should fill the histogram buckets", func() {
+			meter := NewPrometheusMeter(10, 1)
+			histogram := meter.Float64Histogram("latency", "latency")
+
+			histogram.Record(context.Background(), 0.5)
+			histogram.Record(context.Background(), 5)
+			histogram.Record(context.Background(), 50)
+
+			assert.Equal(g, `# HELP latency latency
+# TYPE latency histogram
+latency_bucket{le="1"} 1
+latency_bucket{le="10"} 2
+latency_bucket{le="+Inf"} 3
+latency_sum 55.5
+latency_count 3
+`, meter.String())
+		})
+
+		g.It("should escape the label values", func() {
+			meter := NewPrometheusMeter()
+			meter.Int64Counter("calls", "calls").Add(context.Background(), 1, attribute.String("name", `say "hi"`))
+
+			assert.Contains(g, meter.String(), `calls_total{name="say \"hi\""} 1`)
+		})
+	})
+}
@@ -0,0 +1,221 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schmurfy/chipi/response"
+)
+
+// RateLimit allows Requests per Window for each client of an operation
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// String returns the limit in the format of the `rate-limit` tag
+func (l *RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseRateLimit parses a limit like "100/1m" (or "10/s")
+func ParseRateLimit(value string) (*RateLimit, error) {
+	requests, window, found := strings.Cut(value, "/")
+	if !found {
+		return nil, fmt.Errorf("rate limit %q must be requests/window", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || (n <= 0) {
+		return nil, fmt.Errorf("invalid number of requests in rate limit %q", value)
+	}
+
+	// "s", "m" and "h" are a single unit
+	window = strings.TrimSpace(window)
+	if (window != "") && ((window[0] < '0') || (window[0] > '9')) {
+		window = "1" + window
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || (d <= 0) {
+		return nil, fmt.Errorf("invalid window in rate limit %q", value)
+	}
+
+	return &RateLimit{Requests: n, Window: d}, nil
+}
+
+// OperationRateLimit returns the limit declared with the `rate-limit` tag of
+// the Path field of the request object typ (ex: `rate-limit:"100/1m"`), nil if
+// there is none
+func OperationRateLimit(typ reflect.Type) *RateLimit {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return nil
+	}
+
+	value, found := pathField.Tag.Lookup("rate-limit")
+	if !found {
+		return nil
+	}
+
+	// invalid limits are reported by Verify
+	limit, err := ParseRateLimit(value)
+	if err != nil {
+		return nil
+	}
+
+	return limit
+}
+
+// RateLimitStore counts the requests of the clients, it can be shared by
+// the instances of the api (ex: a Redis implementation) to enforce global limits
+type RateLimitStore interface {
+	// Take counts a request for key and returns the number of requests in
+	// the current window and when this window ends
+	Take(ctx context.Context, key string, window time.Duration) (int, time.Time, error)
+}
+
+// RateLimitOptions enable the rate limiting of the operations
+type RateLimitOptions struct {
+	// Store counts the requests (default: a MemoryRateLimitStore)
+	Store RateLimitStore
+
+	// Limit applies to the operations without `rate-limit` tag, they are
+	// not limited if nil
+	Limit *RateLimit
+
+	// Key identifies the client of a request (default: its remote address)
+	Key func(r *http.Request) string
+}
+
+func (o *RateLimitOptions) key(r *http.Request) string {
+	if o.Key != nil {
+		return o.Key(r)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// rateLimiter enforces the limit of an operation
+type rateLimiter struct {
+	operation string
+	limit     *RateLimit
+	options   *RateLimitOptions
+}
+
+// newRateLimiter returns the limiter of the request object typ, nil if
+// the operation is not limited
+func newRateLimiter(typ reflect.Type, opts *RateLimitOptions) *rateLimiter {
+	if opts == nil {
+		return nil
+	}
+
+	limit := OperationRateLimit(typ)
+	if limit == nil {
+		limit = opts.Limit
+	}
+
+	if limit == nil {
+		return nil
+	}
+
+	if opts.Store == nil {
+		copied := *opts
+		copied.Store = NewMemoryRateLimitStore()
+		opts = &copied
+	}
+
+	return &rateLimiter{operation: operationKey(typ), limit: limit, options: opts}
+}
+
+// allow counts the request and sets the RateLimit headers, returns
+// false once the client exceeded the limit
+func (l *rateLimiter) allow(ctx context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
+	count, reset, err := l.options.Store.Take(ctx, l.operation+":"+l.options.key(r), l.limit.Window)
+	if err != nil {
+		return true, err
+	}
+
+	remaining := l.limit.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	seconds := int(time.Until(reset).Round(time.Second) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(l.limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds))
+
+	if count <= l.limit.Requests {
+		return true, nil
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return false, nil
+}
+
+// writeRateLimited sends the 429 problem
+func writeRateLimited(w http.ResponseWriter, limit *RateLimit) {
+	response.NewProblem(http.StatusTooManyRequests, fmt.Sprintf("rate limit of %s exceeded", limit)).Write(w)
+}
+
+// MemoryRateLimitStore counts the requests in fixed windows in memory, the
+// limits only apply to the current process
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	nextSweep time.Time
+}
+
+type rateWindow struct {
+	count int
+	end   time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{windows: map[string]*rateWindow{}}
+}
+
+// Take implements RateLimitStore
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the ended windows are removed from time to time
+	if now.After(s.nextSweep) {
+		for k, w := range s.windows {
+			if !now.Before(w.end) {
+				delete(s.windows, k)
+			}
+		}
+		s.nextSweep = now.Add(window)
+	}
+
+	w, found := s.windows[key]
+	if !found || !now.Before(w.end) {
+		w = &rateWindow{end: now.Add(window)}
+		s.windows[key] = w
+	}
+	w.count++
+
+	return w.count, w.end, nil
+}
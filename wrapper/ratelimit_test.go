@@ -0,0 +1,155 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type limitedRequest struct {
+	Path     struct{} `rate-limit:"2/1m"`
+	Response struct{}
+}
+
+func (r *limitedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type unlimitedRequest struct {
+	Path     struct{}
+	Response struct{}
+}
+
+func (r *unlimitedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type invalidRateLimitRequest struct {
+	Path struct{} `rate-limit:"many"`
+}
+
+func (r *invalidRateLimitRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("ParseRateLimit", func() {
+		g.It("should parse the limits", func() {
+			limit, err := ParseRateLimit("100/1m")
+			require.NoError(g, err)
+			assert.Equal(g, &RateLimit{Requests: 100, Window: time.Minute}, limit)
+
+			limit, err = ParseRateLimit("10/s")
+			require.NoError(g, err)
+			assert.Equal(g, &RateLimit{Requests: 10, Window: time.Second}, limit)
+		})
+
+		g.It("should reject the invalid limits", func() {
+			for _, value := range []string{"100", "0/1m", "a/1m", "10/0s", "10/soon"} {
+				_, err := ParseRateLimit(value)
+				assert.Error(g, err, value)
+			}
+		})
+	})
+
+	g.Describe("RateLimit", func() {
+		send := func(handler http.HandlerFunc, remoteAddr string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = remoteAddr
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+
+		g.It("should reject the requests over the limit of the operation", func() {
+			handler := WrapRequestWithOptions(&limitedRequest{}, &Options{RateLimit: &RateLimitOptions{}})
+
+			w := send(handler, "10.0.0.1:1234")
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "2", w.Header().Get("RateLimit-Limit"))
+			assert.Equal(g, "1", w.Header().Get("RateLimit-Remaining"))
+			assert.Equal(g, "60", w.Header().Get("RateLimit-Reset"))
+
+			w = send(handler, "10.0.0.1:1235")
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "0", w.Header().Get("RateLimit-Remaining"))
+
+			w = send(handler, "10.0.0.1:1236")
+			assert.Equal(g, http.StatusTooManyRequests, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(g, "60", w.Header().Get("Retry-After"))
+
+			// counted by client
+			w = send(handler, "10.0.0.2:1234")
+			assert.Equal(g, http.StatusOK, w.Code)
+		})
+
+		g.It("should apply the default limit to the other operations", func() {
+			opts := &Options{RateLimit: &RateLimitOptions{
+				Limit: &RateLimit{Requests: 1, Window: time.Minute},
+				Key:   func(r *http.Request) string { return "everyone" },
+			}}
+			handler := WrapRequestWithOptions(&unlimitedRequest{}, opts)
+
+			assert.Equal(g, http.StatusOK, send(handler, "10.0.0.1:1234").Code)
+			assert.Equal(g, http.StatusTooManyRequests, send(handler, "10.0.0.2:1234").Code)
+
+			// the tag has priority
+			handler = WrapRequestWithOptions(&limitedRequest{}, opts)
+			assert.Equal(g, http.StatusOK, send(handler, "10.0.0.1:1234").Code)
+			assert.Equal(g, http.StatusOK, send(handler, "10.0.0.1:1234").Code)
+		})
+
+		g.It("should not limit the operations without limit", func() {
+			handler := WrapRequestWithOptions(&unlimitedRequest{}, &Options{RateLimit: &RateLimitOptions{}})
+
+			w := send(handler, "10.0.0.1:1234")
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Empty(g, w.Header().Get("RateLimit-Limit"))
+		})
+
+		g.It("should let the requests through when the store fails", func() {
+			handler := WrapRequestWithOptions(&limitedRequest{}, &Options{RateLimit: &RateLimitOptions{Store: failingRateLimitStore{}}})
+			assert.Equal(g, http.StatusOK, send(handler, "10.0.0.1:1234").Code)
+		})
+
+		g.It("should start a new window once the previous one ended", func() {
+			store := NewMemoryRateLimitStore()
+
+			count, _, err := store.Take(context.Background(), "key", 10*time.Millisecond)
+			require.NoError(g, err)
+			assert.Equal(g, 1, count)
+
+			count, _, _ = store.Take(context.Background(), "key", 10*time.Millisecond)
+			assert.Equal(g, 2, count)
+
+			time.Sleep(15 * time.Millisecond)
+			count, _, _ = store.Take(context.Background(), "key", 10*time.Millisecond)
+			assert.Equal(g, 1, count)
+		})
+
+		g.It("should report the invalid limits", func() {
+			err := Verify(&invalidRateLimitRequest{}, "GET", "/")
+			assert.Error(g, err)
+			assert.Contains(g, err.Error(), `rate limit "many"`)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+)
+
+// RecordedResponse is a response kept to be sent again
+type RecordedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// Fingerprint identifies the request of the idempotent responses
+	Fingerprint string
+}
+
+// write sends the recorded response to w
+func (resp *RecordedResponse) write(w http.ResponseWriter) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// responseRecorder keeps a copy of the response written to the underlying
+// ResponseWriter, the headers set before it was created are not recorded
+type responseRecorder struct {
+	http.ResponseWriter
+	before http.Header
+	header http.Header
+	status int
+	body   bytes.Buffer
+
+	// called with the status before the headers are recorded
+	onHeader func(status int)
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, before: w.Header().Clone()}
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.start(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.start(http.StatusOK)
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) start(status int) {
+	w.status = status
+	if w.onHeader != nil {
+		w.onHeader(status)
+	}
+
+	w.header = http.Header{}
+	for name, values := range w.Header() {
+		if !reflect.DeepEqual(w.before[name], values) {
+			w.header[name] = append([]string{}, values...)
+		}
+	}
+}
+
+// recorded returns the response written, nil if nothing was written
+func (w *responseRecorder) recorded() *RecordedResponse {
+	if w.status == 0 {
+		return nil
+	}
+
+	return &RecordedResponse{
+		Status: w.status,
+		Header: w.header,
+		Body:   w.body.Bytes(),
+	}
+}
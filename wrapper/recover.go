@@ -0,0 +1,52 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/schmurfy/chipi/response"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PanicHandlerFunc is called instead of HandleError when the handler panics
+type PanicHandlerFunc func(ctx context.Context, w http.ResponseWriter, err *PanicError)
+
+// PanicError wraps the value given to panic, it unwraps to a 500 Problem
+// so the error handlers report it as an internal error
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return response.NewProblem(http.StatusInternalServerError, "")
+}
+
+// recoverPanic converts a panic to a PanicError which is recorded on the span
+// and given to the panic handler, HandleError or written as a Problem
+func recoverPanic(ctx context.Context, w http.ResponseWriter, obj interface{}, panicHandler PanicHandlerFunc, value interface{}) error {
+	// the server aborts the connection silently for this one
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+
+	err := &PanicError{Value: value, Stack: debug.Stack()}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(codes.Error, err.Error())
+
+	if panicHandler != nil {
+		panicHandler(ctx, w, err)
+	} else {
+		handleError(ctx, w, obj, err)
+	}
+
+	return err
+}
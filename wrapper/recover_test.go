@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panickingRequest struct {
+	Path struct{}
+}
+
+func (r *panickingRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	panic("boom")
+}
+
+type panickingWithErrorHandlerRequest struct {
+	response.ErrorEncoder
+	panickingRequest
+}
+
+type abortedRequest struct {
+	Path struct{}
+}
+
+func (r *abortedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	panic(http.ErrAbortHandler)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Panic recovery", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func() *http.Request {
+			req := httptest.NewRequest("GET", "/", nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should return a 500 problem", func() {
+			WrapRequest(&panickingRequest{})(w, newRequest())
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should give the panic to HandleError", func() {
+			WrapRequest(&panickingWithErrorHandlerRequest{})(w, newRequest())
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should call the panic handler", func() {
+			var recovered *PanicError
+
+			opts := &Options{
+				PanicHandler: func(ctx context.Context, w http.ResponseWriter, err *PanicError) {
+					recovered = err
+					w.WriteHeader(http.StatusServiceUnavailable)
+				},
+			}
+			WrapRequestWithOptions(&panickingWithErrorHandlerRequest{}, opts)(w, newRequest())
+
+			assert.Equal(g, http.StatusServiceUnavailable, w.Code)
+			require.NotNil(g, recovered)
+			assert.Equal(g, "boom", recovered.Value)
+			assert.Equal(g, "panic: boom", recovered.Error())
+			assert.NotEmpty(g, recovered.Stack)
+		})
+
+		g.It("should not recover aborted requests", func() {
+			handler := WrapRequest(&abortedRequest{})
+
+			assert.PanicsWithValue(g, http.ErrAbortHandler, func() {
+				handler(w, newRequest())
+			})
+		})
+	})
+}
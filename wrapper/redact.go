@@ -0,0 +1,167 @@
+package wrapper
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+const (
+	RedactedValue = "[REDACTED]"
+)
+
+var (
+	_sensitiveTypes sync.Map
+
+	// lower cased names of the fields sensitive without tag
+	_sensitiveNames sync.Map
+)
+
+// AddSensitiveNames declares names of fields always handled as if they were
+// tagged with `chipi:"sensitive"` (ex: "password", "authorization"), they are
+// compared without case to the Go, json and parameter names of the fields,
+// it must be called before the handlers are wrapped
+func AddSensitiveNames(names ...string) {
+	for _, name := range names {
+		_sensitiveNames.Store(strings.ToLower(name), true)
+	}
+
+	// the types checked before may have changed
+	_sensitiveTypes.Range(func(key, value interface{}) bool {
+		_sensitiveTypes.Delete(key)
+		return true
+	})
+}
+
+func isSensitiveField(f reflect.StructField) bool {
+	tag := schema.ParseJsonTag(f)
+	if (tag.Sensitive != nil) && *tag.Sensitive {
+		return true
+	}
+
+	for _, name := range []string{f.Name, tag.Name, f.Tag.Get("name")} {
+		if _, found := _sensitiveNames.Load(strings.ToLower(name)); found && (name != "") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns a copy of obj where every field tagged with `chipi:"sensitive"`
+// is masked, strings are replaced by RedactedValue and other types are zeroed.
+// obj itself is never modified.
+func Redact(obj interface{}) interface{} {
+	if obj == nil {
+		return nil
+	}
+
+	return redactValue(reflect.ValueOf(obj)).Interface()
+}
+
+func redactValue(v reflect.Value) reflect.Value {
+	if !hasSensitiveFields(v.Type()) {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		ret := reflect.New(v.Type().Elem())
+		ret.Elem().Set(redactValue(v.Elem()))
+		return ret
+
+	case reflect.Struct:
+		ret := reflect.New(v.Type()).Elem()
+		ret.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			f := ret.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+
+			if isSensitiveField(v.Type().Field(i)) {
+				f.Set(maskedValue(f.Type()))
+			} else {
+				f.Set(redactValue(v.Field(i)))
+			}
+		}
+		return ret
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		ret := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			ret.Index(i).Set(redactValue(v.Index(i)))
+		}
+		return ret
+
+	case reflect.Array:
+		ret := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			ret.Index(i).Set(redactValue(v.Index(i)))
+		}
+		return ret
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		ret := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			ret.SetMapIndex(iter.Key(), redactValue(iter.Value()))
+		}
+		return ret
+	}
+
+	return v
+}
+
+func maskedValue(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(RedactedValue).Convert(t)
+	}
+
+	return reflect.Zero(t)
+}
+
+// hasSensitiveFields returns true if a value of type t may contain a sensitive
+// field, results are cached per type
+func hasSensitiveFields(t reflect.Type) bool {
+	if ret, found := _sensitiveTypes.Load(t); found {
+		return ret.(bool)
+	}
+
+	ret := lookupSensitiveFields(t, map[reflect.Type]bool{})
+	_sensitiveTypes.Store(t, ret)
+	return ret
+}
+
+func lookupSensitiveFields(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return lookupSensitiveFields(t.Elem(), visited)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if isSensitiveField(f) || lookupSensitiveFields(f.Type, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,270 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/schmurfy/chipi/schema"
+)
+
+var (
+	_renamedTypes sync.Map
+)
+
+// renamedField is a field known by encoding/json, name is the documented
+// one (from `chipi:"name=..."`)
+type renamedField struct {
+	index     int
+	name      string
+	jsonName  string
+	excluded  bool
+	readOnly  bool
+	writeOnly bool
+	embedded  bool
+}
+
+// renamedFields returns the json fields of the structure t
+func renamedFields(t reflect.Type) []renamedField {
+	ret := []renamedField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := schema.ParseJsonTag(f)
+
+		if f.Tag.Get("json") == "-" {
+			continue
+		}
+
+		// encoding/json promotes the fields of embedded structures
+		_, named := f.Tag.Lookup("json")
+		embedded := f.Anonymous && !named && (derefType(f.Type).Kind() == reflect.Struct)
+		if !f.IsExported() && !embedded {
+			continue
+		}
+
+		ret = append(ret, renamedField{
+			index:     i,
+			name:      tag.Name,
+			jsonName:  tag.JsonName,
+			excluded:  (tag.Excluded != nil) && *tag.Excluded,
+			readOnly:  (tag.ReadOnly != nil) && *tag.ReadOnly,
+			writeOnly: (tag.WriteOnly != nil) && *tag.WriteOnly,
+			embedded:  embedded,
+		})
+	}
+
+	return ret
+}
+
+// hasRenamedFields returns true if a value of type t may contain a field
+// excluded, renamed, read only or write only by its chipi tag, results are
+// cached per type
+func hasRenamedFields(t reflect.Type) bool {
+	if ret, found := _renamedTypes.Load(t); found {
+		return ret.(bool)
+	}
+
+	ret := lookupRenamedFields(t, map[reflect.Type]bool{})
+	_renamedTypes.Store(t, ret)
+	return ret
+}
+
+func lookupRenamedFields(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return lookupRenamedFields(t.Elem(), visited)
+
+	case reflect.Struct:
+		for _, field := range renamedFields(t) {
+			if field.excluded || field.readOnly || field.writeOnly || (field.name != field.jsonName) || lookupRenamedFields(t.Field(field.index).Type, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// renameMode selects how the keys of a json value are rewritten
+type renameMode struct {
+	// decoding converts the documented names to the json ones
+	decoding bool
+	// request bodies skip the read only fields, responses the write only ones
+	request bool
+}
+
+var (
+	_decodeRequest  = renameMode{decoding: true, request: true}
+	_encodeRequest  = renameMode{decoding: false, request: true}
+	_decodeResponse = renameMode{decoding: true, request: false}
+	_encodeResponse = renameMode{decoding: false, request: false}
+)
+
+// renameBody rewrites a json body sent with the documented names into the
+// one expected by encoding/json for a value of type t, the excluded fields
+// are dropped and the read only ones are reported in parsingErrors
+func renameBody(body io.Reader, t reflect.Type, path string, parsingErrors map[string]string) (io.ReadCloser, error) {
+	var decoded interface{}
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	err := decoder.Decode(&decoded)
+	if err == io.EOF {
+		return io.NopCloser(&bytes.Buffer{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(renameKeys(decoded, t, _decodeRequest, path, parsingErrors))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// renameResponse returns obj as encoded by encoding/json with the documented
+// names, the excluded and write only fields are dropped
+func renameResponse(obj interface{}) (interface{}, error) {
+	return renameValue(obj, _encodeResponse)
+}
+
+func renameValue(obj interface{}, mode renameMode) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decodeJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return renameKeys(decoded, reflect.TypeOf(obj), mode, "", nil), nil
+}
+
+// EncodeRequestBody encodes a json Body the way the clients have to send
+// it: with the names of the `chipi:"name=..."` tags and without the
+// excluded and read only fields
+func EncodeRequestBody(body interface{}) ([]byte, error) {
+	renamed, err := renameValue(body, _encodeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(renamed)
+}
+
+// DecodeResponseBody decodes a json Response sent by a handler into target,
+// see EncodeRequestBody
+func DecodeResponseBody(data []byte, target interface{}) error {
+	decoded, err := decodeJsonValue(data)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(renameKeys(decoded, reflect.TypeOf(target), _decodeResponse, "", nil))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+// renameKeys walks the decoded json value of type t, the read only fields
+// of the decoded requests are reported in parsingErrors
+func renameKeys(value interface{}, t reflect.Type, mode renameMode, path string, parsingErrors map[string]string) interface{} {
+	if (t == nil) || !hasRenamedFields(t) {
+		return value
+	}
+	t = derefType(t)
+
+	switch v := value.(type) {
+	case []interface{}:
+		if (t.Kind() == reflect.Slice) || (t.Kind() == reflect.Array) {
+			for i, item := range v {
+				v[i] = renameKeys(item, t.Elem(), mode, fmt.Sprintf("%s[%d]", path, i), parsingErrors)
+			}
+		}
+
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, item := range v {
+				v[key] = renameKeys(item, t.Elem(), mode, path+"."+key, parsingErrors)
+			}
+
+		case reflect.Struct:
+			ret := map[string]interface{}{}
+			renameStructKeys(v, ret, t, mode, path, parsingErrors)
+			return ret
+		}
+	}
+
+	return value
+}
+
+func renameStructKeys(src map[string]interface{}, dst map[string]interface{}, t reflect.Type, mode renameMode, path string, parsingErrors map[string]string) {
+	for _, field := range renamedFields(t) {
+		fieldType := t.Field(field.index).Type
+
+		if field.embedded {
+			renameStructKeys(src, dst, derefType(fieldType), mode, path, parsingErrors)
+			continue
+		}
+
+		skipped := field.excluded ||
+			((mode == _encodeRequest) && field.readOnly) ||
+			((mode == _encodeResponse) && field.writeOnly)
+		if skipped {
+			continue
+		}
+
+		from, to := field.jsonName, field.name
+		if mode.decoding {
+			from, to = to, from
+		}
+
+		item, found := src[from]
+		if !found {
+			continue
+		}
+
+		fieldPath := path + "." + field.name
+		if (mode == _decodeRequest) && field.readOnly {
+			parsingErrors[fieldPath] = "is read only"
+			continue
+		}
+
+		dst[to] = renameKeys(item, fieldType, mode, fieldPath, parsingErrors)
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// decodeJsonValue decodes data into the generic json values, the numbers
+// are kept as json.Number as float64 would round the large integers
+func decodeJsonValue(data []byte) (interface{}, error) {
+	var decoded interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&decoded)
+
+	return decoded, err
+}
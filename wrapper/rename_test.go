@@ -0,0 +1,164 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type renamedOwner struct {
+	Name string `json:"name" chipi:"name=full_name"`
+}
+
+type renamedPet struct {
+	Name     string         `json:"name" chipi:"name=pet_name"`
+	Secret   string         `json:"secret" chipi:"-"`
+	Age      int            `json:"age"`
+	Owners   []renamedOwner `json:"owners"`
+	Internal string         `chipi:"name=internal_id"`
+}
+
+type renamedPetRequest struct {
+	Path     struct{}
+	Body     renamedPet
+	Response renamedPet
+}
+
+func (r *renamedPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = r.Body
+	r.Response.Secret = "hunter2"
+	return nil
+}
+
+type brokenPetName string
+
+func (n brokenPetName) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("broken name")
+}
+
+type brokenPetRequest struct {
+	Path     struct{}
+	Response struct {
+		Name brokenPetName `json:"name" chipi:"name=pet_name"`
+	}
+}
+
+func (r *brokenPetRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type accountProfile struct {
+	CreatedAt string `json:"created_at" chipi:"readonly"`
+	Bio       string `json:"bio"`
+}
+
+type account struct {
+	ID       string           `json:"id" chipi:"readonly"`
+	Password string           `json:"password" chipi:"writeonly"`
+	Login    string           `json:"login"`
+	Profiles []accountProfile `json:"profiles"`
+}
+
+type createAccountRequest struct {
+	Path     struct{}
+	Body     account
+	Response account
+}
+
+func (r *createAccountRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = r.Body
+	r.Response.ID = "42"
+	return nil
+}
+
+func TestRenamedFields(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("renamed fields", func() {
+		newRequest := func(body string) *http.Request {
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+			return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		decode := func(body string) (*renamedPetRequest, map[string]string) {
+			errs := map[string]string{}
+			obj, _, _ := createFilledRequestObject(newRequest(body), &renamedPetRequest{}, errs)
+			return obj.Interface().(*renamedPetRequest), errs
+		}
+
+		g.It("should bind the body with the documented names", func() {
+			req, errs := decode(`{"pet_name": "Rex", "age": 3, "owners": [{"full_name": "Joe"}], "internal_id": "x1"}`)
+			require.Empty(g, errs)
+			assert.Equal(g, renamedPet{
+				Name:     "Rex",
+				Age:      3,
+				Owners:   []renamedOwner{{Name: "Joe"}},
+				Internal: "x1",
+			}, req.Body)
+		})
+
+		g.It("should ignore the json names and the excluded fields", func() {
+			req, errs := decode(`{"name": "Rex", "secret": "hunter2"}`)
+			require.Empty(g, errs)
+			assert.Equal(g, renamedPet{}, req.Body)
+		})
+
+		g.It("should accept an empty body", func() {
+			_, errs := decode(``)
+			assert.Empty(g, errs)
+		})
+
+		g.It("should encode the response with the documented names", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&renamedPetRequest{})(w, newRequest(`{"pet_name": "Rex", "owners": [{"full_name": "Joe"}]}`))
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"pet_name": "Rex", "age": 0, "owners": [{"full_name": "Joe"}], "internal_id": ""}`, w.Body.String())
+		})
+
+		g.It("should send a problem when the response cannot be renamed", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&brokenPetRequest{})(w, newRequest(``))
+
+			require.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+		})
+
+		g.It("should reject the read only fields sent in the body", func() {
+			errs := map[string]string{}
+			_, _, err := createFilledRequestObject(newRequest(`{"id": "1", "login": "joe", "profiles": [{"bio": "hi", "created_at": "now"}]}`), &createAccountRequest{}, errs)
+			require.Error(g, err)
+			assert.Equal(g, map[string]string{
+				"request.body.id":                     "is read only",
+				"request.body.profiles[0].created_at": "is read only",
+			}, errs)
+		})
+
+		g.It("should encode the request bodies like a client", func() {
+			data, err := EncodeRequestBody(account{ID: "1", Login: "joe", Password: "hunter2"})
+			require.NoError(g, err)
+			assert.JSONEq(g, `{"login": "joe", "password": "hunter2", "profiles": null}`, string(data))
+
+			var pet renamedPet
+			err = DecodeResponseBody([]byte(`{"pet_name": "Rex", "owners": [{"full_name": "Joe"}]}`), &pet)
+			require.NoError(g, err)
+			assert.Equal(g, renamedPet{Name: "Rex", Owners: []renamedOwner{{Name: "Joe"}}}, pet)
+		})
+
+		g.It("should not send the write only fields", func() {
+			w := httptest.NewRecorder()
+			WrapRequest(&createAccountRequest{})(w, newRequest(`{"login": "joe", "password": "hunter2"}`))
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"id": "42", "login": "joe", "profiles": null}`, w.Body.String())
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/schmurfy/chipi/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID can be injected in the fields of the request objects tagged
+// `chipi:"inject"` to get the id of the request (see Options.RequestID)
+type RequestID string
+
+type requestIDKey struct{}
+
+// RequestIDOptions enable the request ids: the X-Request-Id header of the
+// request is used (or an id is generated), stored in the context, recorded
+// on the span and sent back with the response and in the error bodies
+type RequestIDOptions struct {
+	// Generate returns the ids of the requests without valid X-Request-Id
+	// header (default: 32 random hex characters)
+	Generate func() string
+
+	// IgnoreHeader, when set, always generates the ids (ex: the api is not
+	// behind a proxy setting the header)
+	IgnoreHeader bool
+}
+
+func init() {
+	Provide(func(ctx context.Context) RequestID {
+		return RequestID(RequestIDFromContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the id of the request handled with ctx,
+// empty if the request ids are not enabled
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithRequestID returns a copy of ctx holding id
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// start returns the context of r with its id, which is also recorded on
+// the span and set in the response headers
+func (o *RequestIDOptions) start(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
+	id := r.Header.Get(response.RequestIDHeader)
+	if o.IgnoreHeader || !validRequestID(id) {
+		id = o.generate()
+	}
+
+	w.Header().Set(response.RequestIDHeader, id)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+
+	return ContextWithRequestID(ctx, id)
+}
+
+func (o *RequestIDOptions) generate() string {
+	if o.Generate != nil {
+		return o.Generate()
+	}
+
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+
+	return hex.EncodeToString(data)
+}
+
+// validRequestID rejects the ids which could be used to inject content in
+// the logs or the responses
+func validRequestID(id string) bool {
+	if (id == "") || (len(id) > maxRequestIDLength) {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if (id[i] <= ' ') || (id[i] > '~') {
+			return false
+		}
+	}
+
+	return true
+}
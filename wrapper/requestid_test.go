@@ -0,0 +1,106 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type identifiedRequest struct {
+	Path  struct{}
+	Query struct {
+		Fail bool `json:"fail"`
+	}
+	ID       RequestID `chipi:"inject"`
+	Response struct {
+		Injected string `json:"injected"`
+		Context  string `json:"context"`
+	}
+}
+
+func (r *identifiedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Query.Fail {
+		return response.NewError(http.StatusConflict, "conflict", "already exists")
+	}
+
+	r.Response.Injected = string(r.ID)
+	r.Response.Context = RequestIDFromContext(ctx)
+	return nil
+}
+
+func TestRequestID(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("RequestID", func() {
+		opts := &Options{RequestID: &RequestIDOptions{
+			Generate: func() string { return "generated" },
+		}}
+
+		send := func(options *Options, target string, id string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			if id != "" {
+				req.Header.Set("X-Request-Id", id)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(&identifiedRequest{}, options)(w, req)
+			return w
+		}
+
+		g.It("should use the id of the request", func() {
+			w := send(opts, "/", "abc-123")
+
+			assert.Equal(g, "abc-123", w.Header().Get("X-Request-Id"))
+			assert.JSONEq(g, `{"injected": "abc-123", "context": "abc-123"}`, w.Body.String())
+		})
+
+		g.It("should generate the missing or invalid ids", func() {
+			w := send(opts, "/", "")
+			assert.Equal(g, "generated", w.Header().Get("X-Request-Id"))
+			assert.JSONEq(g, `{"injected": "generated", "context": "generated"}`, w.Body.String())
+
+			w = send(opts, "/", "bad id\x7f")
+			assert.Equal(g, "generated", w.Header().Get("X-Request-Id"))
+
+			w = send(&Options{RequestID: &RequestIDOptions{IgnoreHeader: true, Generate: opts.RequestID.Generate}}, "/", "abc-123")
+			assert.Equal(g, "generated", w.Header().Get("X-Request-Id"))
+		})
+
+		g.It("should generate random ids by default", func() {
+			first := send(&Options{RequestID: &RequestIDOptions{}}, "/", "").Header().Get("X-Request-Id")
+			second := send(&Options{RequestID: &RequestIDOptions{}}, "/", "").Header().Get("X-Request-Id")
+
+			assert.Len(g, first, 32)
+			assert.NotEqual(g, first, second)
+		})
+
+		g.It("should include the id in the error responses", func() {
+			w := send(opts, "/?fail=true", "abc-123")
+			assert.Equal(g, http.StatusConflict, w.Code)
+			assert.JSONEq(g, `{"status": 409, "code": "conflict", "message": "already exists", "request_id": "abc-123"}`, w.Body.String())
+
+			w = send(opts, "/?fail=maybe", "abc-123")
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+
+			var problem response.Problem
+			require.NoError(g, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(g, "abc-123", problem.RequestID)
+		})
+
+		g.It("should not set ids by default", func() {
+			w := send(nil, "/", "abc-123")
+
+			assert.Empty(g, w.Header().Get("X-Request-Id"))
+			assert.JSONEq(g, `{"injected": "", "context": ""}`, w.Body.String())
+		})
+	})
+}
@@ -0,0 +1,186 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	_roleTypes sync.Map
+)
+
+// RolesFunc returns the roles of the caller from the context given to Handle,
+// they select the Response fields visible with the `roles` and `unmasked-roles`
+// tags
+type RolesFunc func(ctx context.Context) []string
+
+// roleField is a json field restricted to some roles
+type roleField struct {
+	renamedField
+	// the field is omitted for the other roles
+	roles []string
+	// the field is masked for the other roles
+	unmaskedRoles []string
+}
+
+func tagRoles(f reflect.StructField, name string) []string {
+	var ret []string
+	for _, role := range strings.Split(f.Tag.Get(name), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			ret = append(ret, role)
+		}
+	}
+
+	return ret
+}
+
+func roleFields(t reflect.Type) []roleField {
+	fields := renamedFields(t)
+	ret := make([]roleField, len(fields))
+	for i, field := range fields {
+		f := t.Field(field.index)
+		ret[i] = roleField{
+			renamedField:  field,
+			roles:         tagRoles(f, "roles"),
+			unmaskedRoles: tagRoles(f, "unmasked-roles"),
+		}
+	}
+
+	return ret
+}
+
+// hasRoleFields returns true if a value of type t may contain a field with
+// a `roles` or `unmasked-roles` tag, results are cached per type
+func hasRoleFields(t reflect.Type) bool {
+	if ret, found := _roleTypes.Load(t); found {
+		return ret.(bool)
+	}
+
+	ret := lookupRoleFields(t, map[reflect.Type]bool{})
+	_roleTypes.Store(t, ret)
+	return ret
+}
+
+func lookupRoleFields(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return lookupRoleFields(t.Elem(), visited)
+
+	case reflect.Struct:
+		for _, field := range roleFields(t) {
+			if (len(field.roles) > 0) || (len(field.unmaskedRoles) > 0) || lookupRoleFields(t.Field(field.index).Type, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// FilterRoles returns obj as encoded by encoding/json without the fields whose
+// `roles` tag does not include one of roles and with the fields whose
+// `unmasked-roles` tag does not include one of them masked (see Redact), it
+// can be used by the ResponseEncoders
+func FilterRoles(obj interface{}, roles []string) (interface{}, error) {
+	return filterRoles(obj, reflect.TypeOf(obj), roles, false)
+}
+
+// filterRoles filters obj of type t, renamed is true if obj is the value
+// returned by renameResponse
+func filterRoles(obj interface{}, t reflect.Type, roles []string, renamed bool) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decodeJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, role := range roles {
+		granted[role] = true
+	}
+
+	return filterRoleKeys(decoded, t, granted, renamed), nil
+}
+
+func filterRoleKeys(value interface{}, t reflect.Type, granted map[string]bool, renamed bool) interface{} {
+	if (t == nil) || !hasRoleFields(t) {
+		return value
+	}
+	t = derefType(t)
+
+	switch v := value.(type) {
+	case []interface{}:
+		if (t.Kind() == reflect.Slice) || (t.Kind() == reflect.Array) {
+			for i, item := range v {
+				v[i] = filterRoleKeys(item, t.Elem(), granted, renamed)
+			}
+		}
+
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, item := range v {
+				v[key] = filterRoleKeys(item, t.Elem(), granted, renamed)
+			}
+
+		case reflect.Struct:
+			filterStructKeys(v, t, granted, renamed)
+		}
+	}
+
+	return value
+}
+
+func filterStructKeys(value map[string]interface{}, t reflect.Type, granted map[string]bool, renamed bool) {
+	for _, field := range roleFields(t) {
+		fieldType := t.Field(field.index).Type
+
+		if field.embedded {
+			filterStructKeys(value, derefType(fieldType), granted, renamed)
+			continue
+		}
+
+		key := field.jsonName
+		if renamed {
+			key = field.name
+		}
+
+		item, found := value[key]
+		if !found {
+			continue
+		}
+
+		switch {
+		case (len(field.roles) > 0) && !hasRole(granted, field.roles):
+			delete(value, key)
+
+		case (len(field.unmaskedRoles) > 0) && !hasRole(granted, field.unmaskedRoles):
+			value[key] = maskedValue(derefType(fieldType)).Interface()
+
+		default:
+			value[key] = filterRoleKeys(item, fieldType, granted, renamed)
+		}
+	}
+}
+
+func hasRole(granted map[string]bool, roles []string) bool {
+	for _, role := range roles {
+		if granted[role] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roleKey struct{}
+
+type roleAccount struct {
+	Name    string `json:"name"`
+	Email   string `json:"email" unmasked-roles:"admin,support"`
+	Balance int    `json:"balance" roles:"admin"`
+}
+
+type rolesRequest struct {
+	Path     struct{}
+	Response struct {
+		Account  roleAccount   `json:"account"`
+		Accounts []roleAccount `json:"accounts"`
+		Notes    string        `chipi:"name=internal_notes" roles:"admin"`
+	}
+}
+
+func (r *rolesRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response.Account = roleAccount{Name: "john", Email: "john@example.com", Balance: 42}
+	r.Response.Accounts = []roleAccount{r.Response.Account}
+	r.Response.Notes = "vip"
+	return nil
+}
+
+type negotiatedAccount struct {
+	Name    string `json:"name"`
+	Secret  string `json:"secret" chipi:"writeonly"`
+	Balance int    `json:"balance" roles:"admin"`
+}
+
+type negotiatedRolesRequest struct {
+	Path     struct{}
+	Response negotiatedAccount
+}
+
+func (r *negotiatedRolesRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = negotiatedAccount{Name: "john", Secret: "pw", Balance: 42}
+	return nil
+}
+
+func TestRoles(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Roles", func() {
+		opts := &Options{
+			Roles: func(ctx context.Context) []string {
+				roles, _ := ctx.Value(roleKey{}).([]string)
+				return roles
+			},
+		}
+
+		send := func(opts *Options, roles ...string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/", nil)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext())
+			req = req.WithContext(context.WithValue(ctx, roleKey{}, roles))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(&rolesRequest{}, opts)(w, req)
+			return w
+		}
+
+		g.It("should send every field to the allowed roles", func() {
+			w := send(opts, "admin")
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{
+				"account": {"name": "john", "email": "john@example.com", "balance": 42},
+				"accounts": [{"name": "john", "email": "john@example.com", "balance": 42}],
+				"internal_notes": "vip"
+			}`, w.Body.String())
+		})
+
+		g.It("should omit and mask the fields for the other roles", func() {
+			w := send(opts)
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{
+				"account": {"name": "john", "email": "[REDACTED]"},
+				"accounts": [{"name": "john", "email": "[REDACTED]"}]
+			}`, w.Body.String())
+		})
+
+		g.It("should match any of the roles", func() {
+			w := send(opts, "support")
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{
+				"account": {"name": "john", "email": "john@example.com"},
+				"accounts": [{"name": "john", "email": "john@example.com"}]
+			}`, w.Body.String())
+		})
+
+		g.It("should not filter without Roles option", func() {
+			w := send(nil)
+
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Contains(g, w.Body.String(), `"balance":42`)
+		})
+
+		g.It("should only negotiate json for the filtered responses", func() {
+			negotiated := &Options{Roles: opts.Roles, Encoders: DefaultEncoders()}
+			send := func(accept string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("Accept", accept)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				w := httptest.NewRecorder()
+				WrapRequestWithOptions(&negotiatedRolesRequest{}, negotiated)(w, req)
+				return w
+			}
+
+			w := send("application/xml")
+			assert.Equal(g, http.StatusNotAcceptable, w.Code)
+			assert.NotContains(g, w.Body.String(), "pw")
+			assert.NotContains(g, w.Body.String(), "42")
+
+			w = send("application/xml, application/json;q=0.5")
+			require.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+			assert.JSONEq(g, `{"name": "john"}`, w.Body.String())
+
+			assert.Equal(g, []string{"application/json"}, NegotiatedMediaTypes(&negotiatedRolesRequest{}, negotiated))
+			assert.Equal(g, []string{"application/json", "application/xml"}, NegotiatedMediaTypes(&defaultEncoderRequest{}, negotiated))
+		})
+
+		g.It("should filter values for the encoders", func() {
+			filtered, err := FilterRoles(roleAccount{Name: "john", Email: "john@example.com", Balance: 42}, []string{"support"})
+			require.NoError(g, err)
+
+			assert.Equal(g, map[string]interface{}{"name": "john", "email": "john@example.com"}, filtered)
+		})
+	})
+}
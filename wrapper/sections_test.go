@@ -0,0 +1,99 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sharedPetPath struct {
+	Id int
+}
+
+type sharedPetQuery struct {
+	Count int
+}
+
+type sharedPetHeader struct {
+	ApiKey string
+}
+
+type valueSectionsRequest struct {
+	Path   sharedPetPath
+	Query  sharedPetQuery
+	Header sharedPetHeader
+}
+
+type pointerSectionsRequest struct {
+	Path   *sharedPetPath
+	Query  *sharedPetQuery
+	Header *sharedPetHeader
+}
+
+func TestSections(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("request sections", func() {
+		newRequest := func(url string) *http.Request {
+			req := httptest.NewRequest("GET", url, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("Id", "42")
+			req.Header.Set("ApiKey", "azerty")
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		}
+
+		g.It("should bind value sections", func() {
+			vv, _, err := createFilledRequestObject(newRequest("/pet/42?count=3"), &valueSectionsRequest{}, map[string]string{})
+			require.NoError(g, err)
+
+			obj, ok := vv.Interface().(*valueSectionsRequest)
+			require.True(g, ok)
+			assert.Equal(g, 42, obj.Path.Id)
+			assert.Equal(g, 3, obj.Query.Count)
+			assert.Equal(g, "azerty", obj.Header.ApiKey)
+		})
+
+		g.It("should allocate and bind pointer sections", func() {
+			vv, _, err := createFilledRequestObject(newRequest("/pet/42?count=3"), &pointerSectionsRequest{}, map[string]string{})
+			require.NoError(g, err)
+
+			obj, ok := vv.Interface().(*pointerSectionsRequest)
+			require.True(g, ok)
+			require.NotNil(g, obj.Path)
+			assert.Equal(g, 42, obj.Path.Id)
+			require.NotNil(g, obj.Query)
+			assert.Equal(g, 3, obj.Query.Count)
+			require.NotNil(g, obj.Header)
+			assert.Equal(g, "azerty", obj.Header.ApiKey)
+		})
+
+		g.It("should leave pointer Query nil without query parameters", func() {
+			vv, _, err := createFilledRequestObject(newRequest("/pet/42"), &pointerSectionsRequest{}, map[string]string{})
+			require.NoError(g, err)
+
+			obj, ok := vv.Interface().(*pointerSectionsRequest)
+			require.True(g, ok)
+			assert.Nil(g, obj.Query)
+		})
+
+		g.It("should not share the prototype sections between requests", func() {
+			prototype := &pointerSectionsRequest{
+				Query: &sharedPetQuery{Count: 1},
+			}
+
+			vv, _, err := createFilledRequestObject(newRequest("/pet/42?count=3"), prototype, map[string]string{})
+			require.NoError(g, err)
+
+			obj, ok := vv.Interface().(*pointerSectionsRequest)
+			require.True(g, ok)
+			assert.Equal(g, 3, obj.Query.Count)
+			assert.Equal(g, 1, prototype.Query.Count)
+		})
+	})
+}
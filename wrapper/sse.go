@@ -0,0 +1,131 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	EventStreamContentType = "text/event-stream"
+
+	defaultHeartbeat = 15 * time.Second
+)
+
+// Event is a server-sent event, Data is written as is for strings
+// and bytes and encoded as json otherwise
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+func (e *Event) write(w io.Writer) error {
+	var data []byte
+	var err error
+
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(d)
+	case []byte:
+		data = d
+	default:
+		data, err = json.Marshal(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	if e.ID != "" {
+		fmt.Fprintf(buffer, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(buffer, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(buffer, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(buffer, "data: %s\n", line)
+	}
+	buffer.WriteString("\n")
+
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
+
+// serveEvents writes the events sent by Stream until it returns or the client
+// disconnects, comments are sent every heartbeat to keep the connection open
+func serveEvents(ctx context.Context, w http.ResponseWriter, streamer Streamer, heartbeat time.Duration) error {
+	flush := func() {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flush()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- streamer.Stream(ctx, events)
+	}()
+
+	// wait for Stream to return, it must stop once ctx is done
+	wait := func() error {
+		cancel()
+		for {
+			select {
+			case <-events:
+			case err := <-done:
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if err := event.write(w); err != nil {
+				_ = wait()
+				return err
+			}
+			flush()
+
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				_ = wait()
+				return err
+			}
+			flush()
+
+		case err := <-done:
+			return err
+
+		case <-ctx.Done():
+			return wait()
+		}
+	}
+}
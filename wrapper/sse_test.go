@@ -0,0 +1,94 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamingRequest struct {
+	Path  struct{}
+	Query struct {
+		Count int
+		Wait  bool
+	}
+
+	Stopped chan error
+}
+
+func (r *streamingRequest) Stream(ctx context.Context, events chan<- Event) error {
+	for i := 0; i < r.Query.Count; i++ {
+		events <- Event{ID: strings.Repeat("i", i+1), Data: someData{N: uint(i), Str: "line"}}
+	}
+
+	if r.Query.Wait {
+		<-ctx.Done()
+		r.Stopped <- ctx.Err()
+		return ctx.Err()
+	}
+
+	events <- Event{Event: "done", Data: "bye\nbye", Retry: 2 * time.Second}
+	return nil
+}
+
+func TestServerSentEvents(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Server-sent events", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(ctx context.Context, query string) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should write the events", func() {
+			WrapRequestWithOptions(&streamingRequest{}, nil)(w, newRequest(context.Background(), "count=2"))
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "text/event-stream", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "no-cache", w.Result().Header.Get("Cache-Control"))
+			assert.True(g, w.Flushed)
+			assert.Equal(g, ""+
+				"id: i\ndata: {\"N\":0,\"Str\":\"line\"}\n\n"+
+				"id: ii\ndata: {\"N\":1,\"Str\":\"line\"}\n\n"+
+				"event: done\nretry: 2000\ndata: bye\ndata: bye\n\n",
+				w.Body.String())
+		})
+
+		g.It("should send heartbeats and stop when the client disconnects", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan error, 1)
+
+			opts := &Options{Heartbeat: 5 * time.Millisecond}
+			go func() {
+				time.Sleep(30 * time.Millisecond)
+				cancel()
+			}()
+
+			WrapRequestWithOptions(&streamingRequest{Stopped: stopped}, opts)(w, newRequest(ctx, "wait=true"))
+
+			require.Len(g, stopped, 1)
+			assert.True(g, errors.Is(<-stopped, context.Canceled))
+			assert.Contains(g, w.Body.String(), ": heartbeat\n\n")
+		})
+
+		g.It("should be accepted instead of Handle", func() {
+			err := Verify(&streamingRequest{}, "GET", "/")
+			assert.NoError(g, err)
+		})
+	})
+}
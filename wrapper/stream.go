@@ -0,0 +1,89 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/schmurfy/chipi/response"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const StreamContentType = "application/octet-stream"
+
+var (
+	_readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	_readCloserType = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+	_fileType       = reflect.TypeOf(response.FileResponse{})
+)
+
+// IsStreamedBody returns true if the Body field is an io.Reader or an
+// io.ReadCloser given the request body as is
+func IsStreamedBody(f reflect.StructField) bool {
+	return (f.Type == _readerType) || (f.Type == _readCloserType)
+}
+
+// IsStreamedResponse returns true if the Response field is an io.Reader
+// copied as is to the client or a response.FileResponse
+func IsStreamedResponse(f reflect.StructField) bool {
+	return f.Type.Implements(_readerType) || IsFileResponse(f)
+}
+
+// IsFileResponse returns true if the Response field is a response.FileResponse
+func IsFileResponse(f reflect.StructField) bool {
+	return (f.Type == _fileType) || (f.Type == reflect.PtrTo(_fileType))
+}
+
+// StreamedResponseContentType returns the media type documented for streamed
+// responses (and bodies)
+func StreamedResponseContentType(f reflect.StructField) string {
+	if contentType, found := f.Tag.Lookup("content-type"); found {
+		return contentType
+	}
+
+	return StreamContentType
+}
+
+// writeStreamedResponse copies the reader to the client, the content type
+// is given by the reader or the request object (ContentTyper) or the content-type tag
+func writeStreamedResponse(ctx context.Context, w http.ResponseWriter, obj interface{}, f reflect.StructField, value reflect.Value) {
+	if ((value.Kind() == reflect.Interface) || (value.Kind() == reflect.Ptr)) && value.IsNil() {
+		return
+	}
+
+	contentType := StreamedResponseContentType(f)
+	if typer, ok := obj.(ContentTyper); ok {
+		contentType = typer.ContentType()
+	}
+
+	if IsFileResponse(f) {
+		file := reflect.Indirect(value).Interface().(response.FileResponse)
+		if file.ContentType == "" {
+			file.ContentType = contentType
+		}
+
+		err := file.Write(w)
+		if err != nil {
+			trace.SpanFromContext(ctx).RecordError(err)
+		}
+		return
+	}
+
+	reader := value.Interface().(io.Reader)
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if typer, ok := reader.(ContentTyper); ok {
+		contentType = typer.ContentType()
+	}
+
+	w.Header().Set("Content-Type", contentType)
+
+	_, err := io.Copy(w, reader)
+	if err != nil {
+		// the status is already sent
+		trace.SpanFromContext(ctx).RecordError(err)
+	}
+}
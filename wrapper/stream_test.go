@@ -0,0 +1,160 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type trackedReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *trackedReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+type streamedRequest struct {
+	Path     struct{}
+	Response io.Reader
+
+	Reader io.Reader
+}
+
+func (r *streamedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = r.Reader
+	return nil
+}
+
+type typedStreamedRequest struct {
+	Path     struct{}
+	Response io.ReadCloser `content-type:"text/csv"`
+}
+
+func (r *typedStreamedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = io.NopCloser(strings.NewReader("a,b"))
+	return nil
+}
+
+func (r *typedStreamedRequest) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+type uploadRequest struct {
+	Path     struct{}
+	Body     io.ReadCloser `content-type:"text/csv"`
+	Response struct {
+		Size int
+	}
+}
+
+func (r *uploadRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	r.Response.Size = len(data)
+	return nil
+}
+
+func TestStreamedBodies(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Streamed bodies", func() {
+		g.It("should give the request body to the handler", func() {
+			req := httptest.NewRequest("POST", "/", strings.NewReader("id,name\n1,rex\n"))
+			req.Header.Set("Content-Type", "text/csv")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequest(&uploadRequest{})(w, req)
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"Size": 14}`, w.Body.String())
+		})
+
+		g.It("should not require a BodyDecoder", func() {
+			assert.NoError(g, Verify(&uploadRequest{}, "POST", "/"))
+		})
+	})
+}
+
+type downloadRequest struct {
+	Path     struct{}
+	Response response.FileResponse `content-type:"text/csv"`
+
+	Reader *trackedReader
+}
+
+func (r *downloadRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = response.FileResponse{
+		Reader:   r.Reader,
+		Filename: "pets list.csv",
+		Size:     7,
+	}
+	return nil
+}
+
+func TestStreamedResponses(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Streamed responses", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func() *http.Request {
+			req := httptest.NewRequest("GET", "/", nil)
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should copy the reader", func() {
+			reader := &trackedReader{Reader: strings.NewReader("some data")}
+			WrapRequest(&streamedRequest{Reader: reader})(w, newRequest())
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "application/octet-stream", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "some data", w.Body.String())
+			assert.True(g, reader.closed)
+		})
+
+		g.It("should use the content type of the request object", func() {
+			WrapRequest(&typedStreamedRequest{})(w, newRequest())
+
+			assert.Equal(g, "text/csv; charset=utf-8", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, "a,b", w.Body.String())
+		})
+
+		g.It("should write nothing without reader", func() {
+			WrapRequest(&streamedRequest{})(w, newRequest())
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Empty(g, w.Body.String())
+		})
+
+		g.It("should send the files with their headers", func() {
+			reader := &trackedReader{Reader: strings.NewReader("id,name")}
+			WrapRequest(&downloadRequest{Reader: reader})(w, newRequest())
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.Equal(g, "text/csv", w.Result().Header.Get("Content-Type"))
+			assert.Equal(g, `attachment; filename="pets list.csv"`, w.Result().Header.Get("Content-Disposition"))
+			assert.Equal(g, "7", w.Result().Header.Get("Content-Length"))
+			assert.Equal(g, "id,name", w.Body.String())
+			assert.True(g, reader.closed)
+		})
+	})
+}
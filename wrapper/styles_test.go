@@ -0,0 +1,94 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type styledQueryRequest struct {
+	Path  struct{}
+	Query struct {
+		Tags   []string  `style:"form"`
+		Ids    []int     `style:"spaceDelimited"`
+		Names  []string  `style:"pipeDelimited"`
+		Colors *[]string `style:"pipeDelimited"`
+	}
+}
+
+func (r *styledQueryRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type invalidStyleRequest struct {
+	Path  struct{}
+	Query struct {
+		Name string `style:"pipeDelimited"`
+	}
+}
+
+func (r *invalidStyleRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestQueryStyles(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("query array styles", func() {
+		bind := func(query string) (*styledQueryRequest, map[string]string) {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			parsingErrors := map[string]string{}
+			vv, _, err := createFilledRequestObject(req, &styledQueryRequest{}, parsingErrors)
+			if err != nil {
+				return nil, parsingErrors
+			}
+
+			return vv.Interface().(*styledQueryRequest), parsingErrors
+		}
+
+		g.It("should split the form style on commas", func() {
+			obj, parsingErrors := bind("tags=a,b&tags=c")
+			require.Empty(g, parsingErrors)
+			assert.Equal(g, []string{"a", "b", "c"}, obj.Query.Tags)
+		})
+
+		g.It("should split the spaceDelimited style on spaces", func() {
+			obj, parsingErrors := bind("ids=1%202%203")
+			require.Empty(g, parsingErrors)
+			assert.Equal(g, []int{1, 2, 3}, obj.Query.Ids)
+		})
+
+		g.It("should split the pipeDelimited style on pipes", func() {
+			obj, parsingErrors := bind("names=a,b%7Cc&colors=red%7Cblue")
+			require.Empty(g, parsingErrors)
+			assert.Equal(g, []string{"a,b", "c"}, obj.Query.Names)
+			require.NotNil(g, obj.Query.Colors)
+			assert.Equal(g, []string{"red", "blue"}, *obj.Query.Colors)
+		})
+
+		g.It("should merge the repeated parameters", func() {
+			obj, parsingErrors := bind("names=a&names=b%7Cc")
+			require.Empty(g, parsingErrors)
+			assert.Equal(g, []string{"a", "b", "c"}, obj.Query.Names)
+		})
+
+		g.It("should report the invalid items", func() {
+			_, parsingErrors := bind("ids=1%20x")
+			assert.Contains(g, parsingErrors, "request.query.ids")
+		})
+
+		g.It("should only accept the delimited styles on slices", func() {
+			err := Verify(&invalidStyleRequest{}, "GET", "/")
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), "Query.Name: pipeDelimited style is only supported for slices")
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/schmurfy/chipi/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrHandlerTimeout is returned when Handle fails after the timeout of its
+// operation expired
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// HandlerTimeout returns the timeout declared with the `timeout` tag of the
+// Path field of the request object typ (ex: `timeout:"5s"`), 0 if there is none
+func HandlerTimeout(typ reflect.Type) time.Duration {
+	pathField, found := typ.FieldByName("Path")
+	if !found {
+		return 0
+	}
+
+	// invalid durations are reported by Verify
+	timeout, err := parseTimeout(pathField)
+	if err != nil {
+		return 0
+	}
+
+	return timeout
+}
+
+func parseTimeout(f reflect.StructField) (time.Duration, error) {
+	value, found := f.Tag.Lookup("timeout")
+	if !found {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if (err == nil) && (timeout <= 0) {
+		err = fmt.Errorf("timeout must be positive")
+	}
+
+	return timeout, err
+}
+
+// handleWithTimeout runs handle with a context expiring after timeout (if
+// any), returns true if its error comes from the expiration
+func handleWithTimeout(ctx context.Context, timeout time.Duration, handle handleFunc, req interface{}, r *http.Request, w http.ResponseWriter) (bool, error) {
+	if timeout <= 0 {
+		return false, handle(ctx, req, r, w)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := handle(ctx, req, r, w)
+	return (err != nil) && errors.Is(ctx.Err(), context.DeadlineExceeded), err
+}
+
+// writeTimeout records the expiration on the span and sends a 504 problem
+func writeTimeout(ctx context.Context, w http.ResponseWriter, timeout time.Duration) error {
+	err := fmt.Errorf("%w after %s", ErrHandlerTimeout, timeout)
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("chipi.timeout", timeout.String()))
+	span.SetStatus(codes.Error, err.Error())
+
+	response.NewProblem(http.StatusGatewayTimeout, err.Error()).Write(w)
+
+	return err
+}
@@ -0,0 +1,106 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowRequest struct {
+	Path  struct{}
+	Query struct {
+		Delay int `json:"delay"`
+	}
+	Response struct {
+		Done bool `json:"done"`
+	}
+}
+
+func (r *slowRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	select {
+	case <-time.After(time.Duration(r.Query.Delay) * time.Millisecond):
+		r.Response.Done = true
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type taggedTimeoutRequest struct {
+	Path     struct{} `timeout:"10ms"`
+	Response struct {
+		Deadline bool `json:"deadline"`
+	}
+}
+
+func (r *taggedTimeoutRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	_, r.Response.Deadline = ctx.Deadline()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type invalidTimeoutRequest struct {
+	Path struct{} `timeout:"soon"`
+}
+
+func (r *invalidTimeoutRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestTimeout(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Timeout", func() {
+		send := func(obj HandlerInterface, options *Options, target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+			w := httptest.NewRecorder()
+			WrapRequestWithOptions(obj, options)(w, req)
+			return w
+		}
+
+		g.It("should answer the requests handled in time", func() {
+			w := send(&slowRequest{}, &Options{Timeout: time.Second}, "/?delay=1")
+
+			assert.Equal(g, http.StatusOK, w.Code)
+			assert.JSONEq(g, `{"done": true}`, w.Body.String())
+		})
+
+		g.It("should send a 504 once the timeout expired", func() {
+			w := send(&slowRequest{}, &Options{Timeout: 10 * time.Millisecond}, "/?delay=1000")
+
+			assert.Equal(g, http.StatusGatewayTimeout, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+
+			var problem response.Problem
+			require.NoError(g, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(g, "handler timed out after 10ms", problem.Detail)
+		})
+
+		g.It("should use the timeout of the route", func() {
+			w := send(&taggedTimeoutRequest{}, nil, "/")
+			assert.Equal(g, http.StatusGatewayTimeout, w.Code)
+		})
+
+		g.It("should not set a deadline by default", func() {
+			w := send(&slowRequest{}, nil, "/?delay=1")
+			assert.Equal(g, http.StatusOK, w.Code)
+		})
+
+		g.It("should report the invalid timeouts", func() {
+			err := Verify(&invalidTimeoutRequest{}, "GET", "/")
+			assert.Error(g, err)
+			assert.Contains(g, err.Error(), `invalid timeout "soon"`)
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// routePattern returns the chi pattern of the route matching r, empty if
+// the request was not routed by chi
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+
+	return ""
+}
+
+// startSpan starts the span of a request named "{METHOD} {route pattern}"
+// (the method alone if the route is unknown) with the http attributes
+func startSpan(r *http.Request) (context.Context, trace.Span) {
+	route := routePattern(r)
+
+	name := r.Method
+	if route != "" {
+		name += " " + route
+	}
+
+	return _tracer.Start(r.Context(), name,
+		trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", route, r)...),
+	)
+}
+
+// recordStatus adds the response status to the span, only the server
+// errors are errors of the span
+func recordStatus(span trace.Span, status int) {
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// ParamAttributeFunc returns the span attribute of a parameter value (the
+// sensitive values are already redacted), false omits it
+type ParamAttributeFunc func(key string, value string) (attribute.KeyValue, bool)
+
+type paramAttributesKey struct{}
+
+// omitParamAttribute is the ParamAttributeFunc of Options.DisableParamAttributes
+func omitParamAttribute(key string, value string) (attribute.KeyValue, bool) {
+	return attribute.KeyValue{}, false
+}
+
+// setParamAttribute records the value of a parameter on the span of ctx,
+// transformed by the ParamAttributeFunc of the options
+func setParamAttribute(ctx context.Context, key string, value string) {
+	attr := attribute.String(key, value)
+
+	if fn, ok := ctx.Value(paramAttributesKey{}).(ParamAttributeFunc); ok {
+		var keep bool
+		if attr, keep = fn(key, value); !keep {
+			return
+		}
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attr)
+}
@@ -0,0 +1,173 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testSpan keeps the name, attributes and status of a span
+type testSpan struct {
+	trace.Span
+	name   string
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+}
+
+func (s *testSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *testSpan) End(options ...trace.SpanEndOption) {}
+
+type testTracerProvider struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (p *testTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return p
+}
+
+func (p *testTracerProvider) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(options...)
+	span := &testSpan{
+		Span:  trace.SpanFromContext(ctx),
+		name:  name,
+		attrs: map[attribute.Key]attribute.Value{},
+	}
+	span.SetAttributes(config.Attributes()...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spans = append(p.spans, span)
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (p *testTracerProvider) last() *testSpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.spans) == 0 {
+		return nil
+	}
+	return p.spans[len(p.spans)-1]
+}
+
+type tracedRequest struct {
+	Path struct {
+		Id int32
+	}
+	Response struct{}
+}
+
+func (r *tracedRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Path.Id == 0 {
+		return errors.New("no pet")
+	}
+	return nil
+}
+
+func (r *tracedRequest) HandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func TestTracing(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	provider := &testTracerProvider{}
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	g.Describe("Tracing", func() {
+		router := chi.NewRouter()
+		router.Get("/pets/{Id}", WrapRequest(&tracedRequest{}))
+
+		send := func(target string) *testSpan {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+
+			span := provider.last()
+			require.NotNil(g, span)
+			return span
+		}
+
+		g.It("should name the span after the method and the route", func() {
+			span := send("/pets/1")
+
+			assert.Equal(g, "GET /pets/{Id}", span.name)
+			assert.Equal(g, "GET", span.attrs["http.method"].AsString())
+			assert.Equal(g, "/pets/{Id}", span.attrs["http.route"].AsString())
+			assert.Equal(g, "/pets/1", span.attrs["http.target"].AsString())
+		})
+
+		g.It("should record the status of the response", func() {
+			span := send("/pets/1")
+			assert.Equal(g, int64(200), span.attrs["http.status_code"].AsInt64())
+			assert.Equal(g, codes.Unset, span.status)
+
+			span = send("/pets/abc")
+			assert.Equal(g, int64(400), span.attrs["http.status_code"].AsInt64())
+			assert.Equal(g, codes.Unset, span.status)
+
+			span = send("/pets/0")
+			assert.Equal(g, int64(500), span.attrs["http.status_code"].AsInt64())
+			assert.Equal(g, codes.Error, span.status)
+		})
+
+		g.It("should record the parameters on the span", func() {
+			span := send("/pets/1")
+			assert.Equal(g, "1", span.attrs["request.path.Id"].AsString())
+		})
+
+		g.It("should not record the parameters when disabled", func() {
+			router := chi.NewRouter()
+			router.Get("/pets/{Id}", WrapRequestWithOptions(&tracedRequest{}, &Options{DisableParamAttributes: true}))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil))
+
+			span := provider.last()
+			assert.NotContains(g, span.attrs, attribute.Key("request.path.Id"))
+			assert.Equal(g, "/pets/{Id}", span.attrs["http.route"].AsString())
+		})
+
+		g.It("should transform the parameters", func() {
+			router := chi.NewRouter()
+			router.Get("/pets/{Id}", WrapRequestWithOptions(&tracedRequest{}, &Options{
+				ParamAttributes: func(key string, value string) (attribute.KeyValue, bool) {
+					return attribute.String("pet."+key, "#"+value), true
+				},
+			}))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil))
+
+			span := provider.last()
+			assert.Equal(g, "#1", span.attrs["pet.request.path.Id"].AsString())
+			assert.NotContains(g, span.attrs, attribute.Key("request.path.Id"))
+		})
+
+		g.It("should use the method alone outside of a route", func() {
+			req := httptest.NewRequest("GET", "/", nil)
+			WrapRequest(&unlimitedRequest{})(httptest.NewRecorder(), req)
+
+			assert.Equal(g, "GET", provider.last().name)
+		})
+	})
+}
@@ -0,0 +1,347 @@
+package wrapper
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/schmurfy/chipi/schema"
+	"github.com/schmurfy/chipi/shared"
+)
+
+var (
+	_validMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	}
+)
+
+// VerificationError lists every problem found by Verify
+type VerificationError struct {
+	Type     string
+	Problems []string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("invalid request object %s:\n- %s", e.Type, strings.Join(e.Problems, "\n- "))
+}
+
+type verifier struct {
+	problems []string
+}
+
+func (v *verifier) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// Verify checks that obj can be bound and handled by the wrapper when mounted
+// on the given route, all the problems found are reported at once
+// in a *VerificationError
+func Verify(obj interface{}, method string, pattern string) error {
+	v := &verifier{}
+
+	typ := reflect.TypeOf(obj)
+	if (typ == nil) || (typ.Kind() != reflect.Ptr) || (typ.Elem().Kind() != reflect.Struct) {
+		return &VerificationError{
+			Type:     fmt.Sprintf("%T", obj),
+			Problems: []string{"pointer to struct expected"},
+		}
+	}
+
+	if !isValidMethod(method) {
+		v.addf("unknown method %q", method)
+	}
+
+	_, isHandler := obj.(HandlerInterface)
+	_, isHandlerWithRequest := obj.(HandlerWithRequestInterface)
+	_, isStreamer := obj.(Streamer)
+	if !isHandler && !isHandlerWithRequest && !isStreamer && !IsWebsocketHandler(obj) {
+		v.addf("must implement HandlerInterface (is Handle declared with a pointer receiver ?)")
+	}
+
+	st := typ.Elem()
+
+	v.verifyPath(st, pattern)
+
+	if f, found := st.FieldByName("Query"); found {
+		v.verifySection(f, "Query", QueryParamName)
+	}
+
+	if f, found := st.FieldByName("Header"); found {
+		v.verifySection(f, "Header", HeaderParamName)
+	}
+
+	if f, found := st.FieldByName("Cookie"); found {
+		v.verifySection(f, "Cookie", CookieParamName)
+	}
+
+	if f, found := st.FieldByName("Body"); found {
+		if _, ok := obj.(BodyDecoder); !ok && !HasDefaultBodyDecoding(f) {
+			v.addf("must implement BodyDecoder to have a %s Body", bodyMediaType(f))
+		}
+
+		if _, err := parseMaxBytes(f); err != nil {
+			v.addf("invalid max-bytes %q on Body", f.Tag.Get("max-bytes"))
+		}
+	}
+
+	if f, found := st.FieldByName("Response"); found {
+		if _, ok := obj.(ResponseEncoder); !ok && !HasDefaultResponseEncoding(f) {
+			v.addf("must implement ResponseEncoder to have a %s Response", bodyMediaType(f))
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &VerificationError{
+			Type:     st.Name(),
+			Problems: v.problems,
+		}
+	}
+
+	return nil
+}
+
+func isValidMethod(method string) bool {
+	for _, m := range _validMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *verifier) verifyPath(st reflect.Type, pattern string) {
+	params := routeParams(pattern)
+
+	pathField, found := st.FieldByName("Path")
+	if !found {
+		if len(params) > 0 {
+			v.addf("Path field required for route parameters %s", strings.Join(params, ", "))
+		}
+		return
+	}
+
+	pathType, ok := sectionStructType(pathField.Type)
+	if !ok {
+		v.addf("Path must be a struct, got %s", pathField.Type)
+		return
+	}
+
+	for _, key := range params {
+		if _, found := pathType.FieldByName(key); !found {
+			v.addf("Path.%s field missing for route parameter {%s}", key, key)
+		}
+	}
+
+	if sunset, found := pathField.Tag.Lookup("sunset"); found {
+		if _, err := parseTime(sunset); err != nil {
+			v.addf("invalid sunset date %q on Path", sunset)
+		}
+	}
+
+	if value, found := pathField.Tag.Lookup("rate-limit"); found {
+		if _, err := ParseRateLimit(value); err != nil {
+			v.addf("Path: %s", err.Error())
+		}
+	}
+
+	if _, found := pathField.Tag.Lookup("permissions"); found && (len(OperationPermissions(st)) == 0) {
+		v.addf("empty permissions on Path")
+	}
+
+	if _, err := parseCacheTTL(pathField); err != nil {
+		v.addf("invalid cache %q on Path", pathField.Tag.Get("cache"))
+	}
+
+	if value, found := pathField.Tag.Lookup("async"); found {
+		if _, err := strconv.ParseBool(value); err != nil {
+			v.addf("invalid async %q on Path", value)
+		}
+	}
+
+	if _, err := parseTimeout(pathField); err != nil {
+		v.addf("invalid timeout %q on Path", pathField.Tag.Get("timeout"))
+	}
+
+	if _, err := schema.ParseExtensions(pathField.Tag); err != nil {
+		v.addf("Path: %s", err.Error())
+	}
+
+	v.verifySection(pathField, "Path", func(f reflect.StructField) string { return f.Name })
+}
+
+func (v *verifier) verifySection(sectionField reflect.StructField, section string, paramName func(reflect.StructField) string) {
+	st, ok := sectionStructType(sectionField.Type)
+	if !ok {
+		v.addf("%s must be a struct, got %s", section, sectionField.Type)
+		return
+	}
+
+	names := map[string]string{}
+
+	for _, f := range SectionFields(st) {
+		tag := schema.ParseJsonTag(f)
+
+		if f.PkgPath != "" {
+			v.addf("%s.%s is unexported and cannot be set", section, f.Name)
+			continue
+		}
+
+		if (tag.Rest != nil) && *tag.Rest {
+			if section != "Query" {
+				v.addf("%s.%s: catch-all fields are only supported in Query", section, f.Name)
+			} else if !reflect.TypeOf(map[string][]string{}).ConvertibleTo(f.Type) {
+				v.addf("%s.%s: catch-all field must be a map[string][]string, got %s", section, f.Name, f.Type)
+			}
+			continue
+		}
+
+		if isDeepObjectField(f) {
+			t := f.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+
+			if section != "Query" {
+				v.addf("%s.%s: map fields are only supported in Query", section, f.Name)
+			} else if (t.Key().Kind() != reflect.String) || !isSupportedParamType(t.Elem()) {
+				v.addf("%s.%s: unsupported type %s", section, f.Name, f.Type)
+			}
+		} else if !isSupportedParamType(f.Type) {
+			v.addf("%s.%s: unsupported type %s", section, f.Name, f.Type)
+		} else if def, found := f.Tag.Lookup("default"); found {
+			if _, err := convertValue(f.Type, def); err != nil {
+				v.addf("%s.%s: invalid default value %q: %s", section, f.Name, def, err.Error())
+			}
+		}
+
+		if style := f.Tag.Get("style"); (section == "Query") && (_styleSeparators[style] != "") {
+			if t := f.Type; (t.Kind() != reflect.Slice) && ((t.Kind() != reflect.Ptr) || (t.Elem().Kind() != reflect.Slice)) {
+				v.addf("%s.%s: %s style is only supported for slices", section, f.Name, style)
+			}
+		}
+
+		if len(tag.Enum) > 0 {
+			if _, err := schema.EnumValues(f.Type, tag.Enum); err != nil {
+				v.addf("%s.%s: %s", section, f.Name, err.Error())
+			}
+		}
+
+		if _, err := schema.ParseConstraints(f); err != nil {
+			v.addf("%s.%s: %s", section, f.Name, err.Error())
+		}
+
+		if fields := paramFields(f); fields != nil {
+			t := f.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+
+			if !t.Implements(_fieldsCheckerType) {
+				v.addf("%s.%s: fields tag is only supported for Sort and Filter, got %s", section, f.Name, f.Type)
+			} else if len(fields) == 0 {
+				v.addf("%s.%s: empty fields tag", section, f.Name)
+			}
+		}
+
+		if _, err := schema.ParseExtensions(f.Tag); err != nil {
+			v.addf("%s.%s: %s", section, f.Name, err.Error())
+		}
+
+		name := paramName(f)
+		if other, exists := names[name]; exists {
+			v.addf("%s.%s and %s.%s are both bound to %q", section, other, section, f.Name, name)
+		} else {
+			names[name] = f.Name
+		}
+	}
+}
+
+func sectionStructType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t, t.Kind() == reflect.Struct
+}
+
+// isSupportedParamType returns true if convertValue can handle the type
+func isSupportedParamType(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(_textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return isSupportedParamType(t.Elem())
+
+	case reflect.Struct, reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// QueryParamName returns the name of the query parameter bound to f
+func QueryParamName(f reflect.StructField) string {
+	name := schema.ParseJsonTag(f).Name
+	if name == f.Name {
+		name = shared.ToSnakeCase(f.Name)
+	}
+
+	return name
+}
+
+// HeaderParamName returns the canonical name of the header bound to f
+func HeaderParamName(f reflect.StructField) string {
+	if name := f.Tag.Get("name"); name != "" {
+		return http.CanonicalHeaderKey(name)
+	}
+
+	return http.CanonicalHeaderKey(f.Name)
+}
+
+// CookieParamName returns the name of the cookie bound to f
+func CookieParamName(f reflect.StructField) string {
+	if name := f.Tag.Get("name"); name != "" {
+		return name
+	}
+
+	return f.Name
+}
+
+// routeParams extracts the parameter names from a chi pattern,
+// ex: "/pets/{Id}/{Name:[a-z]+}" => [Id, Name]
+func routeParams(pattern string) []string {
+	ret := []string{}
+
+	depth := 0
+	start := 0
+	for i, c := range pattern {
+		switch c {
+		case '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+
+		case '}':
+			depth--
+			if depth == 0 {
+				name := pattern[start:i]
+				if idx := strings.Index(name, ":"); idx >= 0 {
+					name = name[:idx]
+				}
+				ret = append(ret, name)
+			}
+		}
+	}
+
+	return ret
+}
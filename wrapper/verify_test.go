@@ -0,0 +1,134 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/schmurfy/chipi/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validVerifyRequest struct {
+	request.JsonBodyDecoder
+
+	Path struct {
+		Id   int
+		Name string
+	}
+	Query struct {
+		Count   *int                `default:"10"`
+		Names   []string            `default:"a,b"`
+		Filters map[string][]string `chipi:"rest"`
+	}
+	Header struct {
+		ApiKey string
+	}
+	Body struct {
+		Name string
+	}
+}
+
+func (r *validVerifyRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+type invalidVerifyRequest struct {
+	Path struct {
+		Id int
+	} `deprecated:"true" sunset:"soon" chipi-ext:"internal"`
+	Query struct {
+		Callback func()
+		Values   map[int]string
+		hidden   string
+		Name     string
+		Other    string `json:"name"`
+		Limit    int    `default:"ten"`
+		Code     string `min:"1"`
+	}
+	Header struct {
+		Token  string `chipi:"rest"`
+		ApiKey string
+		Key    string `name:"apikey"`
+		Extra  map[string]string
+	}
+	Body struct{} `content-type:"text/csv"`
+}
+
+type noPathRequest struct{}
+
+func (r *noPathRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	return nil
+}
+
+func TestVerify(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Verify", func() {
+		g.It("should accept valid request objects", func() {
+			err := Verify(&validVerifyRequest{}, "POST", "/pets/{Id}/{Name:[a-z]+}")
+			require.NoError(g, err)
+		})
+
+		g.It("should reject non pointers", func() {
+			err := Verify(validVerifyRequest{}, "POST", "/")
+			require.Error(g, err)
+		})
+
+		g.It("should report every problem at once", func() {
+			err := Verify(&invalidVerifyRequest{}, "FETCH", "/pets/{Id}/{Name}")
+			require.Error(g, err)
+
+			var verr *VerificationError
+			require.True(g, errors.As(err, &verr))
+
+			assert.Equal(g, "invalidVerifyRequest", verr.Type)
+			assert.ElementsMatch(g, []string{
+				`unknown method "FETCH"`,
+				`invalid sunset date "soon" on Path`,
+				`Path: invalid extension "internal", the name must start with x-`,
+				"must implement HandlerInterface (is Handle declared with a pointer receiver ?)",
+				"Path.Name field missing for route parameter {Name}",
+				"Query.Callback: unsupported type func()",
+				"Query.Values: unsupported type map[int]string",
+				"Query.hidden is unexported and cannot be set",
+				`Query.Name and Query.Other are both bound to "name"`,
+				`Query.Limit: invalid default value "ten": strconv.ParseInt: parsing "ten": invalid syntax`,
+				"Query.Code: min requires a number, got string",
+				"Header.Token: catch-all fields are only supported in Query",
+				`Header.ApiKey and Header.Key are both bound to "Apikey"`,
+				"Header.Extra: map fields are only supported in Query",
+				"must implement BodyDecoder to have a text/csv Body",
+			}, verr.Problems)
+		})
+
+		g.It("should require a Path struct for route parameters", func() {
+			err := Verify(&noPathRequest{}, "GET", "/")
+			require.NoError(g, err)
+
+			err = Verify(&noPathRequest{}, "GET", "/{Id}")
+			require.Error(g, err)
+			assert.Contains(g, err.Error(), "Path field required for route parameters Id")
+		})
+
+		g.It("should panic when a verified request object is not valid", func() {
+			assert.Panics(g, func() {
+				WrapRequestVerified("GET", "/{Id}", &invalidVerifyRequest{}, nil)
+			})
+
+			assert.NotPanics(g, func() {
+				WrapRequestVerified("GET", "/{Id}", &validVerifyRequest{}, &Options{})
+			})
+		})
+	})
+
+	g.Describe("routeParams", func() {
+		g.It("should extract parameters names", func() {
+			assert.Equal(g, []string{"Id", "Name", "Code"}, routeParams("/pets/{Id}/{Name:[a-z]+}/{Code:[0-9]{3}}/*"))
+			assert.Equal(g, []string{}, routeParams("/pets"))
+		})
+	})
+}
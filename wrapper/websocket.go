@@ -0,0 +1,146 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/schmurfy/chipi/shared"
+)
+
+// WebsocketHandler is implemented by request objects handling websockets,
+// C is the connection type returned by the UpgradeFunc
+type WebsocketHandler[C any] interface {
+	HandleWebsocket(ctx context.Context, conn C) error
+}
+
+// UpgradeFunc performs the websocket handshake with the library of your
+// choice, it is responsible for the error response if the upgrade fails
+type UpgradeFunc[C any] func(w http.ResponseWriter, r *http.Request) (C, error)
+
+// IsWebsocketHandler returns true if obj implements WebsocketHandler
+// for any connection type
+func IsWebsocketHandler(obj interface{}) bool {
+	typ := reflect.TypeOf(obj)
+	if typ == nil {
+		return false
+	}
+
+	_, found := typ.MethodByName("HandleWebsocket")
+	return found
+}
+
+func WrapWebsocket[C any](obj WebsocketHandler[C], upgrade UpgradeFunc[C]) http.HandlerFunc {
+	return WrapWebsocketWithOptions(obj, upgrade, nil)
+}
+
+// WrapWebsocketWithOptions binds the request the same way as WrapRequestWithOptions
+// and runs the pre handlers before the upgrade, the connection is then given to
+// HandleWebsocket (only the Authorizer, PreHandlers, RateLimit, PanicHandler and
+// Logger options are used)
+func WrapWebsocketWithOptions[C any](obj WebsocketHandler[C], upgrade UpgradeFunc[C], opts *Options) http.HandlerFunc {
+	preHandlers := opts.preHandlers()
+	authorizer := opts.authorizer()
+	permissions := OperationPermissions(reflect.TypeOf(obj).Elem())
+	logger := opts.logger()
+	panicHandler := opts.panicHandler()
+	rateLimiter := opts.rateLimiter(reflect.TypeOf(obj).Elem())
+	operation := reflect.TypeOf(obj).Elem().Name()
+	plan := planFor(reflect.TypeOf(obj).Elem())
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		ctx, span := startSpan(r)
+
+		// the response belongs to the connection once upgraded
+		upgraded := false
+
+		defer func() {
+			value := recover()
+			if value != nil {
+				if upgraded {
+					if value == http.ErrAbortHandler {
+						panic(value)
+					}
+					err = &PanicError{Value: value, Stack: debug.Stack()}
+				} else {
+					err = recoverPanic(ctx, w, obj, panicHandler, value)
+				}
+
+				logger.Error(ctx, "websocket handler panicked",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: err},
+				)
+			}
+
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}()
+
+		if rateLimiter != nil {
+			allowed, limitErr := rateLimiter.allow(ctx, w, r)
+			if limitErr != nil {
+				logger.Warn(ctx, "failed to count the request",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: limitErr},
+				)
+			} else if !allowed {
+				writeRateLimited(w, rateLimiter.limit)
+				return
+			}
+		}
+
+		parsingErrors := map[string]string{}
+
+		vv, _, err := createFilledRequestObject(r, obj, parsingErrors)
+		if err != nil {
+			parsingProblem(parsingErrors).Write(w)
+			return
+		}
+
+		err = injectDependencies(ctx, vv, plan)
+		if err != nil {
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
+			}
+			return
+		}
+
+		authCtx, err := authenticate(ctx, vv.Interface(), r)
+		if err == nil {
+			err = authorize(authCtx, authorizer, permissions, vv.Interface())
+		}
+		if err != nil {
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
+			}
+			return
+		}
+
+		handlerCtx, err := runPreHandlers(authCtx, preHandlers, vv.Interface())
+		if err != nil {
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
+			}
+			return
+		}
+
+		conn, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		upgraded = true
+
+		err = vv.Interface().(WebsocketHandler[C]).HandleWebsocket(handlerCtx, conn)
+		if err != nil {
+			logger.Warn(ctx, "websocket handler failed",
+				shared.Field{Key: "operation", Value: operation},
+				shared.Field{Key: "error", Value: err},
+			)
+		}
+	}
+}
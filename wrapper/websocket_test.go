@@ -0,0 +1,170 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConn struct {
+	w http.ResponseWriter
+}
+
+func upgradeTestConn(w http.ResponseWriter, r *http.Request) (*testConn, error) {
+	if r.Header.Get("Upgrade") != "websocket" {
+		http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("not a websocket")
+	}
+
+	w.WriteHeader(http.StatusSwitchingProtocols)
+	return &testConn{w: w}, nil
+}
+
+type websocketRequest struct {
+	Path  struct{}
+	Query struct {
+		Room   string
+		Reject bool
+	}
+}
+
+func (r *websocketRequest) PreHandle(ctx context.Context) (context.Context, error) {
+	if r.Query.Reject {
+		return nil, errors.New("rejected")
+	}
+	return ctx, nil
+}
+
+func (r *websocketRequest) HandleWebsocket(ctx context.Context, conn *testConn) error {
+	_, err := conn.w.Write([]byte("joined " + r.Query.Room))
+	return err
+}
+
+type limitedWebsocketRequest struct {
+	Path struct{} `rate-limit:"1/1m"`
+}
+
+func (r *limitedWebsocketRequest) HandleWebsocket(ctx context.Context, conn *testConn) error {
+	return nil
+}
+
+type panickingWebsocketRequest struct {
+	Path struct{}
+}
+
+func (r *panickingWebsocketRequest) HandleWebsocket(ctx context.Context, conn *testConn) error {
+	panic("connection lost")
+}
+
+func TestWebsocket(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Websocket", func() {
+		var w *httptest.ResponseRecorder
+
+		newRequest := func(query string, upgrade bool) *http.Request {
+			req := httptest.NewRequest("GET", "/?"+query, nil)
+			if upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		}
+
+		g.BeforeEach(func() {
+			w = httptest.NewRecorder()
+		})
+
+		g.It("should give the connection to the bound request object", func() {
+			WrapWebsocket[*testConn](&websocketRequest{}, upgradeTestConn)(w, newRequest("room=lobby", true))
+
+			assert.Equal(g, http.StatusSwitchingProtocols, w.Code)
+			assert.Equal(g, "joined lobby", w.Body.String())
+		})
+
+		g.It("should reject invalid requests before the upgrade", func() {
+			WrapWebsocket[*testConn](&websocketRequest{}, upgradeTestConn)(w, newRequest("reject=maybe", true))
+
+			assert.Equal(g, http.StatusBadRequest, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should run the pre handlers before the upgrade", func() {
+			called := false
+			opts := &Options{
+				PreHandlers: []PreHandlerFunc{
+					func(ctx context.Context, req interface{}) (context.Context, error) {
+						called = true
+						return ctx, nil
+					},
+				},
+			}
+
+			WrapWebsocketWithOptions[*testConn](&websocketRequest{}, upgradeTestConn, opts)(w, newRequest("reject=true", true))
+
+			assert.True(g, called)
+			assert.NotEqual(g, http.StatusSwitchingProtocols, w.Code)
+		})
+
+		g.It("should leave the failed upgrades to the upgrade function", func() {
+			WrapWebsocket[*testConn](&websocketRequest{}, upgradeTestConn)(w, newRequest("room=lobby", false))
+
+			assert.Equal(g, http.StatusUpgradeRequired, w.Code)
+		})
+
+		g.It("should limit the requests before the upgrade", func() {
+			handler := WrapWebsocketWithOptions[*testConn](&limitedWebsocketRequest{}, upgradeTestConn, &Options{RateLimit: &RateLimitOptions{}})
+
+			handler(w, newRequest("", true))
+			require.Equal(g, http.StatusSwitchingProtocols, w.Code)
+
+			w = httptest.NewRecorder()
+			handler(w, newRequest("", true))
+			assert.Equal(g, http.StatusTooManyRequests, w.Code)
+			assert.Equal(g, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.NotEmpty(g, w.Header().Get("Retry-After"))
+		})
+
+		g.It("should recover the panics before the upgrade", func() {
+			opts := &Options{
+				PreHandlers: []PreHandlerFunc{
+					func(ctx context.Context, req interface{}) (context.Context, error) {
+						panic("no database")
+					},
+				},
+			}
+
+			WrapWebsocketWithOptions[*testConn](&websocketRequest{}, upgradeTestConn, opts)(w, newRequest("room=lobby", true))
+
+			assert.Equal(g, http.StatusInternalServerError, w.Code)
+			assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+		})
+
+		g.It("should not write a response for the panics after the upgrade", func() {
+			var recovered *PanicError
+			opts := &Options{
+				PanicHandler: func(ctx context.Context, w http.ResponseWriter, err *PanicError) {
+					recovered = err
+				},
+			}
+
+			require.NotPanics(g, func() {
+				WrapWebsocketWithOptions[*testConn](&panickingWebsocketRequest{}, upgradeTestConn, opts)(w, newRequest("", true))
+			})
+
+			assert.Equal(g, http.StatusSwitchingProtocols, w.Code)
+			assert.Empty(g, w.Body.String())
+			assert.Nil(g, recovered)
+		})
+
+		g.It("should be accepted by Verify", func() {
+			require.NoError(g, Verify(&websocketRequest{}, "GET", "/"))
+		})
+	})
+}
@@ -2,32 +2,72 @@ package wrapper
 
 import (
 	"context"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/schmurfy/chipi/request"
+	"github.com/schmurfy/chipi/response"
 	"github.com/schmurfy/chipi/schema"
 	"github.com/schmurfy/chipi/shared"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requiredParameterMessage = "required parameter missing"
 )
 
 var (
 	_tracer  = otel.Tracer("chipi")
 	_noValue = reflect.Value{}
+
+	_defaultBodyDecoder     BodyDecoder     = &request.JsonBodyDecoder{}
+	_defaultResponseEncoder ResponseEncoder = &response.JsonEncoder{}
+
+	_timeType            = reflect.TypeOf(time.Time{})
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
 func convertValue(fieldType reflect.Type, value string) (reflect.Value, error) {
+	return convertDelimitedValue(fieldType, value, "")
+}
+
+// convertDelimitedValue splits the slices on separator, the default
+// (empty) separator accepts a comma separated list with optional brackets
+func convertDelimitedValue(fieldType reflect.Type, value string, separator string) (reflect.Value, error) {
+	if fieldType == _timeType {
+		t, err := parseTime(value)
+		if err != nil {
+			return _noValue, err
+		}
+		return reflect.ValueOf(t), nil
+	}
+
+	// custom types (uuids, enums, ...) know how to parse themselves
+	if (fieldType.Kind() != reflect.Ptr) && reflect.PtrTo(fieldType).Implements(_textUnmarshalerType) {
+		setValue := reflect.New(fieldType)
+		err := setValue.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		if err != nil {
+			return _noValue, err
+		}
+		return setValue.Elem(), nil
+	}
+
 	switch fieldType.Kind() {
 	case reflect.Ptr:
 		fieldType := fieldType.Elem()
-		setValue, err := convertValue(fieldType, value)
+		setValue, err := convertDelimitedValue(fieldType, value, separator)
 		if err != nil {
 			return _noValue, err
 		}
@@ -36,9 +76,13 @@ func convertValue(fieldType reflect.Type, value string) (reflect.Value, error) {
 		return setValuePtr, nil
 
 	case reflect.Slice:
-		param := strings.Split(
-			strings.Trim(value, `[]`),
-			",")
+		var param []string
+		if separator == "" {
+			param = strings.Split(strings.Trim(value, `[]`), ",")
+		} else {
+			param = strings.Split(value, separator)
+		}
+
 		sliceType := fieldType.Elem()
 		setValue := reflect.New(reflect.SliceOf(sliceType)).Elem()
 		for _, v := range param {
@@ -100,8 +144,56 @@ func convertValue(fieldType reflect.Type, value string) (reflect.Value, error) {
 	}
 }
 
-func setFValue(ctx context.Context, path string, f reflect.Value, value string) error {
-	v, err := convertValue(f.Type(), value)
+// ParseValue parses a parameter value the same way they are bound
+// to request objects
+func ParseValue(t reflect.Type, value string) (interface{}, error) {
+	v, err := convertValue(t, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Interface(), nil
+}
+
+// parseTime accepts RFC3339 timestamps, dates (2006-01-02)
+// and unix timestamps in seconds
+func parseTime(value string) (time.Time, error) {
+	value = strings.Trim(value, `"`)
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	// "+" of the timezone offset is decoded as a space in query strings
+	if strings.Contains(value, " ") {
+		if t, err := time.Parse(time.RFC3339Nano, strings.Replace(value, " ", "+", 1)); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(n, 0).UTC(), nil
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		sec := math.Floor(f)
+		return time.Unix(int64(sec), int64((f-sec)*1e9)).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339, date or unix timestamp", value)
+}
+
+func setFValue(ctx context.Context, path string, f reflect.Value, value string, sensitive bool) error {
+	return setDelimitedValue(ctx, path, f, value, "", sensitive)
+}
+
+// setDelimitedValue is setFValue for the slices using separator
+func setDelimitedValue(ctx context.Context, path string, f reflect.Value, value string, separator string, sensitive bool) error {
+	v, err := convertDelimitedValue(f.Type(), value, separator)
 
 	if err != nil {
 		return err
@@ -109,108 +201,249 @@ func setFValue(ctx context.Context, path string, f reflect.Value, value string)
 
 	f.Set(v)
 
-	trace.SpanFromContext(ctx).SetAttributes(attribute.String(path, value))
+	if sensitive {
+		value = RedactedValue
+	}
+
+	setParamAttribute(ctx, path, value)
 	return nil
 }
 
-func createFilledRequestObject(r *http.Request, obj interface{}, parsingErrors map[string]string) (ret reflect.Value, response reflect.Value, err error) {
-	typ := reflect.TypeOf(obj)
+// sectionValue returns the structure holding a request section (Path, Query, Header, Cookie),
+// sections declared as pointers are allocated if allocate is true or if the prototype
+// already points to a value, otherwise they are left nil and an invalid value is returned
+func sectionValue(f reflect.Value, allocate bool) reflect.Value {
+	if !f.IsValid() || (f.Kind() != reflect.Ptr) {
+		return f
+	}
 
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
+	if f.IsNil() && !allocate {
+		return _noValue
 	}
 
-	rr := reflect.ValueOf(obj)
-	ret = reflect.New(typ)
+	// never share the prototype's structure between requests
+	v := reflect.New(f.Type().Elem())
+	if !f.IsNil() {
+		v.Elem().Set(f.Elem())
+	}
+	f.Set(v)
 
-	// copy already set field
-	for i := 0; i < ret.Elem().NumField(); i++ {
-		f := ret.Elem().Field(i)
-		if f.CanSet() {
-			f.Set(rr.Elem().Field(i))
+	return v.Elem()
+}
+
+// queryFieldValue returns the value to parse for a query field, repeated
+// parameters (?id=1&id=2) are merged for slices
+func queryFieldValue(t reflect.Type, values []string) string {
+	return delimitedQueryFieldValue(t, values, "")
+}
+
+// delimitedQueryFieldValue is queryFieldValue merging the repeated
+// parameters with separator (a comma by default)
+func delimitedQueryFieldValue(t reflect.Type, values []string, separator string) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if (len(values) > 1) && (t.Kind() == reflect.Slice) && !reflect.PtrTo(t).Implements(_textUnmarshalerType) {
+		if separator == "" {
+			separator = ","
 		}
+		return strings.Join(values, separator)
 	}
 
-	ctx := r.Context()
+	return values[0]
+}
 
-	hasParamsErrors := false
+func isRequiredField(f reflect.StructField) bool {
+	tag := schema.ParseJsonTag(f)
+	return (tag.Required != nil) && *tag.Required
+}
 
-	// path
-	pathValue := ret.Elem().FieldByName("Path")
-	rctx := chi.RouteContext(r.Context())
-	for _, k := range rctx.URLParams.Keys {
-		fieldValue := pathValue.FieldByName(k)
-		if fieldValue.IsValid() {
-			path := "request.path." + k
-			err = setFValue(ctx,
-				path,
-				fieldValue,
-				rctx.URLParam(k),
-			)
-			if err != nil {
-				parsingErrors[path] = err.Error()
-				hasParamsErrors = true
-			}
+func isDeepObjectField(f reflect.StructField) bool {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Map
+}
+
+// setDeepObjectValue fills a map from parameters using the deepObject
+// style (?filter[name]=x&filter[age]=3), returns false if none was found
+func setDeepObjectValue(ctx context.Context, path string, f reflect.Value, name string, query url.Values, declared map[string]bool, sensitive bool) (bool, error) {
+	mapType := f.Type()
+	if mapType.Kind() == reflect.Ptr {
+		mapType = mapType.Elem()
+	}
+
+	keys := []string{}
+	for k := range query {
+		if strings.HasPrefix(k, name+"[") && strings.HasSuffix(k, "]") {
+			keys = append(keys, k)
 		}
 	}
+	sort.Strings(keys)
 
-	// query
-	queryValue := ret.Elem().FieldByName("Query")
-	if queryValue.IsValid() {
-		for i := 0; i < queryValue.NumField(); i++ {
+	m := reflect.MakeMap(mapType)
+	for _, k := range keys {
+		key := k[len(name)+1 : len(k)-1]
+		if (key == "") || strings.ContainsAny(key, "[]") {
+			// nested objects are not supported
+			continue
+		}
 
-			queryFieldName := queryValue.Type().Field(i).Name
-			structField, _ := queryValue.Type().FieldByName(queryFieldName)
+		declared[k] = true
 
-			// Tag "json" overwrite the key
-			parsedQueryFieldName := schema.ParseJsonTag(structField).Name
-			if parsedQueryFieldName == structField.Name {
-				parsedQueryFieldName = shared.ToSnakeCase(structField.Name)
-			}
-			path := "request.query." + parsedQueryFieldName
+		value := queryFieldValue(mapType.Elem(), query[k])
+		v, err := convertValue(mapType.Elem(), value)
+		if err != nil {
+			return true, fmt.Errorf("%s: %s", key, err.Error())
+		}
 
-			if value, ok := r.URL.Query()[parsedQueryFieldName]; ok {
-				err = setFValue(ctx,
-					path,
-					queryValue.Field(i),
-					value[0],
-				)
-				if err != nil {
-					parsingErrors[path] = err.Error()
-					hasParamsErrors = true
-				}
-			}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(mapType.Key()), v)
+
+		if sensitive {
+			value = RedactedValue
 		}
+		setParamAttribute(ctx, path+"."+key, value)
 	}
 
-	// header
-	headerValue := ret.Elem().FieldByName("Header")
-	if headerValue.IsValid() {
-		for i := 0; i < headerValue.NumField(); i++ {
-
-			attributeName := headerValue.Type().Field(i).Name
-			structField, _ := headerValue.Type().FieldByName(attributeName)
-
-			// Tag "name" overwrite the key
-			name := structField.Tag.Get("name")
-			headerName := attributeName
-			if name != "" {
-				headerName = name
-			}
-			path := "request.header." + attributeName
-			if r.Header.Get(headerName) != "" {
-				err = setFValue(ctx,
-					path,
-					headerValue.Field(i),
-					r.Header.Get(headerName),
-				)
-				if err != nil {
-					parsingErrors[path] = err.Error()
-					hasParamsErrors = true
-				}
-			}
+	if m.Len() == 0 {
+		return false, nil
+	}
+
+	if f.Kind() == reflect.Ptr {
+		ptr := reflect.New(mapType)
+		ptr.Elem().Set(m)
+		m = ptr
+	}
+	f.Set(m)
+
+	return true, nil
+}
+
+// setRestValue fills the catch-all query field with every parameter
+// not matching a declared field
+func setRestValue(f reflect.Value, query url.Values, declared map[string]bool) error {
+	if !reflect.TypeOf(query).ConvertibleTo(f.Type()) {
+		return fmt.Errorf("catch-all field must be a map[string][]string, got %s", f.Type())
+	}
+
+	rest := url.Values{}
+	for k, values := range query {
+		if !declared[k] {
+			rest[k] = values
+		}
+	}
+
+	if len(rest) > 0 {
+		f.Set(reflect.ValueOf(rest).Convert(f.Type()))
+	}
+
+	return nil
+}
+
+func validationProblem(err error, status int) *response.Problem {
+	var problem *response.Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	return response.NewProblem(status, err.Error())
+}
+
+// encodingProblem reports a Response which could not be rewritten as json
+func encodingProblem(err error) *response.Problem {
+	return response.NewProblem(http.StatusInternalServerError, err.Error())
+}
+
+// handleError gives err to HandleError if implemented, problems and
+// structured errors are written as is otherwise, returns false if err
+// was not handled
+func handleError(ctx context.Context, w http.ResponseWriter, obj interface{}, err error) bool {
+	var problem *response.Problem
+	var apiErr *response.Error
+
+	if rr, ok := obj.(ErrorHandlerInterface); ok {
+		rr.HandleError(ctx, w, err)
+	} else if errors.As(err, &problem) {
+		problem.Write(w)
+	} else if errors.As(err, &apiErr) {
+		apiErr.Write(w)
+	} else {
+		return false
+	}
+
+	return true
+}
+
+// logUnhandledError reports the errors which are neither given to a
+// HandleError method nor problems, the client only gets an empty response
+func logUnhandledError(ctx context.Context, logger shared.Logger, operation string, err error) {
+	logger.Error(ctx, "unhandled error",
+		shared.Field{Key: "operation", Value: operation},
+		shared.Field{Key: "error", Value: err},
+	)
+}
+
+// parsingProblem reports the binding errors as invalid parameters
+func parsingProblem(parsingErrors map[string]string) *response.Problem {
+	problem := response.NewProblem(http.StatusBadRequest, "the request is invalid")
+
+	names := make([]string, 0, len(parsingErrors))
+	for name := range parsingErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		problem.InvalidParams = append(problem.InvalidParams, response.InvalidParam{
+			Name:   name,
+			Reason: parsingErrors[name],
+		})
+	}
+
+	return problem
+}
+
+func createFilledRequestObject(r *http.Request, obj interface{}, parsingErrors map[string]string) (ret reflect.Value, response reflect.Value, err error) {
+	return fillRequestObject(r, newRequestObject(obj), parsingErrors)
+}
+
+// newRequestObject allocates a new request object initialized from the prototype obj
+func newRequestObject(obj interface{}) reflect.Value {
+	ret := reflect.New(reflect.TypeOf(obj).Elem())
+	copyPrototype(ret, reflect.ValueOf(obj))
+
+	return ret
+}
+
+// copyPrototype copies the already set fields of the prototype
+func copyPrototype(ret reflect.Value, prototype reflect.Value) {
+	for i := 0; i < ret.Elem().NumField(); i++ {
+		f := ret.Elem().Field(i)
+		if f.CanSet() {
+			f.Set(prototype.Elem().Field(i))
 		}
 	}
+}
+
+// fillRequestObject binds the request to ret, a new request object
+func fillRequestObject(r *http.Request, obj reflect.Value, parsingErrors map[string]string) (ret reflect.Value, response reflect.Value, err error) {
+	ret = obj
+	typ := ret.Type().Elem()
+	ctx := r.Context()
+	plan := planFor(typ)
+
+	// generated code binds the parameters without reflection
+	var hasParamsErrors bool
+	if filler, ok := ret.Interface().(RequestFiller); ok {
+		binder := newBinder(r, parsingErrors)
+		filler.FillFromRequest(binder)
+		hasParamsErrors = binder.failed
+	} else {
+		hasParamsErrors = bindParams(r, ret.Elem(), plan, parsingErrors)
+	}
 
 	if hasParamsErrors {
 		err = errors.New("input parsing error")
@@ -218,8 +451,13 @@ func createFilledRequestObject(r *http.Request, obj interface{}, parsingErrors m
 	}
 
 	// body
-	bodyValue := ret.Elem().FieldByName("Body")
-	if bodyValue.IsValid() {
+	if plan.streamedBody {
+		// read by the handler
+		if r.Body != nil {
+			ret.Elem().FieldByIndex(plan.body).Set(reflect.ValueOf(r.Body))
+		}
+	} else if plan.hasBody {
+		bodyValue := ret.Elem().FieldByIndex(plan.body)
 		var bodyObject interface{}
 		if bodyValue.Kind() == reflect.Ptr {
 			body := reflect.New(bodyValue.Type().Elem())
@@ -230,82 +468,708 @@ func createFilledRequestObject(r *http.Request, obj interface{}, parsingErrors m
 		}
 
 		path := "request.body"
-		// call the request method if it implements a custom decoder
-		if decoder, ok := ret.Interface().(BodyDecoder); ok {
+		bodyField := plan.bodyField
+		decoder, hasDecoder := ret.Interface().(BodyDecoder)
+
+		mediaType := requestMediaType(r)
+		builtin := !hasDecoder || isFormBody(bodyField)
+
+		if builtin && (mediaType == FormContentType) {
+			err = decodeFormBody(ctx, r, bodyValue, parsingErrors)
+			if err != nil {
+				return
+			}
+		} else if builtin && (mediaType == MultipartContentType) {
+			err = decodeMultipartBody(ctx, r, bodyValue, parsingErrors)
+			if err != nil {
+				return
+			}
+		} else if hasDecoder {
+			// call the request method if it implements a custom decoder
 			err = decoder.DecodeBody(r.Body, bodyObject, ret)
 			if err != nil {
 				parsingErrors[path] = err.Error()
 				return
 			}
+		} else if impls := implementationsOf(bodyValue); impls != nil {
+			// interfaces are decoded into their registered implementations
+			err = decodeImplementation(r.Body, bodyValue, impls)
+			if err != nil {
+				parsingErrors[path] = err.Error()
+				return
+			}
 		} else {
-			err = fmt.Errorf(
-				"structure %s needs to implement BodyDecoder interface",
-				typ.Name(),
-			)
+			// default to json
+			var body io.ReadCloser = r.Body
+			if hasRenamedFields(bodyValue.Type()) {
+				body, err = renameBody(r.Body, bodyValue.Type(), path, parsingErrors)
+				if err != nil {
+					parsingErrors[path] = err.Error()
+					return
+				}
+
+				if len(parsingErrors) > 0 {
+					err = errors.New("read only fields sent")
+					return
+				}
+			}
+
+			err = _defaultBodyDecoder.DecodeBody(body, bodyObject, ret)
 			if err != nil {
 				parsingErrors[path] = err.Error()
+				return
 			}
-			return
 		}
 	}
 
-	response = ret.Elem().FieldByName("Response")
+	// constraints declared by the min, max, minLength, maxLength and pattern tags
+	if validateConstraints(r, ret.Elem(), plan, parsingErrors) {
+		err = errors.New("input validation error")
+		return
+	}
+
+	if plan.hasResponse {
+		response = ret.Elem().FieldByIndex(plan.response)
+	}
 
 	return
 }
 
-func WrapRequest(obj interface{}) http.HandlerFunc {
+// bindParams fills the Path, Query, Header and Cookie sections of obj using
+// reflection, returns true if a parameter could not be parsed
+func bindParams(r *http.Request, obj reflect.Value, plan *bindingPlan, parsingErrors map[string]string) bool {
+	ctx := r.Context()
+
+	hasParamsErrors := false
+	setParam := func(param *paramPlan, section reflect.Value, value string) {
+		err := setDelimitedValue(ctx, param.path, section.FieldByIndex(param.index), value, param.separator, param.sensitive)
+		if err == nil {
+			err = checkEnum(section.FieldByIndex(param.index), param.enum)
+		}
+		if err == nil {
+			err = checkFields(section.FieldByIndex(param.index), param.fields)
+		}
+
+		if err != nil {
+			parsingErrors[param.path] = err.Error()
+			hasParamsErrors = true
+		}
+	}
+
+	// absent parameters get their default value or are reported if required
+	setMissingParam := func(param *paramPlan, section reflect.Value) {
+		if param.hasDefault {
+			setParam(param, section, param.defaultValue)
+		} else if param.required {
+			parsingErrors[param.path] = requiredParameterMessage
+			hasParamsErrors = true
+		}
+	}
+
+	// path
+	rctx := chi.RouteContext(r.Context())
+	pathValue := plan.path.value(obj, len(rctx.URLParams.Keys) > 0)
+	if pathValue.IsValid() {
+		for _, k := range rctx.URLParams.Keys {
+			if param, found := plan.path.byName[k]; found {
+				setParam(param, pathValue, rctx.URLParam(k))
+			}
+		}
+	}
+
+	// query
+	query := r.URL.Query()
+	queryValue := plan.query.value(obj, (len(query) > 0) || ((plan.query != nil) && plan.query.needsValue))
+	if queryValue.IsValid() {
+		declared := map[string]bool{}
+
+		for _, param := range plan.query.params {
+			declared[param.name] = true
+
+			if param.deepObject {
+				found, err := setDeepObjectValue(ctx, param.path, queryValue.FieldByIndex(param.index), param.name, query, declared, param.sensitive)
+				if err == nil {
+					err = checkFields(queryValue.FieldByIndex(param.index), param.fields)
+				}
+				if err != nil {
+					parsingErrors[param.path] = err.Error()
+					hasParamsErrors = true
+				} else if !found && param.required {
+					parsingErrors[param.path] = requiredParameterMessage
+					hasParamsErrors = true
+				}
+				continue
+			}
+
+			if value, ok := query[param.name]; ok {
+				setParam(param, queryValue, delimitedQueryFieldValue(param.field.Type, value, param.separator))
+			} else {
+				setMissingParam(param, queryValue)
+			}
+		}
+
+		// catch-all field, filled with undeclared parameters
+		if plan.query.rest != nil {
+			err := setRestValue(queryValue.FieldByIndex(plan.query.rest), query, declared)
+			if err != nil {
+				parsingErrors["request.query"] = err.Error()
+				hasParamsErrors = true
+			}
+		}
+	}
+
+	// header
+	headerValue := plan.header.value(obj, true)
+	if headerValue.IsValid() {
+		for _, param := range plan.header.params {
+			if value := r.Header.Get(param.name); value != "" {
+				setParam(param, headerValue, value)
+			} else {
+				setMissingParam(param, headerValue)
+			}
+		}
+	}
+
+	// cookies
+	cookieValue := plan.cookie.value(obj, true)
+	if cookieValue.IsValid() {
+		for _, param := range plan.cookie.params {
+			if cookie, cookieErr := r.Cookie(param.name); (cookieErr == nil) && (cookie.Value != "") {
+				setParam(param, cookieValue, cookie.Value)
+			} else {
+				setMissingParam(param, cookieValue)
+			}
+		}
+	}
+
+	return hasParamsErrors
+}
+
+// runPreHandlers invokes the hooks from the options followed by the
+// PreHandle method of the request object
+func runPreHandlers(ctx context.Context, preHandlers []PreHandlerFunc, req interface{}) (context.Context, error) {
+	for _, preHandler := range preHandlers {
+		newCtx, err := preHandler(ctx, req)
+		if err != nil {
+			return ctx, err
+		}
+
+		if newCtx != nil {
+			ctx = newCtx
+		}
+	}
+
+	if pre, ok := req.(PreHandler); ok {
+		newCtx, err := pre.PreHandle(ctx)
+		if err != nil {
+			return ctx, err
+		}
+
+		if newCtx != nil {
+			ctx = newCtx
+		}
+	}
+
+	return ctx, nil
+}
+
+// handleFunc calls the handler of a bound request object
+type handleFunc func(ctx context.Context, req interface{}, r *http.Request, w http.ResponseWriter) error
+
+// WrapRequest wraps a request object with a Handle method checked at compile time,
+// use WrapRequestWithOptions for the other kinds of handlers
+func WrapRequest[T HandlerInterface](obj T) http.HandlerFunc {
+	return wrapRequest(obj, nil, typedHandler[T](), nil)
+}
+
+// WrapRequestPooled is like WrapRequest but recycles the request objects once
+// the response is written instead of allocating one per request, Handle must
+// not keep a reference to its request object (or to its fields) after returning
+func WrapRequestPooled[T HandlerInterface](obj T, opts *Options) http.HandlerFunc {
+	return wrapRequest(obj, opts, typedHandler[T](), newRequestPool(obj))
+}
+
+func typedHandler[T HandlerInterface]() handleFunc {
+	return func(ctx context.Context, req interface{}, r *http.Request, w http.ResponseWriter) error {
+		return req.(T).Handle(ctx, w)
+	}
+}
+
+func WrapRequestWithOptions(obj interface{}, opts *Options) http.HandlerFunc {
+	return wrapRequest(obj, opts, dynamicHandler(obj), nil)
+}
+
+// WrapRequestVerified is like WrapRequestWithOptions but panics if Verify
+// reports that obj is not valid for the route defined by method and pattern
+func WrapRequestVerified(method string, pattern string, obj interface{}, opts *Options) http.HandlerFunc {
+	if err := Verify(obj, method, pattern); err != nil {
+		panic(err)
+	}
+
+	return WrapRequestWithOptions(obj, opts)
+}
+
+// dynamicHandler picks the handler implemented by obj once
+// instead of checking it on every request
+func dynamicHandler(obj interface{}) handleFunc {
+	switch obj.(type) {
+	case Streamer:
+		// handled by serveEvents
+		return nil
+
+	case HandlerWithRequestInterface:
+		return func(ctx context.Context, req interface{}, r *http.Request, w http.ResponseWriter) error {
+			return req.(HandlerWithRequestInterface).Handle(ctx, r, w)
+		}
+
+	case HandlerInterface:
+		return func(ctx context.Context, req interface{}, r *http.Request, w http.ResponseWriter) error {
+			return req.(HandlerInterface).Handle(ctx, w)
+		}
+	}
+
+	return func(ctx context.Context, req interface{}, r *http.Request, w http.ResponseWriter) error {
+		return nil
+	}
+}
+
+func wrapRequest(obj interface{}, opts *Options, handle handleFunc, pool *requestPool) http.HandlerFunc {
+	operation := reflect.TypeOf(obj).Elem().Name()
+
+	// computed now rather than on the first request
+	plan := planFor(reflect.TypeOf(obj).Elem())
+	observer := opts.observer()
+	metrics := opts.metrics()
+	bodyCaptureLimit := opts.bodyCaptureLimit()
+	compressor := opts.compressor()
+	decompression := opts.decompression()
+	maxBodyBytes := opts.maxBodyBytes(reflect.TypeOf(obj).Elem())
+	preHandlers := opts.preHandlers()
+	afterHandlers := opts.afterHandlers()
+	panicHandler := opts.panicHandler()
+	requestValidator := opts.requestValidator()
+	responseValidator := opts.responseValidator()
+	heartbeat := opts.heartbeat()
+	timeout := opts.timeout(reflect.TypeOf(obj).Elem())
+	paramAttributes := opts.paramAttributes()
+	requestID := opts.requestID()
+	authorizer := opts.authorizer()
+	rolesFunc := opts.roles()
+	permissions := OperationPermissions(reflect.TypeOf(obj).Elem())
+	rateLimiter := opts.rateLimiter(reflect.TypeOf(obj).Elem())
+	idempotency := opts.idempotency(reflect.TypeOf(obj).Elem())
+	cache := opts.responseCache(obj)
+	asyncOperations := opts.asyncOperations()
+	logger := opts.logger()
+	deprecation := opts.deprecationHeaders(reflect.TypeOf(obj).Elem())
+	_, isStreamer := obj.(Streamer)
+
+	encoders := negotiatedEncoders(obj, opts)
+	responseField, hasResponse := reflect.TypeOf(obj).Elem().FieldByName("Response")
+	streamed := hasResponse && IsStreamedResponse(responseField)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var err error
 		var vv reflect.Value
 		var response reflect.Value
+		var capture *bodyCapture
+
+		// the status of the response is given to the span, the metrics and the observer
+		recorder := newStatusRecorder(w)
+		start := time.Now()
+
+		ctx, span := startSpan(r)
+		if paramAttributes != nil {
+			ctx = context.WithValue(ctx, paramAttributesKey{}, paramAttributes)
+		}
 
-		ctx, span := _tracer.Start(r.Context(), "WrapRequest")
+		if requestID != nil {
+			ctx = requestID.start(ctx, w, r)
+		}
+
+		// the parameters are recorded on the span while binding
+		r = r.WithContext(ctx)
+
+		for name, values := range deprecation {
+			w.Header()[name] = values
+		}
 
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
 			}
+			recordStatus(span, recorder.Status())
 			span.End()
 		}()
 
+		// before the decompression to limit the bytes sent
+		var limited *limitedBody
+		if maxBodyBytes > 0 {
+			limited = limitBody(w, r, maxBodyBytes)
+		}
+
+		// before the observer to capture the decompressed body
+		var decompressed *decompressedBody
+		var decompressErr error
+		if decompression != nil {
+			decompressed, decompressErr = decompressBody(r, decompression)
+		}
+
+		w = recorder
+
+		if metrics != nil {
+			attrs := metrics.start(ctx, r)
+
+			defer func() {
+				metrics.finish(ctx, attrs, start, recorder.Status(), err != nil)
+			}()
+		}
+
+		if observer != nil {
+			if _, ok := observer.(BodyObserver); ok && (r.Body != nil) {
+				capture = &bodyCapture{ReadCloser: r.Body, limit: bodyCaptureLimit}
+				r.Body = capture
+			}
+
+			defer func() {
+				observer.OnResponseEncoded(ctx, operation, recorder.Status(), recorder.size)
+			}()
+		}
+
+		var gzipWriter *gzipResponseWriter
+		if compressor != nil {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if (r.Method != http.MethodHead) && acceptsGzip(r) {
+				gzipWriter = &gzipResponseWriter{ResponseWriter: w, compressor: compressor}
+				w = gzipWriter
+
+				defer func() {
+					closeErr := gzipWriter.Close()
+					if closeErr != nil {
+						span.RecordError(closeErr)
+						logger.Warn(ctx, "failed to close the compressed response",
+							shared.Field{Key: "operation", Value: operation},
+							shared.Field{Key: "error", Value: closeErr},
+						)
+					}
+				}()
+			}
+		}
+
+		// set once the pre handlers ran, the after handlers are only run then
+		var handlerCtx context.Context
+		var handlerErr error
+
+		defer func() {
+			value := recover()
+			if value != nil {
+				err = recoverPanic(ctx, w, obj, panicHandler, value)
+				handlerErr = err
+
+				logger.Error(ctx, "request handler panicked",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: err},
+				)
+			}
+
+			if handlerCtx != nil {
+				runAfterHandlers(handlerCtx, afterHandlers, vv.Interface(), handlerErr)
+			}
+
+			// before the request object is recycled
+			if accessObserver, ok := observer.(AccessObserver); ok {
+				entry := newAccessEntry(operation, r, start, recorder.Status(), vv, response, (err == nil) && !streamed)
+				accessObserver.OnRequestHandled(ctx, entry)
+			}
+
+			// the state of the request object is unknown after a panic, it is not recycled
+			if (value == nil) && (pool != nil) && vv.IsValid() {
+				pool.put(vv)
+			}
+		}()
+
+		if rateLimiter != nil {
+			allowed, limitErr := rateLimiter.allow(ctx, w, r)
+			if limitErr != nil {
+				logger.Warn(ctx, "failed to count the request",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: limitErr},
+				)
+			} else if !allowed {
+				writeRateLimited(w, rateLimiter.limit)
+				return
+			}
+		}
+
+		var mediaType string
+		var encoder EncoderFunc
+		if encoders != nil {
+			var acceptable bool
+			w.Header().Add("Vary", "Accept")
+
+			mediaType, encoder, acceptable = encoders.negotiate(r.Header.Get("Accept"))
+			if !acceptable {
+				writeNotAcceptable(w, encoders)
+				return
+			}
+		}
+
+		if problem := bodyTooLarge(limited, nil); problem != nil {
+			problem.Write(w)
+			return
+		}
+
+		if decompressErr != nil {
+			err = decompressErr
+			decompressionProblem(err).Write(w)
+			return
+		}
+
+		if requestValidator != nil {
+			err = requestValidator(r)
+			if problem := bodyTooLarge(limited, decompressed); (err != nil) && (problem != nil) {
+				problem.Write(w)
+				return
+			} else if err != nil {
+				logger.Debug(ctx, "invalid request",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: err},
+				)
+				validationProblem(err, http.StatusBadRequest).Write(w)
+				return
+			}
+		}
+
 		parsingErrors := map[string]string{}
 
-		vv, response, err = createFilledRequestObject(r, obj, parsingErrors)
+		if pool != nil {
+			vv, response, err = fillRequestObject(r, pool.get(), parsingErrors)
+		} else {
+			vv, response, err = createFilledRequestObject(r, obj, parsingErrors)
+		}
+		if problem := bodyTooLarge(limited, decompressed); (err != nil) && (problem != nil) {
+			problem.Write(w)
+			return
+		} else if err != nil {
+			logger.Debug(ctx, "invalid request",
+				shared.Field{Key: "operation", Value: operation},
+				shared.Field{Key: "errors", Value: parsingErrors},
+			)
+			parsingProblem(parsingErrors).Write(w)
+			return
+		}
+
+		if observer != nil {
+			observer.OnRequestBound(ctx, operation, Redact(vv.Interface()))
+
+			if capture != nil {
+				bodyField := vv.Elem().FieldByName("Body")
+				if !bodyField.IsValid() || !hasSensitiveFields(bodyField.Type()) {
+					observer.(BodyObserver).OnRequestBody(ctx, operation, capture.Bytes())
+				}
+			}
+		}
+
+		if bypasser, ok := vv.Interface().(CompressionBypasser); ok && (gzipWriter != nil) {
+			gzipWriter.bypass = bypasser.BypassCompression()
+		}
+
+		err = injectDependencies(ctx, vv, plan)
 		if err != nil {
-			data, err := json.Marshal(parsingErrors)
-			if err != nil {
-				data = []byte(`{}`)
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
+			}
+			return
+		}
+
+		var authCtx context.Context
+		authCtx, err = authenticate(ctx, vv.Interface(), r)
+		if err == nil {
+			err = authorize(authCtx, authorizer, permissions, vv.Interface())
+		}
+		if err != nil {
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
 			}
-			w.Header().Set("content-type", "application/json")
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintln(w, string(data))
 			return
 		}
 
-		if rr, ok := vv.Interface().(HandlerWithRequestInterface); ok {
-			err = rr.Handle(ctx, r, w)
-		} else if rr, ok := vv.Interface().(HandlerInterface); ok {
-			err = rr.Handle(ctx, w)
+		// the keys can be scoped by authenticated client
+		if (idempotency != nil) && !isStreamer {
+			idempotent, done, idempotencyErr := idempotency.start(authCtx, w, r, vv.Elem())
+			if idempotencyErr != nil {
+				logger.Warn(ctx, "failed to start the idempotent request",
+					shared.Field{Key: "operation", Value: operation},
+					shared.Field{Key: "error", Value: idempotencyErr},
+				)
+			} else if done {
+				return
+			} else if idempotent != nil {
+				w = idempotent
+
+				defer func() {
+					finishErr := idempotent.finish(ctx)
+					if finishErr != nil {
+						logger.Warn(ctx, "failed to record the idempotent response",
+							shared.Field{Key: "operation", Value: operation},
+							shared.Field{Key: "error", Value: finishErr},
+						)
+					}
+				}()
+			}
+		}
+
+		handlerCtx, err = runPreHandlers(authCtx, preHandlers, vv.Interface())
+		handlerErr = err
+		if err == nil {
+			if isStreamer {
+				if gzipWriter != nil {
+					gzipWriter.bypass = true
+				}
+
+				// the response is already started, errors can only be recorded
+				err = serveEvents(handlerCtx, w, vv.Interface().(Streamer), heartbeat)
+				handlerErr = err
+				if err != nil {
+					logger.Warn(ctx, "event stream interrupted",
+						shared.Field{Key: "operation", Value: operation},
+						shared.Field{Key: "error", Value: err},
+					)
+				}
+				return
+			}
+
+			// after the pre handlers, the cached responses can be scoped by their context
+			if (cache != nil) && IsSafeMethod(r.Method) {
+				cached, done, cacheErr := cache.start(handlerCtx, w, r, vv.Elem(), mediaType)
+				if cacheErr != nil {
+					logger.Warn(ctx, "failed to load the cached response",
+						shared.Field{Key: "operation", Value: operation},
+						shared.Field{Key: "error", Value: cacheErr},
+					)
+				}
+
+				if done {
+					return
+				} else if cached != nil {
+					w = cached
+
+					defer func() {
+						finishErr := cached.finish(ctx, r.Method)
+						if finishErr != nil {
+							logger.Warn(ctx, "failed to cache the response",
+								shared.Field{Key: "operation", Value: operation},
+								shared.Field{Key: "error", Value: finishErr},
+							)
+						}
+					}()
+				}
+			}
+
+			etagger, hasETag := vv.Interface().(ETagger)
+			if hasETag {
+				tag := formatETag(etagger.ETag(handlerCtx))
+				if status := checkPreconditions(r, tag); status != 0 {
+					writePrecondition(w, status, tag)
+					return
+				}
+
+				if tag != "" {
+					w.Header().Set("ETag", tag)
+				}
+			}
+
+			var timedOut bool
+			timedOut, err = handleWithTimeout(handlerCtx, timeout, handle, vv.Interface(), r, w)
+			handlerErr = err
+			if timedOut {
+				err = writeTimeout(ctx, w, timeout)
+				handlerErr = err
+				return
+			}
+
+			// errors are sent without tag, the other methods may have changed the resource
+			if hasETag && (err != nil) {
+				w.Header().Del("ETag")
+			} else if hasETag && !IsSafeMethod(r.Method) {
+				w.Header().Del("ETag")
+				if tag := formatETag(etagger.ETag(handlerCtx)); tag != "" {
+					w.Header().Set("ETag", tag)
+				}
+			}
+		}
+
+		// the operation continues in the background
+		var async *AsyncResult
+		if errors.As(err, &async) && (async.Run != nil) {
+			err = asyncOperations.start(handlerCtx, w, operation, async)
+			handlerErr = err
+			if err == nil {
+				return
+			}
 		}
 
 		if err != nil {
-			if rr, ok := vv.Interface().(ErrorHandlerInterface); ok {
-				rr.HandleError(ctx, w, err)
+			if !handleError(ctx, w, vv.Interface(), err) {
+				logUnhandledError(ctx, logger, operation, err)
 			}
 
 		} else if response.IsValid() {
+			// the fields renamed or excluded by their chipi tag
+			jsonResponse := response.Interface()
+			if hasRenamedFields(response.Type()) && !streamed {
+				jsonResponse, err = renameResponse(jsonResponse)
+				if err != nil {
+					encodingProblem(err).Write(w)
+					return
+				}
+			}
+
+			if (responseValidator != nil) && !streamed {
+				err = responseValidator(r, jsonResponse)
+				if err != nil {
+					logger.Error(ctx, "invalid response",
+						shared.Field{Key: "operation", Value: operation},
+						shared.Field{Key: "error", Value: err},
+					)
+					validationProblem(err, http.StatusInternalServerError).Write(w)
+					return
+				}
+			}
+
+			// the fields hidden or masked to the roles of the caller
+			if (rolesFunc != nil) && hasRoleFields(response.Type()) && !streamed {
+				jsonResponse, err = filterRoles(jsonResponse, response.Type(), rolesFunc(handlerCtx), hasRenamedFields(response.Type()))
+				if err != nil {
+					encodingProblem(err).Write(w)
+					return
+				}
+			}
+
+			// the fields selected by the Fieldset of the Query
+			if fields := plan.requestedFields(vv.Elem()); (fields != nil) && !streamed {
+				jsonResponse, err = projectFields(jsonResponse, fields)
+				if err != nil {
+					encodingProblem(err).Write(w)
+					return
+				}
+			}
+
+			if !streamed {
+				setPageLinks(w, r, vv, response)
+			}
+
 			// encode response if any
-			if encoder, ok := obj.(ResponseEncoder); ok {
-				encoder.EncodeResponse(ctx, w, response.Interface())
+			if responseEncoder, ok := obj.(ResponseEncoder); ok {
+				responseEncoder.EncodeResponse(ctx, w, response.Interface())
+			} else if streamed {
+				writeStreamedResponse(ctx, w, vv.Interface(), responseField, response)
+			} else if encoder != nil {
+				// only the json encoders are negotiated for the rewritten responses
+				writeNegotiatedResponse(w, mediaType, encoder, jsonResponse)
 			} else {
-				err = fmt.Errorf(
-					"structure %s needs to implement ResponseEncoder interface",
-					vv.Type().Name(),
-				)
-				return
-
+				_defaultResponseEncoder.EncodeResponse(ctx, w, jsonResponse)
 			}
 		}
 
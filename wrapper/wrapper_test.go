@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 	"github.com/go-chi/chi/v5"
@@ -26,6 +29,23 @@ type someData struct {
 	Str string
 }
 
+type petId struct {
+	Kind string
+	N    int
+}
+
+func (id *petId) UnmarshalText(data []byte) error {
+	kind, n, found := strings.Cut(string(data), "-")
+	if !found {
+		return fmt.Errorf("invalid pet id: %s", data)
+	}
+
+	var err error
+	id.Kind = kind
+	id.N, err = strconv.Atoi(n)
+	return err
+}
+
 type sharedDecoder struct{}
 
 func (r *sharedDecoder) DecodeBody(body io.ReadCloser, target interface{}, obj interface{}) error {
@@ -51,6 +71,31 @@ func (r *createTestUser) Handle(ctx context.Context, w http.ResponseWriter) erro
 	return encoder.Encode(r.Body)
 }
 
+type defaultEncoderRequest struct {
+	Path     struct{}
+	Response someData
+}
+
+func (r *defaultEncoderRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	r.Response = someData{N: 7, Str: "created"}
+	return nil
+}
+
+type problemRequest struct {
+	Path  struct{}
+	Query struct {
+		Count int
+		Fail  bool
+	}
+}
+
+func (r *problemRequest) Handle(ctx context.Context, w http.ResponseWriter) error {
+	if r.Query.Fail {
+		return fmt.Errorf("wrapped: %w", response.NewProblem(http.StatusConflict, "already exists"))
+	}
+	return nil
+}
+
 func TestWrapper(t *testing.T) {
 	g := goblin.Goblin(t)
 
@@ -89,6 +134,13 @@ func TestWrapper(t *testing.T) {
 
 				Loc    loc
 				LocPtr *loc
+
+				Time    time.Time
+				TimePtr *time.Time
+
+				Id     petId
+				IdPtr  *petId
+				ArrIds []petId
 			}
 			ctx := context.Background()
 
@@ -124,6 +176,16 @@ func TestWrapper(t *testing.T) {
 
 				{"LocPtr", `{"Type": "toto"}`, loc{Type: "toto"}},
 				{"Loc", `{"Type": "titi"}`, loc{Type: "titi"}},
+
+				{"Time", "2021-03-04T10:20:30Z", time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC)},
+				{"TimePtr", "2021-03-04T10:20:30.5Z", time.Date(2021, 3, 4, 10, 20, 30, 500000000, time.UTC)},
+				{"Time", "2021-03-04", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)},
+				{"Time", "1614853230", time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC)},
+				{"Time", "2021-03-04T12:20:30 02:00", time.Date(2021, 3, 4, 12, 20, 30, 0, time.FixedZone("", 2*3600))},
+
+				{"Id", "dog-4", petId{Kind: "dog", N: 4}},
+				{"IdPtr", "cat-2", petId{Kind: "cat", N: 2}},
+				{"ArrIds", "dog-4,cat-2", []petId{{Kind: "dog", N: 4}, {Kind: "cat", N: 2}}},
 			}
 
 			for _, tt := range tests {
@@ -133,7 +195,7 @@ func TestWrapper(t *testing.T) {
 					st := st{}
 					vv := reflect.ValueOf(&st).Elem().FieldByName(tt.Field)
 
-					err := setFValue(ctx, "unused", vv, tt.Value)
+					err := setFValue(ctx, "unused", vv, tt.Value, false)
 					require.NoError(g, err)
 
 					if strings.HasSuffix(tt.Field, "Ptr") {
@@ -145,6 +207,11 @@ func TestWrapper(t *testing.T) {
 				})
 			}
 
+			g.It("should reject invalid times", func() {
+				st := st{}
+				err := setFValue(ctx, "unused", reflect.ValueOf(&st).Elem().FieldByName("Time"), "yesterday", false)
+				require.Error(g, err)
+			})
 		})
 
 		g.Describe("incoming request", func() {
@@ -162,10 +229,17 @@ func TestWrapper(t *testing.T) {
 					PascalCaseJsonTagField    *string `json:"overrided_name"`
 					Slice                     []string
 					Tag                       string `json:"tag,omitempty"`
+					Internal                  string `chipi:"ignore"`
 				}
 
 				Header struct {
 					XZoovClientId string `name:"X-Zoov-ClientId"`
+					Retries       int    `name:"X-Retries"`
+				}
+
+				Cookie struct {
+					SessionId string `name:"session_id"`
+					Theme     string
 				}
 
 				PrivateString string
@@ -185,6 +259,8 @@ func TestWrapper(t *testing.T) {
 				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
 				req.Header.Set("X-Zoov-ClientId", "azerty")
+				req.Header.Set("X-Retries", "3")
+				req.AddCookie(&http.Cookie{Name: "session_id", Value: "abcd"})
 
 				// path
 				rctx.URLParams.Add("Id", "42")
@@ -197,6 +273,7 @@ func TestWrapper(t *testing.T) {
 				query.Set("pascal_case_no_json_tag_field", "some_value_1")
 				query.Set("overrided_name", "some_value_2")
 				query.Set("tag", "some_tag_value")
+				query.Set("internal", "some_internal_value")
 				slice = []string{"name", "duration", "label"}
 				query.Set("slice", strings.Join(slice, ","))
 
@@ -221,6 +298,12 @@ func TestWrapper(t *testing.T) {
 
 			g.It("should get param from header", func() {
 				assert.Equal(g, "azerty", reqObject.Header.XZoovClientId)
+				assert.Equal(g, 3, reqObject.Header.Retries)
+			})
+
+			g.It("should get param from cookies", func() {
+				assert.Equal(g, "abcd", reqObject.Cookie.SessionId)
+				assert.Equal(g, "", reqObject.Cookie.Theme)
 			})
 
 			g.It("should fill wrapper with path variables", func() {
@@ -252,16 +335,342 @@ func TestWrapper(t *testing.T) {
 				require.Equal(g, "some_tag_value", reqObject.Query.Tag)
 			})
 
+			g.It("should not bind ignored fields", func() {
+				assert.Equal(g, "", reqObject.Query.Internal)
+			})
+
 			g.It("should parse slice field", func() {
 				require.Equal(g, slice, reqObject.Query.Slice)
 			})
 
+			g.It("should merge repeated parameters into slices", func() {
+				req := httptest.NewRequest("GET", "/?slice=a&slice=b,c&tag=x&tag=y", nil)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(req, &testRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				obj := vv.Interface().(*testRequest)
+				assert.Equal(g, []string{"a", "b", "c"}, obj.Query.Slice)
+				assert.Equal(g, "x", obj.Query.Tag)
+			})
+
 			g.It("should parse unspecified field to zero value", func() {
 				require.Nil(g, reqObject.Query.FieldUnspecifiedInRequest)
 			})
 
 		})
 
+		g.Describe("query catch-all", func() {
+			type restRequest struct {
+				Query struct {
+					Name    string
+					Filters map[string][]string `chipi:"rest"`
+				}
+			}
+
+			type restValuesRequest struct {
+				Query struct {
+					Filters url.Values `chipi:"rest"`
+				}
+			}
+
+			newRequest := func(query string) *http.Request {
+				req := httptest.NewRequest("GET", "/items?"+query, nil)
+				return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+			}
+
+			g.It("should collect undeclared parameters", func() {
+				vv, _, err := createFilledRequestObject(newRequest("name=john&filter.Status=a&filter.Status=b&other=1"), &restRequest{}, map[string]string{})
+				require.NoError(g, err)
+
+				obj, ok := vv.Interface().(*restRequest)
+				require.True(g, ok)
+
+				assert.Equal(g, "john", obj.Query.Name)
+				assert.Equal(g, map[string][]string{
+					"filter.Status": {"a", "b"},
+					"other":         {"1"},
+				}, obj.Query.Filters)
+			})
+
+			g.It("should support url.Values", func() {
+				vv, _, err := createFilledRequestObject(newRequest("a=1&a=2"), &restValuesRequest{}, map[string]string{})
+				require.NoError(g, err)
+
+				obj, ok := vv.Interface().(*restValuesRequest)
+				require.True(g, ok)
+
+				assert.Equal(g, []string{"1", "2"}, obj.Query.Filters["a"])
+			})
+
+			g.It("should leave the field nil without undeclared parameters", func() {
+				vv, _, err := createFilledRequestObject(newRequest("name=john"), &restRequest{}, map[string]string{})
+				require.NoError(g, err)
+
+				obj, ok := vv.Interface().(*restRequest)
+				require.True(g, ok)
+
+				assert.Nil(g, obj.Query.Filters)
+			})
+		})
+
+		g.Describe("deepObject parameters", func() {
+			type deepObjectRequest struct {
+				Query struct {
+					Filter map[string]string
+					Range  *map[string]int
+					Rest   map[string][]string `chipi:"rest"`
+				}
+			}
+
+			newRequest := func(query string) (*deepObjectRequest, map[string]string) {
+				req := httptest.NewRequest("GET", "/?"+query, nil)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				vv, _, _ := createFilledRequestObject(req, &deepObjectRequest{}, parsingErrors)
+				return vv.Interface().(*deepObjectRequest), parsingErrors
+			}
+
+			g.It("should fill maps from bracketed parameters", func() {
+				obj, parsingErrors := newRequest("filter[name]=rex&filter[kind]=dog&range[min]=2&other=1")
+				require.Empty(g, parsingErrors)
+
+				assert.Equal(g, map[string]string{"name": "rex", "kind": "dog"}, obj.Query.Filter)
+				require.NotNil(g, obj.Query.Range)
+				assert.Equal(g, map[string]int{"min": 2}, *obj.Query.Range)
+				assert.Equal(g, map[string][]string{"other": {"1"}}, obj.Query.Rest)
+			})
+
+			g.It("should leave maps nil without parameters", func() {
+				obj, parsingErrors := newRequest("other=1")
+				require.Empty(g, parsingErrors)
+
+				assert.Nil(g, obj.Query.Filter)
+				assert.Nil(g, obj.Query.Range)
+			})
+
+			g.It("should report invalid values", func() {
+				_, parsingErrors := newRequest("range[min]=two")
+				assert.Equal(g, map[string]string{
+					"request.query.range": `min: strconv.ParseInt: parsing "two": invalid syntax`,
+				}, parsingErrors)
+			})
+		})
+
+		g.Describe("required parameters", func() {
+			type requiredRequest struct {
+				Query *struct {
+					Name  string `chipi:"required"`
+					Count int
+				}
+				Header struct {
+					ApiKey string `name:"X-Api-Key" chipi:"required"`
+				}
+				Cookie struct {
+					Session string `chipi:"required"`
+				}
+			}
+
+			g.It("should report missing parameters", func() {
+				req := httptest.NewRequest("GET", "/", nil)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				_, _, err := createFilledRequestObject(req, &requiredRequest{}, parsingErrors)
+				require.Error(g, err)
+
+				assert.Equal(g, map[string]string{
+					"request.query.name":     "required parameter missing",
+					"request.header.ApiKey":  "required parameter missing",
+					"request.cookie.Session": "required parameter missing",
+				}, parsingErrors)
+			})
+
+			g.It("should accept present parameters", func() {
+				req := httptest.NewRequest("GET", "/?name=", nil)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+				req.Header.Set("X-Api-Key", "key")
+				req.AddCookie(&http.Cookie{Name: "Session", Value: "abcd"})
+
+				parsingErrors := map[string]string{}
+				_, _, err := createFilledRequestObject(req, &requiredRequest{}, parsingErrors)
+				require.NoError(g, err)
+				assert.Empty(g, parsingErrors)
+			})
+		})
+
+		g.Describe("default values", func() {
+			type defaultRequest struct {
+				Query *struct {
+					Limit  int      `default:"20"`
+					Sort   *string  `default:"name"`
+					Fields []string `default:"id,name"`
+				}
+				Header struct {
+					Lang string `name:"Accept-Language" default:"en"`
+				}
+			}
+
+			newRequest := func(url string) *defaultRequest {
+				req := httptest.NewRequest("GET", url, nil)
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(req, &defaultRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				return vv.Interface().(*defaultRequest)
+			}
+
+			g.It("should apply defaults for absent parameters", func() {
+				obj := newRequest("/")
+				require.NotNil(g, obj.Query)
+				assert.Equal(g, 20, obj.Query.Limit)
+				require.NotNil(g, obj.Query.Sort)
+				assert.Equal(g, "name", *obj.Query.Sort)
+				assert.Equal(g, []string{"id", "name"}, obj.Query.Fields)
+				assert.Equal(g, "en", obj.Header.Lang)
+			})
+
+			g.It("should not override present parameters", func() {
+				obj := newRequest("/?limit=5&sort=age")
+				assert.Equal(g, 5, obj.Query.Limit)
+				assert.Equal(g, "age", *obj.Query.Sort)
+			})
+		})
+
+		g.Describe("form body", func() {
+			type formRequest struct {
+				Body struct {
+					Name   string
+					Age    *int     `json:"age"`
+					Tags   []string `json:"tags"`
+					Hidden string   `json:"-"`
+				} `content-type:"application/x-www-form-urlencoded"`
+			}
+
+			type formWithDecoderRequest struct {
+				sharedDecoder
+				Body *someData
+			}
+
+			newRequest := func(body string) *http.Request {
+				req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+				return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+			}
+
+			g.It("should fill the body from the form", func() {
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(newRequest("Name=rex&age=3&tags=a&tags=b&Hidden=x"), &formRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				obj := vv.Interface().(*formRequest)
+				assert.Equal(g, "rex", obj.Body.Name)
+				require.NotNil(g, obj.Body.Age)
+				assert.Equal(g, 3, *obj.Body.Age)
+				assert.Equal(g, []string{"a", "b"}, obj.Body.Tags)
+				assert.Equal(g, "", obj.Body.Hidden)
+			})
+
+			g.It("should report invalid values", func() {
+				parsingErrors := map[string]string{}
+				_, _, err := createFilledRequestObject(newRequest("age=old"), &formRequest{}, parsingErrors)
+				require.Error(g, err)
+
+				assert.Equal(g, map[string]string{
+					"request.body.age": `strconv.ParseInt: parsing "old": invalid syntax`,
+				}, parsingErrors)
+			})
+
+			g.It("should keep custom decoders for bodies not declared as forms", func() {
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(newRequest("N=4&Str=abc"), &formWithDecoderRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				obj := vv.Interface().(*formWithDecoderRequest)
+				assert.Equal(g, "some great string !", obj.Body.Str)
+			})
+		})
+
+		g.Describe("multipart body", func() {
+			type uploadRequest struct {
+				Body struct {
+					Title       string                  `json:"title"`
+					Size        int                     `json:"size"`
+					File        *multipart.FileHeader   `json:"file"`
+					Attachments []*multipart.FileHeader `json:"attachments"`
+				} `content-type:"multipart/form-data"`
+			}
+
+			g.It("should bind values and files", func() {
+				buffer := &bytes.Buffer{}
+				mw := multipart.NewWriter(buffer)
+				require.NoError(g, mw.WriteField("title", "holidays"))
+				require.NoError(g, mw.WriteField("size", "42"))
+				for _, name := range []string{"file", "attachments", "attachments"} {
+					fw, err := mw.CreateFormFile(name, name+".txt")
+					require.NoError(g, err)
+					_, err = fw.Write([]byte("content of " + name))
+					require.NoError(g, err)
+				}
+				require.NoError(g, mw.Close())
+
+				req := httptest.NewRequest("POST", "/", buffer)
+				req.Header.Set("Content-Type", mw.FormDataContentType())
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(req, &uploadRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				obj := vv.Interface().(*uploadRequest)
+				assert.Equal(g, "holidays", obj.Body.Title)
+				assert.Equal(g, 42, obj.Body.Size)
+
+				require.NotNil(g, obj.Body.File)
+				assert.Equal(g, "file.txt", obj.Body.File.Filename)
+				f, err := obj.Body.File.Open()
+				require.NoError(g, err)
+				data, err := io.ReadAll(f)
+				require.NoError(g, err)
+				assert.Equal(g, "content of file", string(data))
+
+				assert.Len(g, obj.Body.Attachments, 2)
+			})
+		})
+
+		g.Describe("default body decoder", func() {
+			type jsonRequest struct {
+				Body *someData
+			}
+
+			g.It("should decode json without BodyDecoder", func() {
+				req := httptest.NewRequest("POST", "/", strings.NewReader(`{"N": 3, "Str": "abc"}`))
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				vv, _, err := createFilledRequestObject(req, &jsonRequest{}, parsingErrors)
+				require.NoError(g, err)
+
+				obj := vv.Interface().(*jsonRequest)
+				assert.Equal(g, &someData{N: 3, Str: "abc"}, obj.Body)
+			})
+
+			g.It("should report invalid json", func() {
+				req := httptest.NewRequest("POST", "/", strings.NewReader(`{"N": "three"}`))
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+
+				parsingErrors := map[string]string{}
+				_, _, err := createFilledRequestObject(req, &jsonRequest{}, parsingErrors)
+				require.Error(g, err)
+				assert.Contains(g, parsingErrors["request.body"], "cannot unmarshal string")
+			})
+		})
+
 		g.Describe("custom body decoder", func() {
 			g.It("should be called", func() {
 				rctx := chi.NewRouteContext()
@@ -280,6 +689,75 @@ func TestWrapper(t *testing.T) {
 				assert.JSONEq(g, `{"N": 0, "Str": "some great string !"}`, writtenbody.String())
 			})
 		})
+
+		g.Describe("binding plan", func() {
+			g.It("should be computed once per type", func() {
+				typ := reflect.TypeOf(problemRequest{})
+
+				plan := planFor(typ)
+				require.NotNil(g, plan.query)
+				assert.Same(g, plan, planFor(typ))
+
+				require.Len(g, plan.query.params, 2)
+				assert.Equal(g, "count", plan.query.params[0].name)
+				assert.Equal(g, "request.query.count", plan.query.params[0].path)
+				assert.Nil(g, plan.header)
+				assert.False(g, plan.hasBody)
+			})
+		})
+
+		g.Describe("problems", func() {
+			newRequest := func(query string) *http.Request {
+				r := httptest.NewRequest("GET", "/?"+query, nil)
+				return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+			}
+
+			g.It("should report parsing errors as problem+json", func() {
+				w := httptest.NewRecorder()
+				WrapRequest(&problemRequest{})(w, newRequest("count=abc"))
+
+				assert.Equal(g, http.StatusBadRequest, w.Code)
+				assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+				assert.JSONEq(g, `{
+					"type": "about:blank",
+					"title": "Bad Request",
+					"status": 400,
+					"detail": "the request is invalid",
+					"invalid-params": [
+						{"name": "request.query.count", "reason": "strconv.ParseInt: parsing \"abc\": invalid syntax"}
+					]
+				}`, w.Body.String())
+			})
+
+			g.It("should write problems returned by Handle", func() {
+				w := httptest.NewRecorder()
+				WrapRequest(&problemRequest{})(w, newRequest("fail=true"))
+
+				assert.Equal(g, http.StatusConflict, w.Code)
+				assert.Equal(g, "application/problem+json", w.Result().Header.Get("Content-Type"))
+				assert.JSONEq(g, `{
+					"type": "about:blank",
+					"title": "Conflict",
+					"status": 409,
+					"detail": "already exists"
+				}`, w.Body.String())
+			})
+		})
+
+		g.Describe("default response encoder", func() {
+			g.It("should encode the response as json", func() {
+				r := httptest.NewRequest("GET", "/", nil)
+				r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+				w := httptest.NewRecorder()
+
+				handler := WrapRequest(&defaultEncoderRequest{})
+				handler(w, r)
+
+				assert.Equal(g, http.StatusOK, w.Code)
+				assert.Equal(g, "application/json", w.Result().Header.Get("Content-Type"))
+				assert.JSONEq(g, `{"N": 7, "Str": "created"}`, w.Body.String())
+			})
+		})
 	})
 }
 
@@ -313,3 +791,32 @@ func BenchmarkDecoding(b *testing.B) {
 	})
 
 }
+
+func BenchmarkBinding(b *testing.B) {
+	type benchRequest struct {
+		Path struct {
+			Id int
+		}
+		Query struct {
+			Count int
+			Tags  []string
+		}
+		Header struct {
+			Retries int `name:"X-Retries"`
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/pets/42?count=3&tags=a&tags=b", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("Id", "42")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req.Header.Set("X-Retries", "2")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, err := createFilledRequestObject(req, &benchRequest{}, map[string]string{})
+		if err != nil {
+			b.Fatalf("err: %s", err.Error())
+		}
+	}
+}